@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"homework10/internal/adsctl"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := adsctl.NewRootCommand(adsctl.Options{}).ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,102 @@
+// loadgen подает смешанную нагрузку на запущенный сервис (cmd/main) и печатает перцентили
+// задержки, пропускную способность и ошибки по операциям.
+//
+//	loadgen -transport grpc -address localhost:8080 -rps 500 -concurrency 32 -duration 1m
+//	loadgen -transport http -address http://localhost:18080 -mix get_ad=80,list_ads=20
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"homework10/internal/loadgen"
+	"homework10/pkg/client"
+	"os"
+	"os/signal"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func main() {
+	cfg := loadgen.DefaultConfig()
+	transport := flag.String("transport", "http", "transport: http or grpc")
+	address := flag.String("address", "", "service address (default http://localhost:18080 or localhost:8080)")
+	tenant := flag.String("tenant", "", "tenant of the requests")
+	mix := flag.String("mix", "", "operation weights, e.g. get_ad=50,list_ads=25 (operations: "+ops()+")")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	timeout := flag.Duration("timeout", client.DefaultConfig().Timeout, "timeout of one request")
+	flag.Float64Var(&cfg.RPS, "rps", cfg.RPS, "target requests per second")
+	flag.IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "max requests in flight")
+	flag.DurationVar(&cfg.Duration, "duration", cfg.Duration, "load duration, not counting setup")
+	flag.IntVar(&cfg.Users, "users", cfg.Users, "users created before the load")
+	flag.Int64Var(&cfg.Seed, "seed", 0, "random seed, 0 - random")
+	flag.Parse()
+
+	if *mix != "" {
+		m, err := loadgen.ParseMix(*mix)
+		if err != nil {
+			fail(err)
+		}
+		cfg.Mix = m
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	// повторы искажают задержки, нагрузка измеряет ответы сервиса как есть
+	ccfg := client.Config{Timeout: *timeout, Tenant: *tenant}
+	var ac client.AdsClient
+	switch *transport {
+	case "http":
+		if *address == "" {
+			*address = "http://localhost:18080"
+		}
+		ac = client.NewHTTPClient(*address, ccfg, nil)
+	case "grpc":
+		if *address == "" {
+			*address = "localhost:8080"
+		}
+		var err error
+		if ac, err = client.DialGRPC(ctx, *address, ccfg, grpc.WithTransportCredentials(insecure.NewCredentials())); err != nil {
+			fail(err)
+		}
+	default:
+		fail(fmt.Errorf("unknown transport %q", *transport))
+	}
+	defer ac.Close()
+
+	runner, err := loadgen.New(ac, cfg)
+	if err != nil {
+		fail(err)
+	}
+	report, err := runner.Run(ctx)
+	if err != nil {
+		fail(err)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.Write(os.Stdout)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func ops() string {
+	names := make([]string, len(loadgen.Ops))
+	for i, op := range loadgen.Ops {
+		names[i] = string(op)
+	}
+	return strings.Join(names, ", ")
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
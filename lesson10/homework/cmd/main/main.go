@@ -2,15 +2,33 @@ package main
 
 import (
 	"context"
-	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/cache"
 	"homework10/internal/app"
+	"homework10/internal/audit"
+	"homework10/internal/capture"
+	"homework10/internal/config"
 	"homework10/internal/graceful"
+	"homework10/internal/idgen"
+	"homework10/internal/policy"
 	grpcSvc "homework10/internal/ports/grpc"
+	grpcSvcV2 "homework10/internal/ports/grpc/v2"
 	"homework10/internal/ports/httpgin"
+	"homework10/internal/scheduler"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"fmt"
 	"log"
 	"net"
 )
@@ -18,10 +36,175 @@ import (
 const (
 	grpcPort = ":8080"
 	httpPort = ":18080"
+
+	// adminEmailEnv задает почту администратора, создаваемого при старте
+	adminEmailEnv = "ADS_ADMIN_EMAIL"
+
+	// idGeneratorEnv выбирает генератор ID: sequence (по умолчанию) или snowflake
+	idGeneratorEnv = "ADS_ID_GENERATOR"
+	// nodeIDEnv - номер узла для Snowflake ID
+	nodeIDEnv = "ADS_NODE_ID"
+	// stringIDsEnv включает отдачу ID строками в HTTP API
+	stringIDsEnv = "ADS_STRING_IDS"
+	// trustedProxiesEnv - адреса и подсети прокси через запятую, которым HTTP-сервер доверяет
+	// адрес клиента из X-Forwarded-For, без нее заголовок не учитывается
+	trustedProxiesEnv = "ADS_TRUSTED_PROXIES"
+	// tenantsConfigEnv - путь к YAML-файлу с арендаторами, без него работает только арендатор по умолчанию
+	tenantsConfigEnv = "ADS_TENANTS_CONFIG"
+	// shutdownTimeoutEnv - общий срок остановки сервиса, например 30s
+	shutdownTimeoutEnv = "ADS_SHUTDOWN_TIMEOUT"
+	// drainDelayEnv - пауза между снятием готовности и остановкой серверов, например 5s
+	drainDelayEnv = "ADS_DRAIN_DELAY"
+	// runtimeConfigEnv - путь к YAML-файлу настроек, изменяемых без перезапуска (уровень журнала,
+	// ограничения валидации, флаги); файл и файл арендаторов перечитываются по SIGHUP
+	runtimeConfigEnv = "ADS_RUNTIME_CONFIG"
+	// configWatchIntervalEnv - период проверки изменений файлов настроек, 0 - только по SIGHUP
+	configWatchIntervalEnv = "ADS_CONFIG_WATCH_INTERVAL"
+	// policyConfigEnv - путь к YAML-файлу с правилами контент-политики, без него объявления не проверяются
+	policyConfigEnv = "ADS_POLICY_CONFIG"
+	// captureFileEnv - файл JSONL, в который записываются запросы и ответы для воспроизведения утилитой replay
+	captureFileEnv = "ADS_CAPTURE_FILE"
+	// auditFileEnv - файл JSONL журнала аудита, без него журнал хранится в таблице в памяти
+	auditFileEnv = "ADS_AUDIT_FILE"
 )
 
+// newTenants читает реестр арендаторов из файла, заданного переменной окружения
+func newTenants() (*tenant.Registry, error) {
+	if path := os.Getenv(tenantsConfigEnv); path != "" {
+		return tenant.Load(path)
+	}
+	return tenant.NewRegistry(nil)
+}
+
+// newApp создает приложение с журналом аудита sink, с контент-политикой из файла,
+// если он задан переменной окружения
+func newApp(repo app.Repository, sink audit.Sink) (app.App, error) {
+	path := os.Getenv(policyConfigEnv)
+	if path == "" {
+		return app.NewAppWithAudit(repo, nil, sink), nil
+	}
+	engine, err := policy.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("content policy rules: %v\n", engine.Rules())
+	return app.NewAppWithAudit(repo, engine, sink), nil
+}
+
+// newAuditSink открывает файл журнала аудита, если он задан переменной окружения
+func newAuditSink() (audit.Sink, io.Closer, error) {
+	path := os.Getenv(auditFileEnv)
+	if path == "" {
+		return adrepo.NewAuditTable(), nil, nil
+	}
+	sink, err := audit.OpenFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sink, sink, nil
+}
+
+// newLifecycleConfig читает сроки остановки из переменных окружения
+func newLifecycleConfig() (graceful.Config, error) {
+	cfg := graceful.DefaultConfig()
+	for env, d := range map[string]*time.Duration{shutdownTimeoutEnv: &cfg.ShutdownTimeout, drainDelayEnv: &cfg.DrainDelay} {
+		if v := os.Getenv(env); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil {
+				return graceful.Config{}, fmt.Errorf("invalid %s: %w", env, err)
+			}
+			*d = parsed
+		}
+	}
+	return cfg, nil
+}
+
+// newIDs создает генераторы идентификаторов по переменным окружения
+func newIDs() (adrepo.IDs, error) {
+	switch gen := os.Getenv(idGeneratorEnv); gen {
+	case "", "sequence":
+		return adrepo.NewSequenceIDs(), nil
+	case "snowflake":
+		var node int64
+		if env := os.Getenv(nodeIDEnv); env != "" {
+			var err error
+			if node, err = strconv.ParseInt(env, 10, 64); err != nil {
+				return adrepo.IDs{}, fmt.Errorf("invalid %s: %w", nodeIDEnv, err)
+			}
+		}
+		sf, err := idgen.NewSnowflake(node, nil)
+		if err != nil {
+			return adrepo.IDs{}, err
+		}
+		return adrepo.SharedIDs(sf), nil
+	default:
+		return adrepo.IDs{}, fmt.Errorf("unknown id generator %q", gen)
+	}
+}
+
+// bootstrapAdmin создает первого администратора, иначе назначать роли было бы некому
+func bootstrapAdmin(ctx context.Context, repo app.Repository, email string) {
+	id, err := repo.AddUser(ctx, user.User{Nickname: "admin", Email: email, Verified: true, Role: user.RoleAdmin})
+	if err != nil {
+		log.Printf("failed to create admin %s: %s\n", email, err.Error())
+		return
+	}
+	log.Printf("created admin %s with id %d for tenant %s\n", email, id, tenant.IDFromContext(ctx))
+}
+
 func main() {
-	appSvc := app.NewApp(adrepo.New())
+	ids, err := newIDs()
+	if err != nil {
+		log.Fatalf("failed to configure ids: %v", err)
+	}
+	tenants, err := newTenants()
+	if err != nil {
+		log.Fatalf("failed to configure tenants: %v", err)
+	}
+	// у каждого арендатора свои хранилище и кэш, генераторы ID общие
+	repo := adrepo.NewTenantRepository(func(tenant.ID) app.Repository {
+		return cache.New(adrepo.NewShardedRepositoryWithIDs(adrepo.DefaultShards, ids), cache.DefaultConfig())
+	})
+	auditSink, auditFile, err := newAuditSink()
+	if err != nil {
+		log.Fatalf("failed to open audit log: %v", err)
+	}
+	appSvc, err := newApp(repo, auditSink)
+	if err != nil {
+		log.Fatalf("failed to configure content policy: %v", err)
+	}
+	if email := os.Getenv(adminEmailEnv); email != "" {
+		for _, id := range tenants.IDs() {
+			t, _ := tenants.Lookup(id)
+			bootstrapAdmin(tenant.NewContext(context.Background(), t), repo, email)
+		}
+	}
+
+	store := config.NewStore(nil)
+	reloader := config.NewReloader(store, os.Getenv(runtimeConfigEnv), tenants, os.Getenv(tenantsConfigEnv))
+	if err := reloader.Reload(); err != nil {
+		log.Fatalf("failed to load runtime config: %v", err)
+	}
+	watchInterval := 5 * time.Second
+	if v := os.Getenv(configWatchIntervalEnv); v != "" {
+		if watchInterval, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("invalid %s: %v", configWatchIntervalEnv, err)
+		}
+	}
+
+	lifecycleCfg, err := newLifecycleConfig()
+	if err != nil {
+		log.Fatalf("failed to configure shutdown: %v", err)
+	}
+	lifecycle := graceful.NewManager(lifecycleCfg)
+
+	var rec *capture.FileRecorder
+	if path := os.Getenv(captureFileEnv); path != "" {
+		if rec, err = capture.Create(path); err != nil {
+			log.Fatalf("failed to open capture file: %v", err)
+		}
+		log.Printf("recording requests to %s\n", path)
+	}
 
 	lis, err := net.Listen("tcp", grpcPort)
 	if err != nil {
@@ -29,25 +212,84 @@ func main() {
 	}
 
 	svc := grpcSvc.NewService(appSvc)
-	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+	unary := []grpc.UnaryServerInterceptor{
 		grpcSvc.UnaryLoggerInterceptor,
 		grpcSvc.UnaryRecoveryInterceptor(),
-	))
+		grpcSvc.UnaryLocaleInterceptor(),
+		grpcSvc.UnaryConfigInterceptor(store),
+		grpcSvc.UnaryAuditInterceptor(),
+		grpcSvc.UnaryTenantInterceptor(tenants),
+	}
+	if rec != nil {
+		unary = append([]grpc.UnaryServerInterceptor{grpcSvc.UnaryCaptureInterceptor(rec.Recorder)}, unary...)
+	}
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(
+			grpcSvc.StreamLoggerInterceptor,
+			grpcSvc.StreamRecoveryInterceptor(),
+			grpcSvc.StreamLocaleInterceptor(),
+			grpcSvc.StreamConfigInterceptor(store),
+			grpcSvc.StreamAuditInterceptor(),
+			grpcSvc.StreamTenantInterceptor(tenants),
+		),
+	)
 	grpcSvc.RegisterAdServiceServer(grpcServer, svc)
+	grpcSvc.RegisterAdminServiceServer(grpcServer, grpcSvc.NewAdminService(appSvc))
+	grpcSvcV2.RegisterAdServiceServer(grpcServer, grpcSvcV2.NewService(appSvc))
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
 
-	httpServer := httpgin.NewHTTPServer(httpPort, appSvc)
-
-	eg, ctx := errgroup.WithContext(context.Background())
+	stringIDs, _ := strconv.ParseBool(os.Getenv(stringIDsEnv))
+	proxies, err := httpgin.ParseTrustedProxies(os.Getenv(trustedProxiesEnv))
+	if err != nil {
+		log.Fatalf("failed to parse %s: %v", trustedProxiesEnv, err)
+	}
+	httpServer := httpgin.NewHTTPServerWithOptions(httpPort, appSvc, httpgin.Options{
+		Tenants:        tenants,
+		StringIDs:      stringIDs,
+		TrustedProxies: proxies,
+	})
+	httpServer.Handler = httpgin.ConfigHandler(store, httpServer.Handler)
+	if rec != nil {
+		httpServer.Handler = httpgin.RecordHandler(rec.Recorder, httpServer.Handler)
+	}
+	// пробы не проходят через API и не записываются
+	mux := http.NewServeMux()
+	mux.Handle("/readyz", lifecycle.ReadinessHandler())
+	mux.Handle("/", httpServer.Handler)
+	httpServer.Handler = mux
 
-	sigQuit := make(chan os.Signal, 1)
-	eg.Go(graceful.CaptureSignal(ctx, sigQuit))
-	// run grpc server
-	eg.Go(grpcSvc.RunGRPCServerGracefully(ctx, lis, grpcServer))
-	// run http server
-	eg.Go(httpgin.RunHTTPServerGracefully(ctx, httpServer))
+	// компоненты останавливаются по фазам: снятие готовности, ожидание, серверы,
+	// фоновые задачи и буферы, хранилище
+	lifecycle.Register(grpcSvc.ReadinessComponent(healthServer))
+	lifecycle.Register(grpcSvc.Component(grpcServer, lis))
+	lifecycle.Register(httpgin.Component(httpServer, nil))
+	lifecycle.Register(scheduler.Component(scheduler.NewWithAudit(repo, auditSink, scheduler.DefaultInterval)))
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	lifecycle.Register(graceful.Worker("config reloader", graceful.PhaseFlushWorkers, func(ctx context.Context) error {
+		return reloader.Watch(ctx, sighup, watchInterval)
+	}))
+	if rec != nil {
+		lifecycle.Register(graceful.Closer("capture file", graceful.PhaseFlushWorkers, rec))
+	}
+	if auditFile != nil {
+		lifecycle.Register(graceful.Closer("audit log", graceful.PhaseFlushWorkers, auditFile))
+	}
+	if closer, ok := app.Repository(repo).(io.Closer); ok {
+		lifecycle.Register(graceful.Closer("repository", graceful.PhaseCloseRepository, closer))
+	}
 
-	if err := eg.Wait(); err != nil {
-		log.Printf("gracefully shutting down the servers: %s\n", err.Error())
+	ctx, cancel := graceful.SignalContext(context.Background())
+	defer cancel()
+	report, err := lifecycle.Run(ctx)
+	if err != nil {
+		log.Printf("shutting down the servers: %s\n", err.Error())
+	}
+	if err := report.Err(); err != nil {
+		log.Printf("servers were shutdown with errors in %s: %s\n", report.Duration, err.Error())
+		os.Exit(1)
 	}
-	log.Println("servers were successfully shutdown")
+	log.Printf("servers were successfully shutdown in %s\n", report.Duration)
 }
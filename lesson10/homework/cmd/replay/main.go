@@ -0,0 +1,115 @@
+// replay воспроизводит запись запросов, сделанную сервисом с ADS_CAPTURE_FILE,
+// на новом приложении с репозиторием в памяти и печатает расхождения ответов.
+//
+//	replay [-tenants tenants.yaml] [-policy policy.yaml] [-config runtime.yaml] [-string-ids]
+//	       [-admin-email admin@example.com] capture.jsonl
+//
+// Настройки арендаторов, контент-политики, времени выполнения, формата ID и администратора
+// должны совпадать с настройками записывавшего сервиса, иначе ответы разойдутся.
+// Код выхода 1 - есть расхождения.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/capture"
+	"homework10/internal/config"
+	"homework10/internal/policy"
+	"homework10/internal/replay"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"os"
+	"strings"
+)
+
+func main() {
+	tenantsPath := flag.String("tenants", "", "tenants config used by the recorded service")
+	policyPath := flag.String("policy", "", "content policy config used by the recorded service")
+	configPath := flag.String("config", "", "runtime config (ADS_RUNTIME_CONFIG) used by the recorded service")
+	stringIDs := flag.Bool("string-ids", false, "the recorded service returned string IDs (ADS_STRING_IDS)")
+	adminEmail := flag.String("admin-email", "", "admin email the recorded service was started with")
+	ignore := flag.String("ignore", strings.Join(replay.DefaultIgnore, ","), "comma-separated response fields to skip")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] capture.jsonl\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ok, err := run(flag.Arg(0), *tenantsPath, *policyPath, *configPath, *adminEmail, replay.Options{
+		StringIDs: *stringIDs,
+		Ignore:    strings.Split(*ignore, ","),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+func run(path string, tenantsPath string, policyPath string, configPath string, adminEmail string, opts replay.Options) (bool, error) {
+	entries, err := capture.Open(path)
+	if err != nil {
+		return false, err
+	}
+	tenants, err := tenant.NewRegistry(nil)
+	if tenantsPath != "" {
+		tenants, err = tenant.Load(tenantsPath)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// как и в сервисе: у каждого арендатора свое хранилище, генераторы ID общие
+	ids := adrepo.NewSequenceIDs()
+	repo := adrepo.NewTenantRepository(func(tenant.ID) app.Repository {
+		return adrepo.NewRepositoryMapWithIDs(ids)
+	})
+	var engine *policy.Engine
+	if policyPath != "" {
+		if engine, err = policy.Load(policyPath); err != nil {
+			return false, err
+		}
+	}
+	a := app.NewAppWithAudit(repo, engine, adrepo.NewAuditTable())
+	if configPath != "" {
+		c, err := config.Load(configPath)
+		if err != nil {
+			return false, err
+		}
+		opts.Config = config.NewStore(c)
+	}
+	if adminEmail != "" {
+		for _, id := range tenants.IDs() {
+			t, _ := tenants.Lookup(id)
+			admin := user.User{Nickname: "admin", Email: adminEmail, Verified: true, Role: user.RoleAdmin}
+			if _, err := repo.AddUser(tenant.NewContext(context.Background(), t), admin); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	opts.Tenants = tenants
+	r, err := replay.New(a, opts)
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+
+	report, err := r.Run(context.Background(), entries)
+	if err != nil {
+		return false, err
+	}
+	if err := report.Write(os.Stdout); err != nil {
+		return false, err
+	}
+	return report.OK(), nil
+}
@@ -0,0 +1,154 @@
+package adrepo
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"sort"
+)
+
+func (r *RepositoryMap) AddFavorite(ctx context.Context, uid int64, adID int64) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.userTable[uid]; !ok {
+		return app.ErrUserNotFound
+	}
+	if _, ok := r.adTable[adID]; !ok {
+		return app.ErrAdNotFound
+	}
+	if _, ok := r.favorites[uid]; !ok {
+		r.favorites[uid] = make(map[int64]struct{})
+	}
+	r.favorites[uid][adID] = struct{}{}
+	return nil
+}
+
+func (r *RepositoryMap) DeleteFavorite(ctx context.Context, uid int64, adID int64) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.userTable[uid]; !ok {
+		return app.ErrUserNotFound
+	}
+	if _, ok := r.favorites[uid][adID]; !ok {
+		return app.ErrAdNotFound
+	}
+	delete(r.favorites[uid], adID)
+	return nil
+}
+
+func (r *RepositoryMap) GetFavorites(ctx context.Context, uid int64) (*ads.AdList, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if _, ok := r.userTable[uid]; !ok {
+		return nil, app.ErrUserNotFound
+	}
+	al := ads.AdList{Data: make([]ads.Ad, 0, len(r.favorites[uid]))}
+	for adID := range r.favorites[uid] {
+		// удаленные объявления просто пропадают из избранного
+		if ad, ok := r.adTable[adID]; ok {
+			al.Data = append(al.Data, ad)
+		}
+	}
+	sort.Slice(al.Data, func(i, j int) bool { return al.Data[i].ID < al.Data[j].ID })
+	return &al, nil
+}
+
+func (r *RepositoryMap) AddSavedSearch(ctx context.Context, s app.SavedSearch) (int64, error) {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.userTable[s.UserID]; !ok {
+		return 0, app.ErrUserNotFound
+	}
+	s.ID = r.ids.Searches.Next()
+	r.searchTable[s.ID] = s
+	return s.ID, nil
+}
+
+func (r *RepositoryMap) GetSavedSearchByID(ctx context.Context, id int64) (*app.SavedSearch, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if s, ok := r.searchTable[id]; !ok {
+		return nil, app.ErrSearchNotFound
+	} else {
+		return &s, nil
+	}
+}
+
+func (r *RepositoryMap) GetUserSavedSearches(ctx context.Context, uid int64) ([]app.SavedSearch, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if _, ok := r.userTable[uid]; !ok {
+		return nil, app.ErrUserNotFound
+	}
+	sl := make([]app.SavedSearch, 0)
+	for _, s := range r.searchTable {
+		if s.UserID == uid {
+			sl = append(sl, s)
+		}
+	}
+	sort.Slice(sl, func(i, j int) bool { return sl[i].ID < sl[j].ID })
+	return sl, nil
+}
+
+func (r *RepositoryMap) GetSavedSearches(ctx context.Context) ([]app.SavedSearch, error) {
+	r.RLock()
+	defer r.RUnlock()
+	sl := make([]app.SavedSearch, 0, len(r.searchTable))
+	for _, s := range r.searchTable {
+		sl = append(sl, s)
+	}
+	sort.Slice(sl, func(i, j int) bool { return sl[i].ID < sl[j].ID })
+	return sl, nil
+}
+
+func (r *RepositoryMap) DeleteSavedSearchByID(ctx context.Context, id int64) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.searchTable[id]; !ok {
+		return app.ErrSearchNotFound
+	}
+	delete(r.searchTable, id)
+	return nil
+}
+
+func (r *RepositoryMap) AddNotification(ctx context.Context, n app.Notification) (int64, error) {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.userTable[n.UserID]; !ok {
+		return 0, app.ErrUserNotFound
+	}
+	n.ID = r.ids.Notifications.Next()
+	r.notificationTable[n.ID] = n
+	return n.ID, nil
+}
+
+func (r *RepositoryMap) GetNotifications(ctx context.Context, uid int64) ([]app.Notification, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if _, ok := r.userTable[uid]; !ok {
+		return nil, app.ErrUserNotFound
+	}
+	nl := make([]app.Notification, 0)
+	for _, n := range r.notificationTable {
+		if n.UserID == uid {
+			nl = append(nl, n)
+		}
+	}
+	sort.Slice(nl, func(i, j int) bool { return nl[i].ID < nl[j].ID })
+	return nl, nil
+}
+
+// deleteUserAlerts удаляет избранное, поиски и уведомления пользователя, вызывается под блокировкой
+func (r *RepositoryMap) deleteUserAlerts(uid int64) {
+	delete(r.favorites, uid)
+	for id, s := range r.searchTable {
+		if s.UserID == uid {
+			delete(r.searchTable, id)
+		}
+	}
+	for id, n := range r.notificationTable {
+		if n.UserID == uid {
+			delete(r.notificationTable, id)
+		}
+	}
+}
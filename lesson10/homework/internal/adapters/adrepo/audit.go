@@ -0,0 +1,32 @@
+package adrepo
+
+import (
+	"context"
+	"homework10/internal/audit"
+	"sync"
+)
+
+// AuditTable - таблица журнала аудита в памяти. Одна таблица общая для всех арендаторов:
+// арендатор хранится в записи, а выборка администратором ограничивается его арендатором
+type AuditTable struct {
+	mu      sync.RWMutex
+	entries []audit.Entry
+}
+
+func NewAuditTable() *AuditTable {
+	return &AuditTable{}
+}
+
+func (t *AuditTable) Write(_ context.Context, e *audit.Entry) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e.ID = int64(len(t.entries)) + 1
+	t.entries = append(t.entries, *e)
+	return nil
+}
+
+func (t *AuditTable) Query(_ context.Context, f audit.Filter) ([]audit.Entry, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return audit.Select(t.entries, f), nil
+}
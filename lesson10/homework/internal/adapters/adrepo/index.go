@@ -0,0 +1,161 @@
+package adrepo
+
+import (
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"math"
+	"sync"
+	"time"
+)
+
+type idSet map[int64]struct{}
+
+// adIndex - вторичные индексы объявлений, изменяется под блокировкой шарда объявления
+type adIndex struct {
+	sync.RWMutex
+	byAuthor    map[int64]idSet
+	byPublished map[bool]idSet
+	byDate      map[int]idSet
+	byChanged   map[int]idSet // по дню последнего изменения (ads.Ad.LastChanged)
+}
+
+func newAdIndex() adIndex {
+	return adIndex{
+		byAuthor:    make(map[int64]idSet),
+		byPublished: make(map[bool]idSet),
+		byDate:      make(map[int]idSet),
+		byChanged:   make(map[int]idSet),
+	}
+}
+
+// dateKey совпадает с тем, как ListAdsParams сравнивает даты создания
+func dateKey(t time.Time) int {
+	year, month, day := t.Date()
+	return year*10000 + int(month)*100 + day
+}
+
+// between объединяет дневные корзины индекса, пересекающиеся с интервалом [from, to).
+// Границы расширены на сутки: ключи корзин считаются в часовом поясе самого объявления
+func between(index map[int]idSet, from *time.Time, to *time.Time) idSet {
+	lo, hi := math.MinInt, math.MaxInt
+	if from != nil {
+		lo = dateKey(from.UTC().Add(-24 * time.Hour))
+	}
+	if to != nil {
+		hi = dateKey(to.UTC().Add(24 * time.Hour))
+	}
+	set := make(idSet)
+	for key, ids := range index {
+		if key < lo || key > hi {
+			continue
+		}
+		for id := range ids {
+			set[id] = struct{}{}
+		}
+	}
+	return set
+}
+
+func addTo[K comparable](index map[K]idSet, key K, id int64) {
+	set, ok := index[key]
+	if !ok {
+		set = make(idSet)
+		index[key] = set
+	}
+	set[id] = struct{}{}
+}
+
+func removeFrom[K comparable](index map[K]idSet, key K, id int64) {
+	set, ok := index[key]
+	if !ok {
+		return
+	}
+	delete(set, id)
+	if len(set) == 0 {
+		delete(index, key)
+	}
+}
+
+func (x *adIndex) add(ad ads.Ad) {
+	x.Lock()
+	defer x.Unlock()
+	addTo(x.byAuthor, ad.AuthorID, ad.ID)
+	addTo(x.byPublished, ad.Published, ad.ID)
+	addTo(x.byDate, dateKey(ad.DateCreated), ad.ID)
+	addTo(x.byChanged, dateKey(ad.LastChanged()), ad.ID)
+}
+
+func (x *adIndex) remove(ad ads.Ad) {
+	x.Lock()
+	defer x.Unlock()
+	removeFrom(x.byAuthor, ad.AuthorID, ad.ID)
+	removeFrom(x.byPublished, ad.Published, ad.ID)
+	removeFrom(x.byDate, dateKey(ad.DateCreated), ad.ID)
+	removeFrom(x.byChanged, dateKey(ad.LastChanged()), ad.ID)
+}
+
+// update переносит объявление между корзинами индексов, если изменились проиндексированные поля
+func (x *adIndex) update(before ads.Ad, after ads.Ad) {
+	published := before.Published != after.Published
+	changed := dateKey(before.LastChanged()) != dateKey(after.LastChanged())
+	if !published && !changed {
+		return
+	}
+	x.Lock()
+	defer x.Unlock()
+	if published {
+		removeFrom(x.byPublished, before.Published, after.ID)
+		addTo(x.byPublished, after.Published, after.ID)
+	}
+	if changed {
+		removeFrom(x.byChanged, dateKey(before.LastChanged()), after.ID)
+		addTo(x.byChanged, dateKey(after.LastChanged()), after.ID)
+	}
+}
+
+func (x *adIndex) authorAds(uid int64) []int64 {
+	x.RLock()
+	defer x.RUnlock()
+	return x.byAuthor[uid].ids()
+}
+
+// candidates возвращает объявления из самого узкого подходящего индекса,
+// ok == false, если ни один из фильтров не проиндексирован
+func (x *adIndex) candidates(params app.ListAdsParams) (ids []int64, ok bool) {
+	x.RLock()
+	defer x.RUnlock()
+	var best idSet
+	consider := func(set idSet) {
+		if !ok || len(set) < len(best) {
+			best = set
+			ok = true
+		}
+	}
+	if params.Uid != nil {
+		consider(x.byAuthor[*params.Uid])
+	}
+	if params.Published != nil {
+		consider(x.byPublished[*params.Published])
+	}
+	if params.Date != nil {
+		consider(x.byDate[dateKey(*params.Date)])
+	}
+	if params.CreatedFrom != nil || params.CreatedTo != nil {
+		consider(between(x.byDate, params.CreatedFrom, params.CreatedTo))
+	}
+	if params.ChangedSince != nil {
+		consider(between(x.byChanged, params.ChangedSince, nil))
+	}
+	if !ok {
+		return nil, false
+	}
+	return best.ids(), true
+}
+
+func (s idSet) ids() []int64 {
+	ids := make([]int64, 0, len(s))
+	for id := range s {
+		ids = append(ids, id)
+	}
+	return ids
+}
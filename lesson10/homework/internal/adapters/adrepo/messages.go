@@ -0,0 +1,181 @@
+package adrepo
+
+import (
+	"context"
+	"homework10/internal/app"
+	"homework10/internal/messages"
+	"sort"
+)
+
+func (r *RepositoryMap) AddConversation(ctx context.Context, c messages.Conversation) (int64, error) {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.userTable[c.BuyerID]; !ok {
+		return 0, app.ErrUserNotFound
+	}
+	if _, ok := r.userTable[c.SellerID]; !ok {
+		return 0, app.ErrUserNotFound
+	}
+	c.ID = r.ids.Conversations.Next()
+	r.conversationTable[c.ID] = c
+	return c.ID, nil
+}
+
+func (r *RepositoryMap) GetConversationByID(ctx context.Context, id int64) (*messages.Conversation, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if c, ok := r.conversationTable[id]; !ok {
+		return nil, app.ErrConversationNotFound
+	} else {
+		return &c, nil
+	}
+}
+
+func (r *RepositoryMap) FindConversation(ctx context.Context, adID int64, buyerID int64) (*messages.Conversation, error) {
+	r.RLock()
+	defer r.RUnlock()
+	for _, c := range r.conversationTable {
+		if c.AdID == adID && c.BuyerID == buyerID {
+			return &c, nil
+		}
+	}
+	return nil, app.ErrConversationNotFound
+}
+
+func (r *RepositoryMap) GetUserConversations(ctx context.Context, uid int64) ([]messages.Conversation, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if _, ok := r.userTable[uid]; !ok {
+		return nil, app.ErrUserNotFound
+	}
+	cl := make([]messages.Conversation, 0)
+	for _, c := range r.conversationTable {
+		if c.HasParticipant(uid) {
+			cl = append(cl, c)
+		}
+	}
+	// сначала переписки с самыми свежими сообщениями
+	sort.Slice(cl, func(i, j int) bool {
+		if cl[i].DateChanged.Equal(cl[j].DateChanged) {
+			return cl[i].ID > cl[j].ID
+		}
+		return cl[i].DateChanged.After(cl[j].DateChanged)
+	})
+	return cl, nil
+}
+
+func (r *RepositoryMap) AddMessage(ctx context.Context, m messages.Message) (int64, error) {
+	r.Lock()
+	defer r.Unlock()
+	c, ok := r.conversationTable[m.ConversationID]
+	if !ok {
+		return 0, app.ErrConversationNotFound
+	}
+	m.ID = r.ids.Messages.Next()
+	r.messageTable[c.ID] = append(r.messageTable[c.ID], m)
+	c.DateChanged = m.DateSent
+	r.conversationTable[c.ID] = c
+	return m.ID, nil
+}
+
+func (r *RepositoryMap) GetMessages(ctx context.Context, convID int64, offset int, limit int) ([]messages.Message, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if _, ok := r.conversationTable[convID]; !ok {
+		return nil, app.ErrConversationNotFound
+	}
+	history := r.messageTable[convID]
+	if offset > len(history) {
+		offset = len(history)
+	}
+	end := offset + limit
+	if end > len(history) {
+		end = len(history)
+	}
+	ml := make([]messages.Message, end-offset)
+	copy(ml, history[offset:end])
+	return ml, nil
+}
+
+func (r *RepositoryMap) MarkMessagesRead(ctx context.Context, convID int64, uid int64, upTo int64) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.conversationTable[convID]; !ok {
+		return app.ErrConversationNotFound
+	}
+	history := r.messageTable[convID]
+	for i := range history {
+		if history[i].ID > upTo {
+			break
+		}
+		if history[i].RecipientID == uid {
+			history[i].Read = true
+		}
+	}
+	return nil
+}
+
+func (r *RepositoryMap) CountUnreadMessages(ctx context.Context, convID int64, uid int64) (int, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if _, ok := r.conversationTable[convID]; !ok {
+		return 0, app.ErrConversationNotFound
+	}
+	unread := 0
+	for _, m := range r.messageTable[convID] {
+		if m.RecipientID == uid && !m.Read {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+func (r *RepositoryMap) AddBlock(ctx context.Context, uid int64, blockedID int64) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.userTable[uid]; !ok {
+		return app.ErrUserNotFound
+	}
+	if _, ok := r.userTable[blockedID]; !ok {
+		return app.ErrUserNotFound
+	}
+	if _, ok := r.blocks[uid]; !ok {
+		r.blocks[uid] = make(map[int64]struct{})
+	}
+	r.blocks[uid][blockedID] = struct{}{}
+	return nil
+}
+
+func (r *RepositoryMap) DeleteBlock(ctx context.Context, uid int64, blockedID int64) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.userTable[uid]; !ok {
+		return app.ErrUserNotFound
+	}
+	if _, ok := r.blocks[uid][blockedID]; !ok {
+		return app.ErrUserNotFound
+	}
+	delete(r.blocks[uid], blockedID)
+	return nil
+}
+
+func (r *RepositoryMap) IsBlocked(ctx context.Context, uid int64, blockedID int64) (bool, error) {
+	r.RLock()
+	defer r.RUnlock()
+	_, ok := r.blocks[uid][blockedID]
+	return ok, nil
+}
+
+// deleteUserConversations удаляет переписки и блокировки пользователя, вызывается под блокировкой
+func (r *RepositoryMap) deleteUserConversations(uid int64) {
+	for id, c := range r.conversationTable {
+		if c.HasParticipant(uid) {
+			delete(r.conversationTable, id)
+			delete(r.messageTable, id)
+		}
+	}
+	delete(r.blocks, uid)
+	for _, blocked := range r.blocks {
+		delete(blocked, uid)
+	}
+}
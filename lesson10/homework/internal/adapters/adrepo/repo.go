@@ -2,29 +2,92 @@ package adrepo
 
 import (
 	"context"
+	"fmt"
 	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/idgen"
+	"homework10/internal/messages"
 	"homework10/internal/user"
+	"sort"
 	"sync"
 	"time"
 )
 
+var (
+	ErrEmailExists    = fmt.Errorf("user with such email %w", app.ErrAlreadyExists)
+	ErrNicknameExists = fmt.Errorf("user with such nickname %w", app.ErrAlreadyExists)
+)
+
 func New() app.Repository {
 	return NewRepositoryMap()
 }
 
+// IDs задает генераторы идентификаторов всех сущностей репозитория, все поля обязательны
+type IDs struct {
+	Ads           idgen.Generator
+	Users         idgen.Generator
+	Searches      idgen.Generator
+	Notifications idgen.Generator
+	Conversations idgen.Generator
+	Messages      idgen.Generator
+}
+
+// NewSequenceIDs возвращает монотонные последовательности, начинающиеся с нуля
+func NewSequenceIDs() IDs {
+	return IDs{
+		Ads:           idgen.NewSequence(0),
+		Users:         idgen.NewSequence(0),
+		Searches:      idgen.NewSequence(0),
+		Notifications: idgen.NewSequence(0),
+		Conversations: idgen.NewSequence(0),
+		Messages:      idgen.NewSequence(0),
+	}
+}
+
+// SharedIDs возвращает генератор g для всех сущностей, например один Snowflake на узел
+func SharedIDs(g idgen.Generator) IDs {
+	return IDs{Ads: g, Users: g, Searches: g, Notifications: g, Conversations: g, Messages: g}
+}
+
 type RepositoryMap struct {
-	sync.Mutex
+	sync.RWMutex
+	ids       IDs
 	adTable   map[int64]ads.Ad
 	userTable map[int64]user.User
 	user2ads  map[int64]map[int64]struct{}
+	emails    map[string]int64
+	nicknames map[string]int64
+
+	verificationTable map[string]app.VerificationToken
+
+	favorites         map[int64]map[int64]struct{}
+	searchTable       map[int64]app.SavedSearch
+	notificationTable map[int64]app.Notification
+
+	conversationTable map[int64]messages.Conversation
+	messageTable      map[int64][]messages.Message
+	blocks            map[int64]map[int64]struct{}
 }
 
 func NewRepositoryMap() *RepositoryMap {
+	return NewRepositoryMapWithIDs(NewSequenceIDs())
+}
+
+func NewRepositoryMapWithIDs(ids IDs) *RepositoryMap {
 	return &RepositoryMap{
-		adTable:   make(map[int64]ads.Ad),
-		userTable: make(map[int64]user.User),
-		user2ads:  make(map[int64]map[int64]struct{}),
+		ids:               ids,
+		adTable:           make(map[int64]ads.Ad),
+		userTable:         make(map[int64]user.User),
+		user2ads:          make(map[int64]map[int64]struct{}),
+		emails:            make(map[string]int64),
+		nicknames:         make(map[string]int64),
+		verificationTable: make(map[string]app.VerificationToken),
+		favorites:         make(map[int64]map[int64]struct{}),
+		searchTable:       make(map[int64]app.SavedSearch),
+		notificationTable: make(map[int64]app.Notification),
+		conversationTable: make(map[int64]messages.Conversation),
+		messageTable:      make(map[int64][]messages.Message),
+		blocks:            make(map[int64]map[int64]struct{}),
 	}
 }
 
@@ -34,15 +97,15 @@ func (r *RepositoryMap) AddAd(ctx context.Context, ad ads.Ad) (int64, error) {
 	if _, ok := r.userTable[ad.AuthorID]; !ok {
 		return 0, app.ErrUserNotFound
 	}
-	ad.ID = int64(len(r.adTable))
+	ad.ID = r.ids.Ads.Next()
 	r.adTable[ad.ID] = ad
 	r.user2ads[ad.AuthorID][ad.ID] = struct{}{}
 	return ad.ID, nil
 }
 
 func (r *RepositoryMap) GetAdByID(ctx context.Context, id int64) (*ads.Ad, error) {
-	r.Lock()
-	defer r.Unlock()
+	r.RLock()
+	defer r.RUnlock()
 	if ad, ok := r.adTable[id]; !ok {
 		return nil, app.ErrAdNotFound
 	} else {
@@ -77,35 +140,128 @@ func (r *RepositoryMap) UpdateAdContent(ctx context.Context, id int64, title str
 	return nil
 }
 
-func (r *RepositoryMap) GetAdList(ctx context.Context, params app.ListAdsParams) (*ads.AdList, error) {
+func (r *RepositoryMap) PatchAd(ctx context.Context, id int64, patch app.AdPatch, date time.Time) error {
 	r.Lock()
 	defer r.Unlock()
+	ad, ok := r.adTable[id]
+	if !ok {
+		return app.ErrAdNotFound
+	}
+	patch.Apply(&ad)
+	ad.DateChanged = date
+	r.adTable[id] = ad
+	return nil
+}
+
+func (r *RepositoryMap) GetAdList(ctx context.Context, params app.ListAdsParams) (*ads.AdList, error) {
+	r.RLock()
+	defer r.RUnlock()
 	al := ads.AdList{Data: make([]ads.Ad, 0)}
 	for _, ad := range r.adTable {
-		if params.Published == nil || *params.Published == ad.Published {
-			if (params.Uid == nil || *params.Uid == ad.AuthorID) && (params.Title == nil || *params.Title == ad.Title) {
-				if year, month, day := ad.DateCreated.Date(); params.Date == nil ||
-					(params.Date.Year() == year && params.Date.Month() == month && params.Date.Day() == day) {
-					al.Data = append(al.Data, ad)
-				}
-			}
+		if params.Matches(ad) {
+			al.Data = append(al.Data, ad)
 		}
 	}
 	return &al, nil
 }
 
+func (r *RepositoryMap) UpdateAdSchedule(ctx context.Context, id int64, publishAt *time.Time, expiresAt *time.Time, date time.Time) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.adTable[id]; !ok {
+		return app.ErrAdNotFound
+	}
+	ad := r.adTable[id]
+	ad.PublishAt = publishAt
+	ad.ExpiresAt = expiresAt
+	ad.DateChanged = date
+	r.adTable[id] = ad
+	return nil
+}
+
+func (r *RepositoryMap) SetAdFlags(ctx context.Context, id int64, flags []string) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.adTable[id]; !ok {
+		return app.ErrAdNotFound
+	}
+	ad := r.adTable[id]
+	ad.Flags = flags
+	r.adTable[id] = ad
+	return nil
+}
+
+func (r *RepositoryMap) PublishScheduledAds(ctx context.Context, now time.Time) ([]ads.Change, error) {
+	r.Lock()
+	defer r.Unlock()
+	changes := make([]ads.Change, 0)
+	for id, ad := range r.adTable {
+		if ad.Published || ad.PublishAt == nil || ad.PublishAt.After(now) {
+			continue
+		}
+		before := ad
+		ad.PublishAt = nil
+		ad.DateChanged = now
+		ad.Published = !ad.IsExpired(now)
+		r.adTable[id] = ad
+		changes = append(changes, ads.Change{Before: before, After: ad})
+	}
+	sortChanges(changes)
+	return changes, nil
+}
+
+func (r *RepositoryMap) ExpireAds(ctx context.Context, now time.Time) ([]ads.Change, error) {
+	r.Lock()
+	defer r.Unlock()
+	changes := make([]ads.Change, 0)
+	for id, ad := range r.adTable {
+		if !ad.Published || !ad.IsExpired(now) {
+			continue
+		}
+		before := ad
+		ad.Published = false
+		ad.DateChanged = now
+		r.adTable[id] = ad
+		changes = append(changes, ads.Change{Before: before, After: ad})
+	}
+	sortChanges(changes)
+	return changes, nil
+}
+
+// sortChanges упорядочивает изменения по ID объявлений, чтобы порядок не зависел от обхода map
+func sortChanges(changes []ads.Change) {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].After.ID < changes[j].After.ID })
+}
+
+// checkUnique проверяет, что почта и никнейм не заняты другим пользователем (без учета регистра)
+func (r *RepositoryMap) checkUnique(id int64, nickname string, email string) error {
+	if owner, ok := r.emails[user.Key(email)]; ok && owner != id {
+		return ErrEmailExists
+	}
+	if owner, ok := r.nicknames[user.Key(nickname)]; ok && owner != id {
+		return ErrNicknameExists
+	}
+	return nil
+}
+
 func (r *RepositoryMap) AddUser(ctx context.Context, u user.User) (int64, error) {
 	r.Lock()
 	defer r.Unlock()
-	u.ID = int64(len(r.userTable))
+	// -1 не совпадает ни с одним ID, а номер выдается только после проверки уникальности
+	if err := r.checkUnique(-1, u.Nickname, u.Email); err != nil {
+		return 0, err
+	}
+	u.ID = r.ids.Users.Next()
 	r.userTable[u.ID] = u
 	r.user2ads[u.ID] = make(map[int64]struct{})
+	r.emails[user.Key(u.Email)] = u.ID
+	r.nicknames[user.Key(u.Nickname)] = u.ID
 	return u.ID, nil
 }
 
 func (r *RepositoryMap) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
-	r.Lock()
-	defer r.Unlock()
+	r.RLock()
+	defer r.RUnlock()
 	if u, ok := r.userTable[id]; !ok {
 		return nil, app.ErrUserNotFound
 	} else {
@@ -119,10 +275,48 @@ func (r *RepositoryMap) UpdateUser(ctx context.Context, id int64, nickname strin
 	if _, ok := r.userTable[id]; !ok {
 		return app.ErrUserNotFound
 	}
+	if err := r.checkUnique(id, nickname, email); err != nil {
+		return err
+	}
 	u := r.userTable[id]
+	delete(r.emails, user.Key(u.Email))
+	delete(r.nicknames, user.Key(u.Nickname))
+	if user.Key(u.Email) != user.Key(email) {
+		u.Verified = false
+	}
 	u.Nickname = nickname
 	u.Email = email
 	r.userTable[id] = u
+	r.emails[user.Key(email)] = id
+	r.nicknames[user.Key(nickname)] = id
+	return nil
+}
+
+func (r *RepositoryMap) PatchUser(ctx context.Context, id int64, patch app.UserPatch) error {
+	r.Lock()
+	defer r.Unlock()
+	u, ok := r.userTable[id]
+	if !ok {
+		return app.ErrUserNotFound
+	}
+	merged := u
+	patch.Apply(&merged)
+	if err := r.checkUnique(id, merged.Nickname, merged.Email); err != nil {
+		return err
+	}
+	// индексы уникальности переписываются только для измененных полей
+	if patch.Email != nil {
+		delete(r.emails, user.Key(u.Email))
+		r.emails[user.Key(merged.Email)] = id
+		if user.Key(u.Email) != user.Key(merged.Email) {
+			merged.Verified = false
+		}
+	}
+	if patch.Nickname != nil {
+		delete(r.nicknames, user.Key(u.Nickname))
+		r.nicknames[user.Key(merged.Nickname)] = id
+	}
+	r.userTable[id] = merged
 	return nil
 }
 
@@ -142,10 +336,59 @@ func (r *RepositoryMap) DeleteUserByID(ctx context.Context, id int64) error {
 	if _, ok := r.userTable[id]; !ok {
 		return app.ErrUserNotFound
 	}
+	r.deleteUser(id)
+	return nil
+}
+
+// deleteUser удаляет пользователя со всеми связанными данными, вызывается под блокировкой
+func (r *RepositoryMap) deleteUser(id int64) {
 	for adID := range r.user2ads[id] {
 		delete(r.adTable, adID)
 	}
 	delete(r.user2ads, id)
+	delete(r.emails, user.Key(r.userTable[id].Email))
+	delete(r.nicknames, user.Key(r.userTable[id].Nickname))
 	delete(r.userTable, id)
+	r.deleteUserVerificationTokens(id)
+	r.deleteUserAlerts(id)
+	r.deleteUserConversations(id)
+}
+
+func (r *RepositoryMap) GetUserList(ctx context.Context, params app.ListUsersParams) ([]user.User, error) {
+	r.RLock()
+	defer r.RUnlock()
+	ul := make([]user.User, 0)
+	for _, u := range r.userTable {
+		if params.Matches(u) {
+			ul = append(ul, u)
+		}
+	}
+	sort.Slice(ul, func(i, j int) bool {
+		return ul[i].ID < ul[j].ID
+	})
+	return ul, nil
+}
+
+func (r *RepositoryMap) SetUserRole(ctx context.Context, id int64, role user.Role) error {
+	r.Lock()
+	defer r.Unlock()
+	u, ok := r.userTable[id]
+	if !ok {
+		return app.ErrUserNotFound
+	}
+	u.Role = role
+	r.userTable[id] = u
+	return nil
+}
+
+func (r *RepositoryMap) SetUserBanned(ctx context.Context, id int64, banned bool) error {
+	r.Lock()
+	defer r.Unlock()
+	u, ok := r.userTable[id]
+	if !ok {
+		return app.ErrUserNotFound
+	}
+	u.Banned = banned
+	r.userTable[id] = u
 	return nil
 }
@@ -0,0 +1,284 @@
+package adrepo
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultShards - число шардов объявлений по умолчанию
+const DefaultShards = 16
+
+type adShard struct {
+	sync.RWMutex
+	ads map[int64]ads.Ad
+}
+
+// ShardedRepository хранит объявления в шардах с отдельными блокировками и ведет вторичные
+// индексы по автору, статусу публикации, дням создания и последнего изменения, так что GetAdList не сканирует
+// всю таблицу. Пользователи, оповещения и переписки хранятся во вложенном RepositoryMap.
+//
+// Порядок захвата блокировок: пользователи -> шард -> индекс.
+type ShardedRepository struct {
+	*RepositoryMap
+	shards []*adShard
+	shift  uint
+	index  adIndex
+}
+
+// NewShardedRepository создает репозиторий с числом шардов, округленным вверх до степени двойки
+func NewShardedRepository(shards int) *ShardedRepository {
+	return NewShardedRepositoryWithIDs(shards, NewSequenceIDs())
+}
+
+func NewShardedRepositoryWithIDs(shards int, ids IDs) *ShardedRepository {
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+	n, bits := 1, uint(0)
+	for n < shards {
+		n <<= 1
+		bits++
+	}
+	r := &ShardedRepository{
+		RepositoryMap: NewRepositoryMapWithIDs(ids),
+		shards:        make([]*adShard, n),
+		shift:         64 - bits,
+		index:         newAdIndex(),
+	}
+	for i := range r.shards {
+		r.shards[i] = &adShard{ads: make(map[int64]ads.Ad)}
+	}
+	return r
+}
+
+// shard перемешивает биты ID (хеш Фибоначчи): у Snowflake ID младшие биты часто нулевые
+func (r *ShardedRepository) shard(id int64) *adShard {
+	return r.shards[(uint64(id)*0x9E3779B97F4A7C15)>>r.shift]
+}
+
+// lookup читает объявление из его шарда
+func (r *ShardedRepository) lookup(id int64) (ads.Ad, bool) {
+	s := r.shard(id)
+	s.RLock()
+	defer s.RUnlock()
+	ad, ok := s.ads[id]
+	return ad, ok
+}
+
+// update применяет изменение к объявлению под блокировкой шарда и поддерживает индексы
+func (r *ShardedRepository) update(id int64, change func(ad *ads.Ad)) error {
+	s := r.shard(id)
+	s.Lock()
+	defer s.Unlock()
+	ad, ok := s.ads[id]
+	if !ok {
+		return app.ErrAdNotFound
+	}
+	before := ad
+	change(&ad)
+	s.ads[id] = ad
+	r.index.update(before, ad)
+	return nil
+}
+
+func (r *ShardedRepository) AddAd(ctx context.Context, ad ads.Ad) (int64, error) {
+	// блокировка на чтение не дает удалить автора, но не мешает параллельным вставкам
+	r.RepositoryMap.RLock()
+	defer r.RepositoryMap.RUnlock()
+	if _, ok := r.userTable[ad.AuthorID]; !ok {
+		return 0, app.ErrUserNotFound
+	}
+	ad.ID = r.ids.Ads.Next()
+	s := r.shard(ad.ID)
+	s.Lock()
+	defer s.Unlock()
+	s.ads[ad.ID] = ad
+	r.index.add(ad)
+	return ad.ID, nil
+}
+
+func (r *ShardedRepository) GetAdByID(ctx context.Context, id int64) (*ads.Ad, error) {
+	if ad, ok := r.lookup(id); !ok {
+		return nil, app.ErrAdNotFound
+	} else {
+		return &ad, nil
+	}
+}
+
+func (r *ShardedRepository) UpdateAdStatus(ctx context.Context, id int64, published bool, date time.Time) error {
+	return r.update(id, func(ad *ads.Ad) {
+		ad.Published = published
+		ad.DateChanged = date
+	})
+}
+
+func (r *ShardedRepository) UpdateAdContent(ctx context.Context, id int64, title string, text string, date time.Time) error {
+	return r.update(id, func(ad *ads.Ad) {
+		ad.Title = title
+		ad.Text = text
+		ad.DateChanged = date
+	})
+}
+
+func (r *ShardedRepository) PatchAd(ctx context.Context, id int64, patch app.AdPatch, date time.Time) error {
+	return r.update(id, func(ad *ads.Ad) {
+		patch.Apply(ad)
+		ad.DateChanged = date
+	})
+}
+
+func (r *ShardedRepository) UpdateAdSchedule(ctx context.Context, id int64, publishAt *time.Time, expiresAt *time.Time, date time.Time) error {
+	return r.update(id, func(ad *ads.Ad) {
+		ad.PublishAt = publishAt
+		ad.ExpiresAt = expiresAt
+		ad.DateChanged = date
+	})
+}
+
+func (r *ShardedRepository) SetAdFlags(ctx context.Context, id int64, flags []string) error {
+	return r.update(id, func(ad *ads.Ad) {
+		ad.Flags = flags
+	})
+}
+
+func (r *ShardedRepository) DeleteAdByID(ctx context.Context, id int64) error {
+	s := r.shard(id)
+	s.Lock()
+	defer s.Unlock()
+	ad, ok := s.ads[id]
+	if !ok {
+		return app.ErrAdNotFound
+	}
+	delete(s.ads, id)
+	r.index.remove(ad)
+	return nil
+}
+
+func (r *ShardedRepository) GetAdList(ctx context.Context, params app.ListAdsParams) (*ads.AdList, error) {
+	al := ads.AdList{Data: make([]ads.Ad, 0)}
+	if ids, ok := r.index.candidates(params); ok {
+		// индекс мог устареть между чтениями, поэтому кандидаты перепроверяются
+		for _, id := range ids {
+			if ad, ok := r.lookup(id); ok && params.Matches(ad) {
+				al.Data = append(al.Data, ad)
+			}
+		}
+	} else {
+		for _, s := range r.shards {
+			s.RLock()
+			for _, ad := range s.ads {
+				if params.Matches(ad) {
+					al.Data = append(al.Data, ad)
+				}
+			}
+			s.RUnlock()
+		}
+	}
+	sort.Slice(al.Data, func(i, j int) bool { return al.Data[i].ID < al.Data[j].ID })
+	return &al, nil
+}
+
+func (r *ShardedRepository) PublishScheduledAds(ctx context.Context, now time.Time) ([]ads.Change, error) {
+	changes := make([]ads.Change, 0)
+	for _, s := range r.shards {
+		s.Lock()
+		for id, ad := range s.ads {
+			if ad.Published || ad.PublishAt == nil || ad.PublishAt.After(now) {
+				continue
+			}
+			before := ad
+			ad.PublishAt = nil
+			ad.DateChanged = now
+			ad.Published = !ad.IsExpired(now)
+			s.ads[id] = ad
+			r.index.update(before, ad)
+			changes = append(changes, ads.Change{Before: before, After: ad})
+		}
+		s.Unlock()
+	}
+	sortChanges(changes)
+	return changes, nil
+}
+
+func (r *ShardedRepository) ExpireAds(ctx context.Context, now time.Time) ([]ads.Change, error) {
+	changes := make([]ads.Change, 0)
+	for _, s := range r.shards {
+		s.Lock()
+		for id, ad := range s.ads {
+			if !ad.Published || !ad.IsExpired(now) {
+				continue
+			}
+			before := ad
+			ad.Published = false
+			ad.DateChanged = now
+			s.ads[id] = ad
+			r.index.update(before, ad)
+			changes = append(changes, ads.Change{Before: before, After: ad})
+		}
+		s.Unlock()
+	}
+	sortChanges(changes)
+	return changes, nil
+}
+
+func (r *ShardedRepository) DeleteUserByID(ctx context.Context, id int64) error {
+	r.RepositoryMap.Lock()
+	defer r.RepositoryMap.Unlock()
+	if _, ok := r.userTable[id]; !ok {
+		return app.ErrUserNotFound
+	}
+	for _, adID := range r.index.authorAds(id) {
+		s := r.shard(adID)
+		s.Lock()
+		if ad, ok := s.ads[adID]; ok {
+			delete(s.ads, adID)
+			r.index.remove(ad)
+		}
+		s.Unlock()
+	}
+	r.deleteUser(id)
+	return nil
+}
+
+func (r *ShardedRepository) AddFavorite(ctx context.Context, uid int64, adID int64) error {
+	r.RepositoryMap.Lock()
+	defer r.RepositoryMap.Unlock()
+	if _, ok := r.userTable[uid]; !ok {
+		return app.ErrUserNotFound
+	}
+	if _, ok := r.lookup(adID); !ok {
+		return app.ErrAdNotFound
+	}
+	if _, ok := r.favorites[uid]; !ok {
+		r.favorites[uid] = make(map[int64]struct{})
+	}
+	r.favorites[uid][adID] = struct{}{}
+	return nil
+}
+
+func (r *ShardedRepository) GetFavorites(ctx context.Context, uid int64) (*ads.AdList, error) {
+	r.RepositoryMap.RLock()
+	if _, ok := r.userTable[uid]; !ok {
+		r.RepositoryMap.RUnlock()
+		return nil, app.ErrUserNotFound
+	}
+	ids := make([]int64, 0, len(r.favorites[uid]))
+	for adID := range r.favorites[uid] {
+		ids = append(ids, adID)
+	}
+	r.RepositoryMap.RUnlock()
+
+	al := ads.AdList{Data: make([]ads.Ad, 0, len(ids))}
+	for _, adID := range ids {
+		// удаленные объявления просто пропадают из избранного
+		if ad, ok := r.lookup(adID); ok {
+			al.Data = append(al.Data, ad)
+		}
+	}
+	sort.Slice(al.Data, func(i, j int) bool { return al.Data[i].ID < al.Data[j].ID })
+	return &al, nil
+}
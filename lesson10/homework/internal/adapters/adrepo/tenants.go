@@ -0,0 +1,236 @@
+package adrepo
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/messages"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TenantRepository изолирует данные арендаторов: каждый метод работает с отдельным репозиторием
+// арендатора из контекста. Репозиторий создается фабрикой при первом обращении арендатора,
+// поэтому так разделяется любое хранилище, а кэш, оборачивающий репозиторий арендатора,
+// не смешивает объявления с одинаковыми ID.
+type TenantRepository struct {
+	mu      sync.RWMutex
+	factory func(id tenant.ID) app.Repository
+	repos   map[tenant.ID]app.Repository
+}
+
+func NewTenantRepository(factory func(id tenant.ID) app.Repository) *TenantRepository {
+	return &TenantRepository{factory: factory, repos: make(map[tenant.ID]app.Repository)}
+}
+
+// Tenant возвращает репозиторий арендатора, создавая его при необходимости
+func (r *TenantRepository) Tenant(id tenant.ID) app.Repository {
+	r.mu.RLock()
+	repo, ok := r.repos[id]
+	r.mu.RUnlock()
+	if ok {
+		return repo
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if repo, ok = r.repos[id]; !ok {
+		repo = r.factory(id)
+		r.repos[id] = repo
+	}
+	return repo
+}
+
+// Tenants возвращает арендаторов, у которых уже есть данные, в порядке возрастания
+func (r *TenantRepository) Tenants() []tenant.ID {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]tenant.ID, 0, len(r.repos))
+	for id := range r.repos {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (r *TenantRepository) repo(ctx context.Context) app.Repository {
+	return r.Tenant(tenant.IDFromContext(ctx))
+}
+
+func (r *TenantRepository) AddAd(ctx context.Context, ad ads.Ad) (int64, error) {
+	return r.repo(ctx).AddAd(ctx, ad)
+}
+
+func (r *TenantRepository) GetAdByID(ctx context.Context, id int64) (*ads.Ad, error) {
+	return r.repo(ctx).GetAdByID(ctx, id)
+}
+
+func (r *TenantRepository) UpdateAdStatus(ctx context.Context, id int64, published bool, date time.Time) error {
+	return r.repo(ctx).UpdateAdStatus(ctx, id, published, date)
+}
+
+func (r *TenantRepository) UpdateAdContent(ctx context.Context, id int64, title string, text string, date time.Time) error {
+	return r.repo(ctx).UpdateAdContent(ctx, id, title, text, date)
+}
+
+func (r *TenantRepository) PatchAd(ctx context.Context, id int64, patch app.AdPatch, date time.Time) error {
+	return r.repo(ctx).PatchAd(ctx, id, patch, date)
+}
+
+func (r *TenantRepository) DeleteAdByID(ctx context.Context, id int64) error {
+	return r.repo(ctx).DeleteAdByID(ctx, id)
+}
+
+func (r *TenantRepository) UpdateAdSchedule(ctx context.Context, id int64, publishAt *time.Time, expiresAt *time.Time, date time.Time) error {
+	return r.repo(ctx).UpdateAdSchedule(ctx, id, publishAt, expiresAt, date)
+}
+
+func (r *TenantRepository) SetAdFlags(ctx context.Context, id int64, flags []string) error {
+	return r.repo(ctx).SetAdFlags(ctx, id, flags)
+}
+
+func (r *TenantRepository) GetAdList(ctx context.Context, params app.ListAdsParams) (*ads.AdList, error) {
+	return r.repo(ctx).GetAdList(ctx, params)
+}
+
+func (r *TenantRepository) PublishScheduledAds(ctx context.Context, now time.Time) ([]ads.Change, error) {
+	return r.repo(ctx).PublishScheduledAds(ctx, now)
+}
+
+func (r *TenantRepository) ExpireAds(ctx context.Context, now time.Time) ([]ads.Change, error) {
+	return r.repo(ctx).ExpireAds(ctx, now)
+}
+
+func (r *TenantRepository) AddUser(ctx context.Context, u user.User) (int64, error) {
+	return r.repo(ctx).AddUser(ctx, u)
+}
+
+func (r *TenantRepository) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
+	return r.repo(ctx).GetUserByID(ctx, id)
+}
+
+func (r *TenantRepository) UpdateUser(ctx context.Context, id int64, nickname string, email string) error {
+	return r.repo(ctx).UpdateUser(ctx, id, nickname, email)
+}
+
+func (r *TenantRepository) PatchUser(ctx context.Context, id int64, patch app.UserPatch) error {
+	return r.repo(ctx).PatchUser(ctx, id, patch)
+}
+
+func (r *TenantRepository) DeleteUserByID(ctx context.Context, id int64) error {
+	return r.repo(ctx).DeleteUserByID(ctx, id)
+}
+
+func (r *TenantRepository) AddVerificationToken(ctx context.Context, t app.VerificationToken) error {
+	return r.repo(ctx).AddVerificationToken(ctx, t)
+}
+
+func (r *TenantRepository) GetVerificationToken(ctx context.Context, token string) (*app.VerificationToken, error) {
+	return r.repo(ctx).GetVerificationToken(ctx, token)
+}
+
+func (r *TenantRepository) DeleteVerificationToken(ctx context.Context, token string) error {
+	return r.repo(ctx).DeleteVerificationToken(ctx, token)
+}
+
+func (r *TenantRepository) SetUserVerified(ctx context.Context, id int64, email string) error {
+	return r.repo(ctx).SetUserVerified(ctx, id, email)
+}
+
+func (r *TenantRepository) GetUserList(ctx context.Context, params app.ListUsersParams) ([]user.User, error) {
+	return r.repo(ctx).GetUserList(ctx, params)
+}
+
+func (r *TenantRepository) SetUserRole(ctx context.Context, id int64, role user.Role) error {
+	return r.repo(ctx).SetUserRole(ctx, id, role)
+}
+
+func (r *TenantRepository) SetUserBanned(ctx context.Context, id int64, banned bool) error {
+	return r.repo(ctx).SetUserBanned(ctx, id, banned)
+}
+
+func (r *TenantRepository) AddFavorite(ctx context.Context, uid int64, adID int64) error {
+	return r.repo(ctx).AddFavorite(ctx, uid, adID)
+}
+
+func (r *TenantRepository) DeleteFavorite(ctx context.Context, uid int64, adID int64) error {
+	return r.repo(ctx).DeleteFavorite(ctx, uid, adID)
+}
+
+func (r *TenantRepository) GetFavorites(ctx context.Context, uid int64) (*ads.AdList, error) {
+	return r.repo(ctx).GetFavorites(ctx, uid)
+}
+
+func (r *TenantRepository) AddSavedSearch(ctx context.Context, s app.SavedSearch) (int64, error) {
+	return r.repo(ctx).AddSavedSearch(ctx, s)
+}
+
+func (r *TenantRepository) GetSavedSearchByID(ctx context.Context, id int64) (*app.SavedSearch, error) {
+	return r.repo(ctx).GetSavedSearchByID(ctx, id)
+}
+
+func (r *TenantRepository) GetUserSavedSearches(ctx context.Context, uid int64) ([]app.SavedSearch, error) {
+	return r.repo(ctx).GetUserSavedSearches(ctx, uid)
+}
+
+func (r *TenantRepository) GetSavedSearches(ctx context.Context) ([]app.SavedSearch, error) {
+	return r.repo(ctx).GetSavedSearches(ctx)
+}
+
+func (r *TenantRepository) DeleteSavedSearchByID(ctx context.Context, id int64) error {
+	return r.repo(ctx).DeleteSavedSearchByID(ctx, id)
+}
+
+func (r *TenantRepository) AddNotification(ctx context.Context, n app.Notification) (int64, error) {
+	return r.repo(ctx).AddNotification(ctx, n)
+}
+
+func (r *TenantRepository) GetNotifications(ctx context.Context, uid int64) ([]app.Notification, error) {
+	return r.repo(ctx).GetNotifications(ctx, uid)
+}
+
+func (r *TenantRepository) AddConversation(ctx context.Context, c messages.Conversation) (int64, error) {
+	return r.repo(ctx).AddConversation(ctx, c)
+}
+
+func (r *TenantRepository) GetConversationByID(ctx context.Context, id int64) (*messages.Conversation, error) {
+	return r.repo(ctx).GetConversationByID(ctx, id)
+}
+
+func (r *TenantRepository) FindConversation(ctx context.Context, adID int64, buyerID int64) (*messages.Conversation, error) {
+	return r.repo(ctx).FindConversation(ctx, adID, buyerID)
+}
+
+func (r *TenantRepository) GetUserConversations(ctx context.Context, uid int64) ([]messages.Conversation, error) {
+	return r.repo(ctx).GetUserConversations(ctx, uid)
+}
+
+func (r *TenantRepository) AddMessage(ctx context.Context, m messages.Message) (int64, error) {
+	return r.repo(ctx).AddMessage(ctx, m)
+}
+
+func (r *TenantRepository) GetMessages(ctx context.Context, convID int64, offset int, limit int) ([]messages.Message, error) {
+	return r.repo(ctx).GetMessages(ctx, convID, offset, limit)
+}
+
+func (r *TenantRepository) MarkMessagesRead(ctx context.Context, convID int64, uid int64, upTo int64) error {
+	return r.repo(ctx).MarkMessagesRead(ctx, convID, uid, upTo)
+}
+
+func (r *TenantRepository) CountUnreadMessages(ctx context.Context, convID int64, uid int64) (int, error) {
+	return r.repo(ctx).CountUnreadMessages(ctx, convID, uid)
+}
+
+func (r *TenantRepository) AddBlock(ctx context.Context, uid int64, blockedID int64) error {
+	return r.repo(ctx).AddBlock(ctx, uid, blockedID)
+}
+
+func (r *TenantRepository) DeleteBlock(ctx context.Context, uid int64, blockedID int64) error {
+	return r.repo(ctx).DeleteBlock(ctx, uid, blockedID)
+}
+
+func (r *TenantRepository) IsBlocked(ctx context.Context, uid int64, blockedID int64) (bool, error) {
+	return r.repo(ctx).IsBlocked(ctx, uid, blockedID)
+}
@@ -0,0 +1,63 @@
+package adrepo
+
+import (
+	"context"
+	"homework10/internal/app"
+)
+
+func (r *RepositoryMap) AddVerificationToken(ctx context.Context, t app.VerificationToken) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.userTable[t.UserID]; !ok {
+		return app.ErrUserNotFound
+	}
+	r.verificationTable[t.Token] = t
+	return nil
+}
+
+func (r *RepositoryMap) GetVerificationToken(ctx context.Context, token string) (*app.VerificationToken, error) {
+	r.RLock()
+	defer r.RUnlock()
+	t, ok := r.verificationTable[token]
+	if !ok {
+		return nil, app.ErrTokenNotFound
+	}
+	return &t, nil
+}
+
+func (r *RepositoryMap) DeleteVerificationToken(ctx context.Context, token string) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.verificationTable[token]; !ok {
+		return app.ErrTokenNotFound
+	}
+	delete(r.verificationTable, token)
+	return nil
+}
+
+func (r *RepositoryMap) SetUserVerified(ctx context.Context, id int64, email string) error {
+	r.Lock()
+	defer r.Unlock()
+	u, ok := r.userTable[id]
+	if !ok {
+		return app.ErrUserNotFound
+	}
+	// почта могла смениться, пока письмо шло к пользователю
+	if u.Email != email {
+		return app.ErrTokenNotFound
+	}
+	u.Verified = true
+	r.userTable[id] = u
+	// остальные токены пользователя больше не нужны
+	r.deleteUserVerificationTokens(id)
+	return nil
+}
+
+// deleteUserVerificationTokens должен вызываться под блокировкой
+func (r *RepositoryMap) deleteUserVerificationTokens(uid int64) {
+	for token, t := range r.verificationTable {
+		if t.UserID == uid {
+			delete(r.verificationTable, token)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// lru - кэш фиксированного размера с вытеснением давно не использованных записей
+// и временем жизни записей. Не потокобезопасен, синхронизация на стороне Repository
+type lru[K comparable, V any] struct {
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	items    map[K]*list.Element
+}
+
+func newLRU[K comparable, V any](capacity int, ttl time.Duration) *lru[K, V] {
+	return &lru[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+	}
+}
+
+func (c *lru[K, V]) get(key K, now time.Time) (V, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	e := el.Value.(*entry[K, V])
+	if c.ttl > 0 && !now.Before(e.expiresAt) {
+		c.removeElement(el)
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+func (c *lru[K, V]) put(key K, value V, now time.Time) {
+	if c.capacity <= 0 {
+		return
+	}
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = now.Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+	el := c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: now.Add(c.ttl)})
+	c.items[key] = el
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *lru[K, V]) remove(key K) {
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// removeIf удаляет все записи, для которых pred вернул true
+func (c *lru[K, V]) removeIf(pred func(key K, value V) bool) {
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		e := el.Value.(*entry[K, V])
+		if pred(e.key, e.value) {
+			c.removeElement(el)
+		}
+		el = next
+	}
+}
+
+func (c *lru[K, V]) len() int {
+	return c.order.Len()
+}
+
+func (c *lru[K, V]) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,429 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/user"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Config struct {
+	Capacity     int           // максимальное число объявлений и пользователей в кэше
+	ListCapacity int           // максимальное число списков объявлений в кэше
+	TTL          time.Duration // время жизни записи
+	// ListAdmitAfter - сколько раз запрос списка должен пропустить кэш, прежде чем
+	// его результат будет закэширован. Так в кэш попадают только популярные запросы
+	ListAdmitAfter int
+	Clock          func() time.Time
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Capacity:       10000,
+		ListCapacity:   100,
+		TTL:            time.Minute,
+		ListAdmitAfter: 2,
+	}
+}
+
+// Counter - статистика обращений к одному из кэшей
+type Counter struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+type Stats struct {
+	Ads   Counter
+	Users Counter
+	Lists Counter
+}
+
+type counter struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func (c *counter) hit() {
+	c.hits.Add(1)
+}
+
+func (c *counter) miss() {
+	c.misses.Add(1)
+}
+
+type cachedList struct {
+	params app.ListAdsParams
+	data   []ads.Ad
+}
+
+// Repository - декоратор app.Repository, кэширующий чтение объявлений, пользователей
+// и популярных списков объявлений. Методы, не влияющие на закэшированные данные,
+// передаются обернутому репозиторию без изменений
+type Repository struct {
+	app.Repository
+
+	mu    sync.Mutex
+	clock func() time.Time
+	// gen увеличивается при каждой инвалидации: результат чтения, начатого до
+	// изменения данных, не попадет в кэш
+	gen uint64
+
+	ads        *lru[int64, ads.Ad]
+	users      *lru[int64, user.User]
+	lists      *lru[string, cachedList]
+	listMisses *lru[string, int]
+	admitAfter int
+
+	adStats, userStats, listStats counter
+}
+
+func New(repo app.Repository, cfg Config) *Repository {
+	clock := cfg.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	return &Repository{
+		Repository: repo,
+		clock:      clock,
+		ads:        newLRU[int64, ads.Ad](cfg.Capacity, cfg.TTL),
+		users:      newLRU[int64, user.User](cfg.Capacity, cfg.TTL),
+		lists:      newLRU[string, cachedList](cfg.ListCapacity, cfg.TTL),
+		listMisses: newLRU[string, int](cfg.ListCapacity*10, cfg.TTL),
+		admitAfter: cfg.ListAdmitAfter,
+	}
+}
+
+func (r *Repository) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Stats{
+		Ads:   Counter{Hits: r.adStats.hits.Load(), Misses: r.adStats.misses.Load(), Size: r.ads.len()},
+		Users: Counter{Hits: r.userStats.hits.Load(), Misses: r.userStats.misses.Load(), Size: r.users.len()},
+		Lists: Counter{Hits: r.listStats.hits.Load(), Misses: r.listStats.misses.Load(), Size: r.lists.len()},
+	}
+}
+
+func (r *Repository) GetAdByID(ctx context.Context, id int64) (*ads.Ad, error) {
+	r.mu.Lock()
+	ad, ok := r.ads.get(id, r.clock())
+	gen := r.gen
+	r.mu.Unlock()
+	if ok {
+		r.adStats.hit()
+		return &ad, nil
+	}
+	r.adStats.miss()
+
+	res, err := r.Repository.GetAdByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if gen == r.gen {
+		r.ads.put(id, *res, r.clock())
+	}
+	r.mu.Unlock()
+	return res, nil
+}
+
+func (r *Repository) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
+	r.mu.Lock()
+	u, ok := r.users.get(id, r.clock())
+	gen := r.gen
+	r.mu.Unlock()
+	if ok {
+		r.userStats.hit()
+		return &u, nil
+	}
+	r.userStats.miss()
+
+	res, err := r.Repository.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if gen == r.gen {
+		r.users.put(id, *res, r.clock())
+	}
+	r.mu.Unlock()
+	return res, nil
+}
+
+// GetAdList кэширует список без учета ActiveAt: приложение передает в нем текущее время,
+// поэтому истекшие объявления отфильтровываются при каждом чтении из кэша
+func (r *Repository) GetAdList(ctx context.Context, params app.ListAdsParams) (*ads.AdList, error) {
+	base := params
+	base.ActiveAt = nil
+	key := listKey(base)
+
+	r.mu.Lock()
+	cl, ok := r.lists.get(key, r.clock())
+	gen := r.gen
+	admit := false
+	if !ok {
+		misses, _ := r.listMisses.get(key, r.clock())
+		misses++
+		r.listMisses.put(key, misses, r.clock())
+		admit = misses >= r.admitAfter
+	}
+	r.mu.Unlock()
+	if ok {
+		r.listStats.hit()
+		al := ads.AdList{Data: make([]ads.Ad, 0, len(cl.data))}
+		for _, ad := range cl.data {
+			if params.Matches(ad) {
+				al.Data = append(al.Data, ad)
+			}
+		}
+		return &al, nil
+	}
+	r.listStats.miss()
+
+	if !admit {
+		return r.Repository.GetAdList(ctx, params)
+	}
+
+	al, err := r.Repository.GetAdList(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]ads.Ad, len(al.Data))
+	copy(data, al.Data)
+
+	r.mu.Lock()
+	if gen == r.gen {
+		r.lists.put(key, cachedList{params: base, data: data}, r.clock())
+		r.listMisses.remove(key)
+	}
+	r.mu.Unlock()
+
+	res := ads.AdList{Data: make([]ads.Ad, 0, len(al.Data))}
+	for _, ad := range al.Data {
+		if params.Matches(ad) {
+			res.Data = append(res.Data, ad)
+		}
+	}
+	return &res, nil
+}
+
+func (r *Repository) AddAd(ctx context.Context, ad ads.Ad) (int64, error) {
+	id, err := r.Repository.AddAd(ctx, ad)
+	if err != nil {
+		return id, err
+	}
+	ad.ID = id
+	r.invalidateAds([]ads.Ad{ad})
+	return id, nil
+}
+
+func (r *Repository) UpdateAdStatus(ctx context.Context, id int64, published bool, date time.Time) error {
+	err := r.Repository.UpdateAdStatus(ctx, id, published, date)
+	r.invalidateAd(ctx, id)
+	return err
+}
+
+func (r *Repository) UpdateAdContent(ctx context.Context, id int64, title string, text string, date time.Time) error {
+	err := r.Repository.UpdateAdContent(ctx, id, title, text, date)
+	r.invalidateAd(ctx, id)
+	return err
+}
+
+func (r *Repository) PatchAd(ctx context.Context, id int64, patch app.AdPatch, date time.Time) error {
+	err := r.Repository.PatchAd(ctx, id, patch, date)
+	r.invalidateAd(ctx, id)
+	return err
+}
+
+func (r *Repository) UpdateAdSchedule(ctx context.Context, id int64, publishAt *time.Time, expiresAt *time.Time, date time.Time) error {
+	err := r.Repository.UpdateAdSchedule(ctx, id, publishAt, expiresAt, date)
+	r.invalidateAd(ctx, id)
+	return err
+}
+
+func (r *Repository) SetAdFlags(ctx context.Context, id int64, flags []string) error {
+	err := r.Repository.SetAdFlags(ctx, id, flags)
+	r.invalidateAd(ctx, id)
+	return err
+}
+
+func (r *Repository) DeleteAdByID(ctx context.Context, id int64) error {
+	err := r.Repository.DeleteAdByID(ctx, id)
+	r.invalidateAd(ctx, id)
+	return err
+}
+
+func (r *Repository) PublishScheduledAds(ctx context.Context, now time.Time) ([]ads.Change, error) {
+	changes, err := r.Repository.PublishScheduledAds(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidateAds(changed(changes))
+	return changes, nil
+}
+
+func (r *Repository) ExpireAds(ctx context.Context, now time.Time) ([]ads.Change, error) {
+	changes, err := r.Repository.ExpireAds(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidateAds(changed(changes))
+	return changes, nil
+}
+
+// changed возвращает объявления после изменений
+func changed(changes []ads.Change) []ads.Ad {
+	al := make([]ads.Ad, 0, len(changes))
+	for _, c := range changes {
+		al = append(al, c.After)
+	}
+	return al
+}
+
+func (r *Repository) UpdateUser(ctx context.Context, id int64, nickname string, email string) error {
+	err := r.Repository.UpdateUser(ctx, id, nickname, email)
+	r.invalidateUser(id)
+	return err
+}
+
+func (r *Repository) PatchUser(ctx context.Context, id int64, patch app.UserPatch) error {
+	err := r.Repository.PatchUser(ctx, id, patch)
+	r.invalidateUser(id)
+	return err
+}
+
+func (r *Repository) SetUserVerified(ctx context.Context, id int64, email string) error {
+	err := r.Repository.SetUserVerified(ctx, id, email)
+	r.invalidateUser(id)
+	return err
+}
+
+func (r *Repository) SetUserRole(ctx context.Context, id int64, role user.Role) error {
+	err := r.Repository.SetUserRole(ctx, id, role)
+	r.invalidateUser(id)
+	return err
+}
+
+func (r *Repository) SetUserBanned(ctx context.Context, id int64, banned bool) error {
+	err := r.Repository.SetUserBanned(ctx, id, banned)
+	r.invalidateUser(id)
+	return err
+}
+
+// DeleteUserByID удаляет вместе с пользователем все его объявления
+func (r *Repository) DeleteUserByID(ctx context.Context, id int64) error {
+	err := r.Repository.DeleteUserByID(ctx, id)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gen++
+	r.users.remove(id)
+	r.ads.removeIf(func(_ int64, ad ads.Ad) bool {
+		return ad.AuthorID == id
+	})
+	r.lists.removeIf(func(_ string, cl cachedList) bool {
+		if cl.params.Uid != nil && *cl.params.Uid == id {
+			return true
+		}
+		for _, ad := range cl.data {
+			if ad.AuthorID == id {
+				return true
+			}
+		}
+		return false
+	})
+	return err
+}
+
+func (r *Repository) invalidateUser(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gen++
+	r.users.remove(id)
+}
+
+// invalidateAd сбрасывает объявление и списки, в которых оно было или должно появиться.
+// Новое состояние читается из обернутого репозитория после записи
+func (r *Repository) invalidateAd(ctx context.Context, id int64) {
+	var changed []ads.Ad
+	if ad, err := r.Repository.GetAdByID(ctx, id); err == nil {
+		changed = append(changed, *ad)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gen++
+	r.ads.remove(id)
+	r.lists.removeIf(func(_ string, cl cachedList) bool {
+		return containsAd(cl.data, id) || matchesAny(cl.params, changed)
+	})
+}
+
+func (r *Repository) invalidateAds(al []ads.Ad) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gen++
+	for _, ad := range al {
+		r.ads.remove(ad.ID)
+	}
+	r.lists.removeIf(func(_ string, cl cachedList) bool {
+		for _, ad := range al {
+			if containsAd(cl.data, ad.ID) {
+				return true
+			}
+		}
+		return matchesAny(cl.params, al)
+	})
+}
+
+func containsAd(al []ads.Ad, id int64) bool {
+	for _, ad := range al {
+		if ad.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(params app.ListAdsParams, al []ads.Ad) bool {
+	for _, ad := range al {
+		if params.Matches(ad) {
+			return true
+		}
+	}
+	return false
+}
+
+func listKey(p app.ListAdsParams) string {
+	key := ""
+	if p.Published != nil {
+		key += fmt.Sprintf("published=%t;", *p.Published)
+	}
+	if p.Uid != nil {
+		key += fmt.Sprintf("uid=%d;", *p.Uid)
+	}
+	if p.Date != nil {
+		key += "date=" + p.Date.Format(app.DateLayout) + ";"
+	}
+	if p.Title != nil {
+		key += fmt.Sprintf("title=%q;", *p.Title)
+	}
+	for _, bound := range []struct {
+		name string
+		t    *time.Time
+	}{{"created_from", p.CreatedFrom}, {"created_to", p.CreatedTo}, {"changed_since", p.ChangedSince}} {
+		if bound.t != nil {
+			key += bound.name + "=" + bound.t.UTC().Format(time.RFC3339Nano) + ";"
+		}
+	}
+	if p.Filter != nil {
+		key += "filter=" + p.Filter.String() + ";"
+	}
+	return key
+}
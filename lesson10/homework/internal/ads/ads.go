@@ -10,8 +10,35 @@ type Ad struct {
 	Published   bool
 	DateCreated time.Time
 	DateChanged time.Time
+	PublishAt   *time.Time // момент отложенной публикации (nil - не запланирована)
+	ExpiresAt   *time.Time // момент, после которого объявление снимается с публикации
+	Flags       []string   // причины, по которым объявление ждет проверки модератором (см. пакет policy)
+}
+
+// IsExpired сообщает, истек ли срок жизни объявления к моменту t
+func (ad Ad) IsExpired(t time.Time) bool {
+	return ad.ExpiresAt != nil && !ad.ExpiresAt.After(t)
+}
+
+// LastChanged возвращает момент последнего изменения объявления, для не изменявшегося - момент создания
+func (ad Ad) LastChanged() time.Time {
+	if ad.DateChanged.IsZero() {
+		return ad.DateCreated
+	}
+	return ad.DateChanged
+}
+
+// IsFlagged сообщает, ждет ли объявление проверки модератором: до проверки его нельзя опубликовать
+func (ad Ad) IsFlagged() bool {
+	return len(ad.Flags) > 0
 }
 
 type AdList struct {
 	Data []Ad
 }
+
+// Change - объявление до и после изменения, которое сервис сделал сам (публикация по расписанию, истечение срока)
+type Change struct {
+	Before Ad
+	After  Ad
+}
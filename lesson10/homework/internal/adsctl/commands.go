@@ -0,0 +1,617 @@
+// Package adsctl реализует консольную утилиту для работы с сервисом объявлений
+package adsctl
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/user"
+	"homework10/pkg/client"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Options - зависимости утилиты, подменяются в тестах
+type Options struct {
+	Out io.Writer
+	// Dial создает клиент для профиля, по умолчанию HTTP или gRPC без TLS
+	Dial func(ctx context.Context, p Profile) (client.AdsClient, error)
+}
+
+// Dial - клиент по умолчанию для профиля
+func Dial(ctx context.Context, p Profile) (client.AdsClient, error) {
+	cfg := client.DefaultConfig()
+	if p.Timeout > 0 {
+		cfg.Timeout = p.Timeout
+	}
+	cfg.MaxRetries = p.Retries
+	cfg.Tenant = p.Tenant
+	switch p.Transport {
+	case TransportHTTP:
+		return client.NewHTTPClient(p.Address, cfg, nil), nil
+	case TransportGRPC:
+		return client.DialGRPC(ctx, p.Address, cfg, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownTransport, p.Transport)
+}
+
+type cli struct {
+	opts Options
+
+	configPath string
+	profile    string
+	output     string
+	address    string
+	transport  string
+	tenant     string
+}
+
+func NewRootCommand(opts Options) *cobra.Command {
+	if opts.Out == nil {
+		opts.Out = os.Stdout
+	}
+	if opts.Dial == nil {
+		opts.Dial = Dial
+	}
+	c := &cli{opts: opts}
+
+	root := &cobra.Command{
+		Use:           "adsctl",
+		Short:         "Command-line tool for the ads service",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.SetOut(opts.Out)
+	flags := root.PersistentFlags()
+	flags.StringVar(&c.configPath, "config", DefaultConfigPath(), "path to the config file")
+	flags.StringVarP(&c.profile, "profile", "p", os.Getenv(ProfileEnv), "config profile (defaults to the current one)")
+	flags.StringVarP(&c.output, "output", "o", "", "output format: table, json or yaml")
+	flags.StringVar(&c.address, "address", "", "override the profile address")
+	flags.StringVar(&c.transport, "transport", "", "override the profile transport: http or grpc")
+	flags.StringVar(&c.tenant, "tenant", "", "override the profile tenant")
+	_ = root.RegisterFlagCompletionFunc("profile", c.completeProfiles)
+	_ = root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(
+		[]string{OutputTable, OutputJSON, OutputYAML}, cobra.ShellCompDirectiveNoFileComp))
+	_ = root.RegisterFlagCompletionFunc("transport", cobra.FixedCompletions(
+		[]string{TransportHTTP, TransportGRPC}, cobra.ShellCompDirectiveNoFileComp))
+
+	root.AddCommand(c.adCommand(), c.userCommand(), c.configCommand())
+	return root
+}
+
+func (c *cli) completeProfiles(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := LoadConfig(c.configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return cfg.ProfileNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// resolveProfile применяет к профилю флаги командной строки
+func (c *cli) resolveProfile() (Profile, error) {
+	cfg, err := LoadConfig(c.configPath)
+	if err != nil {
+		return Profile{}, err
+	}
+	p, err := cfg.Profile(c.profile)
+	if err != nil {
+		return Profile{}, err
+	}
+	if c.address != "" {
+		p.Address = c.address
+	}
+	if c.transport != "" {
+		p.Transport = c.transport
+	}
+	if c.tenant != "" {
+		p.Tenant = c.tenant
+	}
+	if c.output != "" {
+		p.Output = c.output
+	}
+	if p.Output == "" {
+		p.Output = OutputTable
+	}
+	if err := checkOutput(p.Output); err != nil {
+		return Profile{}, err
+	}
+	return p, p.Validate()
+}
+
+// run выполняет действие с клиентом выбранного профиля
+func (c *cli) run(cmd *cobra.Command, action func(ctx context.Context, ac client.AdsClient, p Profile) error) error {
+	p, err := c.resolveProfile()
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ac, err := c.opts.Dial(ctx, p)
+	if err != nil {
+		return err
+	}
+	defer ac.Close()
+	return action(ctx, ac, p)
+}
+
+func (c *cli) printAd(p Profile, ad *ads.Ad) error {
+	v := newAdView(*ad)
+	return render(c.opts.Out, p.Output, v, adViews{v})
+}
+
+func (c *cli) printAds(p Profile, al []ads.Ad) error {
+	views := make(adViews, 0, len(al))
+	for _, ad := range al {
+		views = append(views, newAdView(ad))
+	}
+	return render(c.opts.Out, p.Output, views, views)
+}
+
+func (c *cli) printUser(p Profile, u *user.User) error {
+	v := newUserView(*u)
+	return render(c.opts.Out, p.Output, v, userViews{v})
+}
+
+func (c *cli) printUsers(p Profile, ul []user.User) error {
+	views := make(userViews, 0, len(ul))
+	for _, u := range ul {
+		views = append(views, newUserView(u))
+	}
+	return render(c.opts.Out, p.Output, views, views)
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", s)
+	}
+	return id, nil
+}
+
+func (c *cli) adCommand() *cobra.Command {
+	cmd := &cobra.Command{Use: "ad", Aliases: []string{"ads"}, Short: "Manage ads"}
+
+	var uid int64
+	var title, text string
+
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create an ad",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				ad, err := ac.CreateAd(ctx, uid, title, text)
+				if err != nil {
+					return err
+				}
+				return c.printAd(p, ad)
+			})
+		},
+	}
+	create.Flags().Int64Var(&uid, "user", 0, "author id")
+	create.Flags().StringVar(&title, "title", "", "ad title")
+	create.Flags().StringVar(&text, "text", "", "ad text")
+	_ = create.MarkFlagRequired("user")
+
+	get := &cobra.Command{
+		Use:   "get ID",
+		Short: "Show an ad",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				ad, err := ac.GetAd(ctx, id)
+				if err != nil {
+					return err
+				}
+				return c.printAd(p, ad)
+			})
+		},
+	}
+
+	var published bool
+	var listUID int64
+	var date, listTitle, createdFrom, createdTo, changedSince, expr string
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List ads with optional filters",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var params app.ListAdsParams
+			flags := cmd.Flags()
+			if flags.Changed("published") {
+				params.Published = &published
+			}
+			if flags.Changed("user") {
+				params.Uid = &listUID
+			}
+			if flags.Changed("title") {
+				params.Title = &listTitle
+			}
+			if flags.Changed("date") {
+				d, err := time.Parse(app.DateLayout, date)
+				if err != nil {
+					return fmt.Errorf("invalid date %q, expected %s", date, app.DateLayout)
+				}
+				params.Date = &d
+			}
+			now := time.Now()
+			bounds := []struct {
+				flag  string
+				value *string
+				dst   **time.Time
+			}{
+				{"created-from", &createdFrom, &params.CreatedFrom},
+				{"created-to", &createdTo, &params.CreatedTo},
+				{"changed-since", &changedSince, &params.ChangedSince},
+			}
+			for _, b := range bounds {
+				if !flags.Changed(b.flag) {
+					continue
+				}
+				t, err := app.ParseTime(b.value, now)
+				if err != nil {
+					return fmt.Errorf("--%s: %w", b.flag, err)
+				}
+				*b.dst = t
+			}
+			var err error
+			if params.Filter, err = app.ParseFilter(&expr); err != nil {
+				return fmt.Errorf("--filter: %w", err)
+			}
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				al, err := ac.ListAds(ctx, params)
+				if err != nil {
+					return err
+				}
+				return c.printAds(p, al)
+			})
+		},
+	}
+	list.Flags().BoolVar(&published, "published", false, "only published (or with =false unpublished) ads")
+	list.Flags().Int64Var(&listUID, "user", 0, "author id")
+	list.Flags().StringVar(&date, "date", "", "creation date, "+app.DateLayout)
+	list.Flags().StringVar(&listTitle, "title", "", "exact title")
+	list.Flags().StringVar(&createdFrom, "created-from", "", "created at or after: RFC3339, "+app.DateLayout+" or offset like -7d")
+	list.Flags().StringVar(&createdTo, "created-to", "", "created before: RFC3339, "+app.DateLayout+" or offset like -7d")
+	list.Flags().StringVar(&changedSince, "changed-since", "", "changed at or after: RFC3339, "+app.DateLayout+" or offset like -36h")
+	list.Flags().StringVar(&expr, "filter", "", `filter expression, e.g. 'published = true AND title ~ "bike"'`)
+
+	var updUID int64
+	var updTitle, updText string
+	update := &cobra.Command{
+		Use:   "update ID",
+		Short: "Update an ad title and text",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				ad, err := ac.UpdateAd(ctx, id, updUID, updTitle, updText)
+				if err != nil {
+					return err
+				}
+				return c.printAd(p, ad)
+			})
+		},
+	}
+	update.Flags().Int64Var(&updUID, "user", 0, "author id")
+	update.Flags().StringVar(&updTitle, "title", "", "new title")
+	update.Flags().StringVar(&updText, "text", "", "new text")
+	_ = update.MarkFlagRequired("user")
+
+	cmd.AddCommand(create, get, list, update,
+		c.adStatusCommand("publish", "Publish an ad", true),
+		c.adStatusCommand("unpublish", "Unpublish an ad", false),
+		c.adDeleteCommand())
+	return cmd
+}
+
+func (c *cli) adStatusCommand(use string, short string, published bool) *cobra.Command {
+	var uid int64
+	cmd := &cobra.Command{
+		Use:   use + " ID",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				ad, err := ac.ChangeAdStatus(ctx, id, uid, published)
+				if err != nil {
+					return err
+				}
+				return c.printAd(p, ad)
+			})
+		},
+	}
+	cmd.Flags().Int64Var(&uid, "user", 0, "author id")
+	_ = cmd.MarkFlagRequired("user")
+	return cmd
+}
+
+func (c *cli) adDeleteCommand() *cobra.Command {
+	var uid int64
+	cmd := &cobra.Command{
+		Use:   "delete ID",
+		Short: "Delete an ad",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				if err := ac.DeleteAd(ctx, id, uid); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.opts.Out, "ad %d deleted\n", id)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().Int64Var(&uid, "user", 0, "author id")
+	_ = cmd.MarkFlagRequired("user")
+	return cmd
+}
+
+func (c *cli) userCommand() *cobra.Command {
+	cmd := &cobra.Command{Use: "user", Aliases: []string{"users"}, Short: "Manage users"}
+
+	var nickname, email string
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				u, err := ac.CreateUser(ctx, nickname, email)
+				if err != nil {
+					return err
+				}
+				return c.printUser(p, u)
+			})
+		},
+	}
+	create.Flags().StringVar(&nickname, "nickname", "", "user nickname")
+	create.Flags().StringVar(&email, "email", "", "user email")
+	_ = create.MarkFlagRequired("email")
+
+	get := &cobra.Command{
+		Use:   "get ID",
+		Short: "Show a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				u, err := ac.GetUser(ctx, id)
+				if err != nil {
+					return err
+				}
+				return c.printUser(p, u)
+			})
+		},
+	}
+
+	var updNickname, updEmail string
+	update := &cobra.Command{
+		Use:   "update ID",
+		Short: "Update a user nickname and email",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				u, err := ac.UpdateUser(ctx, id, updNickname, updEmail)
+				if err != nil {
+					return err
+				}
+				return c.printUser(p, u)
+			})
+		},
+	}
+	update.Flags().StringVar(&updNickname, "nickname", "", "new nickname")
+	update.Flags().StringVar(&updEmail, "email", "", "new email")
+	_ = update.MarkFlagRequired("email")
+
+	del := &cobra.Command{
+		Use:   "delete ID",
+		Short: "Delete a user with all their ads",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				if err := ac.DeleteUser(ctx, id); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.opts.Out, "user %d deleted\n", id)
+				return nil
+			})
+		},
+	}
+
+	cmd.AddCommand(create, get, c.userListCommand(), update, del)
+	return cmd
+}
+
+func (c *cli) userListCommand() *cobra.Command {
+	var adminID int64
+	var role, nickname, email string
+	var banned, verified bool
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List users (requires a moderator or admin id)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var params app.ListUsersParams
+			flags := cmd.Flags()
+			if flags.Changed("role") {
+				r, err := user.ParseRole(role)
+				if err != nil {
+					return err
+				}
+				params.Role = &r
+			}
+			if flags.Changed("banned") {
+				params.Banned = &banned
+			}
+			if flags.Changed("verified") {
+				params.Verified = &verified
+			}
+			if flags.Changed("nickname") {
+				params.Nickname = &nickname
+			}
+			if flags.Changed("email") {
+				params.Email = &email
+			}
+			return c.run(cmd, func(ctx context.Context, ac client.AdsClient, p Profile) error {
+				if !flags.Changed("admin") {
+					if p.AdminID == nil {
+						return fmt.Errorf("admin id is required: pass --admin or set admin_id in the profile")
+					}
+					adminID = *p.AdminID
+				}
+				ul, err := ac.ListUsers(ctx, adminID, params)
+				if err != nil {
+					return err
+				}
+				return c.printUsers(p, ul)
+			})
+		},
+	}
+	cmd.Flags().Int64Var(&adminID, "admin", 0, "moderator or admin id (defaults to the profile admin_id)")
+	cmd.Flags().StringVar(&role, "role", "", "user, moderator or admin")
+	cmd.Flags().BoolVar(&banned, "banned", false, "only banned (or with =false active) users")
+	cmd.Flags().BoolVar(&verified, "verified", false, "only verified (or with =false unverified) users")
+	cmd.Flags().StringVar(&nickname, "nickname", "", "nickname substring")
+	cmd.Flags().StringVar(&email, "email", "", "email substring")
+	_ = cmd.RegisterFlagCompletionFunc("role", cobra.FixedCompletions(
+		[]string{user.RoleUser.String(), user.RoleModerator.String(), user.RoleAdmin.String()}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func (c *cli) configCommand() *cobra.Command {
+	cmd := &cobra.Command{Use: "config", Short: "Manage config profiles"}
+
+	view := &cobra.Command{
+		Use:   "view",
+		Short: "Show the config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := LoadConfig(c.configPath)
+			if err != nil {
+				return err
+			}
+			format := c.output
+			if format == "" || format == OutputTable {
+				format = OutputYAML
+			}
+			return render(c.opts.Out, format, cfg, nil)
+		},
+	}
+
+	use := &cobra.Command{
+		Use:               "use PROFILE",
+		Short:             "Switch the current profile",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: c.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := LoadConfig(c.configPath)
+			if err != nil {
+				return err
+			}
+			if _, err := cfg.Profile(args[0]); err != nil {
+				return err
+			}
+			cfg.Current = args[0]
+			if err := cfg.Save(c.configPath); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.opts.Out, "switched to profile %q\n", args[0])
+			return nil
+		},
+	}
+
+	var p Profile
+	var adminID int64
+	set := &cobra.Command{
+		Use:   "set-profile NAME",
+		Short: "Create or update a profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := LoadConfig(c.configPath)
+			if err != nil {
+				return err
+			}
+			profile := cfg.Profiles[args[0]]
+			flags := cmd.Flags()
+			if flags.Changed("transport") {
+				profile.Transport = p.Transport
+			}
+			if flags.Changed("address") {
+				profile.Address = p.Address
+			}
+			if flags.Changed("timeout") {
+				profile.Timeout = p.Timeout
+			}
+			if flags.Changed("retries") {
+				profile.Retries = p.Retries
+			}
+			if flags.Changed("admin-id") {
+				profile.AdminID = &adminID
+			}
+			if flags.Changed("profile-output") {
+				profile.Output = p.Output
+			}
+			if flags.Changed("tenant") {
+				profile.Tenant = p.Tenant
+			}
+			if profile.Transport == "" {
+				profile.Transport = TransportHTTP
+			}
+			if err := profile.Validate(); err != nil {
+				return err
+			}
+			cfg.Profiles[args[0]] = profile
+			if err := cfg.Save(c.configPath); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.opts.Out, "profile %q saved\n", args[0])
+			return nil
+		},
+	}
+	// локальные флаги перекрывают одноименные глобальные --transport и --address
+	set.Flags().StringVar(&p.Transport, "transport", "", "http or grpc")
+	set.Flags().StringVar(&p.Address, "address", "", "base URL for http, host:port for grpc")
+	set.Flags().DurationVar(&p.Timeout, "timeout", 0, "per-request timeout")
+	set.Flags().IntVar(&p.Retries, "retries", 0, "retries for idempotent requests")
+	set.Flags().Int64Var(&adminID, "admin-id", 0, "default admin id for admin commands")
+	set.Flags().StringVar(&p.Output, "profile-output", "", "default output format for the profile")
+	set.Flags().StringVar(&p.Tenant, "tenant", "", "tenant of the profile requests")
+
+	cmd.AddCommand(view, use, set)
+	return cmd
+}
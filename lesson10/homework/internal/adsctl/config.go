@@ -0,0 +1,127 @@
+package adsctl
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	TransportHTTP = "http"
+	TransportGRPC = "grpc"
+
+	// ConfigEnv переопределяет путь к файлу конфигурации
+	ConfigEnv = "ADSCTL_CONFIG"
+	// ProfileEnv выбирает профиль, если не задан флаг --profile
+	ProfileEnv = "ADSCTL_PROFILE"
+
+	defaultProfile = "local"
+)
+
+var (
+	ErrUnknownProfile   = errors.New("unknown profile")
+	ErrUnknownTransport = errors.New("unknown transport, expected http or grpc")
+)
+
+// Profile описывает одно окружение сервиса
+type Profile struct {
+	Transport string        `yaml:"transport" json:"transport"`
+	Address   string        `yaml:"address" json:"address"`
+	Timeout   time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Retries   int           `yaml:"retries,omitempty" json:"retries,omitempty"`
+	AdminID   *int64        `yaml:"admin_id,omitempty" json:"admin_id,omitempty"`
+	Output    string        `yaml:"output,omitempty" json:"output,omitempty"`
+	Tenant    string        `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+}
+
+func (p Profile) Validate() error {
+	if p.Transport != TransportHTTP && p.Transport != TransportGRPC {
+		return fmt.Errorf("%w: %q", ErrUnknownTransport, p.Transport)
+	}
+	if p.Address == "" {
+		return errors.New("profile address is empty")
+	}
+	return nil
+}
+
+type Config struct {
+	Current  string             `yaml:"current" json:"current"`
+	Profiles map[string]Profile `yaml:"profiles" json:"profiles"`
+}
+
+// DefaultConfig указывает на локально запущенный сервер
+func DefaultConfig() *Config {
+	return &Config{
+		Current: defaultProfile,
+		Profiles: map[string]Profile{
+			defaultProfile: {Transport: TransportHTTP, Address: "http://localhost:18080", Timeout: 5 * time.Second, Retries: 2},
+		},
+	}
+}
+
+// DefaultConfigPath - $ADSCTL_CONFIG или adsctl/config.yaml в пользовательском каталоге настроек
+func DefaultConfigPath() string {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "adsctl", "config.yaml")
+}
+
+// LoadConfig читает конфигурацию, при отсутствии файла возвращает конфигурацию по умолчанию
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultConfig(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]Profile)
+	}
+	return cfg, nil
+}
+
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Profile возвращает профиль по имени, пустое имя означает текущий профиль
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.Current
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: %q", ErrUnknownProfile, name)
+	}
+	return p, nil
+}
+
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
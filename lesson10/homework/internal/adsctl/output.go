@@ -0,0 +1,142 @@
+package adsctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/user"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+var ErrUnknownOutput = fmt.Errorf("unknown output format, expected %s, %s or %s", OutputTable, OutputJSON, OutputYAML)
+
+type adView struct {
+	ID          int64   `json:"id" yaml:"id"`
+	Title       string  `json:"title" yaml:"title"`
+	Text        string  `json:"text" yaml:"text"`
+	AuthorID    int64   `json:"author_id" yaml:"author_id"`
+	Published   bool    `json:"published" yaml:"published"`
+	DateCreated string  `json:"date_created" yaml:"date_created"`
+	DateChanged string  `json:"date_changed" yaml:"date_changed"`
+	PublishAt   *string `json:"publish_at,omitempty" yaml:"publish_at,omitempty"`
+	ExpiresAt   *string `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+}
+
+type userView struct {
+	ID       int64  `json:"id" yaml:"id"`
+	Nickname string `json:"nickname" yaml:"nickname"`
+	Email    string `json:"email" yaml:"email"`
+	Verified bool   `json:"verified" yaml:"verified"`
+	Role     string `json:"role" yaml:"role"`
+	Banned   bool   `json:"banned" yaml:"banned"`
+}
+
+// tabular - данные, которые умеют выводиться таблицей
+type tabular interface {
+	header() []string
+	rows() [][]string
+}
+
+type adViews []adView
+
+func (v adViews) header() []string {
+	return []string{"ID", "TITLE", "AUTHOR", "PUBLISHED", "CREATED"}
+}
+
+func (v adViews) rows() [][]string {
+	rows := make([][]string, 0, len(v))
+	for _, ad := range v {
+		rows = append(rows, []string{
+			strconv.FormatInt(ad.ID, 10), ad.Title, strconv.FormatInt(ad.AuthorID, 10),
+			strconv.FormatBool(ad.Published), ad.DateCreated,
+		})
+	}
+	return rows
+}
+
+type userViews []userView
+
+func (v userViews) header() []string {
+	return []string{"ID", "NICKNAME", "EMAIL", "ROLE", "VERIFIED", "BANNED"}
+}
+
+func (v userViews) rows() [][]string {
+	rows := make([][]string, 0, len(v))
+	for _, u := range v {
+		rows = append(rows, []string{
+			strconv.FormatInt(u.ID, 10), u.Nickname, u.Email, u.Role,
+			strconv.FormatBool(u.Verified), strconv.FormatBool(u.Banned),
+		})
+	}
+	return rows
+}
+
+func newAdView(ad ads.Ad) adView {
+	return adView{
+		ID:          ad.ID,
+		Title:       ad.Title,
+		Text:        ad.Text,
+		AuthorID:    ad.AuthorID,
+		Published:   ad.Published,
+		DateCreated: app.FormatDate(ad.DateCreated),
+		DateChanged: app.FormatDate(ad.DateChanged),
+		PublishAt:   app.FormatOptionalDate(ad.PublishAt),
+		ExpiresAt:   app.FormatOptionalDate(ad.ExpiresAt),
+	}
+}
+
+func newUserView(u user.User) userView {
+	return userView{
+		ID:       u.ID,
+		Nickname: u.Nickname,
+		Email:    u.Email,
+		Verified: u.Verified,
+		Role:     u.Role.String(),
+		Banned:   u.Banned,
+	}
+}
+
+func checkOutput(format string) error {
+	switch format {
+	case OutputTable, OutputJSON, OutputYAML:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownOutput, format)
+}
+
+// render выводит объект в JSON или YAML, а таблицей - через представление t
+func render(w io.Writer, format string, v any, t tabular) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OutputYAML:
+		enc := yaml.NewEncoder(w)
+		defer enc.Close()
+		return enc.Encode(v)
+	case OutputTable:
+		return printTable(w, t)
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownOutput, format)
+}
+
+func printTable(w io.Writer, t tabular) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header(), "\t"))
+	for _, row := range t.rows() {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
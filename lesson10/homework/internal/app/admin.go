@@ -0,0 +1,279 @@
+package app
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/features"
+	"homework10/internal/user"
+	"strings"
+	"time"
+)
+
+type ListUsersParams struct {
+	Role     *user.Role
+	Banned   *bool
+	Verified *bool
+	Nickname *string // подстрока никнейма без учета регистра
+	Email    *string // подстрока почты без учета регистра
+}
+
+// Matches проверяет, удовлетворяет ли пользователь фильтрам
+func (p ListUsersParams) Matches(u user.User) bool {
+	if p.Role != nil && *p.Role != u.Role {
+		return false
+	}
+	if p.Banned != nil && *p.Banned != u.Banned {
+		return false
+	}
+	if p.Verified != nil && *p.Verified != u.Verified {
+		return false
+	}
+	if p.Nickname != nil && !strings.Contains(user.Key(u.Nickname), user.Key(*p.Nickname)) {
+		return false
+	}
+	if p.Email != nil && !strings.Contains(user.Key(u.Email), user.Key(*p.Email)) {
+		return false
+	}
+	return true
+}
+
+func (a Application) ListUsers(ctx context.Context, adminID int64, params ListUsersParams) ([]user.User, error) {
+	if _, err := a.checkStaff(ctx, adminID); err != nil {
+		return nil, err
+	}
+
+	ul, err := a.repository.GetUserList(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return ul, nil
+}
+
+// BanUser блокирует пользователя: снимает с публикации все его объявления,
+// отменяет отложенные публикации и запрещает публиковать новые
+func (a Application) BanUser(ctx context.Context, adminID int64, id int64) (*user.User, error) {
+	u, err := a.checkCanModerate(ctx, adminID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *u
+	err = a.repository.SetUserBanned(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	u.Banned = true
+
+	al, err := a.repository.GetAdList(ctx, ListAdsParams{Uid: &id})
+	if err != nil {
+		return nil, err
+	}
+	date := time.Now().UTC()
+	for _, ad := range al.Data {
+		if ad.Published {
+			err = a.repository.UpdateAdStatus(ctx, ad.ID, false, date)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if ad.PublishAt != nil {
+			err = a.repository.UpdateAdSchedule(ctx, ad.ID, nil, ad.ExpiresAt, date)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	a.recordUser(ctx, adminID, audit.ActionBan, id, &before, u)
+
+	return u, nil
+}
+
+// UnbanUser снимает блокировку, снятые с публикации объявления автор публикует заново сам
+func (a Application) UnbanUser(ctx context.Context, adminID int64, id int64) (*user.User, error) {
+	u, err := a.checkCanModerate(ctx, adminID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *u
+	err = a.repository.SetUserBanned(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	u.Banned = false
+	a.recordUser(ctx, adminID, audit.ActionUnban, id, &before, u)
+
+	return u, nil
+}
+
+// SetUserRole доступен только администраторам, свою роль изменить нельзя
+func (a Application) SetUserRole(ctx context.Context, adminID int64, id int64, role user.Role) (*user.User, error) {
+	if !role.IsValid() {
+		return nil, user.ErrInvalidRole
+	}
+	admin, err := a.checkStaff(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin.Role != user.RoleAdmin || adminID == id {
+		return nil, ErrForbidden
+	}
+
+	u, err := a.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *u
+	err = a.repository.SetUserRole(ctx, id, role)
+	if err != nil {
+		return nil, err
+	}
+	u.Role = role
+	a.recordUser(ctx, adminID, audit.ActionSetRole, id, &before, u)
+
+	return u, nil
+}
+
+func (a Application) ForceUnpublishAd(ctx context.Context, adminID int64, id int64) (*ads.Ad, error) {
+	if _, err := a.checkStaff(ctx, adminID); err != nil {
+		return nil, err
+	}
+
+	ad, err := a.repository.GetAdByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *ad
+	ad.Published = false
+	ad.PublishAt = nil
+	ad.DateChanged = time.Now().UTC()
+
+	err = a.repository.UpdateAdStatus(ctx, id, false, ad.DateChanged)
+	if err != nil {
+		return nil, err
+	}
+	err = a.repository.UpdateAdSchedule(ctx, id, nil, ad.ExpiresAt, ad.DateChanged)
+	if err != nil {
+		return nil, err
+	}
+	a.recordAd(ctx, adminID, audit.ActionForceUnpublish, id, &before, ad)
+
+	return ad, nil
+}
+
+func (a Application) ForceDeleteAd(ctx context.Context, adminID int64, id int64) error {
+	if _, err := a.checkStaff(ctx, adminID); err != nil {
+		return err
+	}
+
+	// снимок удаляемого объявления нужен только журналу аудита
+	var before *ads.Ad
+	if a.audit != nil {
+		var err error
+		if before, err = a.repository.GetAdByID(ctx, id); err != nil {
+			return err
+		}
+	}
+	err := a.repository.DeleteAdByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	a.recordAd(ctx, adminID, audit.ActionForceDelete, id, before, nil)
+	return nil
+}
+
+func (a Application) ListFlaggedAds(ctx context.Context, adminID int64) (*ads.AdList, error) {
+	if _, err := a.checkStaff(ctx, adminID); err != nil {
+		return nil, err
+	}
+
+	al, err := a.repository.GetAdList(ctx, ListAdsParams{})
+	if err != nil {
+		return nil, err
+	}
+	flagged := ads.AdList{Data: make([]ads.Ad, 0)}
+	for _, ad := range al.Data {
+		if ad.IsFlagged() {
+			flagged.Data = append(flagged.Data, ad)
+		}
+	}
+	return &flagged, nil
+}
+
+// ApproveAd снимает пометки контент-политики; публикует объявление его автор
+func (a Application) ApproveAd(ctx context.Context, adminID int64, id int64) (*ads.Ad, error) {
+	if _, err := a.checkStaff(ctx, adminID); err != nil {
+		return nil, err
+	}
+
+	ad, err := a.repository.GetAdByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ad.IsFlagged() {
+		return ad, nil
+	}
+
+	before := *ad
+	err = a.repository.SetAdFlags(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	ad.Flags = nil
+	a.recordAd(ctx, adminID, audit.ActionApprove, id, &before, ad)
+
+	return ad, nil
+}
+
+// ListFeatures показывает, какие флаги функциональности включены для пользователя uid и почему.
+// Флаги берутся из настроек запроса, поэтому отражают последнюю перезагрузку
+func (a Application) ListFeatures(ctx context.Context, adminID int64, uid int64) ([]features.Evaluation, error) {
+	if _, err := a.checkStaff(ctx, adminID); err != nil {
+		return nil, err
+	}
+	return features.FromContext(ctx).EvaluateAll(uid), nil
+}
+
+// checkStaff проверяет, что пользователь - незаблокированный модератор или администратор
+func (a Application) checkStaff(ctx context.Context, adminID int64) (*user.User, error) {
+	admin, err := a.repository.GetUserByID(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if !admin.Role.IsStaff() || admin.Banned {
+		return nil, ErrForbidden
+	}
+	return admin, nil
+}
+
+// checkCanModerate проверяет, что роль модератора выше роли пользователя id
+func (a Application) checkCanModerate(ctx context.Context, adminID int64, id int64) (*user.User, error) {
+	admin, err := a.checkStaff(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := a.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role >= admin.Role {
+		return nil, ErrForbidden
+	}
+	return u, nil
+}
+
+// checkNotBanned возвращает ErrBanned, если пользователь заблокирован модератором
+func (a Application) checkNotBanned(ctx context.Context, uid int64) error {
+	u, err := a.repository.GetUserByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if u.Banned {
+		return ErrBanned
+	}
+	return nil
+}
@@ -3,24 +3,48 @@ package app
 import (
 	"context"
 	"fmt"
-	"github.com/TobbyMax/validator"
 	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/features"
+	"homework10/internal/mailer"
+	"homework10/internal/messages"
+	"homework10/internal/policy"
 	"homework10/internal/user"
 	"time"
 )
 
 var (
-	ErrForbidden    = fmt.Errorf("forbidden")
-	ErrAdNotFound   = fmt.Errorf("ad with such id does not exist")
-	ErrUserNotFound = fmt.Errorf("user with such id does not exist")
+	ErrForbidden       = fmt.Errorf("forbidden")
+	ErrAdNotFound      = fmt.Errorf("ad with such id does not exist")
+	ErrUserNotFound    = fmt.Errorf("user with such id does not exist")
+	ErrInvalidSchedule = fmt.Errorf("invalid ad schedule")
+	ErrSearchNotFound  = fmt.Errorf("saved search with such id does not exist")
+
+	ErrConversationNotFound = fmt.Errorf("conversation with such id does not exist")
+	ErrBlocked              = fmt.Errorf("user is blocked: %w", ErrForbidden)
+	ErrInvalidPage          = fmt.Errorf("invalid pagination parameters")
+	ErrInvalidTimeRange     = fmt.Errorf("created_from must be before created_to")
+	ErrInvalidAuditRange    = fmt.Errorf("from must be before to")
+
+	ErrAlreadyExists   = fmt.Errorf("already exists")
+	ErrAlreadyVerified = fmt.Errorf("email is already verified: %w", ErrAlreadyExists)
+	ErrTokenNotFound   = fmt.Errorf("verification token does not exist")
+	ErrTokenExpired    = fmt.Errorf("verification token has expired")
+
+	ErrBanned = fmt.Errorf("user is banned: %w", ErrForbidden)
+
+	ErrUnderReview = fmt.Errorf("ad is awaiting moderation: %w", ErrForbidden)
 )
 
 type AdApp interface {
 	CreateAd(ctx context.Context, title string, text string, uid int64) (*ads.Ad, error)
 	ChangeAdStatus(ctx context.Context, id int64, uid int64, published bool) (*ads.Ad, error)
 	UpdateAd(ctx context.Context, id int64, uid int64, title string, text string) (*ads.Ad, error)
+	// PatchAd изменяет только заданные поля, проверяется объявление после слияния
+	PatchAd(ctx context.Context, id int64, uid int64, patch AdPatch) (*ads.Ad, error)
 	GetAd(ctx context.Context, id int64) (*ads.Ad, error)
 	DeleteAd(ctx context.Context, id int64, uid int64) error
+	ScheduleAd(ctx context.Context, id int64, uid int64, publishAt *time.Time, expiresIn int) (*ads.Ad, error)
 
 	ListAds(ctx context.Context, params ListAdsParams) (*ads.AdList, error)
 }
@@ -29,12 +53,61 @@ type UserApp interface {
 	CreateUser(ctx context.Context, nickname string, email string) (*user.User, error)
 	GetUser(ctx context.Context, id int64) (*user.User, error)
 	UpdateUser(ctx context.Context, id int64, nickname string, email string) (*user.User, error)
+	// PatchUser изменяет только заданные поля, проверяется пользователь после слияния
+	PatchUser(ctx context.Context, id int64, patch UserPatch) (*user.User, error)
 	DeleteUser(ctx context.Context, id int64) error
+
+	RequestEmailVerification(ctx context.Context, id int64) error
+	VerifyEmail(ctx context.Context, token string) (*user.User, error)
+}
+
+type AlertApp interface {
+	AddFavorite(ctx context.Context, uid int64, adID int64) (*ads.Ad, error)
+	RemoveFavorite(ctx context.Context, uid int64, adID int64) error
+	ListFavorites(ctx context.Context, uid int64) (*ads.AdList, error)
+
+	CreateSavedSearch(ctx context.Context, uid int64, params ListAdsParams) (*SavedSearch, error)
+	ListSavedSearches(ctx context.Context, uid int64) ([]SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, id int64, uid int64) error
+
+	ListNotifications(ctx context.Context, uid int64) ([]Notification, error)
+}
+
+type MessagingApp interface {
+	StartConversation(ctx context.Context, adID int64, uid int64) (*messages.Conversation, error)
+	ListConversations(ctx context.Context, uid int64) ([]messages.Conversation, error)
+	SendMessage(ctx context.Context, convID int64, uid int64, text string) (*messages.Message, error)
+	ListMessages(ctx context.Context, convID int64, uid int64, offset int, limit int) ([]messages.Message, error)
+	SubscribeMessages(ctx context.Context, uid int64) (<-chan messages.Message, error)
+
+	BlockUser(ctx context.Context, uid int64, blockedID int64) error
+	UnblockUser(ctx context.Context, uid int64, blockedID int64) error
+}
+
+// AdminApp - методы модерации, доступные только модераторам и администраторам
+type AdminApp interface {
+	ListUsers(ctx context.Context, adminID int64, params ListUsersParams) ([]user.User, error)
+	BanUser(ctx context.Context, adminID int64, id int64) (*user.User, error)
+	UnbanUser(ctx context.Context, adminID int64, id int64) (*user.User, error)
+	SetUserRole(ctx context.Context, adminID int64, id int64, role user.Role) (*user.User, error)
+	ForceUnpublishAd(ctx context.Context, adminID int64, id int64) (*ads.Ad, error)
+	ForceDeleteAd(ctx context.Context, adminID int64, id int64) error
+	// ListFlaggedAds возвращает объявления, помеченные контент-политикой для проверки
+	ListFlaggedAds(ctx context.Context, adminID int64) (*ads.AdList, error)
+	// ApproveAd снимает пометки модерации, после этого автор может опубликовать объявление
+	ApproveAd(ctx context.Context, adminID int64, id int64) (*ads.Ad, error)
+	// ListFeatures проверяет флаги функциональности запроса для пользователя uid
+	ListFeatures(ctx context.Context, adminID int64, uid int64) ([]features.Evaluation, error)
+	// ListAudit возвращает записи журнала аудита по фильтру, начиная с последних
+	ListAudit(ctx context.Context, adminID int64, f audit.Filter) ([]audit.Entry, error)
 }
 
 type App interface {
 	AdApp
 	UserApp
+	AlertApp
+	MessagingApp
+	AdminApp
 }
 
 type AdRepository interface {
@@ -42,47 +115,136 @@ type AdRepository interface {
 	GetAdByID(ctx context.Context, id int64) (*ads.Ad, error)
 	UpdateAdStatus(ctx context.Context, id int64, published bool, date time.Time) error
 	UpdateAdContent(ctx context.Context, id int64, title string, text string, date time.Time) error
+	// PatchAd записывает только заданные поля патча
+	PatchAd(ctx context.Context, id int64, patch AdPatch, date time.Time) error
 	DeleteAdByID(ctx context.Context, id int64) error
+	UpdateAdSchedule(ctx context.Context, id int64, publishAt *time.Time, expiresAt *time.Time, date time.Time) error
+	// SetAdFlags заменяет пометки модерации объявления, nil снимает их
+	SetAdFlags(ctx context.Context, id int64, flags []string) error
 
 	GetAdList(ctx context.Context, params ListAdsParams) (*ads.AdList, error)
+
+	// PublishScheduledAds публикует объявления, время публикации которых наступило к моменту now,
+	// и возвращает все измененные объявления: истекшее к этому моменту теряет расписание, но не публикуется
+	PublishScheduledAds(ctx context.Context, now time.Time) ([]ads.Change, error)
+	// ExpireAds снимает с публикации объявления, срок которых истек к моменту now
+	ExpireAds(ctx context.Context, now time.Time) ([]ads.Change, error)
 }
 
 type UserRepository interface {
 	AddUser(ctx context.Context, u user.User) (int64, error)
 	GetUserByID(ctx context.Context, id int64) (*user.User, error)
+	// UpdateUser сбрасывает подтверждение почты, если она изменилась
 	UpdateUser(ctx context.Context, id int64, nickname string, email string) error
+	// PatchUser записывает только заданные поля патча и сбрасывает подтверждение почты, если она изменилась
+	PatchUser(ctx context.Context, id int64, patch UserPatch) error
 	DeleteUserByID(ctx context.Context, id int64) error
+
+	AddVerificationToken(ctx context.Context, t VerificationToken) error
+	GetVerificationToken(ctx context.Context, token string) (*VerificationToken, error)
+	DeleteVerificationToken(ctx context.Context, token string) error
+	// SetUserVerified подтверждает почту, только если она не менялась с момента выдачи токена
+	SetUserVerified(ctx context.Context, id int64, email string) error
+
+	GetUserList(ctx context.Context, params ListUsersParams) ([]user.User, error)
+	SetUserRole(ctx context.Context, id int64, role user.Role) error
+	SetUserBanned(ctx context.Context, id int64, banned bool) error
+}
+
+type AlertRepository interface {
+	AddFavorite(ctx context.Context, uid int64, adID int64) error
+	DeleteFavorite(ctx context.Context, uid int64, adID int64) error
+	GetFavorites(ctx context.Context, uid int64) (*ads.AdList, error)
+
+	AddSavedSearch(ctx context.Context, s SavedSearch) (int64, error)
+	GetSavedSearchByID(ctx context.Context, id int64) (*SavedSearch, error)
+	GetUserSavedSearches(ctx context.Context, uid int64) ([]SavedSearch, error)
+	GetSavedSearches(ctx context.Context) ([]SavedSearch, error)
+	DeleteSavedSearchByID(ctx context.Context, id int64) error
+
+	AddNotification(ctx context.Context, n Notification) (int64, error)
+	GetNotifications(ctx context.Context, uid int64) ([]Notification, error)
+}
+
+type MessageRepository interface {
+	AddConversation(ctx context.Context, c messages.Conversation) (int64, error)
+	GetConversationByID(ctx context.Context, id int64) (*messages.Conversation, error)
+	FindConversation(ctx context.Context, adID int64, buyerID int64) (*messages.Conversation, error)
+	GetUserConversations(ctx context.Context, uid int64) ([]messages.Conversation, error)
+
+	AddMessage(ctx context.Context, m messages.Message) (int64, error)
+	GetMessages(ctx context.Context, convID int64, offset int, limit int) ([]messages.Message, error)
+	MarkMessagesRead(ctx context.Context, convID int64, uid int64, upTo int64) error
+	CountUnreadMessages(ctx context.Context, convID int64, uid int64) (int, error)
+
+	AddBlock(ctx context.Context, uid int64, blockedID int64) error
+	DeleteBlock(ctx context.Context, uid int64, blockedID int64) error
+	IsBlocked(ctx context.Context, uid int64, blockedID int64) (bool, error)
 }
 
 type Repository interface {
 	AdRepository
 	UserRepository
+	AlertRepository
+	MessageRepository
 }
 
 type Application struct {
 	repository Repository
+	matcher    *Matcher
+	brokers    *brokers
+	mailer     mailer.Mailer
+	policy     *policy.Engine // nil - объявления не проверяются контент-политикой
+	audit      audit.Sink     // nil - изменения не записываются в журнал аудита
 }
 
 func NewApp(repo Repository) App {
 	return NewAdApp(repo)
 }
 
+func NewAppWithMailer(repo Repository, m mailer.Mailer) App {
+	a := NewAdApp(repo)
+	a.mailer = m
+	return a
+}
+
+// NewAppWithPolicy создает приложение, проверяющее объявления правилами контент-политики
+func NewAppWithPolicy(repo Repository, e *policy.Engine) App {
+	a := NewAdApp(repo)
+	a.policy = e
+	return a
+}
+
 func NewAdApp(repo Repository) *Application {
-	return &Application{repository: repo}
+	return &Application{
+		repository: repo,
+		matcher:    NewMatcher(repo),
+		brokers:    newBrokers(),
+		mailer:     mailer.NewLogMailer(),
+	}
 }
 
 func (a Application) CreateAd(ctx context.Context, title string, text string, uid int64) (*ads.Ad, error) {
 	ad := ads.Ad{Title: title, Text: text, AuthorID: uid, Published: false, DateCreated: time.Now().UTC()}
 	ad.DateChanged = ad.DateCreated
-	if err := validator.Validate(ad); err != nil {
+	if err := validateAd(ctx, ad); err != nil {
 		return nil, err
 	}
+	if err := a.checkNotBanned(ctx, uid); err != nil {
+		return nil, err
+	}
+	flags, err := a.checkPolicy(ctx, ad, false)
+	if err != nil {
+		return nil, err
+	}
+	ad.Flags = flags
 
 	id, err := a.repository.AddAd(ctx, ad)
 	if err != nil {
 		return nil, err
 	}
 	ad.ID = id
+	a.recordAd(ctx, uid, audit.ActionCreate, id, nil, &ad)
 
 	return &ad, nil
 }
@@ -105,6 +267,13 @@ func (a Application) ChangeAdStatus(ctx context.Context, id int64, uid int64, pu
 		return nil, ErrForbidden
 	}
 
+	before := *ad
+	wasPublished := ad.Published
+	if published && !wasPublished {
+		if err := a.checkCanPublish(ctx, *ad); err != nil {
+			return nil, err
+		}
+	}
 	ad.Published = published
 	ad.DateChanged = time.Now().UTC()
 
@@ -113,6 +282,66 @@ func (a Application) ChangeAdStatus(ctx context.Context, id int64, uid int64, pu
 		return nil, err
 	}
 
+	// ручное изменение статуса отменяет отложенную публикацию
+	if ad.PublishAt != nil {
+		ad.PublishAt = nil
+		err = a.repository.UpdateAdSchedule(ctx, id, nil, ad.ExpiresAt, ad.DateChanged)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a.recordAd(ctx, uid, audit.ActionChangeStatus, id, &before, ad)
+	if published && !wasPublished {
+		a.notifyPublished(ctx, *ad)
+	}
+
+	return ad, nil
+}
+
+func (a Application) ScheduleAd(ctx context.Context, id int64, uid int64, publishAt *time.Time, expiresIn int) (*ads.Ad, error) {
+	if expiresIn < 0 {
+		return nil, ErrInvalidSchedule
+	}
+	ad, err := a.repository.GetAdByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ad.AuthorID != uid {
+		return nil, ErrForbidden
+	}
+	if ad.Published && publishAt != nil {
+		return nil, ErrInvalidSchedule
+	}
+	if publishAt != nil {
+		if err := a.checkCanPublish(ctx, *ad); err != nil {
+			return nil, err
+		}
+	}
+
+	before := *ad
+	ad.DateChanged = time.Now().UTC()
+	ad.PublishAt = nil
+	if publishAt != nil {
+		t := publishAt.UTC()
+		ad.PublishAt = &t
+	}
+	ad.ExpiresAt = nil
+	if expiresIn > 0 {
+		start := ad.DateChanged
+		if ad.PublishAt != nil {
+			start = *ad.PublishAt
+		}
+		t := start.AddDate(0, 0, expiresIn)
+		ad.ExpiresAt = &t
+	}
+
+	err = a.repository.UpdateAdSchedule(ctx, id, ad.PublishAt, ad.ExpiresAt, ad.DateChanged)
+	if err != nil {
+		return nil, err
+	}
+	a.recordAd(ctx, uid, audit.ActionSchedule, id, &before, ad)
+
 	return ad, nil
 }
 
@@ -125,11 +354,16 @@ func (a Application) UpdateAd(ctx context.Context, id int64, uid int64, title st
 		return nil, ErrForbidden
 	}
 
+	before := *ad
 	ad.Title = title
 	ad.Text = text
 	ad.DateChanged = time.Now().UTC()
 
-	if err := validator.Validate(*ad); err != nil {
+	if err := validateAd(ctx, *ad); err != nil {
+		return nil, err
+	}
+	flags, err := a.checkPolicy(ctx, *ad, true)
+	if err != nil {
 		return nil, err
 	}
 
@@ -137,15 +371,87 @@ func (a Application) UpdateAd(ctx context.Context, id int64, uid int64, title st
 	if err != nil {
 		return nil, err
 	}
+	if err := a.updateFlags(ctx, ad, flags); err != nil {
+		return nil, err
+	}
+	a.recordAd(ctx, uid, audit.ActionUpdate, id, &before, ad)
+
+	return ad, nil
+}
+
+func (a Application) PatchAd(ctx context.Context, id int64, uid int64, patch AdPatch) (*ads.Ad, error) {
+	ad, err := a.repository.GetAdByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ad.AuthorID != uid {
+		return nil, ErrForbidden
+	}
+
+	patch = patch.Changes(*ad)
+	if patch.IsEmpty() {
+		return ad, nil
+	}
+	before := *ad
+	published := patch.Published != nil && *patch.Published
+	patch.Apply(ad)
+	ad.DateChanged = time.Now().UTC()
+
+	if err := validateAd(ctx, *ad); err != nil {
+		return nil, err
+	}
+	flags := ad.Flags
+	if patch.Title != nil || patch.Text != nil {
+		if flags, err = a.checkPolicy(ctx, *ad, true); err != nil {
+			return nil, err
+		}
+	}
+	if published {
+		// публикация проверяется по пометкам, которые получит объявление после патча
+		candidate := *ad
+		candidate.Flags = flags
+		if err := a.checkCanPublish(ctx, candidate); err != nil {
+			return nil, err
+		}
+	}
+
+	err = a.repository.PatchAd(ctx, id, patch, ad.DateChanged)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.updateFlags(ctx, ad, flags); err != nil {
+		return nil, err
+	}
+
+	// как и в ChangeAdStatus, ручное изменение статуса отменяет отложенную публикацию
+	if patch.Published != nil && ad.PublishAt != nil {
+		ad.PublishAt = nil
+		err = a.repository.UpdateAdSchedule(ctx, id, nil, ad.ExpiresAt, ad.DateChanged)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a.recordAd(ctx, uid, audit.ActionPatch, id, &before, ad)
+	if published {
+		a.notifyPublished(ctx, *ad)
+	}
 
 	return ad, nil
 }
 
 func (a Application) ListAds(ctx context.Context, params ListAdsParams) (*ads.AdList, error) {
 	p := true
-	if params.Published == nil && params.Uid == nil && params.Date == nil && params.Title == nil {
+	if !params.HasFilters() {
 		params.Published = &p
 	}
+	if params.CreatedFrom != nil && params.CreatedTo != nil && !params.CreatedFrom.Before(*params.CreatedTo) {
+		return nil, ErrInvalidTimeRange
+	}
+	if params.ActiveAt == nil {
+		now := time.Now().UTC()
+		params.ActiveAt = &now
+	}
 	al, err := a.repository.GetAdList(ctx, params)
 
 	if err != nil {
@@ -158,7 +464,7 @@ func (a Application) ListAds(ctx context.Context, params ListAdsParams) (*ads.Ad
 func (a Application) CreateUser(ctx context.Context, nickname string, email string) (*user.User, error) {
 	u := user.User{Nickname: nickname, Email: email}
 
-	if err := validator.Validate(u); err != nil {
+	if err := validateUser(ctx, u); err != nil {
 		return nil, err
 	}
 
@@ -167,6 +473,8 @@ func (a Application) CreateUser(ctx context.Context, nickname string, email stri
 		return nil, err
 	}
 	u.ID = id
+	a.recordUser(ctx, id, audit.ActionCreate, id, nil, &u)
+	a.sendVerification(ctx, u)
 
 	return &u, nil
 }
@@ -186,10 +494,12 @@ func (a Application) UpdateUser(ctx context.Context, id int64, nickname string,
 		return nil, err
 	}
 
+	before := *u
+	emailChanged := user.Key(u.Email) != user.Key(email)
 	u.Nickname = nickname
 	u.Email = email
 
-	if err := validator.Validate(*u); err != nil {
+	if err := validateUser(ctx, *u); err != nil {
 		return nil, err
 	}
 
@@ -197,6 +507,42 @@ func (a Application) UpdateUser(ctx context.Context, id int64, nickname string,
 	if err != nil {
 		return nil, err
 	}
+	if emailChanged {
+		u.Verified = false
+		a.sendVerification(ctx, *u)
+	}
+	a.recordUser(ctx, id, audit.ActionUpdate, id, &before, u)
+
+	return u, nil
+}
+
+func (a Application) PatchUser(ctx context.Context, id int64, patch UserPatch) (*user.User, error) {
+	u, err := a.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	patch = patch.Changes(*u)
+	if patch.IsEmpty() {
+		return u, nil
+	}
+	before := *u
+	emailChanged := patch.Email != nil && user.Key(u.Email) != user.Key(*patch.Email)
+	patch.Apply(u)
+
+	if err := validateUser(ctx, *u); err != nil {
+		return nil, err
+	}
+
+	err = a.repository.PatchUser(ctx, id, patch)
+	if err != nil {
+		return nil, err
+	}
+	if emailChanged {
+		u.Verified = false
+		a.sendVerification(ctx, *u)
+	}
+	a.recordUser(ctx, id, audit.ActionPatch, id, &before, u)
 
 	return u, nil
 }
@@ -213,13 +559,23 @@ func (a Application) DeleteAd(ctx context.Context, id int64, uid int64) error {
 	if err != nil {
 		return err
 	}
+	a.recordAd(ctx, uid, audit.ActionDelete, id, ad, nil)
 	return nil
 }
 
 func (a Application) DeleteUser(ctx context.Context, id int64) error {
+	// снимок удаляемого пользователя нужен только журналу аудита
+	var before *user.User
+	if a.audit != nil {
+		var err error
+		if before, err = a.repository.GetUserByID(ctx, id); err != nil {
+			return err
+		}
+	}
 	err := a.repository.DeleteUserByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	a.recordUser(ctx, id, audit.ActionDelete, id, before, nil)
 	return nil
 }
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/policy"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"log"
+	"time"
+)
+
+// NewAppWithAudit создает приложение, записывающее изменения объявлений и пользователей
+// в журнал аудита; e - контент-политика, nil - объявления не проверяются
+func NewAppWithAudit(repo Repository, e *policy.Engine, sink audit.Sink) App {
+	a := NewAdApp(repo)
+	a.policy = e
+	a.audit = sink
+	return a
+}
+
+// adSnapshot - снимок объявления в журнале аудита. Формат снимков не зависит
+// от доменных типов, чтобы старые записи читались так же, как новые
+type adSnapshot struct {
+	ID        int64      `json:"id"`
+	Title     string     `json:"title"`
+	Text      string     `json:"text"`
+	AuthorID  int64      `json:"author_id"`
+	Published bool       `json:"published"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Flags     []string   `json:"flags,omitempty"`
+}
+
+func newAdSnapshot(ad *ads.Ad) *adSnapshot {
+	if ad == nil {
+		return nil
+	}
+	return &adSnapshot{
+		ID:        ad.ID,
+		Title:     ad.Title,
+		Text:      ad.Text,
+		AuthorID:  ad.AuthorID,
+		Published: ad.Published,
+		PublishAt: ad.PublishAt,
+		ExpiresAt: ad.ExpiresAt,
+		Flags:     ad.Flags,
+	}
+}
+
+type userSnapshot struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+	Email    string `json:"email"`
+	Verified bool   `json:"verified"`
+	Role     string `json:"role"`
+	Banned   bool   `json:"banned"`
+}
+
+func newUserSnapshot(u *user.User) *userSnapshot {
+	if u == nil {
+		return nil
+	}
+	return &userSnapshot{
+		ID:       u.ID,
+		Nickname: u.Nickname,
+		Email:    u.Email,
+		Verified: u.Verified,
+		Role:     u.Role.String(),
+		Banned:   u.Banned,
+	}
+}
+
+// recordAd пишет изменение объявления в журнал аудита, before или after равны nil
+// для созданного и удаленного объявления
+func (a Application) recordAd(ctx context.Context, actorID int64, action audit.Action, id int64, before *ads.Ad, after *ads.Ad) {
+	a.record(ctx, actorID, action, audit.EntityAd, id, newAdSnapshot(before), newAdSnapshot(after))
+}
+
+// RecordSystemAd пишет в журнал sink изменение объявления, которое сервис сделал сам
+// (см. scheduler), от имени audit.SystemActorID
+func RecordSystemAd(ctx context.Context, sink audit.Sink, action audit.Action, before *ads.Ad, after *ads.Ad) {
+	Application{audit: sink}.recordAd(ctx, audit.SystemActorID, action, after.ID, before, after)
+}
+
+// recordUser пишет изменение пользователя в журнал аудита
+func (a Application) recordUser(ctx context.Context, actorID int64, action audit.Action, id int64, before *user.User, after *user.User) {
+	a.record(ctx, actorID, action, audit.EntityUser, id, newUserSnapshot(before), newUserSnapshot(after))
+}
+
+// record пишет запись журнала. Изменение к этому моменту уже сохранено,
+// поэтому ошибка журнала не отменяет операцию, а пишется в лог
+func (a Application) record(ctx context.Context, actorID int64, action audit.Action, entity audit.Entity, id int64, before any, after any) {
+	if a.audit == nil {
+		return
+	}
+	src := audit.SourceFromContext(ctx)
+	e := audit.Entry{
+		Time:      time.Now().UTC(),
+		Tenant:    tenant.IDFromContext(ctx),
+		ActorID:   actorID,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  id,
+		Before:    audit.Snapshot(before),
+		After:     audit.Snapshot(after),
+		Transport: src.Transport,
+		ClientIP:  src.ClientIP,
+	}
+	if err := a.audit.Write(ctx, &e); err != nil {
+		log.Printf("audit: failed to record %s %s %d: %s\n", action, entity, id, err.Error())
+	}
+}
+
+// ListAudit возвращает записи журнала аудита арендатора запроса по фильтру, начиная с последних
+func (a Application) ListAudit(ctx context.Context, adminID int64, f audit.Filter) ([]audit.Entry, error) {
+	if _, err := a.checkStaff(ctx, adminID); err != nil {
+		return nil, err
+	}
+	if f.Limit < 0 || f.Limit > audit.MaxLimit {
+		return nil, ErrInvalidPage
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return nil, ErrInvalidAuditRange
+	}
+	if a.audit == nil {
+		return []audit.Entry{}, nil
+	}
+	f.Tenant = tenant.IDFromContext(ctx)
+	return a.audit.Query(ctx, f)
+}
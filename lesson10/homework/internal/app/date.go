@@ -1,6 +1,11 @@
 package app
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 const (
 	DateLayout     = "2006-01-02"
@@ -18,6 +23,72 @@ func ParseDate(s *string) (*time.Time, error) {
 	return &date, nil
 }
 
+// ParseTime разбирает момент времени фильтра списка объявлений. Допустимы RFC3339 с часовым
+// поясом ("2023-05-01T10:00:00+03:00"), дата в DateLayout (полночь UTC) и смещение назад
+// от now ("-36h", "-7d", "-2w"). Результат приводится к UTC
+func ParseTime(s *string, now time.Time) (*time.Time, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var t time.Time
+	if strings.HasPrefix(*s, "-") {
+		ago, err := parseAgo((*s)[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid relative time %q: %w", *s, err)
+		}
+		t = now.Add(-ago)
+	} else if parsed, err := time.Parse(time.RFC3339, *s); err == nil {
+		t = parsed
+	} else if date, err := ParseDate(s); err == nil {
+		t = *date
+	} else {
+		return nil, fmt.Errorf("invalid time %q: expected RFC3339, %s or an offset like -7d", *s, DateLayout)
+	}
+	t = t.UTC()
+	return &t, nil
+}
+
+// parseAgo дополняет time.ParseDuration днями и неделями; отрицательные смещения не допускаются
+func parseAgo(s string) (time.Duration, error) {
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{{"d", 24 * time.Hour}, {"w", 7 * 24 * time.Hour}}
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			n, err := strconv.ParseUint(strings.TrimSuffix(s, u.suffix), 10, 16)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(n) * u.unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+	return d, err
+}
+
 func FormatDate(date time.Time) string {
 	return date.Format(DateTimeLayout)
 }
+
+func ParseDateTime(s *string) (*time.Time, error) {
+	if s == nil {
+		return nil, nil
+	}
+	date, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+func FormatOptionalDate(date *time.Time) *string {
+	if date == nil {
+		return nil
+	}
+	s := FormatDate(*date)
+	return &s
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/TobbyMax/validator"
+	"homework10/internal/messages"
+	"time"
+)
+
+const (
+	DefaultMessagesLimit = 50
+	MaxMessagesLimit     = 100
+)
+
+func (a Application) StartConversation(ctx context.Context, adID int64, uid int64) (*messages.Conversation, error) {
+	ad, err := a.repository.GetAdByID(ctx, adID)
+	if err != nil {
+		return nil, err
+	}
+	if ad.AuthorID == uid || !ad.Published {
+		return nil, ErrForbidden
+	}
+	if err := a.checkNotBanned(ctx, uid); err != nil {
+		return nil, err
+	}
+	if err := a.checkBlocked(ctx, ad.AuthorID, uid); err != nil {
+		return nil, err
+	}
+
+	c, err := a.repository.FindConversation(ctx, adID, uid)
+	if err == nil {
+		return c, nil
+	}
+	if !errors.Is(err, ErrConversationNotFound) {
+		return nil, err
+	}
+
+	conv := messages.Conversation{AdID: adID, BuyerID: uid, SellerID: ad.AuthorID, DateCreated: time.Now().UTC()}
+	conv.DateChanged = conv.DateCreated
+	id, err := a.repository.AddConversation(ctx, conv)
+	if err != nil {
+		return nil, err
+	}
+	conv.ID = id
+
+	return &conv, nil
+}
+
+func (a Application) ListConversations(ctx context.Context, uid int64) ([]messages.Conversation, error) {
+	cl, err := a.repository.GetUserConversations(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cl {
+		cl[i].Unread, err = a.repository.CountUnreadMessages(ctx, cl[i].ID, uid)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cl, nil
+}
+
+func (a Application) SendMessage(ctx context.Context, convID int64, uid int64, text string) (*messages.Message, error) {
+	c, err := a.getConversation(ctx, convID, uid)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkNotBanned(ctx, uid); err != nil {
+		return nil, err
+	}
+	recipient := c.Interlocutor(uid)
+	if err := a.checkBlocked(ctx, recipient, uid); err != nil {
+		return nil, err
+	}
+	if err := a.checkBlocked(ctx, uid, recipient); err != nil {
+		return nil, err
+	}
+
+	m := messages.Message{ConversationID: convID, SenderID: uid, RecipientID: recipient, Text: text, DateSent: time.Now().UTC()}
+	if err := validator.Validate(m); err != nil {
+		return nil, err
+	}
+
+	id, err := a.repository.AddMessage(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	m.ID = id
+	a.broker(ctx).Publish(m)
+
+	return &m, nil
+}
+
+// ListMessages возвращает страницу истории переписки в хронологическом порядке
+// и отмечает полученные на этой странице сообщения прочитанными
+func (a Application) ListMessages(ctx context.Context, convID int64, uid int64, offset int, limit int) ([]messages.Message, error) {
+	if offset < 0 || limit < 0 {
+		return nil, ErrInvalidPage
+	}
+	if limit == 0 {
+		limit = DefaultMessagesLimit
+	}
+	if limit > MaxMessagesLimit {
+		limit = MaxMessagesLimit
+	}
+	if _, err := a.getConversation(ctx, convID, uid); err != nil {
+		return nil, err
+	}
+
+	ml, err := a.repository.GetMessages(ctx, convID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(ml) == 0 {
+		return ml, nil
+	}
+
+	err = a.repository.MarkMessagesRead(ctx, convID, uid, ml[len(ml)-1].ID)
+	if err != nil {
+		return nil, err
+	}
+	return ml, nil
+}
+
+func (a Application) BlockUser(ctx context.Context, uid int64, blockedID int64) error {
+	if uid == blockedID {
+		return ErrForbidden
+	}
+	if _, err := a.repository.GetUserByID(ctx, blockedID); err != nil {
+		return err
+	}
+	err := a.repository.AddBlock(ctx, uid, blockedID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a Application) UnblockUser(ctx context.Context, uid int64, blockedID int64) error {
+	err := a.repository.DeleteBlock(ctx, uid, blockedID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SubscribeMessages возвращает канал новых сообщений пользователя,
+// канал закрывается по завершении контекста
+func (a Application) SubscribeMessages(ctx context.Context, uid int64) (<-chan messages.Message, error) {
+	if _, err := a.repository.GetUserByID(ctx, uid); err != nil {
+		return nil, err
+	}
+	ch, unsubscribe := a.broker(ctx).Subscribe(uid)
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+	return ch, nil
+}
+
+// getConversation возвращает переписку, если uid - ее участник
+func (a Application) getConversation(ctx context.Context, convID int64, uid int64) (*messages.Conversation, error) {
+	c, err := a.repository.GetConversationByID(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.HasParticipant(uid) {
+		return nil, ErrForbidden
+	}
+	return c, nil
+}
+
+// checkBlocked возвращает ErrBlocked, если пользователь uid заблокировал blockedID
+func (a Application) checkBlocked(ctx context.Context, uid int64, blockedID int64) error {
+	blocked, err := a.repository.IsBlocked(ctx, uid, blockedID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
+}
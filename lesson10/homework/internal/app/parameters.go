@@ -1,10 +1,141 @@
 package app
 
-import "time"
+import (
+	"homework10/internal/ads"
+	"homework10/internal/filter"
+	"homework10/internal/user"
+	"strings"
+	"time"
+)
 
 type ListAdsParams struct {
 	Published *bool
 	Uid       *int64
 	Date      *time.Time
 	Title     *string
+	ActiveAt  *time.Time // если задан, исключаются объявления, срок которых истек к этому моменту
+
+	CreatedFrom  *time.Time // объявления, созданные не раньше этого момента
+	CreatedTo    *time.Time // объявления, созданные строго раньше этого момента
+	ChangedSince *time.Time // объявления, измененные (или созданные) не раньше этого момента
+
+	Filter filter.Expr // произвольное выражение фильтра, проверяется вместе с остальными полями
+}
+
+// ParseFilter разбирает выражение фильтра (см. пакет filter), nil и пустая строка - без фильтра
+func ParseFilter(s *string) (filter.Expr, error) {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil, nil
+	}
+	return filter.Parse(*s)
+}
+
+// HasFilters сообщает, задан ли хотя бы один фильтр, выбираемый пользователем
+func (p ListAdsParams) HasFilters() bool {
+	return p.Published != nil || p.Uid != nil || p.Date != nil || p.Title != nil ||
+		p.CreatedFrom != nil || p.CreatedTo != nil || p.ChangedSince != nil || p.Filter != nil
+}
+
+// Matches проверяет, удовлетворяет ли объявление фильтрам
+func (p ListAdsParams) Matches(ad ads.Ad) bool {
+	if p.Published != nil && *p.Published != ad.Published {
+		return false
+	}
+	if p.Uid != nil && *p.Uid != ad.AuthorID {
+		return false
+	}
+	if p.Title != nil && *p.Title != ad.Title {
+		return false
+	}
+	if p.Date != nil {
+		year, month, day := ad.DateCreated.Date()
+		if p.Date.Year() != year || p.Date.Month() != month || p.Date.Day() != day {
+			return false
+		}
+	}
+	if p.CreatedFrom != nil && ad.DateCreated.Before(*p.CreatedFrom) {
+		return false
+	}
+	if p.CreatedTo != nil && !ad.DateCreated.Before(*p.CreatedTo) {
+		return false
+	}
+	if p.ChangedSince != nil && ad.LastChanged().Before(*p.ChangedSince) {
+		return false
+	}
+	if p.Filter != nil && !p.Filter.Eval(ad) {
+		return false
+	}
+	if p.ActiveAt != nil && ad.IsExpired(*p.ActiveAt) {
+		return false
+	}
+	return true
+}
+
+// AdPatch - частичное обновление объявления, nil-поля не изменяются
+type AdPatch struct {
+	Title     *string
+	Text      *string
+	Published *bool
+}
+
+// Changes оставляет в патче только поля, значения которых отличаются от текущих
+func (p AdPatch) Changes(ad ads.Ad) AdPatch {
+	if p.Title != nil && *p.Title == ad.Title {
+		p.Title = nil
+	}
+	if p.Text != nil && *p.Text == ad.Text {
+		p.Text = nil
+	}
+	if p.Published != nil && *p.Published == ad.Published {
+		p.Published = nil
+	}
+	return p
+}
+
+func (p AdPatch) IsEmpty() bool {
+	return p.Title == nil && p.Text == nil && p.Published == nil
+}
+
+// Apply переносит заданные поля патча в объявление
+func (p AdPatch) Apply(ad *ads.Ad) {
+	if p.Title != nil {
+		ad.Title = *p.Title
+	}
+	if p.Text != nil {
+		ad.Text = *p.Text
+	}
+	if p.Published != nil {
+		ad.Published = *p.Published
+	}
+}
+
+// UserPatch - частичное обновление пользователя, nil-поля не изменяются
+type UserPatch struct {
+	Nickname *string
+	Email    *string
+}
+
+// Changes оставляет в патче только поля, значения которых отличаются от текущих
+func (p UserPatch) Changes(u user.User) UserPatch {
+	if p.Nickname != nil && *p.Nickname == u.Nickname {
+		p.Nickname = nil
+	}
+	if p.Email != nil && *p.Email == u.Email {
+		p.Email = nil
+	}
+	return p
+}
+
+func (p UserPatch) IsEmpty() bool {
+	return p.Nickname == nil && p.Email == nil
+}
+
+// Apply переносит заданные поля патча в пользователя
+func (p UserPatch) Apply(u *user.User) {
+	if p.Nickname != nil {
+		u.Nickname = *p.Nickname
+	}
+	if p.Email != nil {
+		u.Email = *p.Email
+	}
 }
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"homework10/internal/features"
+	"time"
+)
+
+// PremoderationFlag - пометка новых объявлений авторов, для которых включен флаг features.Premoderation
+const PremoderationFlag = features.Premoderation + ": new ads are published after moderation"
+
+// checkPolicy проверяет объявление правилами контент-политики. Возвращает пометки для
+// модератора или policy.Rejection, если объявление нельзя сохранить.
+// stored - объявление уже сохранено, и его не нужно сравнивать с самим собой
+func (a Application) checkPolicy(ctx context.Context, ad ads.Ad, stored bool) ([]string, error) {
+	if a.policy == nil {
+		return premoderate(ctx, ad, stored, nil), nil
+	}
+	d, err := a.policy.Check(ctx, ad, func(ctx context.Context, authorID int64) ([]ads.Ad, error) {
+		al, err := a.repository.GetAdList(ctx, ListAdsParams{Uid: &authorID})
+		if err != nil {
+			return nil, err
+		}
+		others := make([]ads.Ad, 0, len(al.Data))
+		for _, other := range al.Data {
+			if !stored || other.ID != ad.ID {
+				others = append(others, other)
+			}
+		}
+		return others, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Err(); err != nil {
+		return nil, err
+	}
+	return premoderate(ctx, ad, stored, d.FlagReasons()), nil
+}
+
+// premoderate добавляет к пометкам пометку предварительной модерации: новому объявлению -
+// если флаг включен для автора, сохраненному - если оно еще не одобрено модератором
+func premoderate(ctx context.Context, ad ads.Ad, stored bool, flags []string) []string {
+	pending := stored && hasFlag(ad.Flags, PremoderationFlag)
+	if pending || !stored && features.Enabled(ctx, features.Premoderation, ad.AuthorID) {
+		return append(flags, PremoderationFlag)
+	}
+	return flags
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCanPublish проверяет, что автор не заблокирован и объявление не ждет проверки модератором
+func (a Application) checkCanPublish(ctx context.Context, ad ads.Ad) error {
+	if err := a.checkNotBanned(ctx, ad.AuthorID); err != nil {
+		return err
+	}
+	if ad.IsFlagged() {
+		return ErrUnderReview
+	}
+	return nil
+}
+
+// updateFlags сохраняет новые пометки объявления. Помеченное объявление снимается
+// с публикации и теряет отложенную публикацию до проверки модератором
+func (a Application) updateFlags(ctx context.Context, ad *ads.Ad, flags []string) error {
+	if !sameFlags(ad.Flags, flags) {
+		if err := a.repository.SetAdFlags(ctx, ad.ID, flags); err != nil {
+			return err
+		}
+		ad.Flags = flags
+	}
+	if !ad.IsFlagged() || !ad.Published && ad.PublishAt == nil {
+		return nil
+	}
+
+	ad.Published = false
+	ad.PublishAt = nil
+	ad.DateChanged = time.Now().UTC()
+	if err := a.repository.UpdateAdStatus(ctx, ad.ID, false, ad.DateChanged); err != nil {
+		return err
+	}
+	return a.repository.UpdateAdSchedule(ctx, ad.ID, nil, ad.ExpiresAt, ad.DateChanged)
+}
+
+func sameFlags(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
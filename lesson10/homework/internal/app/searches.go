@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"log"
+	"time"
+)
+
+// SavedSearch - сохраненный пользователем поиск объявлений
+type SavedSearch struct {
+	ID          int64
+	UserID      int64
+	Params      ListAdsParams
+	DateCreated time.Time
+}
+
+// Notification - уведомление о публикации объявления, подходящего под сохраненный поиск
+type Notification struct {
+	ID          int64
+	UserID      int64
+	AdID        int64
+	SearchID    int64
+	DateCreated time.Time
+}
+
+// Matcher сопоставляет опубликованные объявления с сохраненными поисками
+// и записывает уведомления для подписанных пользователей
+type Matcher struct {
+	repository Repository
+}
+
+func NewMatcher(repo Repository) *Matcher {
+	return &Matcher{repository: repo}
+}
+
+func (m *Matcher) AdPublished(ctx context.Context, ad ads.Ad) error {
+	searches, err := m.repository.GetSavedSearches(ctx)
+	if err != nil {
+		return err
+	}
+	notified := make(map[int64]struct{})
+	for _, s := range searches {
+		if s.UserID == ad.AuthorID || !s.Params.Matches(ad) {
+			continue
+		}
+		// одно уведомление на пользователя, даже если подошло несколько его поисков
+		if _, ok := notified[s.UserID]; ok {
+			continue
+		}
+		notified[s.UserID] = struct{}{}
+		n := Notification{UserID: s.UserID, AdID: ad.ID, SearchID: s.ID, DateCreated: time.Now().UTC()}
+		if _, err := m.repository.AddNotification(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyPublished не прерывает публикацию, если уведомления записать не удалось
+func (a Application) notifyPublished(ctx context.Context, ad ads.Ad) {
+	if err := a.matcher.AdPublished(ctx, ad); err != nil {
+		log.Printf("failed to match ad %d against saved searches: %s\n", ad.ID, err.Error())
+	}
+}
+
+func (a Application) AddFavorite(ctx context.Context, uid int64, adID int64) (*ads.Ad, error) {
+	ad, err := a.repository.GetAdByID(ctx, adID)
+	if err != nil {
+		return nil, err
+	}
+	if !ad.Published && ad.AuthorID != uid {
+		return nil, ErrForbidden
+	}
+	err = a.repository.AddFavorite(ctx, uid, adID)
+	if err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+func (a Application) RemoveFavorite(ctx context.Context, uid int64, adID int64) error {
+	err := a.repository.DeleteFavorite(ctx, uid, adID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a Application) ListFavorites(ctx context.Context, uid int64) (*ads.AdList, error) {
+	fl, err := a.repository.GetFavorites(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	// снятые с публикации чужие объявления в избранном не показываем
+	al := ads.AdList{Data: make([]ads.Ad, 0, len(fl.Data))}
+	for _, ad := range fl.Data {
+		if ad.Published || ad.AuthorID == uid {
+			al.Data = append(al.Data, ad)
+		}
+	}
+	return &al, nil
+}
+
+func (a Application) CreateSavedSearch(ctx context.Context, uid int64, params ListAdsParams) (*SavedSearch, error) {
+	params.ActiveAt = nil
+	s := SavedSearch{UserID: uid, Params: params, DateCreated: time.Now().UTC()}
+
+	id, err := a.repository.AddSavedSearch(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	s.ID = id
+
+	return &s, nil
+}
+
+func (a Application) ListSavedSearches(ctx context.Context, uid int64) ([]SavedSearch, error) {
+	sl, err := a.repository.GetUserSavedSearches(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return sl, nil
+}
+
+func (a Application) DeleteSavedSearch(ctx context.Context, id int64, uid int64) error {
+	s, err := a.repository.GetSavedSearchByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.UserID != uid {
+		return ErrForbidden
+	}
+	err = a.repository.DeleteSavedSearchByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a Application) ListNotifications(ctx context.Context, uid int64) ([]Notification, error) {
+	nl, err := a.repository.GetNotifications(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return nl, nil
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/TobbyMax/validator"
+	"homework10/internal/ads"
+	"homework10/internal/config"
+	"homework10/internal/messages"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"sync"
+)
+
+// brokers рассылает сообщения отдельно для каждого арендатора: ID пользователей разных арендаторов совпадают
+type brokers struct {
+	mu sync.Mutex
+	m  map[tenant.ID]*messages.Broker
+}
+
+func newBrokers() *brokers {
+	return &brokers{m: make(map[tenant.ID]*messages.Broker)}
+}
+
+func (b *brokers) get(id tenant.ID) *messages.Broker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br, ok := b.m[id]
+	if !ok {
+		br = messages.NewBroker()
+		b.m[id] = br
+	}
+	return br
+}
+
+// broker возвращает рассылку арендатора из контекста
+func (a Application) broker(ctx context.Context) *messages.Broker {
+	return a.brokers.get(tenant.IDFromContext(ctx))
+}
+
+// limitError оформляет нарушение ограничения арендатора так же, как ошибки валидатора
+func limitError(field string, max int, length int) validator.ValidationError {
+	return validator.ValidationError{Err: fmt.Errorf(
+		"field '%s' of type string is not valid: has tenant constraint ('max': %d), but got length = %d", field, max, length)}
+}
+
+// effectiveLimits возвращает ограничения валидации запроса: общие из настроек и арендатора, оба из контекста
+func effectiveLimits(ctx context.Context) tenant.Limits {
+	return config.FromContext(ctx).LimitsFor(tenant.FromContext(ctx))
+}
+
+// validateAd проверяет объявление по тегам модели и ограничениям из контекста
+func validateAd(ctx context.Context, ad ads.Ad) error {
+	if err := validator.Validate(ad); err != nil {
+		return err
+	}
+	limits := effectiveLimits(ctx)
+	var verrs validator.ValidationErrors
+	if limits.MaxTitleLength > 0 && len(ad.Title) > limits.MaxTitleLength {
+		verrs = append(verrs, limitError("Title", limits.MaxTitleLength, len(ad.Title)))
+	}
+	if limits.MaxTextLength > 0 && len(ad.Text) > limits.MaxTextLength {
+		verrs = append(verrs, limitError("Text", limits.MaxTextLength, len(ad.Text)))
+	}
+	if len(verrs) > 0 {
+		return verrs
+	}
+	return nil
+}
+
+// validateUser проверяет пользователя как user.Validate и по ограничениям из контекста
+func validateUser(ctx context.Context, u user.User) error {
+	if err := user.Validate(u); err != nil {
+		return err
+	}
+	limits := effectiveLimits(ctx)
+	if limits.MaxNicknameLength > 0 && len(u.Nickname) > limits.MaxNicknameLength {
+		return validator.ValidationErrors{limitError("Nickname", limits.MaxNicknameLength, len(u.Nickname))}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"homework10/internal/audit"
+	"homework10/internal/mailer"
+	"homework10/internal/user"
+	"log"
+	"time"
+)
+
+const VerificationTTL = 24 * time.Hour
+
+type VerificationToken struct {
+	Token     string
+	UserID    int64
+	Email     string
+	ExpiresAt time.Time
+}
+
+func (t VerificationToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+func (a Application) RequestEmailVerification(ctx context.Context, id int64) error {
+	u, err := a.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if u.Verified {
+		return ErrAlreadyVerified
+	}
+	return a.issueVerification(ctx, *u)
+}
+
+func (a Application) VerifyEmail(ctx context.Context, token string) (*user.User, error) {
+	t, err := a.repository.GetVerificationToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if t.IsExpired(time.Now().UTC()) {
+		if err := a.repository.DeleteVerificationToken(ctx, token); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenExpired
+	}
+
+	err = a.repository.SetUserVerified(ctx, t.UserID, t.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := a.repository.GetUserByID(ctx, t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// токены удаляются при подтверждении, поэтому до него почта не была подтверждена
+	before := *u
+	before.Verified = false
+	a.recordUser(ctx, u.ID, audit.ActionVerifyEmail, u.ID, &before, u)
+	return u, nil
+}
+
+// sendVerification отправляет письмо после регистрации или смены почты.
+// Ошибка доставки не отменяет операцию: письмо можно запросить повторно
+func (a Application) sendVerification(ctx context.Context, u user.User) {
+	if err := a.issueVerification(ctx, u); err != nil {
+		log.Printf("failed to send verification email to user %d: %s\n", u.ID, err.Error())
+	}
+}
+
+func (a Application) issueVerification(ctx context.Context, u user.User) error {
+	token, err := newVerificationToken()
+	if err != nil {
+		return err
+	}
+	t := VerificationToken{Token: token, UserID: u.ID, Email: u.Email, ExpiresAt: time.Now().UTC().Add(VerificationTTL)}
+	if err := a.repository.AddVerificationToken(ctx, t); err != nil {
+		return err
+	}
+
+	return a.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Email verification",
+		Body: fmt.Sprintf("Hello, %s!\nYour verification token: %s\nIt is valid until %s.",
+			u.Nickname, token, t.ExpiresAt.Format(time.RFC3339)),
+	})
+}
+
+func newVerificationToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,215 @@
+// Package apperr - единая классификация ошибок приложения для HTTP и gRPC.
+//
+// Classify сопоставляет ошибке машиночитаемый код (Code), класс (Kind), по которому
+// транспорт выбирает HTTP статус или код gRPC, и нарушения по полям для ошибок валидации.
+package apperr
+
+import (
+	"errors"
+	"github.com/TobbyMax/validator"
+	"homework10/internal/app"
+	"homework10/internal/policy"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Kind - класс ошибки, от него зависит HTTP статус и код gRPC
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalidArgument
+	KindNotFound
+	KindAlreadyExists
+	KindForbidden
+	KindResourceExhausted
+)
+
+// Code - машиночитаемый код ошибки, стабильный между версиями API
+type Code string
+
+const (
+	CodeInternal             Code = "INTERNAL"
+	CodeInvalidArgument      Code = "INVALID_ARGUMENT"
+	CodeValidationFailed     Code = "VALIDATION_FAILED"
+	CodeInvalidSchedule      Code = "INVALID_SCHEDULE"
+	CodeInvalidPage          Code = "INVALID_PAGE"
+	CodeInvalidTimeRange     Code = "INVALID_TIME_RANGE"
+	CodeInvalidAuditRange    Code = "INVALID_AUDIT_RANGE"
+	CodeInvalidRole          Code = "INVALID_ROLE"
+	CodeTokenExpired         Code = "TOKEN_EXPIRED"
+	CodeAdNotFound           Code = "AD_NOT_FOUND"
+	CodeUserNotFound         Code = "USER_NOT_FOUND"
+	CodeSearchNotFound       Code = "SEARCH_NOT_FOUND"
+	CodeConversationNotFound Code = "CONVERSATION_NOT_FOUND"
+	CodeTokenNotFound        Code = "TOKEN_NOT_FOUND"
+	CodeAlreadyExists        Code = "ALREADY_EXISTS"
+	CodeAlreadyVerified      Code = "ALREADY_VERIFIED"
+	CodeForbidden            Code = "FORBIDDEN"
+	CodeUserBanned           Code = "USER_BANNED"
+	CodeUserBlocked          Code = "USER_BLOCKED"
+	CodeInvalidTenant        Code = "INVALID_TENANT"
+	CodeTenantNotFound       Code = "TENANT_NOT_FOUND"
+	CodeRateLimited          Code = "RATE_LIMITED"
+	CodePolicyViolation      Code = "POLICY_VIOLATION"
+	CodeAdUnderReview        Code = "AD_UNDER_REVIEW"
+)
+
+// InternalMessage - сообщение, которое клиент получает вместо текста внутренней ошибки
+const InternalMessage = "internal error"
+
+// FieldViolation - нарушение ограничения одного поля запроса
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// Error - классифицированная ошибка
+type Error struct {
+	Kind    Kind
+	Code    Code
+	Message string
+	Fields  []FieldViolation
+}
+
+type rule struct {
+	target error
+	kind   Kind
+	code   Code
+}
+
+// rules упорядочены от частных ошибок к общим: ErrBanned, ErrBlocked и ErrUnderReview оборачивают
+// ErrForbidden, ErrAlreadyVerified - ErrAlreadyExists
+var rules = []rule{
+	{app.ErrBanned, KindForbidden, CodeUserBanned},
+	{app.ErrBlocked, KindForbidden, CodeUserBlocked},
+	{app.ErrUnderReview, KindForbidden, CodeAdUnderReview},
+	{app.ErrForbidden, KindForbidden, CodeForbidden},
+	{app.ErrAlreadyVerified, KindAlreadyExists, CodeAlreadyVerified},
+	{app.ErrAlreadyExists, KindAlreadyExists, CodeAlreadyExists},
+	{app.ErrAdNotFound, KindNotFound, CodeAdNotFound},
+	{app.ErrUserNotFound, KindNotFound, CodeUserNotFound},
+	{app.ErrSearchNotFound, KindNotFound, CodeSearchNotFound},
+	{app.ErrConversationNotFound, KindNotFound, CodeConversationNotFound},
+	{app.ErrTokenNotFound, KindNotFound, CodeTokenNotFound},
+	{app.ErrTokenExpired, KindInvalidArgument, CodeTokenExpired},
+	{app.ErrInvalidSchedule, KindInvalidArgument, CodeInvalidSchedule},
+	{app.ErrInvalidPage, KindInvalidArgument, CodeInvalidPage},
+	{app.ErrInvalidTimeRange, KindInvalidArgument, CodeInvalidTimeRange},
+	{app.ErrInvalidAuditRange, KindInvalidArgument, CodeInvalidAuditRange},
+	{user.ErrInvalidRole, KindInvalidArgument, CodeInvalidRole},
+	{tenant.ErrInvalidID, KindInvalidArgument, CodeInvalidTenant},
+	{tenant.ErrUnknownTenant, KindNotFound, CodeTenantNotFound},
+	{tenant.ErrRateLimited, KindResourceExhausted, CodeRateLimited},
+	{policy.ErrRejected, KindInvalidArgument, CodePolicyViolation},
+}
+
+// invalidError помечает ошибку разбора запроса в транспорте
+type invalidError struct {
+	err    error
+	fields []FieldViolation
+}
+
+func (e invalidError) Error() string {
+	return e.err.Error()
+}
+
+func (e invalidError) Unwrap() error {
+	return e.err
+}
+
+// Invalid помечает ошибку как неверный аргумент, если у нее нет более точной классификации
+func Invalid(err error) error {
+	return invalidError{err: err}
+}
+
+// InvalidFields - то же, что Invalid, с нарушениями по полям
+func InvalidFields(err error, fields []FieldViolation) error {
+	return invalidError{err: err, fields: fields}
+}
+
+func Classify(err error) *Error {
+	// нарушения контент-политики раскладываются по полям так же, как ошибки валидатора
+	var rejection policy.Rejection
+	if errors.As(err, &rejection) {
+		fields := make([]FieldViolation, len(rejection.Violations))
+		for i, v := range rejection.Violations {
+			fields[i] = FieldViolation{Field: v.Field, Description: v.Reason}
+		}
+		return &Error{Kind: KindInvalidArgument, Code: CodePolicyViolation, Message: err.Error(), Fields: fields}
+	}
+	for _, r := range rules {
+		if errors.Is(err, r.target) {
+			return &Error{Kind: r.kind, Code: r.code, Message: err.Error()}
+		}
+	}
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		return &Error{Kind: KindInvalidArgument, Code: CodeValidationFailed, Message: err.Error(), Fields: Violations(verrs)}
+	}
+	var invalid invalidError
+	if errors.As(err, &invalid) {
+		code := CodeInvalidArgument
+		if len(invalid.fields) > 0 {
+			code = CodeValidationFailed
+		}
+		return &Error{Kind: KindInvalidArgument, Code: code, Message: err.Error(), Fields: invalid.fields}
+	}
+	// текст внутренней ошибки (значение паники, адреса и запросы хранилища) клиенту
+	// не отдается, исходная ошибка остается только в логе
+	log.Printf("internal error: %s\n", err.Error())
+	return &Error{Kind: KindInternal, Code: CodeInternal, Message: InternalMessage}
+}
+
+// Err возвращает ошибку приложения, соответствующую коду, или nil
+func (c Code) Err() error {
+	for _, r := range rules {
+		if r.code == c {
+			return r.target
+		}
+	}
+	return nil
+}
+
+var fieldPattern = regexp.MustCompile(`^field '(\w+)'`)
+
+// Violations раскладывает ошибки валидатора по полям. Имя поля структуры
+// переводится в snake_case, как в запросах API
+func Violations(verrs validator.ValidationErrors) []FieldViolation {
+	fields := make([]FieldViolation, 0, len(verrs))
+	for _, v := range verrs {
+		if v.Err == nil {
+			continue
+		}
+		desc := v.Err.Error()
+		var field string
+		switch m := fieldPattern.FindStringSubmatch(desc); {
+		case errors.Is(v.Err, user.ErrInvalidEmail):
+			field = "email"
+		case m != nil:
+			field = FieldName(m[1])
+		}
+		fields = append(fields, FieldViolation{Field: field, Description: desc})
+	}
+	return fields
+}
+
+// FieldName переводит имя поля структуры в snake_case: AuthorID -> author_id
+func FieldName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
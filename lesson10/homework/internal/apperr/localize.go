@@ -0,0 +1,64 @@
+package apperr
+
+import (
+	"homework10/internal/i18n"
+	"homework10/internal/user"
+	"regexp"
+)
+
+var (
+	// constraintPattern разбирает сообщение валидатора (validator.ErrFieldNotValid)
+	// и ограничения арендатора
+	constraintPattern = regexp.MustCompile(`^field '\w+' of type (\w+) is not valid: has (tenant )?constraint \('(\w+)': (.*)\), but got (?:value|length) = (.*)$`)
+	// bindingPattern разбирает ошибку проверки тега binding при разборе запроса в gin
+	bindingPattern = regexp.MustCompile(`Field validation for '\w+' failed on the '(\w+)' tag$`)
+)
+
+// Localize переводит сообщение и описания нарушений по полям на язык l.
+// Сообщения без перевода в каталоге остаются на исходном языке
+func (e Error) Localize(l i18n.Locale) Error {
+	if l == i18n.Default {
+		return e
+	}
+	var args []any
+	if e.Code == CodeInvalidArgument {
+		args = append(args, e.Message)
+	}
+	if msg, ok := i18n.Message(l, string(e.Code), args...); ok {
+		e.Message = msg
+	}
+	if len(e.Fields) > 0 {
+		fields := make([]FieldViolation, len(e.Fields))
+		for i, f := range e.Fields {
+			fields[i] = FieldViolation{Field: f.Field, Description: localizeViolation(l, f)}
+		}
+		e.Fields = fields
+	}
+	return e
+}
+
+func localizeViolation(l i18n.Locale, f FieldViolation) string {
+	var msg string
+	var ok bool
+	if m := constraintPattern.FindStringSubmatch(f.Description); m != nil {
+		key := "validation."
+		if m[2] != "" {
+			key += "tenant."
+		}
+		key += m[3]
+		if m[1] == "string" && m[3] != "in" {
+			key += ".length"
+		}
+		msg, ok = i18n.Message(l, key, f.Field, m[4], m[5])
+	} else if m := bindingPattern.FindStringSubmatch(f.Description); m != nil {
+		if msg, ok = i18n.Message(l, "validation.binding."+m[1], f.Field); !ok {
+			msg, ok = i18n.Message(l, "validation.binding", f.Field, m[1])
+		}
+	} else if f.Description == user.ErrInvalidEmail.Error() {
+		msg, ok = i18n.Message(l, "validation.email")
+	}
+	if !ok {
+		return f.Description
+	}
+	return msg
+}
@@ -0,0 +1,171 @@
+// Package audit - журнал изменений объявлений и пользователей: кто, что и когда изменил.
+//
+// Приложение записывает каждое изменение (Entry) со снимками сущности до и после него,
+// а транспорт (HTTP или gRPC) кладет в контекст запроса свое имя и адрес клиента (Source).
+// Записи только добавляются: Sink не умеет изменять и удалять их. Журнал хранится в файле
+// JSONL (FileSink) или в таблице репозитория (см. adrepo.AuditTable).
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"homework10/internal/tenant"
+	"reflect"
+	"time"
+)
+
+// Action - вид изменения
+type Action string
+
+const (
+	ActionCreate         Action = "create"
+	ActionUpdate         Action = "update"
+	ActionPatch          Action = "patch"
+	ActionChangeStatus   Action = "change_status"
+	ActionSchedule       Action = "schedule"
+	ActionDelete         Action = "delete"
+	ActionVerifyEmail    Action = "verify_email"
+	ActionBan            Action = "ban"
+	ActionUnban          Action = "unban"
+	ActionSetRole        Action = "set_role"
+	ActionForceUnpublish Action = "force_unpublish"
+	ActionForceDelete    Action = "force_delete"
+	ActionApprove        Action = "approve"
+	// изменения, которые сервис делает сам по расписанию объявления
+	ActionScheduledPublish Action = "scheduled_publish"
+	ActionExpire           Action = "expire"
+)
+
+// SystemActorID - ActorID изменений, которые сервис делает сам, без запроса пользователя.
+// Последовательные ID пользователей начинаются с 0, поэтому системный актор отрицательный
+const SystemActorID int64 = -1
+
+// Entity - вид изменяемой сущности
+type Entity string
+
+const (
+	EntityAd   Entity = "ad"
+	EntityUser Entity = "user"
+)
+
+// Transport - транспорт, которым пришел запрос
+type Transport string
+
+const (
+	TransportHTTP Transport = "http"
+	TransportGRPC Transport = "grpc"
+)
+
+// Entry - запись журнала. Before и After - снимки сущности в JSON, у созданной сущности
+// нет снимка до изменения, у удаленной - после
+type Entry struct {
+	ID        int64           `json:"id"`
+	Time      time.Time       `json:"time"`
+	Tenant    tenant.ID       `json:"tenant"`
+	ActorID   int64           `json:"actor_id"`
+	Action    Action          `json:"action"`
+	Entity    Entity          `json:"entity"`
+	EntityID  int64           `json:"entity_id"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	Transport Transport       `json:"transport,omitempty"`
+	ClientIP  string          `json:"client_ip,omitempty"`
+}
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Filter - условия выборки записей, незаданные поля не ограничивают выборку
+type Filter struct {
+	Tenant    tenant.ID // пустой - все арендаторы
+	ActorID   *int64
+	Action    *Action
+	Entity    *Entity
+	EntityID  *int64
+	Transport *Transport
+	From      *time.Time // записи не раньше этого момента
+	To        *time.Time // записи строго раньше этого момента
+	Limit     int        // не больше MaxLimit, 0 - DefaultLimit
+}
+
+// Matches проверяет, удовлетворяет ли запись фильтру
+func (f Filter) Matches(e Entry) bool {
+	switch {
+	case f.Tenant != "" && f.Tenant != e.Tenant:
+		return false
+	case f.ActorID != nil && *f.ActorID != e.ActorID:
+		return false
+	case f.Action != nil && *f.Action != e.Action:
+		return false
+	case f.Entity != nil && *f.Entity != e.Entity:
+		return false
+	case f.EntityID != nil && *f.EntityID != e.EntityID:
+		return false
+	case f.Transport != nil && *f.Transport != e.Transport:
+		return false
+	case f.From != nil && e.Time.Before(*f.From):
+		return false
+	case f.To != nil && !e.Time.Before(*f.To):
+		return false
+	}
+	return true
+}
+
+// Select выбирает из записей в порядке добавления подходящие под фильтр, начиная с последних
+func Select(entries []Entry, f Filter) []Entry {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	out := make([]Entry, 0)
+	for i := len(entries) - 1; i >= 0 && len(out) < limit; i-- {
+		if f.Matches(entries[i]) {
+			out = append(out, entries[i])
+		}
+	}
+	return out
+}
+
+// Sink - хранилище журнала
+type Sink interface {
+	// Write присваивает записи ID и сохраняет ее
+	Write(ctx context.Context, e *Entry) error
+	// Query возвращает записи по фильтру, начиная с последних
+	Query(ctx context.Context, f Filter) ([]Entry, error)
+}
+
+// Snapshot возвращает снимок сущности в JSON, nil - сущности нет
+func Snapshot(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// Source - откуда пришел запрос
+type Source struct {
+	Transport Transport
+	ClientIP  string
+}
+
+type contextKey struct{}
+
+// NewContext возвращает контекст, несущий источник запроса
+func NewContext(ctx context.Context, s Source) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// SourceFromContext возвращает источник запроса, без него (например, у фоновых задач) - пустой
+func SourceFromContext(ctx context.Context) Source {
+	s, _ := ctx.Value(contextKey{}).(Source)
+	return s
+}
@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var ErrInvalidEntry = errors.New("invalid audit entry")
+
+// FileSink пишет журнал в файл JSONL, по записи на строку. Файл только дописывается,
+// а при открытии существующего файла нумерация записей продолжается
+type FileSink struct {
+	mu  sync.Mutex
+	f   *os.File
+	seq int64
+}
+
+// OpenFile открывает файл журнала для дозаписи и создает его при необходимости
+func OpenFile(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileSink{f: f}
+	entries, err := s.read()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(entries) > 0 {
+		s.seq = entries[len(entries)-1].ID
+	}
+	return s, nil
+}
+
+func (s *FileSink) Write(_ context.Context, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.ID = s.seq + 1
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.seq = e.ID
+	return nil
+}
+
+func (s *FileSink) Query(_ context.Context, f Filter) ([]Entry, error) {
+	s.mu.Lock()
+	entries, err := s.read()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return Select(entries, f), nil
+}
+
+// read читает все записи файла, вызывается под блокировкой
+func (s *FileSink) read() ([]Entry, error) {
+	fi, err := s.f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Read(io.NewSectionReader(s.f, 0, fi.Size()))
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// Read читает записи журнала в формате JSONL
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%w at line %d: %s", ErrInvalidEntry, line, err.Error())
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,188 @@
+// Package capture - запись запросов к API и ответов на них в файл JSONL для последующего воспроизведения.
+//
+// Каждая строка файла - одна запись (Entry): протокол, метод, заголовки, тело запроса и ответа,
+// статус и длительность обработки. Записи пишут промежуточный обработчик httpgin и перехватчик
+// gRPC, читает их пакет replay, который прогоняет те же запросы через новое приложение.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+var ErrInvalidEntry = errors.New("invalid capture entry")
+
+// Entry - запрос и ответ на него
+type Entry struct {
+	// Seq - порядковый номер запроса в момент получения, воспроизведение идет в этом порядке
+	Seq      int64     `json:"seq"`
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"duration_ms"`
+	Protocol string    `json:"protocol"`
+	// Method - метод HTTP или полное имя метода gRPC (/homework10.AdService/CreateAd)
+	Method string `json:"method"`
+	// Path - путь HTTP вместе со строкой запроса
+	Path   string            `json:"path,omitempty"`
+	Header map[string]string `json:"header,omitempty"`
+
+	Request    json.RawMessage `json:"request,omitempty"`
+	RequestRaw string          `json:"request_raw,omitempty"`
+	// Status - HTTP статус или код gRPC
+	Status int `json:"status"`
+	// Error - сообщение статуса gRPC
+	Error       string          `json:"error,omitempty"`
+	Response    json.RawMessage `json:"response,omitempty"`
+	ResponseRaw string          `json:"response_raw,omitempty"`
+}
+
+// SetRequest сохраняет тело запроса: корректный JSON как есть, остальное - строкой,
+// чтобы в файл попадали и тела, которые сервис не смог разобрать
+func (e *Entry) SetRequest(body []byte) {
+	e.Request, e.RequestRaw = splitBody(body)
+}
+
+// SetResponse сохраняет тело ответа так же, как SetRequest
+func (e *Entry) SetResponse(body []byte) {
+	e.Response, e.ResponseRaw = splitBody(body)
+}
+
+// RequestBody возвращает тело запроса в исходном виде
+func (e Entry) RequestBody() []byte {
+	if e.Request != nil {
+		return e.Request
+	}
+	return []byte(e.RequestRaw)
+}
+
+// ResponseBody возвращает тело ответа в исходном виде
+func (e Entry) ResponseBody() []byte {
+	if e.Response != nil {
+		return e.Response
+	}
+	return []byte(e.ResponseRaw)
+}
+
+func splitBody(body []byte) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	if json.Valid(body) {
+		var buf bytes.Buffer
+		if json.Compact(&buf, body) == nil {
+			return buf.Bytes(), ""
+		}
+	}
+	return nil, string(body)
+}
+
+// Recorder дописывает записи в поток, безопасен для одновременного использования
+type Recorder struct {
+	mu  sync.Mutex
+	w   io.Writer
+	seq int64
+	err error
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Next выдает номер очередному запросу
+func (r *Recorder) Next() int64 {
+	return atomic.AddInt64(&r.seq, 1)
+}
+
+// Record записывает запись одной строкой. Ошибка записи сохраняется и возвращается
+// из Err: сбой записи не должен влиять на обработку запросов
+func (r *Recorder) Record(e Entry) {
+	line, err := json.Marshal(e)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		_, err = r.w.Write(append(line, '\n'))
+	}
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Err возвращает первую ошибку записи
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// FileRecorder - Recorder, пишущий в файл
+type FileRecorder struct {
+	*Recorder
+	f *os.File
+}
+
+// Create открывает файл для дозаписи и создает его при необходимости
+func Create(path string) (*FileRecorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileRecorder{Recorder: NewRecorder(f), f: f}, nil
+}
+
+func (r *FileRecorder) Close() error {
+	if err := r.Err(); err != nil {
+		_ = r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
+
+// Read читает записи и упорядочивает их по Seq
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%w at line %d: %s", ErrInvalidEntry, line, err.Error())
+		}
+		if e.Protocol != ProtocolHTTP && e.Protocol != ProtocolGRPC {
+			return nil, fmt.Errorf("%w at line %d: unknown protocol %q", ErrInvalidEntry, line, e.Protocol)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Seq < entries[j].Seq
+	})
+	return entries, nil
+}
+
+// Open читает записи из файла
+func Open(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
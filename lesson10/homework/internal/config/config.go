@@ -0,0 +1,137 @@
+// Package config - настройки сервиса, которые меняются без перезапуска серверов.
+//
+// Config - неизменяемый снимок настроек: уровень журнала, общие ограничения валидации
+// и флаги функциональности (см. пакет features). Store хранит текущий снимок и атомарно заменяет его при
+// перезагрузке (Reloader), а промежуточные обработчики кладут снимок в контекст запроса,
+// поэтому весь запрос, включая приложение, видит одни и те же настройки.
+// Лимиты частоты и ограничения арендаторов хранятся в файле арендаторов и перезагружаются вместе с ним.
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"homework10/internal/features"
+	"homework10/internal/logging"
+	"homework10/internal/tenant"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+var ErrInvalidConfig = errors.New("invalid runtime config")
+
+// Config - снимок настроек. Снимок не изменяется после публикации в Store
+//
+//	log_level: info
+//	limits: {max_title_length: 80}
+//	features: {premoderation: {enabled: true, rollout: 10}}
+type Config struct {
+	LogLevel logging.Level `yaml:"log_level"`
+	// Limits - ограничения валидации для всех арендаторов, арендатор может задать более строгие
+	Limits   tenant.Limits `yaml:"limits"`
+	Features features.Set  `yaml:"features"`
+}
+
+// Default - настройки без файла
+func Default() *Config {
+	return &Config{LogLevel: logging.LevelInfo}
+}
+
+// Validate проверяет настройки перед публикацией
+func (c *Config) Validate() error {
+	if c.LogLevel < logging.LevelDebug || c.LogLevel > logging.LevelError {
+		return fmt.Errorf("%w: unknown log level %d", ErrInvalidConfig, c.LogLevel)
+	}
+	if c.Limits.MaxTitleLength < 0 || c.Limits.MaxTextLength < 0 || c.Limits.MaxNicknameLength < 0 {
+		return fmt.Errorf("%w: limits must not be negative", ErrInvalidConfig)
+	}
+	if err := c.Features.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, err.Error())
+	}
+	return nil
+}
+
+// LimitsFor объединяет общие ограничения с ограничениями арендатора: из двух заданных действует меньшее
+func (c *Config) LimitsFor(t tenant.Tenant) tenant.Limits {
+	own := t.Config.Limits
+	return tenant.Limits{
+		MaxTitleLength:    stricter(c.Limits.MaxTitleLength, own.MaxTitleLength),
+		MaxTextLength:     stricter(c.Limits.MaxTextLength, own.MaxTextLength),
+		MaxNicknameLength: stricter(c.Limits.MaxNicknameLength, own.MaxNicknameLength),
+	}
+}
+
+func stricter(a int, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// Parse читает настройки в формате YAML. Незнакомые поля - ошибка, чтобы опечатка
+// в файле не приводила к молчаливому возврату значения по умолчанию
+func Parse(r io.Reader) (*Config, error) {
+	c := Default()
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidConfig, err.Error())
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load читает настройки из YAML-файла
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Store хранит текущий снимок настроек
+type Store struct {
+	current atomic.Pointer[Config]
+}
+
+// NewStore создает хранилище со снимком c, nil - настройки по умолчанию
+func NewStore(c *Config) *Store {
+	if c == nil {
+		c = Default()
+	}
+	s := &Store{}
+	s.current.Store(c)
+	return s
+}
+
+// Load возвращает текущий снимок
+func (s *Store) Load() *Config {
+	return s.current.Load()
+}
+
+func (s *Store) swap(c *Config) *Config {
+	return s.current.Swap(c)
+}
+
+type contextKey struct{}
+
+var defaults = Default()
+
+// NewContext возвращает контекст, несущий снимок настроек и его флаги функциональности
+func NewContext(ctx context.Context, c *Config) context.Context {
+	return features.NewContext(context.WithValue(ctx, contextKey{}, c), c.Features)
+}
+
+// FromContext возвращает снимок настроек запроса, без него - настройки по умолчанию
+func FromContext(ctx context.Context) *Config {
+	if c, ok := ctx.Value(contextKey{}).(*Config); ok && c != nil {
+		return c
+	}
+	return defaults
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"homework10/internal/logging"
+	"homework10/internal/tenant"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Hook применяет новый снимок к компонентам, которые не читают настройки из контекста
+// (например, уровень журнала). Ошибка хука откатывает перезагрузку
+type Hook func(c *Config) error
+
+// Reloader перечитывает файл настроек и файл арендаторов. Новые настройки публикуются,
+// только если оба файла прочитаны и прошли проверку, иначе остаются прежние
+type Reloader struct {
+	mu          sync.Mutex
+	store       *Store
+	path        string // файл настроек, пустой - настройки по умолчанию
+	tenants     *tenant.Registry
+	tenantsPath string // файл арендаторов, пустой - арендаторы не перезагружаются
+	hooks       []Hook
+	stamps      map[string]stamp
+}
+
+// stamp - признак изменения файла для опроса
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func NewReloader(store *Store, path string, tenants *tenant.Registry, tenantsPath string) *Reloader {
+	r := &Reloader{store: store, path: path, tenants: tenants, tenantsPath: tenantsPath, stamps: make(map[string]stamp)}
+	r.OnReload(func(c *Config) error {
+		logging.SetLevel(c.LogLevel)
+		return nil
+	})
+	return r
+}
+
+// OnReload добавляет хук, вызываемый после публикации каждого снимка
+func (r *Reloader) OnReload(h Hook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, h)
+}
+
+// Reload читает файлы, проверяет и публикует новые настройки. При ошибке хука
+// восстанавливается предыдущий снимок, и хуки применяются к нему повторно
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remember()
+
+	next := Default()
+	if r.path != "" {
+		var err error
+		if next, err = Load(r.path); err != nil {
+			return err
+		}
+	}
+	var tenants *tenant.Registry
+	if r.tenantsPath != "" && r.tenants != nil {
+		var err error
+		if tenants, err = tenant.Load(r.tenantsPath); err != nil {
+			return err
+		}
+	}
+
+	prev := r.store.swap(next)
+	for _, h := range r.hooks {
+		if err := h(next); err != nil {
+			r.store.swap(prev)
+			for _, h := range r.hooks {
+				_ = h(prev)
+			}
+			return fmt.Errorf("apply runtime config, rolled back: %w", err)
+		}
+	}
+	if tenants != nil {
+		r.tenants.Replace(tenants)
+	}
+	log.Printf("config: loaded, log level %s, %d features, %d tenants\n", next.LogLevel, len(next.Features), r.tenantCount())
+	return nil
+}
+
+func (r *Reloader) tenantCount() int {
+	if r.tenants == nil {
+		return 0
+	}
+	return len(r.tenants.IDs())
+}
+
+// remember запоминает состояние файлов, чтобы опрос не перечитывал тот же, в том числе ошибочный, файл
+func (r *Reloader) remember() {
+	for _, path := range r.paths() {
+		r.stamps[path] = fileStamp(path)
+	}
+}
+
+func (r *Reloader) paths() []string {
+	var paths []string
+	if r.path != "" {
+		paths = append(paths, r.path)
+	}
+	if r.tenantsPath != "" && r.tenants != nil {
+		paths = append(paths, r.tenantsPath)
+	}
+	return paths
+}
+
+func fileStamp(path string) stamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{modTime: fi.ModTime(), size: fi.Size()}
+}
+
+// changed сообщает, изменился ли какой-либо файл с последней перезагрузки
+func (r *Reloader) changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, path := range r.paths() {
+		if fileStamp(path) != r.stamps[path] {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch перезагружает настройки по сигналу из signals (SIGHUP) и при изменении файлов,
+// которые опрашиваются раз в interval (0 - только по сигналу). Ошибка перезагрузки
+// не останавливает работу: сервис продолжает работать с прежними настройками
+func (r *Reloader) Watch(ctx context.Context, signals <-chan os.Signal, interval time.Duration) error {
+	var tick <-chan time.Time
+	if interval > 0 && len(r.paths()) > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s := <-signals:
+			log.Printf("config: captured signal %v, reloading\n", s)
+			r.reload()
+		case <-tick:
+			if r.changed() {
+				log.Println("config: files changed, reloading")
+				r.reload()
+			}
+		}
+	}
+}
+
+func (r *Reloader) reload() {
+	if err := r.Reload(); err != nil {
+		log.Printf("config: reload failed, keeping previous config: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,190 @@
+// Package features - флаги функциональности для постепенного включения нового поведения.
+//
+// Флаг включается для всех пользователей, для доли пользователей (rollout, в процентах)
+// и для списка пользователей. Доля считается по хешу имени флага и идентификатора пользователя,
+// поэтому пользователь стабильно попадает в одну группу, увеличение доли не исключает тех,
+// кто уже в нее попал, а разные флаги включаются для разных пользователей.
+// Набор флагов (Set) задается в файле настроек (см. пакет config), перезагружается вместе с ним
+// и передается в контексте запроса: обработчики и приложение проверяют флаги через Enabled.
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var ErrInvalidFlag = errors.New("invalid feature flag")
+
+// Известные флаги
+const (
+	// Premoderation - новые объявления автора ждут проверки модератором до публикации
+	Premoderation = "premoderation"
+)
+
+// Flag - настройки флага. В файле настроек флаг, включенный для всех, можно задать одним значением:
+//
+//	features:
+//	  premoderation: true
+//	  new_list_filter: {enabled: true, rollout: 10, users: [1, 2]}
+type Flag struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Rollout - доля пользователей в процентах (0-100), для которых включен флаг, nil - все пользователи
+	Rollout *float64 `yaml:"rollout,omitempty" json:"rollout,omitempty"`
+	// Users - пользователи, для которых флаг включен независимо от доли
+	Users []int64 `yaml:"users,omitempty" json:"users,omitempty"`
+}
+
+var flagFields = map[string]bool{"enabled": true, "rollout": true, "users": true}
+
+func (f *Flag) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		var enabled bool
+		if err := n.Decode(&enabled); err != nil {
+			return err
+		}
+		*f = Flag{Enabled: enabled}
+		return nil
+	}
+	// Decode узла не проверяет незнакомые поля, даже если это включено у декодера файла
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i < len(n.Content); i += 2 {
+			if key := n.Content[i].Value; !flagFields[key] {
+				return fmt.Errorf("line %d: unknown feature flag field %q", n.Content[i].Line, key)
+			}
+		}
+	}
+	type plain Flag
+	var p plain
+	if err := n.Decode(&p); err != nil {
+		return err
+	}
+	*f = Flag(p)
+	return nil
+}
+
+// Reason - причина результата проверки флага
+type Reason string
+
+const (
+	ReasonUnknown  Reason = "unknown"  // флаг не задан, считается выключенным
+	ReasonDisabled Reason = "disabled" // флаг выключен
+	ReasonEnabled  Reason = "enabled"  // флаг включен для всех
+	ReasonUser     Reason = "user"     // пользователь указан в списке флага
+	ReasonRollout  Reason = "rollout"  // пользователь попал в долю
+	ReasonExcluded Reason = "excluded" // пользователь не попал в долю
+)
+
+// Evaluation - результат проверки флага для пользователя
+type Evaluation struct {
+	Name    string
+	Flag    Flag
+	Enabled bool
+	Reason  Reason
+	// Bucket - место пользователя в шкале доли (0-100): флаг включен, если оно меньше Rollout
+	Bucket float64
+}
+
+// Set - флаги по именам. Set не изменяется после публикации в снимке настроек
+type Set map[string]Flag
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Validate проверяет имена флагов и доли
+func (s Set) Validate() error {
+	for name, f := range s {
+		if !namePattern.MatchString(name) {
+			return fmt.Errorf("%w: invalid name %q", ErrInvalidFlag, name)
+		}
+		if f.Rollout != nil && (*f.Rollout < 0 || *f.Rollout > 100) {
+			return fmt.Errorf("%w: %s: rollout must be between 0 and 100", ErrInvalidFlag, name)
+		}
+		for _, uid := range f.Users {
+			if uid < 0 {
+				return fmt.Errorf("%w: %s: invalid user id %d", ErrInvalidFlag, name, uid)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate проверяет флаг для пользователя uid. Для запросов без пользователя uid = 0
+func (s Set) Evaluate(name string, uid int64) Evaluation {
+	f, ok := s[name]
+	e := Evaluation{Name: name, Flag: f, Bucket: Bucket(name, uid)}
+	switch {
+	case !ok:
+		e.Reason = ReasonUnknown
+	case !f.Enabled:
+		e.Reason = ReasonDisabled
+	case containsUser(f.Users, uid):
+		e.Enabled, e.Reason = true, ReasonUser
+	case f.Rollout == nil:
+		e.Enabled, e.Reason = true, ReasonEnabled
+	case e.Bucket < *f.Rollout:
+		e.Enabled, e.Reason = true, ReasonRollout
+	default:
+		e.Reason = ReasonExcluded
+	}
+	return e
+}
+
+// Enabled сообщает, включен ли флаг для пользователя uid
+func (s Set) Enabled(name string, uid int64) bool {
+	return s.Evaluate(name, uid).Enabled
+}
+
+// EvaluateAll проверяет все заданные флаги для пользователя uid в порядке имен
+func (s Set) EvaluateAll(uid int64) []Evaluation {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]Evaluation, 0, len(names))
+	for _, name := range names {
+		out = append(out, s.Evaluate(name, uid))
+	}
+	return out
+}
+
+func containsUser(users []int64, uid int64) bool {
+	for _, u := range users {
+		if u == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// Bucket возвращает место пользователя в шкале доли флага: число от 0 до 100 с шагом 0.01
+func Bucket(name string, uid int64) float64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(strconv.FormatInt(uid, 10)))
+	return float64(h.Sum32()%10000) / 100
+}
+
+type contextKey struct{}
+
+// NewContext возвращает контекст, несущий набор флагов
+func NewContext(ctx context.Context, s Set) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext возвращает набор флагов запроса, без него - пустой набор (все флаги выключены)
+func FromContext(ctx context.Context) Set {
+	s, _ := ctx.Value(contextKey{}).(Set)
+	return s
+}
+
+// Enabled сообщает, включен ли флаг для пользователя uid в наборе флагов запроса
+func Enabled(ctx context.Context, name string, uid int64) bool {
+	return FromContext(ctx).Enabled(name, uid)
+}
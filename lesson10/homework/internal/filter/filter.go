@@ -0,0 +1,223 @@
+// Package filter - язык выражений для фильтрации списка объявлений.
+//
+// Выражение состоит из сравнений полей с литералами, объединенных AND, OR, NOT и скобками:
+//
+//	published = true AND author_id IN (1, 2) AND title ~ "bike"
+//
+// Parse строит по строке дерево (Expr) и проверяет его по списку допустимых полей и их типов.
+// Дерево вычисляется над объявлением в памяти (Expr.Eval) или переводится в условие SQL с
+// параметрами (SQL), так что новые фильтры не требуют новых полей в запросах транспорта.
+package filter
+
+import (
+	"errors"
+	"homework10/internal/ads"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSyntax       = errors.New("filter syntax error")
+	ErrUnknownField = errors.New("unknown filter field")
+	ErrInvalidValue = errors.New("invalid filter value")
+	ErrTooComplex   = errors.New("filter is too complex")
+)
+
+// Kind - тип поля, от него зависят допустимые операторы и литералы
+type Kind int
+
+const (
+	KindInt Kind = iota
+	KindBool
+	KindString
+	KindTime
+)
+
+// Op - оператор сравнения
+type Op string
+
+const (
+	OpEq       Op = "="
+	OpNe       Op = "!="
+	OpLt       Op = "<"
+	OpLe       Op = "<="
+	OpGt       Op = ">"
+	OpGe       Op = ">="
+	OpContains Op = "~" // подстрока без учета регистра
+	OpIn       Op = "IN"
+)
+
+type field struct {
+	kind   Kind
+	column string
+	get    func(ad ads.Ad) any
+}
+
+// fields - поля, доступные в выражениях, и соответствующие им столбцы SQL
+var fields = map[string]field{
+	"id":         {KindInt, "id", func(ad ads.Ad) any { return ad.ID }},
+	"author_id":  {KindInt, "author_id", func(ad ads.Ad) any { return ad.AuthorID }},
+	"published":  {KindBool, "published", func(ad ads.Ad) any { return ad.Published }},
+	"title":      {KindString, "title", func(ad ads.Ad) any { return ad.Title }},
+	"text":       {KindString, "text", func(ad ads.Ad) any { return ad.Text }},
+	"created_at": {KindTime, "date_created", func(ad ads.Ad) any { return ad.DateCreated }},
+	"updated_at": {KindTime, "COALESCE(date_changed, date_created)", func(ad ads.Ad) any { return ad.LastChanged() }},
+}
+
+// allowed - операторы, допустимые для каждого типа поля
+var allowed = map[Kind][]Op{
+	KindInt:    {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe, OpIn},
+	KindBool:   {OpEq, OpNe},
+	KindString: {OpEq, OpNe, OpContains, OpIn},
+	KindTime:   {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe},
+}
+
+// Expr - узел дерева выражения
+type Expr interface {
+	// Eval сообщает, удовлетворяет ли объявление выражению
+	Eval(ad ads.Ad) bool
+	// String возвращает выражение в каноническом виде, пригодном для повторного разбора
+	String() string
+}
+
+type And struct {
+	Left, Right Expr
+}
+
+type Or struct {
+	Left, Right Expr
+}
+
+type Not struct {
+	Expr Expr
+}
+
+// Compare сравнивает поле с литералом. Value имеет тип int64, bool, string или time.Time
+// в зависимости от типа поля
+type Compare struct {
+	Field string
+	Op    Op
+	Value any
+}
+
+// In проверяет, что значение поля совпадает с одним из литералов
+type In struct {
+	Field  string
+	Values []any
+}
+
+func (e And) Eval(ad ads.Ad) bool {
+	return e.Left.Eval(ad) && e.Right.Eval(ad)
+}
+
+func (e Or) Eval(ad ads.Ad) bool {
+	return e.Left.Eval(ad) || e.Right.Eval(ad)
+}
+
+func (e Not) Eval(ad ads.Ad) bool {
+	return !e.Expr.Eval(ad)
+}
+
+func (e Compare) Eval(ad ads.Ad) bool {
+	v := fields[e.Field].get(ad)
+	if e.Op == OpContains {
+		return strings.Contains(strings.ToLower(v.(string)), strings.ToLower(e.Value.(string)))
+	}
+	c := compare(v, e.Value)
+	switch e.Op {
+	case OpEq:
+		return c == 0
+	case OpNe:
+		return c != 0
+	case OpLt:
+		return c < 0
+	case OpLe:
+		return c <= 0
+	case OpGt:
+		return c > 0
+	case OpGe:
+		return c >= 0
+	}
+	return false
+}
+
+func (e In) Eval(ad ads.Ad) bool {
+	v := fields[e.Field].get(ad)
+	for _, value := range e.Values {
+		if compare(v, value) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// compare сравнивает значения одного типа: -1, 0 или 1; значения bool только на равенство
+func compare(a any, b any) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case time.Time:
+		b := b.(time.Time)
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		}
+		return 0
+	case bool:
+		if a == b.(bool) {
+			return 0
+		}
+		return 1
+	}
+	return 1
+}
+
+func (e And) String() string {
+	return "(" + e.Left.String() + " AND " + e.Right.String() + ")"
+}
+
+func (e Or) String() string {
+	return "(" + e.Left.String() + " OR " + e.Right.String() + ")"
+}
+
+func (e Not) String() string {
+	return "NOT " + e.Expr.String()
+}
+
+func (e Compare) String() string {
+	return e.Field + " " + string(e.Op) + " " + literal(e.Value)
+}
+
+func (e In) String() string {
+	values := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		values[i] = literal(v)
+	}
+	return e.Field + " IN (" + strings.Join(values, ", ") + ")"
+}
+
+func literal(v any) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return strconv.Quote(v)
+	case time.Time:
+		return strconv.Quote(v.Format(time.RFC3339Nano))
+	}
+	return ""
+}
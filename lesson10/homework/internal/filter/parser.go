@@ -0,0 +1,320 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ограничения, защищающие сервис от слишком дорогих выражений
+const (
+	MaxLength   = 1024 // байт в строке выражения
+	MaxDepth    = 16   // вложенность скобок и NOT
+	MaxTerms    = 64   // сравнений в выражении
+	MaxInValues = 100  // литералов в списке IN
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string // для tokString - уже раскрытое значение
+	pos  int
+}
+
+// lex разбивает строку на лексемы
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("%w at %d: unterminated string", ErrSyntax, i)
+			}
+			value, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w at %d: invalid string literal", ErrSyntax, i)
+			}
+			tokens = append(tokens, token{tokString, value, i})
+			i = end + 1
+		case strings.ContainsRune("=!<>~", rune(c)):
+			op := s[i : i+1]
+			if i+1 < len(s) && s[i+1] == '=' && c != '=' && c != '~' {
+				op = s[i : i+2]
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("%w at %d: unexpected '!'", ErrSyntax, i)
+			}
+			tokens = append(tokens, token{tokOp, op, i})
+			i += len(op)
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+				end++
+			}
+			tokens = append(tokens, token{tokNumber, s[i:end], i})
+			i = end
+		case isLetter(c):
+			end := i + 1
+			for end < len(s) && (isLetter(s[end]) || s[end] >= '0' && s[end] <= '9') {
+				end++
+			}
+			tokens = append(tokens, token{tokIdent, s[i:end], i})
+			i = end
+		default:
+			return nil, fmt.Errorf("%w at %d: unexpected %q", ErrSyntax, i, c)
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(s)}), nil
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+	terms  int
+}
+
+// Parse разбирает выражение и проверяет поля, операторы и типы литералов.
+// Приоритет операторов: NOT, затем AND, затем OR; ключевые слова не зависят от регистра
+func Parse(s string) (Expr, error) {
+	if len(s) > MaxLength {
+		return nil, fmt.Errorf("%w: longer than %d bytes", ErrTooComplex, MaxLength)
+	}
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.unexpected(t)
+	}
+	return e, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword сообщает, является ли текущая лексема ключевым словом kw, и пропускает ее
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokIdent && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected(t token) error {
+	if t.kind == tokEOF {
+		return fmt.Errorf("%w: unexpected end of filter", ErrSyntax)
+	}
+	return fmt.Errorf("%w at %d: unexpected %q", ErrSyntax, t.pos, t.text)
+}
+
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (Expr, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > MaxDepth {
+		return nil, fmt.Errorf("%w: nested deeper than %d", ErrTooComplex, MaxDepth)
+	}
+	if p.keyword("NOT") {
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: e}, nil
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, p.unexpected(t)
+		}
+		return e, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (Expr, error) {
+	p.terms++
+	if p.terms > MaxTerms {
+		return nil, fmt.Errorf("%w: more than %d comparisons", ErrTooComplex, MaxTerms)
+	}
+	name := p.next()
+	if name.kind != tokIdent {
+		return nil, p.unexpected(name)
+	}
+	f, ok := fields[name.text]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownField, name.text)
+	}
+
+	var op Op
+	if p.keyword("IN") {
+		op = OpIn
+	} else if t := p.next(); t.kind == tokOp {
+		op = Op(t.text)
+	} else {
+		return nil, p.unexpected(t)
+	}
+	if !opAllowed(f.kind, op) {
+		return nil, fmt.Errorf("%w: operator %s is not supported for %s", ErrInvalidValue, op, name.text)
+	}
+
+	if op != OpIn {
+		v, err := p.value(name.text, f.kind)
+		if err != nil {
+			return nil, err
+		}
+		return Compare{Field: name.text, Op: op, Value: v}, nil
+	}
+	if t := p.next(); t.kind != tokLParen {
+		return nil, p.unexpected(t)
+	}
+	var values []any
+	for {
+		v, err := p.value(name.text, f.kind)
+		if err != nil {
+			return nil, err
+		}
+		if values = append(values, v); len(values) > MaxInValues {
+			return nil, fmt.Errorf("%w: more than %d values in IN", ErrTooComplex, MaxInValues)
+		}
+		if t := p.next(); t.kind == tokRParen {
+			break
+		} else if t.kind != tokComma {
+			return nil, p.unexpected(t)
+		}
+	}
+	return In{Field: name.text, Values: values}, nil
+}
+
+func opAllowed(kind Kind, op Op) bool {
+	for _, o := range allowed[kind] {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// value разбирает литерал, тип которого соответствует полю
+func (p *parser) value(name string, kind Kind) (any, error) {
+	t := p.next()
+	invalid := func() error {
+		if t.kind == tokEOF {
+			return p.unexpected(t)
+		}
+		return fmt.Errorf("%w for %s: %q", ErrInvalidValue, name, t.text)
+	}
+	switch kind {
+	case KindInt:
+		if t.kind == tokNumber {
+			if v, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+				return v, nil
+			}
+		}
+	case KindBool:
+		if t.kind == tokIdent && strings.EqualFold(t.text, "true") {
+			return true, nil
+		}
+		if t.kind == tokIdent && strings.EqualFold(t.text, "false") {
+			return false, nil
+		}
+	case KindString:
+		if t.kind == tokString {
+			return t.text, nil
+		}
+	case KindTime:
+		if t.kind == tokString {
+			if v, err := time.Parse(time.RFC3339, t.text); err == nil {
+				return v.UTC(), nil
+			}
+			if v, err := time.Parse("2006-01-02", t.text); err == nil {
+				return v, nil
+			}
+		}
+	}
+	return nil, invalid()
+}
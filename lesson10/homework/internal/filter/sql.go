@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SQL переводит выражение в условие WHERE с позиционными параметрами ($1, $2, ...).
+// Имена столбцов берутся только из списка допустимых полей, литералы передаются параметрами
+func SQL(e Expr) (string, []any) {
+	var b sqlBuilder
+	where := b.build(e)
+	return where, b.args
+}
+
+type sqlBuilder struct {
+	args []any
+}
+
+func (b *sqlBuilder) param(v any) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *sqlBuilder) build(e Expr) string {
+	switch e := e.(type) {
+	case And:
+		return "(" + b.build(e.Left) + " AND " + b.build(e.Right) + ")"
+	case Or:
+		return "(" + b.build(e.Left) + " OR " + b.build(e.Right) + ")"
+	case Not:
+		return "NOT " + b.build(e.Expr)
+	case Compare:
+		column := fields[e.Field].column
+		switch e.Op {
+		case OpContains:
+			return "strpos(lower(" + column + "), lower(" + b.param(e.Value) + ")) > 0"
+		case OpNe:
+			return column + " <> " + b.param(e.Value)
+		}
+		return column + " " + string(e.Op) + " " + b.param(e.Value)
+	case In:
+		params := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			params[i] = b.param(v)
+		}
+		return fields[e.Field].column + " IN (" + strings.Join(params, ", ") + ")"
+	}
+	return "FALSE"
+}
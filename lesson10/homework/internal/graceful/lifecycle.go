@@ -0,0 +1,337 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Phase - фаза остановки. Фазы выполняются по порядку, компоненты одной фазы - одновременно
+type Phase int
+
+const (
+	PhaseReadinessOff    Phase = iota // сервис перестает считаться готовым (health, /readyz)
+	PhaseDrain                        // ожидание, пока балансировщики уберут сервис, и завершение запросов
+	PhaseStopServers                  // остановка HTTP и gRPC серверов
+	PhaseFlushWorkers                 // остановка фоновых задач и сброс буферов
+	PhaseCloseRepository              // закрытие хранилищ
+)
+
+var phases = []Phase{PhaseReadinessOff, PhaseDrain, PhaseStopServers, PhaseFlushWorkers, PhaseCloseRepository}
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseReadinessOff:
+		return "readiness-off"
+	case PhaseDrain:
+		return "drain"
+	case PhaseStopServers:
+		return "stop-servers"
+	case PhaseFlushWorkers:
+		return "flush-workers"
+	case PhaseCloseRepository:
+		return "close-repository"
+	}
+	return fmt.Sprintf("phase(%d)", int(p))
+}
+
+const (
+	DefaultShutdownTimeout = 30 * time.Second
+	// forceWait - сколько ждать остановки компонента после принудительной остановки
+	forceWait = time.Second
+)
+
+// ErrNotStopped - компонент не остановился и после принудительной остановки
+var ErrNotStopped = errors.New("component did not stop")
+
+// Component - часть сервиса с хуками запуска и остановки. Все хуки необязательны
+type Component struct {
+	Name  string
+	Phase Phase // фаза, в которой вызывается Stop
+	// Start вызывается при запуске в порядке регистрации и не должен блокироваться
+	Start func(ctx context.Context) error
+	// Run - основная работа компонента (например, Serve). Должен вернуться после Stop;
+	// ошибка до начала остановки останавливает весь сервис
+	Run func(ctx context.Context) error
+	// Stop останавливает компонент, ctx истекает вместе с общим сроком остановки
+	Stop func(ctx context.Context) error
+	// Force вызывается, если Stop не успел до общего срока остановки
+	Force func()
+}
+
+// Config - параметры остановки
+type Config struct {
+	ShutdownTimeout time.Duration // общий срок остановки всех компонентов
+	DrainDelay      time.Duration // пауза в фазе drain после снятия готовности
+}
+
+func DefaultConfig() Config {
+	return Config{ShutdownTimeout: DefaultShutdownTimeout}
+}
+
+// ComponentReport - итог остановки одного компонента
+type ComponentReport struct {
+	Name     string
+	Phase    Phase
+	Duration time.Duration
+	Forced   bool // Stop не успел до срока, вызван Force
+	Err      error
+}
+
+// Report - итог остановки сервиса
+type Report struct {
+	Duration         time.Duration
+	DeadlineExceeded bool
+	Components       []ComponentReport
+}
+
+// Err возвращает первую ошибку остановки компонентов
+func (r Report) Err() error {
+	for _, c := range r.Components {
+		if c.Err != nil {
+			return fmt.Errorf("%s: %w", c.Name, c.Err)
+		}
+	}
+	return nil
+}
+
+// Manager запускает компоненты и останавливает их по фазам с общим сроком
+type Manager struct {
+	cfg        Config
+	components []Component
+	ready      atomic.Bool
+}
+
+func NewManager(cfg Config) *Manager {
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	return &Manager{cfg: cfg}
+}
+
+// Register добавляет компонент, компоненты запускаются в порядке регистрации
+func (m *Manager) Register(c Component) {
+	m.components = append(m.components, c)
+}
+
+// Ready сообщает, что все компоненты запущены и остановка еще не началась
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// ReadinessHandler отвечает 200, пока сервис готов принимать запросы, и 503 при запуске и остановке
+func (m *Manager) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.Ready() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "ready")
+	})
+}
+
+// Run запускает компоненты и ждет завершения ctx или ошибки одного из них, после чего
+// останавливает запущенные компоненты. Возвращает отчет об остановке и причину остановки,
+// если это ошибка компонента; завершение ctx ошибкой не считается
+func (m *Manager) Run(ctx context.Context) (Report, error) {
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	defer cancelRun()
+
+	errCh := make(chan error, len(m.components))
+	var running sync.WaitGroup
+	var started []Component
+	var cause error
+	for _, c := range m.components {
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				cause = fmt.Errorf("start %s: %w", c.Name, err)
+				break
+			}
+		}
+		started = append(started, c)
+		if c.Run != nil {
+			running.Add(1)
+			go func(c Component) {
+				defer running.Done()
+				if err := c.Run(runCtx); err != nil {
+					errCh <- fmt.Errorf("%s: %w", c.Name, err)
+				}
+			}(c)
+		}
+	}
+
+	if cause == nil {
+		m.ready.Store(true)
+		log.Printf("lifecycle: %d components started\n", len(started))
+		select {
+		case <-ctx.Done():
+		case cause = <-errCh:
+			log.Printf("lifecycle: %s\n", cause.Error())
+		}
+	}
+
+	report := m.shutdown(started)
+
+	// компоненты, не вернувшиеся после остановки, получают отмену контекста и не ждутся
+	cancelRun()
+	done := make(chan struct{})
+	go func() {
+		running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(forceWait):
+		log.Println("lifecycle: some components are still running after shutdown")
+	}
+	return report, cause
+}
+
+// shutdown останавливает компоненты по фазам, в пределах фазы - одновременно
+func (m *Manager) shutdown(started []Component) Report {
+	m.ready.Store(false)
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.ShutdownTimeout)
+	defer cancel()
+
+	var report Report
+	for _, phase := range phases {
+		if phase == PhaseDrain && m.cfg.DrainDelay > 0 {
+			report.Components = append(report.Components, drain(ctx, m.cfg.DrainDelay))
+		}
+		var inPhase []Component
+		for _, c := range started {
+			if c.Phase == phase && c.Stop != nil {
+				inPhase = append(inPhase, c)
+			}
+		}
+		reports := make([]ComponentReport, len(inPhase))
+		var wg sync.WaitGroup
+		for i, c := range inPhase {
+			wg.Add(1)
+			go func(i int, c Component) {
+				defer wg.Done()
+				reports[i] = stop(ctx, c)
+			}(i, c)
+		}
+		wg.Wait()
+		report.Components = append(report.Components, reports...)
+	}
+	report.Duration = time.Since(start)
+	report.DeadlineExceeded = ctx.Err() != nil
+	log.Printf("lifecycle: shutdown finished in %s\n", report.Duration)
+	return report
+}
+
+func drain(ctx context.Context, delay time.Duration) ComponentReport {
+	start := time.Now()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	r := ComponentReport{Name: "drain delay", Phase: PhaseDrain, Duration: time.Since(start)}
+	log.Printf("lifecycle: [%s] %s waited %s\n", r.Phase, r.Name, r.Duration)
+	return r
+}
+
+// stop вызывает Stop компонента, а по истечении срока - Force
+func stop(ctx context.Context, c Component) ComponentReport {
+	r := ComponentReport{Name: c.Name, Phase: c.Phase}
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Stop(ctx)
+	}()
+	select {
+	case r.Err = <-done:
+	case <-ctx.Done():
+		if c.Force != nil {
+			r.Forced = true
+			c.Force()
+		}
+		select {
+		case r.Err = <-done:
+		case <-time.After(forceWait):
+			r.Err = ErrNotStopped
+		}
+	}
+	r.Duration = time.Since(start)
+
+	switch {
+	case r.Err != nil:
+		log.Printf("lifecycle: [%s] %s failed to stop in %s: %s\n", r.Phase, r.Name, r.Duration, r.Err.Error())
+	case r.Forced:
+		log.Printf("lifecycle: [%s] %s forced to stop in %s\n", r.Phase, r.Name, r.Duration)
+	default:
+		log.Printf("lifecycle: [%s] %s stopped in %s\n", r.Phase, r.Name, r.Duration)
+	}
+	return r
+}
+
+// Worker - фоновая задача: run выполняется до остановки, Stop отменяет его контекст
+// и ждет возврата. Возврат с context.Canceled ошибкой не считается
+func Worker(name string, phase Phase, run func(ctx context.Context) error) Component {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	return Component{
+		Name:  name,
+		Phase: phase,
+		Run: func(context.Context) error {
+			defer close(done)
+			if err := run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		},
+		Stop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	}
+}
+
+// Closer закрывает ресурс в заданной фазе
+func Closer(name string, phase Phase, c io.Closer) Component {
+	return Component{
+		Name:  name,
+		Phase: phase,
+		Stop: func(context.Context) error {
+			return c.Close()
+		},
+	}
+}
+
+// SignalContext возвращает контекст, который завершается по SIGINT или SIGTERM.
+// В отличие от CaptureSignal, SIGHUP не игнорируется: им перезагружаются настройки
+func SignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	sigQuit := make(chan os.Signal, 1)
+	signal.Ignore(syscall.SIGPIPE)
+	signal.Notify(sigQuit, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(sigQuit)
+		select {
+		case s := <-sigQuit:
+			log.Printf("captured signal: %v\n", s)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
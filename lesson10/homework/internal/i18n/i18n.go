@@ -0,0 +1,82 @@
+// Package i18n - каталог переводов сообщений об ошибках и выбор языка ответа.
+//
+// Исходный язык сообщений - английский: ошибки приложения и валидатора формулируются
+// на нем, и для Default перевод не требуется. Каталоги других языков хранят сообщения
+// по ключам: кодам apperr.Code и ключам правил валидации ("validation.min.length" и т.п.).
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale - язык ответа (основной подтег BCP 47)
+type Locale string
+
+const (
+	En Locale = "en"
+	Ru Locale = "ru"
+
+	// Default - язык, на котором сформулированы исходные сообщения
+	Default = En
+)
+
+// catalogs - переводы сообщений по языкам; для Default каталог не нужен
+var catalogs = map[Locale]map[string]string{
+	Ru: ru,
+}
+
+// Supported сообщает, есть ли для языка перевод или он исходный
+func Supported(l Locale) bool {
+	_, ok := catalogs[l]
+	return ok || l == Default
+}
+
+// Message возвращает перевод сообщения key, подставляя args по правилам fmt.
+// ok == false, если для языка нет перевода и нужно оставить исходное сообщение
+func Message(l Locale, key string, args ...any) (string, bool) {
+	format, ok := catalogs[l][key]
+	if !ok {
+		return "", false
+	}
+	if len(args) == 0 {
+		return format, true
+	}
+	return fmt.Sprintf(format, args...), true
+}
+
+// Negotiate выбирает язык ответа по заголовку Accept-Language (RFC 9110):
+// поддерживаемый язык с наибольшим весом q, при равных весах - указанный раньше
+func Negotiate(header string) Locale {
+	type candidate struct {
+		locale Locale
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		l := Locale(primary)
+		if primary == "*" {
+			l = Default
+		}
+		if q > 0 && Supported(l) {
+			candidates = append(candidates, candidate{l, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	if len(candidates) == 0 {
+		return Default
+	}
+	return candidates[0].locale
+}
@@ -0,0 +1,42 @@
+package i18n
+
+// ru - русский каталог. Сообщения валидации получают имя поля, ограничение и фактическое значение
+var ru = map[string]string{
+	"INTERNAL":               "внутренняя ошибка сервера",
+	"INVALID_ARGUMENT":       "неверный аргумент запроса: %s",
+	"VALIDATION_FAILED":      "запрос не прошел проверку",
+	"INVALID_SCHEDULE":       "неверное расписание публикации объявления",
+	"INVALID_PAGE":           "неверные параметры постраничного вывода",
+	"INVALID_TIME_RANGE":     "начало интервала created_from должно быть раньше created_to",
+	"INVALID_AUDIT_RANGE":    "начало интервала from должно быть раньше to",
+	"INVALID_ROLE":           "неизвестная роль пользователя",
+	"TOKEN_EXPIRED":          "срок действия токена подтверждения истек",
+	"AD_NOT_FOUND":           "объявление с таким id не существует",
+	"USER_NOT_FOUND":         "пользователь с таким id не существует",
+	"SEARCH_NOT_FOUND":       "сохраненный поиск с таким id не существует",
+	"CONVERSATION_NOT_FOUND": "переписка с таким id не существует",
+	"TOKEN_NOT_FOUND":        "токен подтверждения не существует",
+	"ALREADY_EXISTS":         "такая запись уже существует",
+	"ALREADY_VERIFIED":       "адрес электронной почты уже подтвержден",
+	"FORBIDDEN":              "доступ запрещен",
+	"USER_BANNED":            "пользователь заблокирован модератором",
+	"USER_BLOCKED":           "собеседник запретил вам писать ему",
+	"INVALID_TENANT":         "неверный идентификатор площадки",
+	"TENANT_NOT_FOUND":       "площадка не существует",
+	"RATE_LIMITED":           "превышен лимит запросов площадки, повторите позже",
+	"POLICY_VIOLATION":       "объявление нарушает правила площадки",
+	"AD_UNDER_REVIEW":        "объявление ждет проверки модератором",
+
+	"validation.min":               "значение поля %s должно быть не меньше %s, получено %s",
+	"validation.max":               "значение поля %s должно быть не больше %s, получено %s",
+	"validation.len":               "значение поля %s должно быть равно %s, получено %s",
+	"validation.min.length":        "поле %s должно содержать не меньше %s символов, получено %s",
+	"validation.max.length":        "поле %s должно содержать не больше %s символов, получено %s",
+	"validation.len.length":        "поле %s должно содержать ровно %s символов, получено %s",
+	"validation.in":                "значение поля %s должно быть одним из: %s, получено %s",
+	"validation.tenant.max.length": "поле %s должно содержать не больше %s символов по правилам площадки, получено %s",
+	"validation.email":             "неверный адрес электронной почты",
+	"validation.binding.required":  "поле %s обязательно",
+	"validation.binding.email":     "поле %s должно содержать адрес электронной почты",
+	"validation.binding":           "поле %s не прошло проверку %s",
+}
@@ -0,0 +1,85 @@
+package idgen
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Generator выдает уникальные возрастающие идентификаторы, безопасен для конкурентного использования
+type Generator interface {
+	Next() int64
+}
+
+// Sequence - монотонный счетчик, идентификаторы не переиспользуются после удаления
+type Sequence struct {
+	next atomic.Int64
+}
+
+func NewSequence(start int64) *Sequence {
+	s := &Sequence{}
+	s.next.Store(start)
+	return s
+}
+
+func (s *Sequence) Next() int64 {
+	return s.next.Add(1) - 1
+}
+
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+
+	MaxNode     = 1<<nodeBits - 1
+	maxSequence = 1<<sequenceBits - 1
+)
+
+// Epoch - начало отсчета времени Snowflake ID
+var Epoch = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var ErrInvalidNode = fmt.Errorf("node id must be in [0, %d]", MaxNode)
+
+// Snowflake собирает ID из миллисекунд с Epoch (41 бит), номера узла (10 бит) и счетчика (12 бит),
+// поэтому узлы могут выдавать ID независимо друг от друга
+type Snowflake struct {
+	mu    sync.Mutex
+	node  int64
+	clock func() time.Time
+	last  int64
+	seq   int64
+}
+
+func NewSnowflake(node int64, clock func() time.Time) (*Snowflake, error) {
+	if node < 0 || node > MaxNode {
+		return nil, ErrInvalidNode
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	return &Snowflake{node: node, clock: clock, last: -1}, nil
+}
+
+func (s *Snowflake) Next() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock().Sub(Epoch).Milliseconds()
+	// при переводе часов назад продолжаем с последней миллисекунды, чтобы не нарушить монотонность
+	if now <= s.last {
+		s.seq++
+		if s.seq > maxSequence {
+			// счетчик исчерпан - занимаем следующую миллисекунду, не дожидаясь ее
+			s.last++
+			s.seq = 0
+		}
+	} else {
+		s.last = now
+		s.seq = 0
+	}
+	return s.last<<(nodeBits+sequenceBits) | s.node<<sequenceBits | s.seq
+}
+
+// Time возвращает момент выдачи Snowflake ID
+func Time(id int64) time.Time {
+	return Epoch.Add(time.Duration(id>>(nodeBits+sequenceBits)) * time.Millisecond)
+}
@@ -0,0 +1,352 @@
+// Package loadgen - генератор нагрузки на сервис объявлений.
+//
+// Runner создает пользователей, а затем с заданной частотой (RPS) отправляет смешанные запросы:
+// создание пользователей и объявлений, публикацию, изменение, чтение и списки объявлений.
+// Доли операций задает Mix. Запросы выполняют Concurrency исполнителей через pkg/client,
+// поэтому нагрузку можно подавать и на HTTP, и на gRPC. Модель открытая: если все исполнители
+// заняты, очередной запрос не откладывается, а считается пропущенным (Report.Dropped),
+// чтобы перегрузка сервиса была видна, а не пряталась за снижением частоты.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"homework10/internal/app"
+	"homework10/pkg/client"
+)
+
+var ErrInvalidConfig = errors.New("invalid load config")
+
+// Op - операция нагрузки
+type Op string
+
+const (
+	OpCreateUser Op = "create_user"
+	OpCreateAd   Op = "create_ad"
+	OpPublishAd  Op = "publish_ad"
+	OpUpdateAd   Op = "update_ad"
+	OpListAds    Op = "list_ads"
+	OpGetAd      Op = "get_ad"
+)
+
+// Ops - все операции в порядке вывода в отчете
+var Ops = []Op{OpCreateUser, OpCreateAd, OpPublishAd, OpUpdateAd, OpListAds, OpGetAd}
+
+// Mix - относительные веса операций, операции без веса не выполняются
+type Mix map[Op]int
+
+// DefaultMix - преобладают чтения, как у доски объявлений
+func DefaultMix() Mix {
+	return Mix{OpCreateUser: 2, OpCreateAd: 10, OpPublishAd: 8, OpUpdateAd: 5, OpListAds: 25, OpGetAd: 50}
+}
+
+// ParseMix разбирает веса вида "get_ad=50,list_ads=25"
+func ParseMix(s string) (Mix, error) {
+	m := make(Mix)
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		name, weight, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: mix entry %q must be op=weight", ErrInvalidConfig, part)
+		}
+		w, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("%w: invalid weight in %q", ErrInvalidConfig, part)
+		}
+		m[Op(strings.TrimSpace(name))] = w
+	}
+	return m, m.validate()
+}
+
+func (m Mix) validate() error {
+	total := 0
+	for op, w := range m {
+		if !op.valid() {
+			return fmt.Errorf("%w: unknown operation %q", ErrInvalidConfig, op)
+		}
+		total += w
+	}
+	if total == 0 {
+		return fmt.Errorf("%w: mix has no operations", ErrInvalidConfig)
+	}
+	return nil
+}
+
+func (o Op) valid() bool {
+	for _, op := range Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Config - параметры нагрузки
+type Config struct {
+	RPS         float64       // целевая частота запросов
+	Concurrency int           // число одновременно выполняемых запросов
+	Duration    time.Duration // длительность нагрузки без учета подготовки
+	Users       int           // пользователей, создаваемых до начала нагрузки
+	Mix         Mix           // nil - DefaultMix
+	Seed        int64         // 0 - случайный
+}
+
+func DefaultConfig() Config {
+	return Config{RPS: 100, Concurrency: 16, Duration: 30 * time.Second, Users: 20}
+}
+
+func (c Config) validate() error {
+	if c.RPS <= 0 || c.Concurrency <= 0 || c.Duration <= 0 || c.Users <= 0 {
+		return fmt.Errorf("%w: rps, concurrency, duration and users must be positive", ErrInvalidConfig)
+	}
+	return c.Mix.validate()
+}
+
+// Runner подает нагрузку через один клиент
+type Runner struct {
+	client client.AdsClient
+	cfg    Config
+	pick   []Op // операции, повторенные по весу
+
+	mu    sync.Mutex
+	rnd   *rand.Rand
+	users []int64
+	ads   []adRef
+	nonce string
+}
+
+type adRef struct {
+	id       int64
+	authorID int64
+}
+
+func New(c client.AdsClient, cfg Config) (*Runner, error) {
+	if cfg.Mix == nil {
+		cfg.Mix = DefaultMix()
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	r := &Runner{client: c, cfg: cfg, rnd: rand.New(rand.NewSource(cfg.Seed))}
+	for _, op := range Ops {
+		for i := 0; i < cfg.Mix[op]; i++ {
+			r.pick = append(r.pick, op)
+		}
+	}
+	// адреса почты уникальны в сервисе, поэтому повторные запуски на том же сервисе различаются
+	r.nonce = strconv.FormatInt(cfg.Seed&0xffffffff, 36)
+	return r, nil
+}
+
+// Run создает пользователей и подает нагрузку до истечения Duration или отмены ctx
+func (r *Runner) Run(ctx context.Context) (Report, error) {
+	for i := 0; i < r.cfg.Users; i++ {
+		if _, err := r.createUser(ctx); err != nil {
+			return Report{}, fmt.Errorf("setup: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Duration)
+	defer cancel()
+
+	stats := newStats()
+	ticks := make(chan Op)
+	var wg sync.WaitGroup
+	for i := 0; i < r.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range ticks {
+				start := time.Now()
+				done, err := r.do(ctx, op)
+				if ctx.Err() != nil && err != nil {
+					// запрос прерван окончанием нагрузки, а не сервисом
+					continue
+				}
+				stats.add(done, time.Since(start), err)
+			}
+		}()
+	}
+
+	// таймер не срабатывает чаще раза в миллисекунду, поэтому на каждом срабатывании
+	// отправляются все запросы, которые должны были начаться к этому моменту
+	start := time.Now()
+	interval := time.Duration(float64(time.Second) / r.cfg.RPS)
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	sent, dropped := 0, 0
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+			for due := int(time.Since(start).Seconds() * r.cfg.RPS); sent < due; sent++ {
+				select {
+				case ticks <- r.nextOp():
+				default:
+					dropped++
+				}
+			}
+		}
+	}
+	ticker.Stop()
+	close(ticks)
+	wg.Wait()
+
+	return stats.report(time.Since(start), dropped), nil
+}
+
+func (r *Runner) nextOp() Op {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pick[r.rnd.Intn(len(r.pick))]
+}
+
+// do выполняет операцию и возвращает выполненную: пока объявлений нет, операции
+// с объявлениями заменяются созданием объявления
+func (r *Runner) do(ctx context.Context, op Op) (Op, error) {
+	switch op {
+	case OpCreateUser:
+		_, err := r.createUser(ctx)
+		return op, err
+	case OpCreateAd:
+		uid := r.randomUser()
+		ad, err := r.client.CreateAd(ctx, uid, r.title(), r.text())
+		if err != nil {
+			return op, err
+		}
+		r.mu.Lock()
+		r.ads = append(r.ads, adRef{id: ad.ID, authorID: uid})
+		r.mu.Unlock()
+		return op, nil
+	case OpPublishAd:
+		ad, ok := r.randomAd()
+		if !ok {
+			return r.do(ctx, OpCreateAd)
+		}
+		_, err := r.client.ChangeAdStatus(ctx, ad.id, ad.authorID, true)
+		return op, err
+	case OpUpdateAd:
+		ad, ok := r.randomAd()
+		if !ok {
+			return r.do(ctx, OpCreateAd)
+		}
+		_, err := r.client.UpdateAd(ctx, ad.id, ad.authorID, r.title(), r.text())
+		return op, err
+	case OpListAds:
+		var params app.ListAdsParams
+		if r.chance(2) {
+			uid := r.randomUser()
+			params.Uid = &uid
+		} else {
+			published := true
+			params.Published = &published
+		}
+		_, err := r.client.ListAds(ctx, params)
+		return op, err
+	case OpGetAd:
+		ad, ok := r.randomAd()
+		if !ok {
+			return r.do(ctx, OpCreateAd)
+		}
+		_, err := r.client.GetAd(ctx, ad.id)
+		return op, err
+	}
+	return op, fmt.Errorf("%w: unknown operation %q", ErrInvalidConfig, op)
+}
+
+func (r *Runner) createUser(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	n := len(r.users)
+	r.mu.Unlock()
+	suffix := fmt.Sprintf("%s-%d-%d", r.nonce, n, r.intn(1_000_000))
+	u, err := r.client.CreateUser(ctx, "load "+suffix, "load-"+suffix+"@example.com")
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	r.users = append(r.users, u.ID)
+	r.mu.Unlock()
+	return u.ID, nil
+}
+
+func (r *Runner) randomUser() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.users[r.rnd.Intn(len(r.users))]
+}
+
+func (r *Runner) randomAd() (adRef, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.ads) == 0 {
+		return adRef{}, false
+	}
+	return r.ads[r.rnd.Intn(len(r.ads))], true
+}
+
+func (r *Runner) intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Intn(n)
+}
+
+func (r *Runner) chance(n int) bool {
+	return r.intn(n) == 0
+}
+
+var vocabulary = strings.Fields(`bike sofa laptop guitar camera phone table chair lamp jacket boots
+	vintage new used cheap rare great working mint original spare small large wooden leather`)
+
+// phrase собирает фразу из n случайных слов, чтобы объявления различались
+func (r *Runner) phrase(n int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	words := make([]string, n)
+	for i := range words {
+		words[i] = vocabulary[r.rnd.Intn(len(vocabulary))]
+	}
+	return strings.Join(words, " ")
+}
+
+func (r *Runner) title() string {
+	return r.phrase(3)
+}
+
+func (r *Runner) text() string {
+	return r.phrase(12)
+}
+
+// sortedOps возвращает операции из отчета в порядке Ops
+func sortedOps(m map[Op]*opStats) []Op {
+	ops := make([]Op, 0, len(m))
+	for op := range m {
+		ops = append(ops, op)
+	}
+	index := func(o Op) int {
+		for i, op := range Ops {
+			if op == o {
+				return i
+			}
+		}
+		return len(Ops)
+	}
+	sort.Slice(ops, func(i, j int) bool { return index(ops[i]) < index(ops[j]) })
+	return ops
+}
@@ -0,0 +1,170 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"homework10/pkg/client"
+)
+
+// Latency - перцентили задержки, в JSON - наносекунды
+type Latency struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+// OpReport - итог по одной операции
+type OpReport struct {
+	Op       Op             `json:"op"`
+	Requests int            `json:"requests"`
+	Errors   int            `json:"errors"`
+	Latency  Latency        `json:"latency"`
+	ByError  map[string]int `json:"by_error,omitempty"` // число ошибок по коду ошибки сервиса
+}
+
+// Report - итог нагрузки
+type Report struct {
+	Duration   time.Duration `json:"duration"`
+	Requests   int           `json:"requests"`
+	Errors     int           `json:"errors"`
+	Dropped    int           `json:"dropped"` // запросы, не начатые из-за занятости всех исполнителей
+	Throughput float64       `json:"throughput"`
+	Latency    Latency       `json:"latency"`
+	Ops        []OpReport    `json:"ops"`
+}
+
+// Write печатает отчет таблицей
+func (r Report) Write(w io.Writer) error {
+	fmt.Fprintf(w, "duration %s, %d requests, %.1f req/s, %d errors, %d dropped\n",
+		r.Duration.Round(time.Millisecond), r.Requests, r.Throughput, r.Errors, r.Dropped)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OP\tREQUESTS\tERRORS\tP50\tP90\tP99\tMAX")
+	row := func(name string, requests int, errs int, l Latency) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n", name, requests, errs,
+			round(l.P50), round(l.P90), round(l.P99), round(l.Max))
+	}
+	for _, op := range r.Ops {
+		row(string(op.Op), op.Requests, op.Errors, op.Latency)
+	}
+	row("total", r.Requests, r.Errors, r.Latency)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, op := range r.Ops {
+		reasons := make([]string, 0, len(op.ByError))
+		for reason := range op.ByError {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		for _, reason := range reasons {
+			fmt.Fprintf(w, "%s: %s x%d\n", op.Op, reason, op.ByError[reason])
+		}
+	}
+	return nil
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
+
+type opStats struct {
+	latencies []time.Duration
+	errors    map[string]int
+}
+
+// stats собирает задержки всех запросов, перцентили считаются по полной выборке
+type stats struct {
+	mu  sync.Mutex
+	ops map[Op]*opStats
+}
+
+func newStats() *stats {
+	return &stats{ops: make(map[Op]*opStats)}
+}
+
+func (s *stats) add(op Op, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.ops[op]
+	if !ok {
+		st = &opStats{errors: make(map[string]int)}
+		s.ops[op] = st
+	}
+	st.latencies = append(st.latencies, d)
+	if err != nil {
+		st.errors[errorReason(err)]++
+	}
+}
+
+func (s *stats) report(d time.Duration, dropped int) Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := Report{Duration: d, Dropped: dropped}
+	var all []time.Duration
+	for _, op := range sortedOps(s.ops) {
+		st := s.ops[op]
+		o := OpReport{Op: op, Requests: len(st.latencies), Latency: percentiles(st.latencies)}
+		for reason, n := range st.errors {
+			o.Errors += n
+			if o.ByError == nil {
+				o.ByError = make(map[string]int)
+			}
+			o.ByError[reason] = n
+		}
+		r.Ops = append(r.Ops, o)
+		r.Requests += o.Requests
+		r.Errors += o.Errors
+		all = append(all, st.latencies...)
+	}
+	r.Latency = percentiles(all)
+	if d > 0 {
+		r.Throughput = float64(r.Requests) / d.Seconds()
+	}
+	return r
+}
+
+// percentiles сортирует выборку и берет перцентили методом ближайшего ранга
+func percentiles(l []time.Duration) Latency {
+	if len(l) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration(nil), l...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(sorted) {
+			i = len(sorted) - 1
+		}
+		return sorted[i]
+	}
+	return Latency{P50: at(0.5), P90: at(0.9), P99: at(0.99), Max: sorted[len(sorted)-1]}
+}
+
+// errorReason - код ошибки сервиса, а для сбоев транспорта - их класс
+func errorReason(err error) string {
+	var e *client.Error
+	switch {
+	case errors.As(err, &e) && e.Reason != "":
+		return string(e.Reason)
+	case errors.As(err, &e):
+		return fmt.Sprintf("status %d", e.Code)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, client.ErrUnavailable):
+		return "unavailable"
+	default:
+		return "transport"
+	}
+}
@@ -0,0 +1,107 @@
+// Package logging - уровень журнала, изменяемый на лету.
+//
+// Сервис пишет журнал стандартным пакетом log, а этот пакет решает, какие записи писать:
+// журнал запросов относится к уровню info, поэтому уровень warn его отключает.
+// Уровень меняется при перезагрузке настроек без перезапуска серверов.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level - уровень важности записи
+type Level int32
+
+const (
+	LevelDebug Level = iota - 1
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int32(l))
+}
+
+// ParseLevel разбирает имя уровня, пустая строка - info
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	parsed, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+var current atomic.Int32
+
+// SetLevel задает минимальный уровень записей журнала
+func SetLevel(l Level) {
+	current.Store(int32(l))
+}
+
+func GetLevel() Level {
+	return Level(current.Load())
+}
+
+// Enabled сообщает, пишутся ли записи уровня l
+func Enabled(l Level) bool {
+	return l >= GetLevel()
+}
+
+// Printf пишет запись уровня l через стандартный журнал
+func Printf(l Level, format string, args ...any) {
+	if Enabled(l) {
+		log.Printf(format, args...)
+	}
+}
+
+// Writer пропускает в w записи, только пока включен уровень l. Нужен для журналов
+// сторонних библиотек, которые пишут в io.Writer (gin.Logger)
+func Writer(l Level, w io.Writer) io.Writer {
+	return levelWriter{level: l, w: w}
+}
+
+type levelWriter struct {
+	level Level
+	w     io.Writer
+}
+
+func (lw levelWriter) Write(p []byte) (int, error) {
+	if !Enabled(lw.level) {
+		return len(p), nil
+	}
+	return lw.w.Write(p)
+}
@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer доставляет письма пользователям
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer только пишет письма в лог, используется по умолчанию
+type LogMailer struct{}
+
+func NewLogMailer() LogMailer {
+	return LogMailer{}
+}
+
+func (LogMailer) Send(_ context.Context, msg Message) error {
+	log.Printf("mail to %s: %s %q\n", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// InMemory сохраняет отправленные письма, используется в тестах
+type InMemory struct {
+	sync.Mutex
+	sent []Message
+}
+
+func NewInMemory() *InMemory {
+	return &InMemory{}
+}
+
+func (m *InMemory) Send(_ context.Context, msg Message) error {
+	m.Lock()
+	defer m.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Sent возвращает копию всех отправленных писем в порядке отправки
+func (m *InMemory) Sent() []Message {
+	m.Lock()
+	defer m.Unlock()
+	res := make([]Message, len(m.sent))
+	copy(res, m.sent)
+	return res
+}
+
+// Last возвращает последнее письмо, отправленное на адрес to
+func (m *InMemory) Last(to string) (Message, bool) {
+	m.Lock()
+	defer m.Unlock()
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		if m.sent[i].To == to {
+			return m.sent[i], true
+		}
+	}
+	return Message{}, false
+}
@@ -0,0 +1,58 @@
+package messages
+
+import (
+	"log"
+	"sync"
+)
+
+const subscriberBuffer = 16
+
+// Broker рассылает новые сообщения подписанным получателям
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan Message]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[int64]map[chan Message]struct{})}
+}
+
+// Subscribe подписывает пользователя на новые сообщения,
+// канал закрывается вызовом возвращаемой функции отписки
+func (b *Broker) Subscribe(uid int64) (<-chan Message, func()) {
+	ch := make(chan Message, subscriberBuffer)
+
+	b.mu.Lock()
+	if _, ok := b.subscribers[uid]; !ok {
+		b.subscribers[uid] = make(map[chan Message]struct{})
+	}
+	b.subscribers[uid][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers[uid], ch)
+			if len(b.subscribers[uid]) == 0 {
+				delete(b.subscribers, uid)
+			}
+			close(ch)
+		})
+	}
+}
+
+// Publish не блокируется: медленный подписчик пропускает сообщение,
+// но всегда может получить его из истории переписки
+func (b *Broker) Publish(m Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[m.RecipientID] {
+		select {
+		case ch <- m:
+		default:
+			log.Printf("message %d dropped for slow subscriber of user %d\n", m.ID, m.RecipientID)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package messages
+
+import "time"
+
+type Conversation struct {
+	ID          int64
+	AdID        int64
+	BuyerID     int64
+	SellerID    int64
+	DateCreated time.Time
+	DateChanged time.Time
+	Unread      int // число непрочитанных сообщений для запросившего пользователя
+}
+
+// HasParticipant сообщает, является ли пользователь участником переписки
+func (c Conversation) HasParticipant(uid int64) bool {
+	return c.BuyerID == uid || c.SellerID == uid
+}
+
+// Interlocutor возвращает собеседника пользователя uid
+func (c Conversation) Interlocutor(uid int64) int64 {
+	if c.BuyerID == uid {
+		return c.SellerID
+	}
+	return c.BuyerID
+}
+
+type Message struct {
+	ID             int64
+	ConversationID int64
+	SenderID       int64
+	RecipientID    int64
+	Text           string `validate:"min:1; max:999"`
+	DateSent       time.Time
+	Read           bool
+}
@@ -0,0 +1,193 @@
+// Package policy - контент-политики объявлений: автоматические проверки перед сохранением.
+//
+// Engine прогоняет объявление через набор правил (Rule): запрещенные слова, ссылки, номера
+// телефонов, почти одинаковые объявления одного автора, оценка спама. Каждое нарушение
+// по настройке правила либо отклоняет объявление (Rejection), либо помечает его для
+// проверки модератором (Decision.Flags). Набор правил задается Config или собирается из
+// собственных реализаций Rule через NewEngine.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"homework10/internal/ads"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	ErrRejected      = errors.New("ad violates content policy")
+	ErrInvalidConfig = errors.New("invalid content policy config")
+)
+
+// Action - реакция на нарушение правила
+type Action string
+
+const (
+	ActionNone   Action = ""       // правило выключено
+	ActionFlag   Action = "flag"   // объявление сохраняется, но ждет проверки модератором
+	ActionReject Action = "reject" // объявление не сохраняется
+)
+
+func (a Action) IsValid() bool {
+	return a == ActionNone || a == ActionFlag || a == ActionReject
+}
+
+// Violation - нарушение одного правила
+type Violation struct {
+	Rule   string // имя правила: banned_word, link, phone, duplicate, spam
+	Field  string // поле запроса (title, text), пустое - объявление целиком
+	Action Action
+	Reason string
+}
+
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Reason
+}
+
+// Rejection - ошибка отклоненного объявления со всеми нарушениями, приведшими к отказу
+type Rejection struct {
+	Violations []Violation
+}
+
+func (r Rejection) Error() string {
+	reasons := make([]string, len(r.Violations))
+	for i, v := range r.Violations {
+		reasons[i] = v.String()
+	}
+	return ErrRejected.Error() + ": " + strings.Join(reasons, "; ")
+}
+
+func (r Rejection) Unwrap() error {
+	return ErrRejected
+}
+
+// Decision - результат проверки объявления
+type Decision struct {
+	Rejections []Violation
+	Flags      []Violation
+}
+
+// Err возвращает Rejection, если хотя бы одно правило отклонило объявление
+func (d Decision) Err() error {
+	if len(d.Rejections) > 0 {
+		return Rejection{Violations: d.Rejections}
+	}
+	return nil
+}
+
+// FlagReasons - причины пометки в том виде, в котором они хранятся в ads.Ad.Flags
+func (d Decision) FlagReasons() []string {
+	if len(d.Flags) == 0 {
+		return nil
+	}
+	reasons := make([]string, len(d.Flags))
+	for i, v := range d.Flags {
+		reasons[i] = v.String()
+	}
+	return reasons
+}
+
+// AuthorAds возвращает остальные объявления автора (без проверяемого), нужна правилам,
+// сравнивающим объявление с соседними
+type AuthorAds func(ctx context.Context, authorID int64) ([]ads.Ad, error)
+
+// Input - проверяемое объявление. Объявления автора загружаются лениво и один раз на проверку
+type Input struct {
+	Ad ads.Ad
+
+	authorAds AuthorAds
+	loaded    bool
+	others    []ads.Ad
+}
+
+// Others возвращает остальные объявления автора
+func (in *Input) Others(ctx context.Context) ([]ads.Ad, error) {
+	if in.loaded || in.authorAds == nil {
+		return in.others, nil
+	}
+	others, err := in.authorAds(ctx, in.Ad.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	in.others, in.loaded = others, true
+	return in.others, nil
+}
+
+// Rule - одно правило контент-политики
+type Rule interface {
+	Name() string
+	// Check возвращает нарушения; ошибка означает, что проверку выполнить не удалось
+	Check(ctx context.Context, in *Input) ([]Violation, error)
+}
+
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine собирает движок из произвольных правил
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// New собирает движок из настроек, правила без действия не включаются
+func New(cfg Config) (*Engine, error) {
+	rules, err := cfg.rules()
+	if err != nil {
+		return nil, err
+	}
+	return NewEngine(rules...), nil
+}
+
+// Load читает настройки правил из YAML-файла
+func Load(path string) (*Engine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read читает настройки правил в формате YAML
+func Read(r io.Reader) (*Engine, error) {
+	var cfg Config
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidConfig, err.Error())
+	}
+	return New(cfg)
+}
+
+// Rules возвращает имена включенных правил в порядке проверки
+func (e *Engine) Rules() []string {
+	names := make([]string, len(e.rules))
+	for i, r := range e.rules {
+		names[i] = r.Name()
+	}
+	return names
+}
+
+// Check прогоняет объявление через все правила. authorAds может быть nil,
+// тогда правила, сравнивающие объявление с другими объявлениями автора, ничего не находят
+func (e *Engine) Check(ctx context.Context, ad ads.Ad, authorAds AuthorAds) (Decision, error) {
+	in := &Input{Ad: ad, authorAds: authorAds}
+	var d Decision
+	for _, r := range e.rules {
+		violations, err := r.Check(ctx, in)
+		if err != nil {
+			return Decision{}, fmt.Errorf("policy rule %s: %w", r.Name(), err)
+		}
+		for _, v := range violations {
+			switch v.Action {
+			case ActionReject:
+				d.Rejections = append(d.Rejections, v)
+			case ActionFlag:
+				d.Flags = append(d.Flags, v)
+			}
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,366 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Значения по умолчанию для незаданных параметров правил
+const (
+	DefaultShingleSize        = 3
+	DefaultDuplicateThreshold = 0.8
+)
+
+// Config - набор правил. Правило без действия (action) выключено
+type Config struct {
+	BannedWords BannedWordsConfig `yaml:"banned_words"`
+	Links       RuleConfig        `yaml:"links"`
+	Phones      RuleConfig        `yaml:"phones"`
+	Duplicates  DuplicatesConfig  `yaml:"duplicates"`
+	Spam        SpamConfig        `yaml:"spam"`
+}
+
+type RuleConfig struct {
+	Action Action `yaml:"action"`
+}
+
+// BannedWordsConfig - запрещенные слова и фразы, сравниваются по целым словам без учета регистра
+type BannedWordsConfig struct {
+	Action Action   `yaml:"action"`
+	Words  []string `yaml:"words"`
+}
+
+// DuplicatesConfig - поиск почти одинаковых объявлений автора: коэффициент Жаккара
+// множеств шинглов (последовательностей из ShingleSize слов) заголовка и текста
+type DuplicatesConfig struct {
+	Action      Action  `yaml:"action"`
+	Threshold   float64 `yaml:"threshold"`
+	ShingleSize int     `yaml:"shingle_size"`
+}
+
+// SpamConfig - пороги оценки спама от 0 до 1, нулевой порог не применяется
+type SpamConfig struct {
+	FlagScore   float64 `yaml:"flag_score"`
+	RejectScore float64 `yaml:"reject_score"`
+}
+
+func (c Config) rules() ([]Rule, error) {
+	for name, a := range map[string]Action{
+		"banned_words": c.BannedWords.Action,
+		"links":        c.Links.Action,
+		"phones":       c.Phones.Action,
+		"duplicates":   c.Duplicates.Action,
+	} {
+		if !a.IsValid() {
+			return nil, fmt.Errorf("%w: unknown action %q for %s", ErrInvalidConfig, a, name)
+		}
+	}
+	var rules []Rule
+	if c.BannedWords.Action != ActionNone {
+		rules = append(rules, newBannedWords(c.BannedWords.Words, c.BannedWords.Action))
+	}
+	if c.Links.Action != ActionNone {
+		rules = append(rules, patternRule{"link", "contains a link", linkPattern, c.Links.Action})
+	}
+	if c.Phones.Action != ActionNone {
+		rules = append(rules, patternRule{"phone", "contains a phone number", phonePattern, c.Phones.Action})
+	}
+	if c.Duplicates.Action != ActionNone {
+		d := duplicates{threshold: c.Duplicates.Threshold, size: c.Duplicates.ShingleSize, action: c.Duplicates.Action}
+		if d.threshold == 0 {
+			d.threshold = DefaultDuplicateThreshold
+		}
+		if d.size == 0 {
+			d.size = DefaultShingleSize
+		}
+		if d.threshold < 0 || d.threshold > 1 || d.size < 0 {
+			return nil, fmt.Errorf("%w: duplicates threshold must be in [0, 1] and shingle size positive", ErrInvalidConfig)
+		}
+		rules = append(rules, d)
+	}
+	if s := c.Spam; s.FlagScore != 0 || s.RejectScore != 0 {
+		if s.FlagScore < 0 || s.FlagScore > 1 || s.RejectScore < 0 || s.RejectScore > 1 {
+			return nil, fmt.Errorf("%w: spam scores must be in [0, 1]", ErrInvalidConfig)
+		}
+		rules = append(rules, spam{flag: s.FlagScore, reject: s.RejectScore})
+	}
+	return rules, nil
+}
+
+// field - проверяемое поле объявления
+type field struct {
+	name  string
+	value string
+}
+
+func fields(in *Input) []field {
+	return []field{{"title", in.Ad.Title}, {"text", in.Ad.Text}}
+}
+
+// words разбивает строку на слова в нижнем регистре
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+type bannedWords struct {
+	phrases [][]string
+	action  Action
+}
+
+func newBannedWords(list []string, action Action) bannedWords {
+	r := bannedWords{action: action}
+	for _, w := range list {
+		if phrase := words(w); len(phrase) > 0 {
+			r.phrases = append(r.phrases, phrase)
+		}
+	}
+	return r
+}
+
+func (r bannedWords) Name() string {
+	return "banned_word"
+}
+
+func (r bannedWords) Check(_ context.Context, in *Input) ([]Violation, error) {
+	var violations []Violation
+	for _, f := range fields(in) {
+		if phrase := r.find(words(f.value)); phrase != "" {
+			violations = append(violations, Violation{
+				Rule: r.Name(), Field: f.name, Action: r.action,
+				Reason: fmt.Sprintf("%s contains banned word %q", f.name, phrase),
+			})
+		}
+	}
+	return violations, nil
+}
+
+// find возвращает первую запрещенную фразу, встречающуюся в тексте целыми словами
+func (r bannedWords) find(text []string) string {
+	for _, phrase := range r.phrases {
+		for i := 0; i+len(phrase) <= len(text); i++ {
+			if equal(text[i:i+len(phrase)], phrase) {
+				return strings.Join(phrase, " ")
+			}
+		}
+	}
+	return ""
+}
+
+func equal(a []string, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	// linkPattern - адреса со схемой, www. и голые домены популярных зон
+	linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|ru|su|io|me|info|biz|xyz|ly|link|site|online)\b`)
+	// phoneCandidate - последовательности цифр с разделителями, реальный номер содержит 10-15 цифр
+	phoneCandidate = regexp.MustCompile(`\+?\d[\d\s().-]{6,}\d`)
+)
+
+// phonePattern находит номера телефонов среди последовательностей цифр
+var phonePattern = matcherFunc(func(s string) bool {
+	for _, m := range phoneCandidate.FindAllString(s, -1) {
+		digits := 0
+		for _, r := range m {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits >= 10 && digits <= 15 {
+			return true
+		}
+	}
+	return false
+})
+
+type matcher interface {
+	MatchString(s string) bool
+}
+
+type matcherFunc func(s string) bool
+
+func (f matcherFunc) MatchString(s string) bool {
+	return f(s)
+}
+
+// patternRule отмечает поля, в которых встречается шаблон
+type patternRule struct {
+	name    string
+	reason  string
+	pattern matcher
+	action  Action
+}
+
+func (r patternRule) Name() string {
+	return r.name
+}
+
+func (r patternRule) Check(_ context.Context, in *Input) ([]Violation, error) {
+	var violations []Violation
+	for _, f := range fields(in) {
+		if r.pattern.MatchString(f.value) {
+			violations = append(violations, Violation{Rule: r.name, Field: f.name, Action: r.action, Reason: f.name + " " + r.reason})
+		}
+	}
+	return violations, nil
+}
+
+type duplicates struct {
+	threshold float64
+	size      int
+	action    Action
+}
+
+func (r duplicates) Name() string {
+	return "duplicate"
+}
+
+func (r duplicates) Check(ctx context.Context, in *Input) ([]Violation, error) {
+	others, err := in.Others(ctx)
+	if err != nil || len(others) == 0 {
+		return nil, err
+	}
+	own := Shingles(in.Ad.Title+" "+in.Ad.Text, r.size)
+	for _, ad := range others {
+		if Similarity(own, Shingles(ad.Title+" "+ad.Text, r.size)) >= r.threshold {
+			return []Violation{{
+				Rule: r.Name(), Action: r.action,
+				Reason: fmt.Sprintf("near-identical to ad %d of the same author", ad.ID),
+			}}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Shingles возвращает множество шинглов текста - последовательностей из size слов.
+// Текст короче size слов дает один шингл из всех слов
+func Shingles(s string, size int) map[string]struct{} {
+	w := words(s)
+	set := make(map[string]struct{})
+	if len(w) < size {
+		if len(w) > 0 {
+			set[strings.Join(w, " ")] = struct{}{}
+		}
+		return set
+	}
+	for i := 0; i+size <= len(w); i++ {
+		set[strings.Join(w[i:i+size], " ")] = struct{}{}
+	}
+	return set
+}
+
+// Similarity - коэффициент Жаккара двух множеств шинглов
+func Similarity(a map[string]struct{}, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for s := range a {
+		if _, ok := b[s]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+type spam struct {
+	flag   float64
+	reject float64
+}
+
+func (r spam) Name() string {
+	return "spam"
+}
+
+func (r spam) Check(_ context.Context, in *Input) ([]Violation, error) {
+	score := SpamScore(in.Ad.Title + "\n" + in.Ad.Text)
+	var action Action
+	switch {
+	case r.reject > 0 && score >= r.reject:
+		action = ActionReject
+	case r.flag > 0 && score >= r.flag:
+		action = ActionFlag
+	default:
+		return nil, nil
+	}
+	return []Violation{{Rule: r.Name(), Action: action, Reason: fmt.Sprintf("spam score %.2f", score)}}, nil
+}
+
+// SpamScore оценивает текст от 0 до 1 по признакам спама: крик заглавными буквами,
+// восклицательные знаки, повторы символов и слов, ссылки и номера телефонов
+func SpamScore(s string) float64 {
+	var score float64
+
+	letters, upper := 0, 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= 10 && float64(upper)/float64(letters) > 0.5 {
+		score += 0.3
+	}
+	if strings.Count(s, "!") >= 3 {
+		score += 0.2
+	}
+	if hasRepeatedRun(s) {
+		score += 0.2
+	}
+	links := len(linkPattern.FindAllString(s, -1))
+	if links > 2 {
+		links = 2
+	}
+	score += 0.2 * float64(links)
+	if phonePattern.MatchString(s) {
+		score += 0.1
+	}
+	if w := words(s); len(w) >= 10 {
+		counts := make(map[string]int)
+		top := 0
+		for _, word := range w {
+			counts[word]++
+			if counts[word] > top {
+				top = counts[word]
+			}
+		}
+		if float64(top)/float64(len(w)) > 0.3 {
+			score += 0.3
+		}
+	}
+
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+// hasRepeatedRun ищет символ, повторенный пять и более раз подряд ("!!!!!", "ооооочень").
+// В RE2 нет обратных ссылок, поэтому без регулярного выражения
+func hasRepeatedRun(s string) bool {
+	var prev rune
+	run := 0
+	for _, r := range s {
+		if r == prev && !unicode.IsSpace(r) {
+			run++
+			if run >= 5 {
+				return true
+			}
+			continue
+		}
+		prev, run = r, 1
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"homework10/internal/audit"
+	"homework10/internal/user"
+	"time"
+)
+
+func (s *AdminService) ListUsers(ctx context.Context, request *ListUsersRequest) (*ListUserResponse, error) {
+	if request.AdminId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	params := app.ListUsersParams{
+		Banned:   request.Banned,
+		Verified: request.Verified,
+		Nickname: request.Nickname,
+		Email:    request.Email,
+	}
+	if request.Role != nil {
+		role, err := user.ParseRole(request.GetRole())
+		if err != nil {
+			return nil, StatusError(err)
+		}
+		params.Role = &role
+	}
+
+	ul, err := s.app.ListUsers(ctx, request.GetAdminId(), params)
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return UserListSuccessResponse(ul), nil
+}
+
+func (s *AdminService) BanUser(ctx context.Context, request *AdminUserRequest) (*UserResponse, error) {
+	if request.AdminId == nil || request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	u, err := s.app.BanUser(ctx, request.GetAdminId(), request.GetUserId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return UserSuccessResponse(u), nil
+}
+
+func (s *AdminService) UnbanUser(ctx context.Context, request *AdminUserRequest) (*UserResponse, error) {
+	if request.AdminId == nil || request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	u, err := s.app.UnbanUser(ctx, request.GetAdminId(), request.GetUserId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return UserSuccessResponse(u), nil
+}
+
+func (s *AdminService) SetUserRole(ctx context.Context, request *SetUserRoleRequest) (*UserResponse, error) {
+	if request.AdminId == nil || request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	role, err := user.ParseRole(request.GetRole())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	u, err := s.app.SetUserRole(ctx, request.GetAdminId(), request.GetUserId(), role)
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return UserSuccessResponse(u), nil
+}
+
+func (s *AdminService) ForceUnpublishAd(ctx context.Context, request *AdminAdRequest) (*AdResponse, error) {
+	if request.AdminId == nil || request.AdId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	ad, err := s.app.ForceUnpublishAd(ctx, request.GetAdminId(), request.GetAdId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return AdSuccessResponse(ad), nil
+}
+
+func (s *AdminService) ForceDeleteAd(ctx context.Context, request *AdminAdRequest) (*emptypb.Empty, error) {
+	if request.AdminId == nil || request.AdId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	err := s.app.ForceDeleteAd(ctx, request.GetAdminId(), request.GetAdId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AdminService) ListFlaggedAds(ctx context.Context, request *ListFlaggedAdsRequest) (*ListAdResponse, error) {
+	if request.AdminId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	al, err := s.app.ListFlaggedAds(ctx, request.GetAdminId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return AdListSuccessResponse(al), nil
+}
+
+func (s *AdminService) ApproveAd(ctx context.Context, request *AdminAdRequest) (*AdResponse, error) {
+	if request.AdminId == nil || request.AdId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	ad, err := s.app.ApproveAd(ctx, request.GetAdminId(), request.GetAdId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return AdSuccessResponse(ad), nil
+}
+
+func (s *AdminService) ListFeatures(ctx context.Context, request *AdminUserRequest) (*ListFeatureResponse, error) {
+	if request.AdminId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	evaluations, err := s.app.ListFeatures(ctx, request.GetAdminId(), request.GetUserId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return FeatureListSuccessResponse(evaluations), nil
+}
+
+func (s *AdminService) ListAudit(ctx context.Context, request *ListAuditRequest) (*ListAuditResponse, error) {
+	if request.AdminId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	f := audit.Filter{
+		ActorID:   request.ActorId,
+		Action:    (*audit.Action)(request.Action),
+		Entity:    (*audit.Entity)(request.Entity),
+		EntityID:  request.EntityId,
+		Transport: (*audit.Transport)(request.Transport),
+		Limit:     int(request.GetLimit()),
+	}
+	now := time.Now()
+	for _, t := range []struct {
+		field string
+		value *string
+		dst   **time.Time
+	}{{"from", request.From, &f.From}, {"to", request.To, &f.To}} {
+		parsed, err := app.ParseTime(t.value, now)
+		if err != nil {
+			return nil, StatusError(apperr.InvalidFields(err, []apperr.FieldViolation{{Field: t.field, Description: err.Error()}}))
+		}
+		*t.dst = parsed
+	}
+	entries, err := s.app.ListAudit(ctx, request.GetAdminId(), f)
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return AuditListSuccessResponse(entries), nil
+}
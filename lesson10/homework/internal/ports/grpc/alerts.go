@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+)
+
+func (s *AdService) AddFavorite(ctx context.Context, request *FavoriteRequest) (*AdResponse, error) {
+	if request.UserId == nil || request.AdId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	ad, err := s.app.AddFavorite(ctx, request.GetUserId(), request.GetAdId())
+
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return AdSuccessResponse(ad), nil
+}
+
+func (s *AdService) RemoveFavorite(ctx context.Context, request *FavoriteRequest) (*emptypb.Empty, error) {
+	if request.UserId == nil || request.AdId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	err := s.app.RemoveFavorite(ctx, request.GetUserId(), request.GetAdId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AdService) ListFavorites(ctx context.Context, request *ListFavoritesRequest) (*ListAdResponse, error) {
+	if request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	al, err := s.app.ListFavorites(ctx, request.GetUserId())
+
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return AdListSuccessResponse(al), nil
+}
+
+func (s *AdService) CreateSavedSearch(ctx context.Context, request *CreateSavedSearchRequest) (*SavedSearchResponse, error) {
+	if request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	date, err := app.ParseDate(request.Date)
+	if err != nil {
+		return nil, StatusError(apperr.Invalid(err))
+	}
+	search, err := s.app.CreateSavedSearch(ctx, request.GetUserId(), app.ListAdsParams{
+		Published: request.Published,
+		Uid:       request.AuthorId,
+		Date:      date,
+		Title:     request.Title,
+	})
+
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return SavedSearchSuccessResponse(search), nil
+}
+
+func (s *AdService) ListSavedSearches(ctx context.Context, request *ListSavedSearchesRequest) (*ListSavedSearchResponse, error) {
+	if request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	sl, err := s.app.ListSavedSearches(ctx, request.GetUserId())
+
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return SavedSearchListSuccessResponse(sl), nil
+}
+
+func (s *AdService) DeleteSavedSearch(ctx context.Context, request *DeleteSavedSearchRequest) (*emptypb.Empty, error) {
+	if request.SearchId == nil || request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	err := s.app.DeleteSavedSearch(ctx, request.GetSearchId(), request.GetUserId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AdService) ListNotifications(ctx context.Context, request *ListNotificationsRequest) (*ListNotificationResponse, error) {
+	if request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	nl, err := s.app.ListNotifications(ctx, request.GetUserId())
+
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return NotificationListSuccessResponse(nl), nil
+}
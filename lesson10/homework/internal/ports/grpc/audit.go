@@ -0,0 +1,33 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"homework10/internal/audit"
+	"net"
+)
+
+// auditContext кладет в контекст вызова транспорт и адрес клиента для журнала аудита
+func auditContext(ctx context.Context) context.Context {
+	src := audit.Source{Transport: audit.TransportGRPC}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		src.ClientIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(src.ClientIP); err == nil {
+			src.ClientIP = host
+		}
+	}
+	return audit.NewContext(ctx, src)
+}
+
+func UnaryAuditInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(auditContext(ctx), req)
+	}
+}
+
+func StreamAuditInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, tenantStream{ServerStream: ss, ctx: auditContext(ss.Context())})
+	}
+}
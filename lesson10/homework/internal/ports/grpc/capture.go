@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"homework10/internal/capture"
+	"time"
+)
+
+// CaptureMetadata - метаданные, влияющие на обработку вызова; только они попадают в запись
+var CaptureMetadata = []string{LocaleMetadataKey, TenantMetadataKey, ":authority"}
+
+// captureJSON - формат сообщений в записи, его же использует воспроизведение
+var captureJSON = protojson.MarshalOptions{UseProtoNames: true}
+
+// UnaryCaptureInterceptor записывает вызовы и ответы на них в rec. Должен стоять первым
+// в цепочке, чтобы записывать итоговый статус после всех перехватчиков.
+// Потоковые вызовы не записываются: их нельзя воспроизвести как пару запрос-ответ
+func UnaryCaptureInterceptor(rec *capture.Recorder) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		e := capture.Entry{Seq: rec.Next(), Time: time.Now().UTC(), Protocol: capture.ProtocolGRPC,
+			Method: info.FullMethod, Header: make(map[string]string)}
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, key := range CaptureMetadata {
+			if v := md.Get(key); len(v) > 0 {
+				e.Header[key] = v[0]
+			}
+		}
+		if m, ok := req.(proto.Message); ok {
+			if body, err := captureJSON.Marshal(m); err == nil {
+				e.SetRequest(body)
+			}
+		}
+
+		resp, err := handler(ctx, req)
+
+		e.Duration = float64(time.Since(e.Time).Microseconds()) / 1000
+		st := status.Convert(err)
+		e.Status, e.Error = int(st.Code()), st.Message()
+		if m, ok := resp.(proto.Message); ok && err == nil {
+			if body, err := captureJSON.Marshal(m); err == nil {
+				e.SetResponse(body)
+			}
+		}
+		rec.Record(e)
+		return resp, err
+	}
+}
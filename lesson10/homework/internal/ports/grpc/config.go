@@ -0,0 +1,22 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"homework10/internal/config"
+)
+
+// UnaryConfigInterceptor кладет в контекст вызова текущий снимок настроек
+func UnaryConfigInterceptor(store *config.Store) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(config.NewContext(ctx, store.Load()), req)
+	}
+}
+
+// StreamConfigInterceptor кладет снимок настроек в контекст потока; поток видит настройки
+// на момент открытия, как и долгий HTTP-запрос
+func StreamConfigInterceptor(store *config.Store) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, tenantStream{ServerStream: ss, ctx: config.NewContext(ss.Context(), store.Load())})
+	}
+}
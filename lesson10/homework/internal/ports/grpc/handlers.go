@@ -2,21 +2,21 @@ package grpc
 
 import (
 	"context"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"homework10/internal/app"
+	"homework10/internal/apperr"
 	"net/mail"
+	"time"
 )
 
 func (s *AdService) CreateAd(ctx context.Context, request *CreateAdRequest) (*AdResponse, error) {
 	if request.UserId == nil {
-		return nil, status.Error(codes.InvalidArgument, ErrMissingArgument.Error())
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
 	}
 	ad, err := s.app.CreateAd(ctx, request.GetTitle(), request.GetText(), request.GetUserId())
 
 	if err != nil {
-		return nil, status.Error(GetErrorCode(err), err.Error())
+		return nil, StatusError(err)
 	}
 
 	return AdSuccessResponse(ad), nil
@@ -24,36 +24,52 @@ func (s *AdService) CreateAd(ctx context.Context, request *CreateAdRequest) (*Ad
 
 func (s *AdService) ChangeAdStatus(ctx context.Context, request *ChangeAdStatusRequest) (*AdResponse, error) {
 	if request.AdId == nil || request.UserId == nil {
-		return nil, status.Error(codes.InvalidArgument, ErrMissingArgument.Error())
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
 	}
 	ad, err := s.app.ChangeAdStatus(ctx, request.GetAdId(), request.GetUserId(), request.GetPublished())
 
 	if err != nil {
-		return nil, status.Error(GetErrorCode(err), err.Error())
+		return nil, StatusError(err)
 	}
 	return AdSuccessResponse(ad), nil
 }
 
 func (s *AdService) UpdateAd(ctx context.Context, request *UpdateAdRequest) (*AdResponse, error) {
 	if request.AdId == nil || request.UserId == nil {
-		return nil, status.Error(codes.InvalidArgument, ErrMissingArgument.Error())
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
 	}
 	ad, err := s.app.UpdateAd(ctx, request.GetAdId(), request.GetUserId(), request.GetTitle(), request.GetText())
 
 	if err != nil {
-		return nil, status.Error(GetErrorCode(err), err.Error())
+		return nil, StatusError(err)
 	}
 	return AdSuccessResponse(ad), nil
 }
 
 func (s *AdService) GetAd(ctx context.Context, request *GetAdRequest) (*AdResponse, error) {
 	if request.AdId == nil {
-		return nil, status.Error(codes.InvalidArgument, ErrMissingArgument.Error())
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
 	}
 	ad, err := s.app.GetAd(ctx, request.GetAdId())
 
 	if err != nil {
-		return nil, status.Error(GetErrorCode(err), err.Error())
+		return nil, StatusError(err)
+	}
+	return AdSuccessResponse(ad), nil
+}
+
+func (s *AdService) ScheduleAd(ctx context.Context, request *ScheduleAdRequest) (*AdResponse, error) {
+	if request.AdId == nil || request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	publishAt, err := app.ParseDateTime(request.PublishAt)
+	if err != nil {
+		return nil, StatusError(apperr.Invalid(err))
+	}
+	ad, err := s.app.ScheduleAd(ctx, request.GetAdId(), request.GetUserId(), publishAt, int(request.GetExpiresIn()))
+
+	if err != nil {
+		return nil, StatusError(err)
 	}
 	return AdSuccessResponse(ad), nil
 }
@@ -61,82 +77,130 @@ func (s *AdService) GetAd(ctx context.Context, request *GetAdRequest) (*AdRespon
 func (s *AdService) ListAds(ctx context.Context, request *ListAdRequest) (*ListAdResponse, error) {
 	date, err := app.ParseDate(request.Date)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, StatusError(apperr.Invalid(err))
 	}
-	al, err := s.app.ListAds(ctx, app.ListAdsParams{
+	params := app.ListAdsParams{
 		Published: request.Published,
 		Uid:       request.UserId,
 		Date:      date,
 		Title:     request.Title,
-	})
+	}
+	if err := parseTimeFilters(&params, request); err != nil {
+		return nil, StatusError(err)
+	}
+	if params.Filter, err = app.ParseFilter(request.Filter); err != nil {
+		return nil, StatusError(apperr.InvalidFields(err, []apperr.FieldViolation{{Field: "filter", Description: err.Error()}}))
+	}
+	al, err := s.app.ListAds(ctx, params)
 
 	if err != nil {
-		return nil, status.Error(GetErrorCode(err), err.Error())
+		return nil, StatusError(err)
 	}
 	return AdListSuccessResponse(al), nil
 }
 
+// parseTimeFilters разбирает фильтры created_from, created_to и changed_since (см. app.ParseTime)
+func parseTimeFilters(params *app.ListAdsParams, request *ListAdRequest) error {
+	now := time.Now()
+	filters := []struct {
+		field string
+		value *string
+		dst   **time.Time
+	}{
+		{"created_from", request.CreatedFrom, &params.CreatedFrom},
+		{"created_to", request.CreatedTo, &params.CreatedTo},
+		{"changed_since", request.ChangedSince, &params.ChangedSince},
+	}
+	for _, f := range filters {
+		t, err := app.ParseTime(f.value, now)
+		if err != nil {
+			return apperr.InvalidFields(err, []apperr.FieldViolation{{Field: f.field, Description: err.Error()}})
+		}
+		*f.dst = t
+	}
+	return nil
+}
+
 func (s *AdService) CreateUser(ctx context.Context, request *CreateUserRequest) (*UserResponse, error) {
 	_, err := mail.ParseAddress(request.GetEmail())
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, StatusError(apperr.Invalid(err))
 	}
 
 	u, err := s.app.CreateUser(ctx, request.GetName(), request.GetEmail())
 
 	if err != nil {
-		return nil, status.Error(GetErrorCode(err), err.Error())
+		return nil, StatusError(err)
 	}
 	return UserSuccessResponse(u), nil
 }
 
 func (s *AdService) UpdateUser(ctx context.Context, request *UpdateUserRequest) (*UserResponse, error) {
 	if request.Id == nil {
-		return nil, status.Error(codes.InvalidArgument, ErrMissingArgument.Error())
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
 	}
 	_, err := mail.ParseAddress(request.GetEmail())
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, StatusError(apperr.Invalid(err))
 	}
 
 	u, err := s.app.UpdateUser(ctx, request.GetId(), request.GetName(), request.GetEmail())
 
 	if err != nil {
-		return nil, status.Error(GetErrorCode(err), err.Error())
+		return nil, StatusError(err)
 	}
 	return UserSuccessResponse(u), nil
 }
 
 func (s *AdService) GetUser(ctx context.Context, request *GetUserRequest) (*UserResponse, error) {
 	if request.Id == nil {
-		return nil, status.Error(codes.InvalidArgument, ErrMissingArgument.Error())
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
 	}
 	u, err := s.app.GetUser(ctx, request.GetId())
 
 	if err != nil {
-		return nil, status.Error(GetErrorCode(err), err.Error())
+		return nil, StatusError(err)
 	}
 	return UserSuccessResponse(u), nil
 }
 
 func (s *AdService) DeleteUser(ctx context.Context, request *DeleteUserRequest) (*emptypb.Empty, error) {
 	if request.Id == nil {
-		return nil, status.Error(codes.InvalidArgument, ErrMissingArgument.Error())
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
 	}
 	err := s.app.DeleteUser(ctx, request.GetId())
 	if err != nil {
-		return nil, status.Error(GetErrorCode(err), err.Error())
+		return nil, StatusError(err)
 	}
 	return &emptypb.Empty{}, nil
 }
 
+func (s *AdService) RequestEmailVerification(ctx context.Context, request *GetUserRequest) (*emptypb.Empty, error) {
+	if request.Id == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	err := s.app.RequestEmailVerification(ctx, request.GetId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AdService) VerifyEmail(ctx context.Context, request *VerifyEmailRequest) (*UserResponse, error) {
+	u, err := s.app.VerifyEmail(ctx, request.GetToken())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return UserSuccessResponse(u), nil
+}
+
 func (s *AdService) DeleteAd(ctx context.Context, request *DeleteAdRequest) (*emptypb.Empty, error) {
 	if request.AdId == nil || request.AuthorId == nil {
-		return nil, status.Error(codes.InvalidArgument, ErrMissingArgument.Error())
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
 	}
 	err := s.app.DeleteAd(ctx, request.GetAdId(), request.GetAuthorId())
 	if err != nil {
-		return nil, status.Error(GetErrorCode(err), err.Error())
+		return nil, StatusError(err)
 	}
 	return &emptypb.Empty{}, nil
 }
@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework10/internal/apperr"
+	"homework10/internal/i18n"
+	"strings"
+)
+
+// LocaleMetadataKey - метаданные с предпочтительными языками ответа в формате Accept-Language
+const LocaleMetadataKey = "accept-language"
+
+// localizeError переводит статус ошибки на язык, запрошенный в метаданных вызова.
+// Перевод строится по деталям статуса, поэтому работает для ошибок любого обработчика
+func localizeError(ctx context.Context, err error) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	locale := i18n.Negotiate(strings.Join(md.Get(LocaleMetadataKey), ","))
+	if err == nil || locale == i18n.Default {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	var e apperr.Error
+	e.Message = st.Message()
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			e.Code = apperr.Code(d.Reason)
+		case *errdetails.BadRequest:
+			for _, f := range d.FieldViolations {
+				e.Fields = append(e.Fields, apperr.FieldViolation{Field: f.Field, Description: f.Description})
+			}
+		}
+	}
+	if e.Code == "" {
+		return err
+	}
+	return newStatus(st.Code(), e.Localize(locale), locale).Err()
+}
+
+func UnaryLocaleInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, localizeError(ctx, err)
+	}
+}
+
+func StreamLocaleInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return localizeError(ss.Context(), handler(srv, ss))
+	}
+}
@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"homework10/internal/apperr"
+)
+
+func (s *AdService) StartConversation(ctx context.Context, request *StartConversationRequest) (*ConversationResponse, error) {
+	if request.AdId == nil || request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	c, err := s.app.StartConversation(ctx, request.GetAdId(), request.GetUserId())
+
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return ConversationSuccessResponse(c), nil
+}
+
+func (s *AdService) ListConversations(ctx context.Context, request *ListConversationsRequest) (*ListConversationResponse, error) {
+	if request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	cl, err := s.app.ListConversations(ctx, request.GetUserId())
+
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return ConversationListSuccessResponse(cl), nil
+}
+
+func (s *AdService) SendMessage(ctx context.Context, request *SendMessageRequest) (*MessageResponse, error) {
+	if request.ConversationId == nil || request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	m, err := s.app.SendMessage(ctx, request.GetConversationId(), request.GetUserId(), request.GetText())
+
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return MessageSuccessResponse(m), nil
+}
+
+func (s *AdService) ListMessages(ctx context.Context, request *ListMessagesRequest) (*ListMessageResponse, error) {
+	if request.ConversationId == nil || request.UserId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	ml, err := s.app.ListMessages(ctx, request.GetConversationId(), request.GetUserId(),
+		int(request.GetOffset()), int(request.GetLimit()))
+
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return MessageListSuccessResponse(ml), nil
+}
+
+func (s *AdService) SubscribeMessages(request *SubscribeMessagesRequest, stream AdService_SubscribeMessagesServer) error {
+	if request.UserId == nil {
+		return StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	ch, err := s.app.SubscribeMessages(stream.Context(), request.GetUserId())
+	if err != nil {
+		return StatusError(err)
+	}
+
+	for m := range ch {
+		if err := stream.Send(MessageSuccessResponse(&m)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *AdService) BlockUser(ctx context.Context, request *BlockUserRequest) (*emptypb.Empty, error) {
+	if request.UserId == nil || request.BlockedId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	err := s.app.BlockUser(ctx, request.GetUserId(), request.GetBlockedId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AdService) UnblockUser(ctx context.Context, request *BlockUserRequest) (*emptypb.Empty, error) {
+	if request.UserId == nil || request.BlockedId == nil {
+		return nil, StatusError(apperr.Invalid(ErrMissingArgument))
+	}
+	err := s.app.UnblockUser(ctx, request.GetUserId(), request.GetBlockedId())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
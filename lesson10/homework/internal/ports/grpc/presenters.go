@@ -2,11 +2,19 @@ package grpc
 
 import (
 	"errors"
-	"github.com/TobbyMax/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
 	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"homework10/internal/audit"
+	"homework10/internal/features"
+	"homework10/internal/i18n"
+	"homework10/internal/messages"
 	"homework10/internal/user"
+	"time"
 )
 
 var ErrMissingArgument = errors.New("required argument is missing")
@@ -20,6 +28,9 @@ func AdSuccessResponse(ad *ads.Ad) *AdResponse {
 		Published:   ad.Published,
 		DateCreated: app.FormatDate(ad.DateCreated),
 		DateChanged: app.FormatDate(ad.DateChanged),
+		PublishAt:   app.FormatOptionalDate(ad.PublishAt),
+		ExpiresAt:   app.FormatOptionalDate(ad.ExpiresAt),
+		Flags:       ad.Flags,
 	}
 }
 
@@ -34,22 +45,196 @@ func AdListSuccessResponse(al *ads.AdList) *ListAdResponse {
 
 func UserSuccessResponse(u *user.User) *UserResponse {
 	return &UserResponse{
-		Id:    u.ID,
-		Name:  u.Nickname,
-		Email: u.Email,
+		Id:       u.ID,
+		Name:     u.Nickname,
+		Email:    u.Email,
+		Verified: u.Verified,
+		Role:     u.Role.String(),
+		Banned:   u.Banned,
 	}
 }
 
-func GetErrorCode(err error) codes.Code {
-	switch {
-	case errors.As(err, &validator.ValidationErrors{}):
+func UserListSuccessResponse(ul []user.User) *ListUserResponse {
+	response := ListUserResponse{List: make([]*UserResponse, 0, len(ul))}
+
+	for _, u := range ul {
+		response.List = append(response.List, UserSuccessResponse(&u))
+	}
+	return &response
+}
+
+func AuditListSuccessResponse(entries []audit.Entry) *ListAuditResponse {
+	response := ListAuditResponse{List: make([]*AuditEntryResponse, 0, len(entries))}
+
+	for _, e := range entries {
+		response.List = append(response.List, &AuditEntryResponse{
+			Id:        e.ID,
+			Time:      e.Time.Format(time.RFC3339Nano),
+			Tenant:    string(e.Tenant),
+			ActorId:   e.ActorID,
+			Action:    string(e.Action),
+			Entity:    string(e.Entity),
+			EntityId:  e.EntityID,
+			Before:    string(e.Before),
+			After:     string(e.After),
+			Transport: string(e.Transport),
+			ClientIp:  e.ClientIP,
+		})
+	}
+	return &response
+}
+
+func FeatureListSuccessResponse(evaluations []features.Evaluation) *ListFeatureResponse {
+	response := ListFeatureResponse{List: make([]*FeatureResponse, 0, len(evaluations))}
+
+	for _, e := range evaluations {
+		response.List = append(response.List, &FeatureResponse{
+			Name:        e.Name,
+			Enabled:     e.Enabled,
+			Reason:      string(e.Reason),
+			Bucket:      e.Bucket,
+			FlagEnabled: e.Flag.Enabled,
+			Rollout:     e.Flag.Rollout,
+			Users:       e.Flag.Users,
+		})
+	}
+	return &response
+}
+
+func SavedSearchSuccessResponse(s *app.SavedSearch) *SavedSearchResponse {
+	var date *string
+	if s.Params.Date != nil {
+		d := s.Params.Date.Format(app.DateLayout)
+		date = &d
+	}
+	return &SavedSearchResponse{
+		Id:          s.ID,
+		UserId:      s.UserID,
+		Published:   s.Params.Published,
+		AuthorId:    s.Params.Uid,
+		Date:        date,
+		Title:       s.Params.Title,
+		DateCreated: app.FormatDate(s.DateCreated),
+	}
+}
+
+func SavedSearchListSuccessResponse(sl []app.SavedSearch) *ListSavedSearchResponse {
+	response := ListSavedSearchResponse{List: make([]*SavedSearchResponse, 0, len(sl))}
+
+	for _, s := range sl {
+		response.List = append(response.List, SavedSearchSuccessResponse(&s))
+	}
+	return &response
+}
+
+func NotificationListSuccessResponse(nl []app.Notification) *ListNotificationResponse {
+	response := ListNotificationResponse{List: make([]*NotificationResponse, 0, len(nl))}
+
+	for _, n := range nl {
+		response.List = append(response.List, &NotificationResponse{
+			Id:          n.ID,
+			UserId:      n.UserID,
+			AdId:        n.AdID,
+			SearchId:    n.SearchID,
+			DateCreated: app.FormatDate(n.DateCreated),
+		})
+	}
+	return &response
+}
+
+func ConversationSuccessResponse(c *messages.Conversation) *ConversationResponse {
+	return &ConversationResponse{
+		Id:          c.ID,
+		AdId:        c.AdID,
+		BuyerId:     c.BuyerID,
+		SellerId:    c.SellerID,
+		Unread:      int64(c.Unread),
+		DateCreated: app.FormatDate(c.DateCreated),
+		DateChanged: app.FormatDate(c.DateChanged),
+	}
+}
+
+func ConversationListSuccessResponse(cl []messages.Conversation) *ListConversationResponse {
+	response := ListConversationResponse{List: make([]*ConversationResponse, 0, len(cl))}
+
+	for _, c := range cl {
+		response.List = append(response.List, ConversationSuccessResponse(&c))
+	}
+	return &response
+}
+
+func MessageSuccessResponse(m *messages.Message) *MessageResponse {
+	return &MessageResponse{
+		Id:             m.ID,
+		ConversationId: m.ConversationID,
+		SenderId:       m.SenderID,
+		RecipientId:    m.RecipientID,
+		Text:           m.Text,
+		Read:           m.Read,
+		DateSent:       app.FormatDate(m.DateSent),
+	}
+}
+
+func MessageListSuccessResponse(ml []messages.Message) *ListMessageResponse {
+	response := ListMessageResponse{List: make([]*MessageResponse, 0, len(ml))}
+
+	for _, m := range ml {
+		response.List = append(response.List, MessageSuccessResponse(&m))
+	}
+	return &response
+}
+
+// ErrorDomain - домен ошибок сервиса в google.rpc.ErrorInfo
+const ErrorDomain = "ads.homework10"
+
+func grpcCode(k apperr.Kind) codes.Code {
+	switch k {
+	case apperr.KindInvalidArgument:
 		return codes.InvalidArgument
-	case errors.Is(err, app.ErrForbidden):
-		return codes.PermissionDenied
-	case errors.Is(err, app.ErrAdNotFound):
-		fallthrough
-	case errors.Is(err, app.ErrUserNotFound):
+	case apperr.KindNotFound:
 		return codes.NotFound
+	case apperr.KindAlreadyExists:
+		return codes.AlreadyExists
+	case apperr.KindForbidden:
+		return codes.PermissionDenied
+	case apperr.KindResourceExhausted:
+		return codes.ResourceExhausted
 	}
 	return codes.Internal
 }
+
+func GetErrorCode(err error) codes.Code {
+	return grpcCode(apperr.Classify(err).Kind)
+}
+
+// StatusError переводит ошибку приложения в google.rpc.Status: код ошибки передается в ErrorInfo,
+// нарушения по полям - в BadRequest
+func StatusError(err error) error {
+	e := apperr.Classify(err)
+	return newStatus(grpcCode(e.Kind), *e, i18n.Default).Err()
+}
+
+// newStatus собирает статус с деталями; для языка, отличного от исходного, сообщение
+// дублируется в LocalizedMessage
+func newStatus(code codes.Code, e apperr.Error, locale i18n.Locale) *status.Status {
+	st := status.New(code, e.Message)
+
+	details := []protoiface.MessageV1{&errdetails.ErrorInfo{Reason: string(e.Code), Domain: ErrorDomain}}
+	if len(e.Fields) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, f := range e.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Description,
+			})
+		}
+		details = append(details, br)
+	}
+	if locale != i18n.Default {
+		details = append(details, &errdetails.LocalizedMessage{Locale: string(locale), Message: e.Message})
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st
+}
@@ -6,8 +6,11 @@ import (
 	grpcRecovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/status"
 	"homework10/internal/app"
+	"homework10/internal/graceful"
+	"homework10/internal/logging"
 	"log"
 	"net"
 	"runtime/debug"
@@ -23,23 +26,49 @@ func NewService(a app.App) AdServiceServer {
 	return service
 }
 
+type AdminService struct {
+	app app.App
+}
+
+func NewAdminService(a app.App) AdminServiceServer {
+	service := &AdminService{app: a}
+	return service
+}
+
 func UnaryLoggerInterceptor(ctx context.Context,
 	req interface{},
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler) (interface{}, error) {
 
 	start := time.Now()
-	log.Printf("-- received request -- | protocol: GRPC | method: %s", info.FullMethod)
+	logging.Printf(logging.LevelInfo, "-- received request -- | protocol: GRPC | method: %s", info.FullMethod)
 
 	h, err := handler(ctx, req)
 
 	latency := time.Since(start)
-	log.Printf("-- handled request -- | protocol: GRPC | latency: %+v | method: %s | error: (%v)\n",
+	logging.Printf(logging.LevelInfo, "-- handled request -- | protocol: GRPC | latency: %+v | method: %s | error: (%v)\n",
 		latency, info.FullMethod, err)
 
 	return h, err
 }
 
+func StreamLoggerInterceptor(srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	start := time.Now()
+	logging.Printf(logging.LevelInfo, "-- received stream -- | protocol: GRPC | method: %s", info.FullMethod)
+
+	err := handler(srv, ss)
+
+	latency := time.Since(start)
+	logging.Printf(logging.LevelInfo, "-- closed stream -- | protocol: GRPC | latency: %+v | method: %s | error: (%v)\n",
+		latency, info.FullMethod, err)
+
+	return err
+}
+
 func UnaryRecoveryInterceptor() grpc.UnaryServerInterceptor {
 	stackTraceLogger := grpcRecovery.WithRecoveryHandlerContext(
 		func(ctx context.Context, p interface{}) error {
@@ -51,6 +80,17 @@ func UnaryRecoveryInterceptor() grpc.UnaryServerInterceptor {
 	return grpcRecovery.UnaryServerInterceptor(stackTraceLogger)
 }
 
+func StreamRecoveryInterceptor() grpc.StreamServerInterceptor {
+	stackTraceLogger := grpcRecovery.WithRecoveryHandlerContext(
+		func(ctx context.Context, p interface{}) error {
+			fmt.Print("\n\n")
+			log.Printf("[PANIC] %s\n%s\n", p, string(debug.Stack()))
+			return status.Errorf(codes.Internal, "%s", p)
+		},
+	)
+	return grpcRecovery.StreamServerInterceptor(stackTraceLogger)
+}
+
 func RunGRPCServerGracefully(ctx context.Context, lis net.Listener, server *grpc.Server) func() error {
 	return func() error {
 		log.Printf("starting grpc server, listening on %s\n", lis.Addr())
@@ -79,3 +119,41 @@ func RunGRPCServerGracefully(ctx context.Context, lis net.Listener, server *grpc
 		}
 	}
 }
+
+// Component - сервер как компонент graceful.Manager. GracefulStop ждет завершения всех вызовов,
+// включая открытые подписки, поэтому по истечении срока остановки сервер останавливается принудительно
+func Component(server *grpc.Server, lis net.Listener) graceful.Component {
+	return graceful.Component{
+		Name:  "grpc server",
+		Phase: graceful.PhaseStopServers,
+		Run: func(context.Context) error {
+			log.Printf("starting grpc server, listening on %s\n", lis.Addr())
+			if err := server.Serve(lis); err != nil {
+				return fmt.Errorf("grpc server can't listen and serve requests: %w", err)
+			}
+			return nil
+		},
+		Stop: func(context.Context) error {
+			server.GracefulStop()
+			return nil
+		},
+		Force: server.Stop,
+	}
+}
+
+// ReadinessComponent переключает статус сервиса health: SERVING при запуске
+// и NOT_SERVING в начале остановки, чтобы клиенты перестали отправлять новые вызовы
+func ReadinessComponent(hs *health.Server) graceful.Component {
+	return graceful.Component{
+		Name:  "grpc health",
+		Phase: graceful.PhaseReadinessOff,
+		Start: func(context.Context) error {
+			hs.Resume()
+			return nil
+		},
+		Stop: func(context.Context) error {
+			hs.Shutdown()
+			return nil
+		},
+	}
+}
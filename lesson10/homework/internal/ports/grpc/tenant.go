@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"homework10/internal/tenant"
+)
+
+// TenantMetadataKey задает арендатора вызова явно, без него арендатор определяется по :authority
+const TenantMetadataKey = "x-tenant-id"
+
+// tenantContext определяет арендатора вызова, проверяет его лимит запросов и кладет арендатора в контекст
+func tenantContext(ctx context.Context, reg *tenant.Registry) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	t, err := reg.Resolve(first(TenantMetadataKey), first(":authority"))
+	if err != nil {
+		return nil, StatusError(err)
+	}
+	if err := reg.Allow(t); err != nil {
+		return nil, StatusError(err)
+	}
+	return tenant.NewContext(ctx, t), nil
+}
+
+func UnaryTenantInterceptor(reg *tenant.Registry) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := tenantContext(ctx, reg)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// tenantStream подменяет контекст потока контекстом с арендатором
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s tenantStream) Context() context.Context {
+	return s.ctx
+}
+
+func StreamTenantInterceptor(reg *tenant.Registry) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := tenantContext(ss.Context(), reg)
+		if err != nil {
+			return err
+		}
+		return handler(srv, tenantStream{ServerStream: ss, ctx: ctx})
+	}
+}
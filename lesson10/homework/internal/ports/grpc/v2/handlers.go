@@ -0,0 +1,169 @@
+package v2
+
+import (
+	"context"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	grpcPort "homework10/internal/ports/grpc"
+	"time"
+)
+
+func missingArgument() error {
+	return grpcPort.StatusError(apperr.Invalid(ErrMissingArgument))
+}
+
+func (s *AdService) CreateAd(ctx context.Context, request *CreateAdRequest) (*Ad, error) {
+	if request.AuthorId == nil {
+		return nil, missingArgument()
+	}
+	ad, err := s.app.CreateAd(ctx, request.GetTitle(), request.GetText(), request.GetAuthorId().GetValue())
+	if err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	return AdSuccessResponse(ad), nil
+}
+
+func (s *AdService) GetAd(ctx context.Context, request *GetAdRequest) (*Ad, error) {
+	if request.Id == nil {
+		return nil, missingArgument()
+	}
+	ad, err := s.app.GetAd(ctx, request.GetId().GetValue())
+	if err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	return AdSuccessResponse(ad), nil
+}
+
+// UpdateAd изменяет только поля из маски, пустая маска означает все изменяемые поля
+func (s *AdService) UpdateAd(ctx context.Context, request *UpdateAdRequest) (*Ad, error) {
+	if request.GetAd().GetId() == nil || request.UserId == nil {
+		return nil, missingArgument()
+	}
+	paths, err := maskPaths(request.UpdateMask, "title", "text", "published")
+	if err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	var patch app.AdPatch
+	if paths["title"] {
+		patch.Title = &request.GetAd().Title
+	}
+	if paths["text"] {
+		patch.Text = &request.GetAd().Text
+	}
+	if paths["published"] {
+		patch.Published = &request.GetAd().Published
+	}
+	ad, err := s.app.PatchAd(ctx, request.GetAd().GetId().GetValue(), request.GetUserId().GetValue(), patch)
+	if err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	return AdSuccessResponse(ad), nil
+}
+
+func (s *AdService) DeleteAd(ctx context.Context, request *DeleteAdRequest) (*emptypb.Empty, error) {
+	if request.Id == nil || request.UserId == nil {
+		return nil, missingArgument()
+	}
+	if err := s.app.DeleteAd(ctx, request.GetId().GetValue(), request.GetUserId().GetValue()); err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AdService) ListAds(ctx context.Context, request *ListAdsRequest) (*ListAdsResponse, error) {
+	params := app.ListAdsParams{Published: request.Published, Title: request.Title}
+	if request.AuthorId != nil {
+		uid := request.GetAuthorId().GetValue()
+		params.Uid = &uid
+	}
+	if request.CreatedOn != nil {
+		if err := request.CreatedOn.CheckValid(); err != nil {
+			return nil, grpcPort.StatusError(apperr.Invalid(err))
+		}
+		year, month, day := request.CreatedOn.AsTime().Date()
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		params.Date = &date
+	}
+	bounds := []struct {
+		field string
+		value *timestamppb.Timestamp
+		dst   **time.Time
+	}{
+		{"created_from", request.CreatedFrom, &params.CreatedFrom},
+		{"created_to", request.CreatedTo, &params.CreatedTo},
+		{"changed_since", request.ChangedSince, &params.ChangedSince},
+	}
+	for _, b := range bounds {
+		if b.value == nil {
+			continue
+		}
+		if err := b.value.CheckValid(); err != nil {
+			return nil, grpcPort.StatusError(apperr.InvalidFields(err, []apperr.FieldViolation{{Field: b.field, Description: err.Error()}}))
+		}
+		t := b.value.AsTime()
+		*b.dst = &t
+	}
+	var err error
+	if params.Filter, err = app.ParseFilter(request.Filter); err != nil {
+		return nil, grpcPort.StatusError(apperr.InvalidFields(err, []apperr.FieldViolation{{Field: "filter", Description: err.Error()}}))
+	}
+	al, err := s.app.ListAds(ctx, params)
+	if err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	return AdListSuccessResponse(al), nil
+}
+
+func (s *AdService) CreateUser(ctx context.Context, request *CreateUserRequest) (*User, error) {
+	u, err := s.app.CreateUser(ctx, request.GetNickname(), request.GetEmail())
+	if err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	return UserSuccessResponse(u), nil
+}
+
+func (s *AdService) GetUser(ctx context.Context, request *GetUserRequest) (*User, error) {
+	if request.Id == nil {
+		return nil, missingArgument()
+	}
+	u, err := s.app.GetUser(ctx, request.GetId().GetValue())
+	if err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	return UserSuccessResponse(u), nil
+}
+
+// UpdateUser изменяет только поля из маски, пустая маска означает все изменяемые поля
+func (s *AdService) UpdateUser(ctx context.Context, request *UpdateUserRequest) (*User, error) {
+	if request.GetUser().GetId() == nil {
+		return nil, missingArgument()
+	}
+	paths, err := maskPaths(request.UpdateMask, "nickname", "email")
+	if err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	var patch app.UserPatch
+	if paths["nickname"] {
+		patch.Nickname = &request.GetUser().Nickname
+	}
+	if paths["email"] {
+		patch.Email = &request.GetUser().Email
+	}
+	u, err := s.app.PatchUser(ctx, request.GetUser().GetId().GetValue(), patch)
+	if err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	return UserSuccessResponse(u), nil
+}
+
+func (s *AdService) DeleteUser(ctx context.Context, request *DeleteUserRequest) (*emptypb.Empty, error) {
+	if request.Id == nil {
+		return nil, missingArgument()
+	}
+	if err := s.app.DeleteUser(ctx, request.GetId().GetValue()); err != nil {
+		return nil, grpcPort.StatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
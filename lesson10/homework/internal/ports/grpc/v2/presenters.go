@@ -0,0 +1,74 @@
+package v2
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/ads"
+	"homework10/internal/apperr"
+	"homework10/internal/user"
+	"time"
+)
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func AdSuccessResponse(ad *ads.Ad) *Ad {
+	return &Ad{
+		Id:          &AdId{Value: ad.ID},
+		Title:       ad.Title,
+		Text:        ad.Text,
+		AuthorId:    &UserId{Value: ad.AuthorID},
+		Published:   ad.Published,
+		CreateTime:  timestamppb.New(ad.DateCreated),
+		UpdateTime:  timestamppb.New(ad.DateChanged),
+		PublishTime: optionalTimestamp(ad.PublishAt),
+		ExpireTime:  optionalTimestamp(ad.ExpiresAt),
+		Flags:       ad.Flags,
+	}
+}
+
+func AdListSuccessResponse(al *ads.AdList) *ListAdsResponse {
+	response := &ListAdsResponse{Ads: make([]*Ad, 0, len(al.Data))}
+	for i := range al.Data {
+		response.Ads = append(response.Ads, AdSuccessResponse(&al.Data[i]))
+	}
+	return response
+}
+
+func UserSuccessResponse(u *user.User) *User {
+	return &User{
+		Id:       &UserId{Value: u.ID},
+		Nickname: u.Nickname,
+		Email:    u.Email,
+		Verified: u.Verified,
+		Role:     Role(u.Role + 1),
+		Banned:   u.Banned,
+	}
+}
+
+// maskPaths проверяет пути маски обновления, пустая маска означает все допустимые поля
+func maskPaths(mask *fieldmaskpb.FieldMask, allowed ...string) (map[string]bool, error) {
+	paths := make(map[string]bool, len(allowed))
+	if len(mask.GetPaths()) == 0 {
+		for _, p := range allowed {
+			paths[p] = true
+		}
+		return paths, nil
+	}
+	for _, p := range mask.GetPaths() {
+		known := false
+		for _, a := range allowed {
+			known = known || p == a
+		}
+		if !known {
+			return nil, apperr.Invalid(fmt.Errorf("%w: unknown field %q", ErrInvalidUpdateMask, p))
+		}
+		paths[p] = true
+	}
+	return paths, nil
+}
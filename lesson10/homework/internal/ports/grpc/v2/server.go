@@ -0,0 +1,22 @@
+// Package v2 - вторая версия gRPC API объявлений (ad.v2): время в google.protobuf.Timestamp,
+// типизированные идентификаторы и частичные обновления по google.protobuf.FieldMask.
+// Работает поверх того же app.App, что и первая версия, и регистрируется на том же сервере.
+package v2
+
+import (
+	"errors"
+	"homework10/internal/app"
+)
+
+var (
+	ErrMissingArgument   = errors.New("required argument is missing")
+	ErrInvalidUpdateMask = errors.New("invalid update mask")
+)
+
+type AdService struct {
+	app app.App
+}
+
+func NewService(a app.App) AdServiceServer {
+	return &AdService{app: a}
+}
@@ -0,0 +1,274 @@
+package httpgin
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"homework10/internal/audit"
+	"homework10/internal/user"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	AdminHeader = "X-Admin-ID"
+	adminIDKey  = "admin_id"
+)
+
+var ErrMissingAdminID = errors.New("missing or invalid " + AdminHeader + " header")
+
+// Метод для получения списка пользователей с фильтрами
+func listUsers(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params app.ListUsersParams
+
+		if roleStr, ok := c.GetQuery("role"); ok {
+			role, err := user.ParseRole(roleStr)
+			if err != nil {
+				errorResponse(c, apperr.Invalid(err))
+				return
+			}
+			params.Role = &role
+		}
+		if bannedStr, ok := c.GetQuery("banned"); ok {
+			banned, err := strconv.ParseBool(bannedStr)
+			if err != nil {
+				errorResponse(c, apperr.Invalid(err))
+				return
+			}
+			params.Banned = &banned
+		}
+		if verifiedStr, ok := c.GetQuery("verified"); ok {
+			verified, err := strconv.ParseBool(verifiedStr)
+			if err != nil {
+				errorResponse(c, apperr.Invalid(err))
+				return
+			}
+			params.Verified = &verified
+		}
+		if nickname, ok := c.GetQuery("nickname"); ok {
+			params.Nickname = &nickname
+		}
+		if email, ok := c.GetQuery("email"); ok {
+			params.Email = &email
+		}
+
+		ul, err := a.ListUsers(c, c.GetInt64(adminIDKey), params)
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, UserListSuccessResponse(responseIDs(c), ul))
+	}
+}
+
+// Метод для блокировки пользователя
+func banUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		u, err := a.BanUser(c, c.GetInt64(adminIDKey), int64(userID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(responseIDs(c), u))
+	}
+}
+
+// Метод для снятия блокировки пользователя
+func unbanUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		u, err := a.UnbanUser(c, c.GetInt64(adminIDKey), int64(userID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(responseIDs(c), u))
+	}
+}
+
+// Метод для изменения роли пользователя
+func setUserRole(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody setUserRoleRequest
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		role, err := user.ParseRole(reqBody.Role)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		u, err := a.SetUserRole(c, c.GetInt64(adminIDKey), int64(userID), role)
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(responseIDs(c), u))
+	}
+}
+
+// Метод для снятия с публикации любого объявления
+func forceUnpublishAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adIDStr := c.Param("ad_id")
+		adID, err := strconv.Atoi(adIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		ad, err := a.ForceUnpublishAd(c, c.GetInt64(adminIDKey), int64(adID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(responseIDs(c), ad))
+	}
+}
+
+// Метод для удаления любого объявления
+func forceDeleteAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adIDStr := c.Param("ad_id")
+		adID, err := strconv.Atoi(adIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		err = a.ForceDeleteAd(c, c.GetInt64(adminIDKey), int64(adID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, DeletionSuccessResponse())
+	}
+}
+
+// Метод для получения объявлений, ждущих проверки модератором
+func listFlaggedAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		al, err := a.ListFlaggedAds(c, c.GetInt64(adminIDKey))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdListSuccessResponse(responseIDs(c), al))
+	}
+}
+
+// Метод для одобрения объявления, помеченного контент-политикой
+func approveAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adIDStr := c.Param("ad_id")
+		adID, err := strconv.Atoi(adIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		ad, err := a.ApproveAd(c, c.GetInt64(adminIDKey), int64(adID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(responseIDs(c), ad))
+	}
+}
+
+// Метод для проверки флагов функциональности для пользователя (user_id в query, без него - запрос без пользователя)
+func listFeatures(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userID int64
+		if userIDStr, ok := c.GetQuery("user_id"); ok {
+			id, err := strconv.Atoi(userIDStr)
+			if err != nil {
+				errorResponse(c, apperr.Invalid(err))
+				return
+			}
+			userID = int64(id)
+		}
+
+		evaluations, err := a.ListFeatures(c, c.GetInt64(adminIDKey), userID)
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, FeatureListSuccessResponse(evaluations))
+	}
+}
+
+// Метод для получения журнала аудита с фильтрами, записи возвращаются начиная с последних
+func listAudit(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query listAuditQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+		f := audit.Filter{
+			ActorID:   query.ActorID,
+			Action:    (*audit.Action)(query.Action),
+			Entity:    (*audit.Entity)(query.Entity),
+			EntityID:  query.EntityID,
+			Transport: (*audit.Transport)(query.Transport),
+			Limit:     query.Limit,
+		}
+		now := time.Now()
+		for _, t := range []struct {
+			field string
+			value *string
+			dst   **time.Time
+		}{{"from", query.From, &f.From}, {"to", query.To, &f.To}} {
+			parsed, err := app.ParseTime(t.value, now)
+			if err != nil {
+				errorResponse(c, apperr.InvalidFields(err, []apperr.FieldViolation{{Field: t.field, Description: err.Error()}}))
+				return
+			}
+			*t.dst = parsed
+		}
+
+		entries, err := a.ListAudit(c, c.GetInt64(adminIDKey), f)
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AuditListSuccessResponse(responseIDs(c), entries))
+	}
+}
@@ -0,0 +1,183 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"net/http"
+	"strconv"
+)
+
+// Метод для добавления объявления в избранное
+func addFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody favoriteRequest
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		ad, err := a.AddFavorite(c, int64(userID), int64(reqBody.AdID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(responseIDs(c), ad))
+	}
+}
+
+// Метод для получения избранных объявлений пользователя
+func listFavorites(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		al, err := a.ListFavorites(c, int64(userID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdListSuccessResponse(responseIDs(c), al))
+	}
+}
+
+// Метод для удаления объявления из избранного
+func removeFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+		adIDStr := c.Param("ad_id")
+		adID, err := strconv.Atoi(adIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		err = a.RemoveFavorite(c, int64(userID), int64(adID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, DeletionSuccessResponse())
+	}
+}
+
+// Метод для сохранения поиска, по которому пользователь будет получать уведомления
+func createSavedSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody savedSearchRequest
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+		date, err := app.ParseDate(reqBody.Date)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		s, err := a.CreateSavedSearch(c, int64(userID), app.ListAdsParams{
+			Published: reqBody.Published,
+			Uid:       (*int64)(reqBody.AuthorID),
+			Date:      date,
+			Title:     reqBody.Title,
+		})
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, SavedSearchSuccessResponse(responseIDs(c), s))
+	}
+}
+
+// Метод для получения сохраненных поисков пользователя
+func listSavedSearches(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		sl, err := a.ListSavedSearches(c, int64(userID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, SavedSearchListSuccessResponse(responseIDs(c), sl))
+	}
+}
+
+// Метод для удаления сохраненного поиска
+func deleteSavedSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+		searchIDStr := c.Param("search_id")
+		searchID, err := strconv.Atoi(searchIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		err = a.DeleteSavedSearch(c, int64(searchID), int64(userID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, DeletionSuccessResponse())
+	}
+}
+
+// Метод для получения уведомлений о новых объявлениях по сохраненным поискам
+func listNotifications(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		nl, err := a.ListNotifications(c, int64(userID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, NotificationListSuccessResponse(responseIDs(c), nl))
+	}
+}
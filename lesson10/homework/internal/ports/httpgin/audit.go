@@ -0,0 +1,17 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/audit"
+)
+
+// AuditMiddleware кладет в контекст запроса транспорт и адрес клиента для журнала аудита.
+// Адрес берется из X-Forwarded-For и X-Real-IP, только если запрос пришел от прокси
+// из Options.TrustedProxies, иначе - адрес соединения
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		src := audit.Source{Transport: audit.TransportHTTP, ClientIP: c.ClientIP()}
+		c.Request = c.Request.WithContext(audit.NewContext(c.Request.Context(), src))
+		c.Next()
+	}
+}
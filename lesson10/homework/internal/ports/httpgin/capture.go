@@ -0,0 +1,72 @@
+package httpgin
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"homework10/internal/capture"
+)
+
+// CaptureHeaders - заголовки, влияющие на обработку запроса; только они попадают в запись
+var CaptureHeaders = []string{"Content-Type", "Accept-Language", AdminHeader, TenantHeader}
+
+// captureWriter запоминает статус и тело ответа
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// RecordHandler записывает запросы к next и ответы на них в rec.
+// Оборачивает весь обработчик сервера, а не группу gin, чтобы в запись попадали
+// и ответы промежуточных обработчиков (арендатор, лимиты, паники)
+func RecordHandler(rec *capture.Recorder, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := capture.Entry{Seq: rec.Next(), Time: time.Now().UTC(), Protocol: capture.ProtocolHTTP,
+			Method: r.Method, Path: r.URL.RequestURI(), Header: make(map[string]string)}
+		for _, h := range CaptureHeaders {
+			if v := r.Header.Get(h); v != "" {
+				e.Header[h] = v
+			}
+		}
+		if r.Host != "" {
+			e.Header["Host"] = r.Host
+		}
+		if r.Body != nil {
+			body, err := io.ReadAll(r.Body)
+			_ = r.Body.Close()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			e.SetRequest(body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		cw := &captureWriter{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+
+		e.Duration = float64(time.Since(e.Time).Microseconds()) / 1000
+		e.Status = cw.status
+		if e.Status == 0 {
+			e.Status = http.StatusOK
+		}
+		e.SetResponse(cw.body.Bytes())
+		rec.Record(e)
+	})
+}
@@ -0,0 +1,15 @@
+package httpgin
+
+import (
+	"net/http"
+
+	"homework10/internal/config"
+)
+
+// ConfigHandler кладет в контекст запроса текущий снимок настроек, чтобы обработчики
+// и приложение не увидели перезагрузку посреди запроса
+func ConfigHandler(store *config.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(config.NewContext(r.Context(), store.Load())))
+	})
+}
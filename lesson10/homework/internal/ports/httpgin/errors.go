@@ -0,0 +1,89 @@
+package httpgin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"homework10/internal/apperr"
+	"homework10/internal/i18n"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType - тип ответа с ошибкой по RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix - префикс URI типа проблемы, за ним следует код ошибки
+const problemTypePrefix = "urn:ads:problem:"
+
+// problemResponse - тело ответа с ошибкой (RFC 7807) с расширениями code и errors
+type problemResponse struct {
+	Type     string                  `json:"type"`
+	Title    string                  `json:"title"`
+	Status   int                     `json:"status"`
+	Detail   string                  `json:"detail"`
+	Instance string                  `json:"instance,omitempty"`
+	Code     apperr.Code             `json:"code"`
+	Errors   []apperr.FieldViolation `json:"errors,omitempty"`
+}
+
+func httpStatus(k apperr.Kind) int {
+	switch k {
+	case apperr.KindInvalidArgument:
+		return http.StatusBadRequest
+	case apperr.KindNotFound:
+		return http.StatusNotFound
+	case apperr.KindAlreadyExists:
+		return http.StatusConflict
+	case apperr.KindForbidden:
+		return http.StatusForbidden
+	case apperr.KindResourceExhausted:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+func newProblemResponse(err error, instance string, locale i18n.Locale) (int, problemResponse) {
+	e := apperr.Classify(err).Localize(locale)
+	status := httpStatus(e.Kind)
+	return status, problemResponse{
+		Type:     problemTypePrefix + strings.ToLower(strings.ReplaceAll(string(e.Code), "_", "-")),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   e.Fields,
+	}
+}
+
+// bindError переводит ошибку разбора тела запроса в неверный аргумент с нарушениями по полям
+func bindError(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return apperr.Invalid(err)
+	}
+	fields := make([]apperr.FieldViolation, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, apperr.FieldViolation{Field: apperr.FieldName(fe.Field()), Description: fe.Error()})
+	}
+	return apperr.InvalidFields(err, fields)
+}
+
+// errorResponse прерывает обработку запроса и отвечает ошибкой в формате problem+json
+// на языке, выбранном по заголовку Accept-Language
+func errorResponse(c *gin.Context, err error) {
+	locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	status, problem := newProblemResponse(err, c.Request.URL.Path, locale)
+	c.Header("Content-Type", ProblemContentType)
+	c.Header("Content-Language", string(locale))
+	c.AbortWithStatusJSON(status, problem)
+}
+
+// RecoveryMiddleware отвечает на панику обработчика внутренней ошибкой в формате problem+json
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		errorResponse(c, fmt.Errorf("panic: %v", recovered))
+	})
+}
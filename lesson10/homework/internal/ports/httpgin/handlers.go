@@ -2,12 +2,13 @@ package httpgin
 
 import (
 	"errors"
-	"github.com/TobbyMax/validator"
 	"github.com/gin-gonic/gin"
 	"homework10/internal/app"
+	"homework10/internal/apperr"
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 var ErrParameterNotFound = errors.New("necessary parameters not provided")
@@ -18,26 +19,19 @@ type Handler = func(*gin.Context) error
 func createAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody createAdRequest
-		err := c.Bind(&reqBody)
+		err := c.ShouldBind(&reqBody)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			errorResponse(c, bindError(err))
 			return
 		}
 
-		ad, err := a.CreateAd(c, reqBody.Title, reqBody.Text, reqBody.UserID)
+		ad, err := a.CreateAd(c, reqBody.Title, reqBody.Text, int64(reqBody.UserID))
 
 		if err != nil {
-			switch {
-			case errors.As(err, &validator.ValidationErrors{}):
-				c.JSON(http.StatusBadRequest, AdErrorResponse(err))
-			case errors.Is(err, app.ErrUserNotFound):
-				c.JSON(http.StatusFailedDependency, AdErrorResponse(err))
-			default:
-				c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
-			}
+			errorResponse(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+		c.JSON(http.StatusOK, AdSuccessResponse(responseIDs(c), ad))
 	}
 }
 
@@ -45,32 +39,25 @@ func createAd(a app.App) gin.HandlerFunc {
 func changeAdStatus(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody changeAdStatusRequest
-		if err := c.Bind(&reqBody); err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
 			return
 		}
 
 		adIDStr := c.Param("ad_id")
 		adID, err := strconv.Atoi(adIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			errorResponse(c, apperr.Invalid(err))
 			return
 		}
 
-		ad, err := a.ChangeAdStatus(c, int64(adID), reqBody.UserID, reqBody.Published)
+		ad, err := a.ChangeAdStatus(c, int64(adID), int64(reqBody.UserID), reqBody.Published)
 
 		if err != nil {
-			switch {
-			case errors.Is(err, app.ErrForbidden):
-				c.JSON(http.StatusForbidden, AdErrorResponse(err))
-			case errors.Is(err, app.ErrAdNotFound):
-				c.JSON(http.StatusNotFound, AdErrorResponse(err))
-			default:
-				c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
-			}
+			errorResponse(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+		c.JSON(http.StatusOK, AdSuccessResponse(responseIDs(c), ad))
 	}
 }
 
@@ -78,34 +65,25 @@ func changeAdStatus(a app.App) gin.HandlerFunc {
 func updateAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody updateAdRequest
-		if err := c.Bind(&reqBody); err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
 			return
 		}
 
 		adIDStr := c.Param("ad_id")
 		adID, err := strconv.Atoi(adIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			errorResponse(c, apperr.Invalid(err))
 			return
 		}
 
-		ad, err := a.UpdateAd(c, int64(adID), reqBody.UserID, reqBody.Title, reqBody.Text)
+		ad, err := a.UpdateAd(c, int64(adID), int64(reqBody.UserID), reqBody.Title, reqBody.Text)
 
 		if err != nil {
-			switch {
-			case errors.As(err, &validator.ValidationErrors{}):
-				c.JSON(http.StatusBadRequest, AdErrorResponse(err))
-			case errors.Is(err, app.ErrForbidden):
-				c.JSON(http.StatusForbidden, AdErrorResponse(err))
-			case errors.Is(err, app.ErrAdNotFound):
-				c.JSON(http.StatusNotFound, AdErrorResponse(err))
-			default:
-				c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
-			}
-			return
-		}
-		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(responseIDs(c), ad))
 	}
 }
 
@@ -115,22 +93,17 @@ func getAd(a app.App) gin.HandlerFunc {
 		adIDStr := c.Param("ad_id")
 		adID, err := strconv.Atoi(adIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			errorResponse(c, apperr.Invalid(err))
 			return
 		}
 
 		ad, err := a.GetAd(c, int64(adID))
 
 		if err != nil {
-			switch {
-			case errors.Is(err, app.ErrAdNotFound):
-				c.JSON(http.StatusNotFound, AdErrorResponse(err))
-			default:
-				c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
-			}
+			errorResponse(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+		c.JSON(http.StatusOK, AdSuccessResponse(responseIDs(c), ad))
 	}
 }
 
@@ -140,92 +113,142 @@ func deleteAd(a app.App) gin.HandlerFunc {
 		adIDStr := c.Param("ad_id")
 		adID, err := strconv.Atoi(adIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			errorResponse(c, apperr.Invalid(err))
 			return
 		}
 		userIDStr, ok := c.GetQuery("user_id")
 		if !ok {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(ErrParameterNotFound))
+			errorResponse(c, apperr.Invalid(ErrParameterNotFound))
 			return
 		}
 		userID, err := strconv.Atoi(userIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			errorResponse(c, apperr.Invalid(err))
 			return
 		}
 
 		err = a.DeleteAd(c, int64(adID), int64(userID))
 
 		if err != nil {
-			switch {
-			case errors.Is(err, app.ErrAdNotFound):
-				c.JSON(http.StatusNotFound, AdErrorResponse(err))
-			case errors.Is(err, app.ErrForbidden):
-				c.JSON(http.StatusForbidden, AdErrorResponse(err))
-			default:
-				c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
-			}
+			errorResponse(c, err)
 			return
 		}
 		c.JSON(http.StatusOK, DeletionSuccessResponse())
 	}
 }
 
+// Метод для планирования публикации и срока жизни объявления
+func scheduleAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody scheduleAdRequest
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+
+		adIDStr := c.Param("ad_id")
+		adID, err := strconv.Atoi(adIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+		publishAt, err := app.ParseDateTime(reqBody.PublishAt)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		ad, err := a.ScheduleAd(c, int64(adID), int64(reqBody.UserID), publishAt, reqBody.ExpiresIn)
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(responseIDs(c), ad))
+	}
+}
+
 // Метод для получения списка объявлений с фильтрами
 func listAds(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody listAdsRequest
 		if err := c.ShouldBindJSON(&reqBody); err != nil && err != io.EOF {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			errorResponse(c, apperr.Invalid(err))
 			return
 		}
 		date, err := app.ParseDate(reqBody.Date)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			errorResponse(c, apperr.Invalid(err))
 			return
 		}
 
-		al, err := a.ListAds(c, app.ListAdsParams{
+		params := app.ListAdsParams{
 			Published: reqBody.Published,
-			Uid:       reqBody.UserID,
+			Uid:       (*int64)(reqBody.UserID),
 			Date:      date,
 			Title:     reqBody.Title,
-		})
+		}
+		if err := parseTimeFilters(&params, reqBody.CreatedFrom, reqBody.CreatedTo, reqBody.ChangedSince); err != nil {
+			errorResponse(c, err)
+			return
+		}
+		if params.Filter, err = app.ParseFilter(reqBody.Filter); err != nil {
+			errorResponse(c, apperr.InvalidFields(err, []apperr.FieldViolation{{Field: "filter", Description: err.Error()}}))
+			return
+		}
+		al, err := a.ListAds(c, params)
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			errorResponse(c, err)
 			return
 		}
 		//if len(al.Data) == 0 {
-		//	c.JSON(http.StatusNoContent, AdListSuccessResponse(al))
+		//	c.JSON(http.StatusNoContent, AdListSuccessResponse(responseIDs(c), al))
 		//	return
 		//}
-		c.JSON(http.StatusOK, AdListSuccessResponse(al))
+		c.JSON(http.StatusOK, AdListSuccessResponse(responseIDs(c), al))
 	}
 }
 
+// parseTimeFilters разбирает фильтры created_from, created_to и changed_since (см. app.ParseTime)
+func parseTimeFilters(params *app.ListAdsParams, createdFrom *string, createdTo *string, changedSince *string) error {
+	now := time.Now()
+	filters := []struct {
+		field string
+		value *string
+		dst   **time.Time
+	}{
+		{"created_from", createdFrom, &params.CreatedFrom},
+		{"created_to", createdTo, &params.CreatedTo},
+		{"changed_since", changedSince, &params.ChangedSince},
+	}
+	for _, f := range filters {
+		t, err := app.ParseTime(f.value, now)
+		if err != nil {
+			return apperr.InvalidFields(err, []apperr.FieldViolation{{Field: f.field, Description: err.Error()}})
+		}
+		*f.dst = t
+	}
+	return nil
+}
+
 // Метод для создания пользователя
 func createUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody createUserRequest
-		err := c.Bind(&reqBody)
+		err := c.ShouldBind(&reqBody)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			errorResponse(c, bindError(err))
 			return
 		}
 
 		u, err := a.CreateUser(c, reqBody.Nickname, reqBody.Email)
 
 		if err != nil {
-			switch {
-			case errors.As(err, &validator.ValidationErrors{}):
-				c.JSON(http.StatusBadRequest, UserErrorResponse(err))
-			default:
-				c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
-			}
+			errorResponse(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, UserSuccessResponse(u))
+		c.JSON(http.StatusOK, UserSuccessResponse(responseIDs(c), u))
 	}
 }
 
@@ -233,32 +256,25 @@ func createUser(a app.App) gin.HandlerFunc {
 func updateUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody updateUserRequest
-		if err := c.Bind(&reqBody); err != nil {
-			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
 			return
 		}
 
 		userIDStr := c.Param("user_id")
 		userID, err := strconv.Atoi(userIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			errorResponse(c, apperr.Invalid(err))
 			return
 		}
 
 		u, err := a.UpdateUser(c, int64(userID), reqBody.Nickname, reqBody.Email)
 
 		if err != nil {
-			switch {
-			case errors.As(err, &validator.ValidationErrors{}):
-				c.JSON(http.StatusBadRequest, UserErrorResponse(err))
-			case errors.Is(err, app.ErrUserNotFound):
-				c.JSON(http.StatusNotFound, UserErrorResponse(err))
-			default:
-				c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
-			}
+			errorResponse(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, UserSuccessResponse(u))
+		c.JSON(http.StatusOK, UserSuccessResponse(responseIDs(c), u))
 	}
 }
 
@@ -268,22 +284,56 @@ func getUser(a app.App) gin.HandlerFunc {
 		userIDStr := c.Param("user_id")
 		userID, err := strconv.Atoi(userIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			errorResponse(c, apperr.Invalid(err))
 			return
 		}
 
 		u, err := a.GetUser(c, int64(userID))
 
 		if err != nil {
-			switch {
-			case errors.Is(err, app.ErrUserNotFound):
-				c.JSON(http.StatusNotFound, UserErrorResponse(err))
-			default:
-				c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
-			}
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(responseIDs(c), u))
+	}
+}
+
+// Метод для повторной отправки письма с подтверждением почты
+func requestEmailVerification(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		err = a.RequestEmailVerification(c, int64(userID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, DeletionSuccessResponse())
+	}
+}
+
+// Метод для подтверждения почты по токену из письма
+func verifyEmail(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody verifyEmailRequest
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+
+		u, err := a.VerifyEmail(c, reqBody.Token)
+
+		if err != nil {
+			errorResponse(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, UserSuccessResponse(u))
+		c.JSON(http.StatusOK, UserSuccessResponse(responseIDs(c), u))
 	}
 }
 
@@ -293,19 +343,14 @@ func deleteUser(a app.App) gin.HandlerFunc {
 		userIDStr := c.Param("user_id")
 		userID, err := strconv.Atoi(userIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			errorResponse(c, apperr.Invalid(err))
 			return
 		}
 
 		err = a.DeleteUser(c, int64(userID))
 
 		if err != nil {
-			switch {
-			case errors.Is(err, app.ErrUserNotFound):
-				c.JSON(http.StatusNotFound, UserErrorResponse(err))
-			default:
-				c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
-			}
+			errorResponse(c, err)
 			return
 		}
 		c.JSON(http.StatusOK, DeletionSuccessResponse())
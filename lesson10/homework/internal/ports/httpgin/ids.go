@@ -0,0 +1,81 @@
+package httpgin
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stringIDsKey - ключ контекста gin, по которому обработчики узнают формат идентификаторов сервера
+const stringIDsKey = "string_ids"
+
+// requestID - идентификатор в теле запроса, принимается и числом, и строкой
+type requestID int64
+
+func (id *requestID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+	v, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return err
+	}
+	*id = requestID(v)
+	return nil
+}
+
+// idFormat - формат идентификаторов в ответах сервера
+type idFormat bool
+
+const (
+	numericIDs idFormat = false
+	stringIDs  idFormat = true
+)
+
+// jsonID - идентификатор в ответе: числом или строкой, как настроен сервер
+type jsonID struct {
+	value  int64
+	format idFormat
+}
+
+func (id jsonID) MarshalJSON() ([]byte, error) {
+	s := strconv.FormatInt(id.value, 10)
+	if id.format == stringIDs {
+		return []byte(`"` + s + `"`), nil
+	}
+	return []byte(s), nil
+}
+
+func (f idFormat) id(v int64) jsonID {
+	return jsonID{value: v, format: f}
+}
+
+func (f idFormat) idPtr(v *int64) *jsonID {
+	if v == nil {
+		return nil
+	}
+	id := f.id(*v)
+	return &id
+}
+
+// IDFormatMiddleware сообщает обработчикам, отдавать ли идентификаторы строками
+func IDFormatMiddleware(stringIDs bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(stringIDsKey, stringIDs)
+		c.Next()
+	}
+}
+
+// responseIDs возвращает формат идентификаторов сервера, обрабатывающего запрос
+func responseIDs(c *gin.Context) idFormat {
+	return idFormat(c.GetBool(stringIDsKey))
+}
@@ -0,0 +1,173 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"net/http"
+	"strconv"
+)
+
+// Метод для начала переписки покупателя с автором объявления
+func startConversation(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody startConversationRequest
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+
+		adIDStr := c.Param("ad_id")
+		adID, err := strconv.Atoi(adIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		conv, err := a.StartConversation(c, int64(adID), int64(reqBody.UserID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, ConversationSuccessResponse(responseIDs(c), conv))
+	}
+}
+
+// Метод для получения переписок пользователя
+func listConversations(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		cl, err := a.ListConversations(c, int64(userID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, ConversationListSuccessResponse(responseIDs(c), cl))
+	}
+}
+
+// Метод для отправки сообщения в переписку
+func sendMessage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody sendMessageRequest
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+
+		convIDStr := c.Param("conversation_id")
+		convID, err := strconv.Atoi(convIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		m, err := a.SendMessage(c, int64(convID), int64(reqBody.UserID), reqBody.Text)
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, MessageSuccessResponse(responseIDs(c), m))
+	}
+}
+
+// Метод для получения истории переписки с пагинацией
+func listMessages(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		convIDStr := c.Param("conversation_id")
+		convID, err := strconv.Atoi(convIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+		userIDStr, ok := c.GetQuery("user_id")
+		if !ok {
+			errorResponse(c, apperr.Invalid(ErrParameterNotFound))
+			return
+		}
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		ml, err := a.ListMessages(c, int64(convID), int64(userID), offset, limit)
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, MessageListSuccessResponse(responseIDs(c), ml))
+	}
+}
+
+// Метод для блокировки пользователя: заблокированный не может писать заблокировавшему
+func blockUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody blockUserRequest
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		err = a.BlockUser(c, int64(userID), int64(reqBody.BlockedID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, DeletionSuccessResponse())
+	}
+}
+
+// Метод для снятия блокировки пользователя
+func unblockUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.Param("user_id")
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+		blockedIDStr := c.Param("blocked_id")
+		blockedID, err := strconv.Atoi(blockedIDStr)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+
+		err = a.UnblockUser(c, int64(userID), int64(blockedID))
+
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, DeletionSuccessResponse())
+	}
+}
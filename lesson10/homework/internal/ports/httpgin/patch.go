@@ -0,0 +1,195 @@
+package httpgin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"homework10/internal/user"
+	"net/http"
+)
+
+// MergePatchContentType - тип тела PATCH-запросов (RFC 7396), обычный application/json тоже принимается
+const MergePatchContentType = "application/merge-patch+json"
+
+var (
+	ErrInvalidMergePatch = errors.New("request body is not a JSON merge patch object")
+	ErrUnknownPatchField = errors.New("unknown field")
+	ErrNullPatchField    = errors.New("field cannot be removed")
+)
+
+// mergePatch - тело PATCH-запроса: изменяются только присутствующие в нем поля
+type mergePatch map[string]json.RawMessage
+
+var (
+	adPatchFields   = []string{"title", "text", "published"}
+	userPatchFields = []string{"nickname", "email"}
+
+	// значения, в которые сбрасываются поля из update_mask, отсутствующие в теле
+	patchDefaults = mergePatch{
+		"title":     json.RawMessage(`""`),
+		"text":      json.RawMessage(`""`),
+		"published": json.RawMessage(`false`),
+		"nickname":  json.RawMessage(`""`),
+		"email":     json.RawMessage(`""`),
+	}
+)
+
+// readMergePatch читает тело запроса и отклоняет поля, которые нельзя изменить
+func readMergePatch(c *gin.Context, known ...string) (mergePatch, error) {
+	var doc mergePatch
+	if err := json.NewDecoder(c.Request.Body).Decode(&doc); err != nil || doc == nil {
+		return nil, apperr.Invalid(ErrInvalidMergePatch)
+	}
+	for name := range doc {
+		found := false
+		for _, k := range known {
+			found = found || name == k
+		}
+		if !found {
+			return nil, apperr.InvalidFields(fmt.Errorf("%w %q", ErrUnknownPatchField, name),
+				[]apperr.FieldViolation{{Field: name, Description: ErrUnknownPatchField.Error()}})
+		}
+	}
+	return doc, nil
+}
+
+// masked оставляет только поля из update_mask, без маски патч не меняется
+func (doc mergePatch) masked(paths map[string]bool) mergePatch {
+	if paths == nil {
+		return doc
+	}
+	res := make(mergePatch, len(paths))
+	for p := range paths {
+		if raw, ok := doc[p]; ok {
+			res[p] = raw
+		} else {
+			res[p] = patchDefaults[p]
+		}
+	}
+	return res
+}
+
+// patchField разбирает поле патча, отсутствующее поле оставляет dst равным nil.
+// null по RFC 7396 удаляет поле, а у объявлений и пользователей необязательных полей нет.
+func patchField[T any](doc mergePatch, name string, dst **T) error {
+	raw, ok := doc[name]
+	if !ok {
+		return nil
+	}
+	if string(raw) == "null" {
+		return apperr.InvalidFields(fmt.Errorf("%w: %s", ErrNullPatchField, name),
+			[]apperr.FieldViolation{{Field: name, Description: ErrNullPatchField.Error()}})
+	}
+	v := new(T)
+	if err := json.Unmarshal(raw, v); err != nil {
+		return apperr.InvalidFields(err, []apperr.FieldViolation{{Field: name, Description: err.Error()}})
+	}
+	*dst = v
+	return nil
+}
+
+// patchUserID извлекает из патча объявления автора, от имени которого выполняется изменение
+func patchUserID(doc mergePatch) (int64, error) {
+	var uid *requestID
+	if err := patchField(doc, "user_id", &uid); err != nil {
+		return 0, err
+	}
+	if uid == nil {
+		return 0, apperr.Invalid(ErrParameterNotFound)
+	}
+	delete(doc, "user_id")
+	return int64(*uid), nil
+}
+
+func newAdPatch(doc mergePatch) (app.AdPatch, error) {
+	var p app.AdPatch
+	if err := patchField(doc, "title", &p.Title); err != nil {
+		return p, err
+	}
+	if err := patchField(doc, "text", &p.Text); err != nil {
+		return p, err
+	}
+	if err := patchField(doc, "published", &p.Published); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+func newUserPatch(doc mergePatch) (app.UserPatch, error) {
+	var p app.UserPatch
+	if err := patchField(doc, "nickname", &p.Nickname); err != nil {
+		return p, err
+	}
+	if err := patchField(doc, "email", &p.Email); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// patchAdRequest применяет к объявлению патч из тела запроса, ограниченный маской paths
+func patchAdRequest(c *gin.Context, a app.App, id int64, paths map[string]bool) (*ads.Ad, error) {
+	doc, err := readMergePatch(c, "user_id", "title", "text", "published")
+	if err != nil {
+		return nil, err
+	}
+	uid, err := patchUserID(doc)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := newAdPatch(doc.masked(paths))
+	if err != nil {
+		return nil, err
+	}
+	return a.PatchAd(c, id, uid, patch)
+}
+
+// patchUserRequest применяет к пользователю патч из тела запроса, ограниченный маской paths
+func patchUserRequest(c *gin.Context, a app.App, id int64, paths map[string]bool) (*user.User, error) {
+	doc, err := readMergePatch(c, userPatchFields...)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := newUserPatch(doc.masked(paths))
+	if err != nil {
+		return nil, err
+	}
+	return a.PatchUser(c, id, patch)
+}
+
+// Метод для частичного обновления объявления (title, text, published) в формате JSON Merge Patch
+func patchAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := idParam(c, "ad_id")
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		ad, err := patchAdRequest(c, a, id, nil)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(responseIDs(c), ad))
+	}
+}
+
+// Метод для частичного обновления пользователя (nickname, email) в формате JSON Merge Patch
+func patchUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := idParam(c, "user_id")
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		u, err := patchUserRequest(c, a, id, nil)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(responseIDs(c), u))
+	}
+}
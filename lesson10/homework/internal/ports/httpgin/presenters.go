@@ -1,10 +1,15 @@
 package httpgin
 
 import (
+	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/audit"
+	"homework10/internal/features"
+	"homework10/internal/messages"
 	"homework10/internal/user"
+	"time"
 )
 
 type createUserRequest struct {
@@ -18,74 +23,200 @@ type updateUserRequest struct {
 }
 
 type userResponse struct {
-	ID       int64  `json:"id"`
+	ID       jsonID `json:"id"`
 	Nickname string `json:"nickname"`
 	Email    string `json:"email"`
+	Verified bool   `json:"verified"`
+	Role     string `json:"role"`
+	Banned   bool   `json:"banned"`
+}
+
+type setUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
 }
 
 type createAdRequest struct {
-	Title  string `json:"title"`
-	Text   string `json:"text"`
-	UserID int64  `json:"user_id"`
+	Title  string    `json:"title"`
+	Text   string    `json:"text"`
+	UserID requestID `json:"user_id"`
 }
 
 type adResponse struct {
-	ID          int64  `json:"id"`
-	Title       string `json:"title"`
-	Text        string `json:"text"`
-	AuthorID    int64  `json:"author_id"`
-	Published   bool   `json:"published"`
-	DateCreated string `json:"date_created"`
-	DateChanged string `json:"date_changed"`
+	ID          jsonID  `json:"id"`
+	Title       string  `json:"title"`
+	Text        string  `json:"text"`
+	AuthorID    jsonID  `json:"author_id"`
+	Published   bool    `json:"published"`
+	DateCreated string  `json:"date_created"`
+	DateChanged string  `json:"date_changed"`
+	PublishAt   *string `json:"publish_at"`
+	ExpiresAt   *string `json:"expires_at"`
+	// Flags - причины, по которым объявление ждет проверки модератором
+	Flags []string `json:"flags,omitempty"`
+}
+
+type featureResponse struct {
+	Name    string        `json:"name"`
+	Enabled bool          `json:"enabled"`
+	Reason  string        `json:"reason"`
+	Bucket  float64       `json:"bucket"`
+	Flag    features.Flag `json:"flag"`
+}
+
+type listAuditQuery struct {
+	ActorID   *int64  `form:"actor_id"`
+	Action    *string `form:"action"`
+	Entity    *string `form:"entity"`
+	EntityID  *int64  `form:"entity_id"`
+	Transport *string `form:"transport"`
+	From      *string `form:"from"`
+	To        *string `form:"to"`
+	Limit     int     `form:"limit"`
+}
+
+type auditEntryResponse struct {
+	ID        jsonID          `json:"id"`
+	Time      string          `json:"time"`
+	Tenant    string          `json:"tenant"`
+	ActorID   jsonID          `json:"actor_id"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  jsonID          `json:"entity_id"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	Transport string          `json:"transport,omitempty"`
+	ClientIP  string          `json:"client_ip,omitempty"`
 }
 
 type changeAdStatusRequest struct {
-	Published bool  `json:"published"`
-	UserID    int64 `json:"user_id"`
+	Published bool      `json:"published"`
+	UserID    requestID `json:"user_id"`
 }
 
 type updateAdRequest struct {
-	Title  string `json:"title"`
-	Text   string `json:"text"`
-	UserID int64  `json:"user_id"`
+	Title  string    `json:"title"`
+	Text   string    `json:"text"`
+	UserID requestID `json:"user_id"`
+}
+
+type scheduleAdRequest struct {
+	UserID    requestID `json:"user_id"`
+	PublishAt *string   `json:"publish_at"`
+	ExpiresIn int       `json:"expires_in"`
 }
 
 type listAdsRequest struct {
-	Published *bool   `json:"published"`
-	UserID    *int64  `json:"user_id"`
-	Date      *string `json:"date"`
-	Title     *string `json:"title"`
+	Published    *bool      `json:"published"`
+	UserID       *requestID `json:"user_id"`
+	Date         *string    `json:"date"`
+	Title        *string    `json:"title"`
+	CreatedFrom  *string    `json:"created_from"`
+	CreatedTo    *string    `json:"created_to"`
+	ChangedSince *string    `json:"changed_since"`
+	Filter       *string    `json:"filter"`
 }
 
 type adListResponse []adResponse
 
-func AdSuccessResponse(ad *ads.Ad) *gin.H {
+type favoriteRequest struct {
+	AdID requestID `json:"ad_id"`
+}
+
+type savedSearchRequest struct {
+	Published *bool      `json:"published"`
+	AuthorID  *requestID `json:"author_id"`
+	Date      *string    `json:"date"`
+	Title     *string    `json:"title"`
+}
+
+type savedSearchResponse struct {
+	ID          jsonID  `json:"id"`
+	UserID      jsonID  `json:"user_id"`
+	Published   *bool   `json:"published"`
+	AuthorID    *jsonID `json:"author_id"`
+	Date        *string `json:"date"`
+	Title       *string `json:"title"`
+	DateCreated string  `json:"date_created"`
+}
+
+type startConversationRequest struct {
+	UserID requestID `json:"user_id"`
+}
+
+type sendMessageRequest struct {
+	UserID requestID `json:"user_id"`
+	Text   string    `json:"text"`
+}
+
+type blockUserRequest struct {
+	BlockedID requestID `json:"blocked_id"`
+}
+
+type conversationResponse struct {
+	ID          jsonID `json:"id"`
+	AdID        jsonID `json:"ad_id"`
+	BuyerID     jsonID `json:"buyer_id"`
+	SellerID    jsonID `json:"seller_id"`
+	Unread      int    `json:"unread"`
+	DateCreated string `json:"date_created"`
+	DateChanged string `json:"date_changed"`
+}
+
+type messageResponse struct {
+	ID             jsonID `json:"id"`
+	ConversationID jsonID `json:"conversation_id"`
+	SenderID       jsonID `json:"sender_id"`
+	RecipientID    jsonID `json:"recipient_id"`
+	Text           string `json:"text"`
+	Read           bool   `json:"read"`
+	DateSent       string `json:"date_sent"`
+}
+
+type notificationResponse struct {
+	ID          jsonID `json:"id"`
+	UserID      jsonID `json:"user_id"`
+	AdID        jsonID `json:"ad_id"`
+	SearchID    jsonID `json:"search_id"`
+	DateCreated string `json:"date_created"`
+}
+
+func AdSuccessResponse(f idFormat, ad *ads.Ad) *gin.H {
 	return &gin.H{
 		"data": adResponse{
-			ID:          ad.ID,
+			ID:          f.id(ad.ID),
 			Title:       ad.Title,
 			Text:        ad.Text,
-			AuthorID:    ad.AuthorID,
+			AuthorID:    f.id(ad.AuthorID),
 			Published:   ad.Published,
 			DateCreated: app.FormatDate(ad.DateCreated),
 			DateChanged: app.FormatDate(ad.DateChanged),
+			PublishAt:   app.FormatOptionalDate(ad.PublishAt),
+			ExpiresAt:   app.FormatOptionalDate(ad.ExpiresAt),
+			Flags:       ad.Flags,
 		},
 		"error": nil,
 	}
 }
 
-func AdListSuccessResponse(al *ads.AdList) *gin.H {
+func AdListSuccessResponse(f idFormat, al *ads.AdList) *gin.H {
 	data := make(adListResponse, 0)
 	for _, ad := range al.Data {
 		data = append(data,
 			adResponse{
-				ID:          ad.ID,
+				ID:          f.id(ad.ID),
 				Title:       ad.Title,
 				Text:        ad.Text,
-				AuthorID:    ad.AuthorID,
+				AuthorID:    f.id(ad.AuthorID),
 				Published:   ad.Published,
 				DateCreated: app.FormatDate(ad.DateCreated),
 				DateChanged: app.FormatDate(ad.DateChanged),
+				PublishAt:   app.FormatOptionalDate(ad.PublishAt),
+				ExpiresAt:   app.FormatOptionalDate(ad.ExpiresAt),
+				Flags:       ad.Flags,
 			})
 	}
 	return &gin.H{
@@ -94,28 +225,187 @@ func AdListSuccessResponse(al *ads.AdList) *gin.H {
 	}
 }
 
-func AdErrorResponse(err error) *gin.H {
+func UserSuccessResponse(f idFormat, u *user.User) *gin.H {
 	return &gin.H{
-		"data":  nil,
-		"error": err.Error(),
+		"data": userResponse{
+			ID:       f.id(u.ID),
+			Nickname: u.Nickname,
+			Email:    u.Email,
+			Verified: u.Verified,
+			Role:     u.Role.String(),
+			Banned:   u.Banned,
+		},
+		"error": nil,
 	}
 }
 
-func UserSuccessResponse(u *user.User) *gin.H {
+func AuditListSuccessResponse(f idFormat, entries []audit.Entry) *gin.H {
+	data := make([]auditEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		data = append(data, auditEntryResponse{
+			ID:        f.id(e.ID),
+			Time:      e.Time.Format(time.RFC3339Nano),
+			Tenant:    string(e.Tenant),
+			ActorID:   f.id(e.ActorID),
+			Action:    string(e.Action),
+			Entity:    string(e.Entity),
+			EntityID:  f.id(e.EntityID),
+			Before:    e.Before,
+			After:     e.After,
+			Transport: string(e.Transport),
+			ClientIP:  e.ClientIP,
+		})
+	}
 	return &gin.H{
-		"data": userResponse{
-			ID:       u.ID,
+		"data":  data,
+		"error": nil,
+	}
+}
+
+func FeatureListSuccessResponse(evaluations []features.Evaluation) *gin.H {
+	data := make([]featureResponse, 0, len(evaluations))
+	for _, e := range evaluations {
+		data = append(data, featureResponse{
+			Name:    e.Name,
+			Enabled: e.Enabled,
+			Reason:  string(e.Reason),
+			Bucket:  e.Bucket,
+			Flag:    e.Flag,
+		})
+	}
+	return &gin.H{
+		"data":  data,
+		"error": nil,
+	}
+}
+
+func UserListSuccessResponse(f idFormat, ul []user.User) *gin.H {
+	data := make([]userResponse, 0, len(ul))
+	for _, u := range ul {
+		data = append(data, userResponse{
+			ID:       f.id(u.ID),
 			Nickname: u.Nickname,
 			Email:    u.Email,
-		},
+			Verified: u.Verified,
+			Role:     u.Role.String(),
+			Banned:   u.Banned,
+		})
+	}
+	return &gin.H{
+		"data":  data,
 		"error": nil,
 	}
 }
 
-func UserErrorResponse(err error) *gin.H {
+func newSavedSearchResponse(f idFormat, s app.SavedSearch) savedSearchResponse {
+	var date *string
+	if s.Params.Date != nil {
+		d := s.Params.Date.Format(app.DateLayout)
+		date = &d
+	}
+	return savedSearchResponse{
+		ID:          f.id(s.ID),
+		UserID:      f.id(s.UserID),
+		Published:   s.Params.Published,
+		AuthorID:    f.idPtr(s.Params.Uid),
+		Date:        date,
+		Title:       s.Params.Title,
+		DateCreated: app.FormatDate(s.DateCreated),
+	}
+}
+
+func SavedSearchSuccessResponse(f idFormat, s *app.SavedSearch) *gin.H {
 	return &gin.H{
-		"data":  nil,
-		"error": err.Error(),
+		"data":  newSavedSearchResponse(f, *s),
+		"error": nil,
+	}
+}
+
+func SavedSearchListSuccessResponse(f idFormat, sl []app.SavedSearch) *gin.H {
+	data := make([]savedSearchResponse, 0, len(sl))
+	for _, s := range sl {
+		data = append(data, newSavedSearchResponse(f, s))
+	}
+	return &gin.H{
+		"data":  data,
+		"error": nil,
+	}
+}
+
+func NotificationListSuccessResponse(f idFormat, nl []app.Notification) *gin.H {
+	data := make([]notificationResponse, 0, len(nl))
+	for _, n := range nl {
+		data = append(data, notificationResponse{
+			ID:          f.id(n.ID),
+			UserID:      f.id(n.UserID),
+			AdID:        f.id(n.AdID),
+			SearchID:    f.id(n.SearchID),
+			DateCreated: app.FormatDate(n.DateCreated),
+		})
+	}
+	return &gin.H{
+		"data":  data,
+		"error": nil,
+	}
+}
+
+func newConversationResponse(f idFormat, conv messages.Conversation) conversationResponse {
+	return conversationResponse{
+		ID:          f.id(conv.ID),
+		AdID:        f.id(conv.AdID),
+		BuyerID:     f.id(conv.BuyerID),
+		SellerID:    f.id(conv.SellerID),
+		Unread:      conv.Unread,
+		DateCreated: app.FormatDate(conv.DateCreated),
+		DateChanged: app.FormatDate(conv.DateChanged),
+	}
+}
+
+func ConversationSuccessResponse(f idFormat, conv *messages.Conversation) *gin.H {
+	return &gin.H{
+		"data":  newConversationResponse(f, *conv),
+		"error": nil,
+	}
+}
+
+func ConversationListSuccessResponse(f idFormat, cl []messages.Conversation) *gin.H {
+	data := make([]conversationResponse, 0, len(cl))
+	for _, conv := range cl {
+		data = append(data, newConversationResponse(f, conv))
+	}
+	return &gin.H{
+		"data":  data,
+		"error": nil,
+	}
+}
+
+func newMessageResponse(f idFormat, m messages.Message) messageResponse {
+	return messageResponse{
+		ID:             f.id(m.ID),
+		ConversationID: f.id(m.ConversationID),
+		SenderID:       f.id(m.SenderID),
+		RecipientID:    f.id(m.RecipientID),
+		Text:           m.Text,
+		Read:           m.Read,
+		DateSent:       app.FormatDate(m.DateSent),
+	}
+}
+
+func MessageSuccessResponse(f idFormat, m *messages.Message) *gin.H {
+	return &gin.H{
+		"data":  newMessageResponse(f, *m),
+		"error": nil,
+	}
+}
+
+func MessageListSuccessResponse(f idFormat, ml []messages.Message) *gin.H {
+	data := make([]messageResponse, 0, len(ml))
+	for _, m := range ml {
+		data = append(data, newMessageResponse(f, m))
+	}
+	return &gin.H{
+		"data":  data,
+		"error": nil,
 	}
 }
 
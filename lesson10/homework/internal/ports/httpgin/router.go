@@ -9,13 +9,58 @@ func AppRouter(r *gin.RouterGroup, a app.App) {
 	r.POST("/ads", createAd(a))                    // Метод для создания объявления (ad)
 	r.PUT("/ads/:ad_id/status", changeAdStatus(a)) // Метод для изменения статуса объявления (опубликовано - Published = true или снято с публикации Published = false)
 	r.PUT("/ads/:ad_id", updateAd(a))              // Метод для обновления текста(Text) или заголовка(Title) объявления
+	r.PATCH("/ads/:ad_id", patchAd(a))             // Метод для частичного обновления объявления (JSON Merge Patch: title, text, published)
 	r.GET("/ads/:ad_id", getAd(a))                 // Метод для получения объявления по ID
 	r.DELETE("/ads/:ad_id", deleteAd(a))
+	r.PUT("/ads/:ad_id/schedule", scheduleAd(a)) // Метод для планирования публикации (PublishAt) и срока жизни (ExpiresIn, в днях) объявления
 
-	r.GET("/ads", listAds(a)) // Метод для получения списка объявлений с фильтрами (по published, userID, date, title)
+	r.GET("/ads", listAds(a)) // Метод для получения списка объявлений с фильтрами (по published, userID, date, title, created_from, created_to, changed_since, filter)
 
-	r.POST("/users", createUser(a))         // Метод для создания пользователя (user)
-	r.GET("/users/:user_id", getUser(a))    // Метод для получения пользователя по ID
-	r.PUT("/users/:user_id", updateUser(a)) // Метод для обновления имени(Nickname) или почты(Email) пользователя
+	r.POST("/users", createUser(a))          // Метод для создания пользователя (user)
+	r.GET("/users/:user_id", getUser(a))     // Метод для получения пользователя по ID
+	r.PUT("/users/:user_id", updateUser(a))  // Метод для обновления имени(Nickname) или почты(Email) пользователя
+	r.PATCH("/users/:user_id", patchUser(a)) // Метод для частичного обновления пользователя (JSON Merge Patch: nickname, email)
 	r.DELETE("/users/:user_id", deleteUser(a))
+	r.POST("/users/:user_id/verification", requestEmailVerification(a)) // Метод для повторной отправки письма с подтверждением почты
+	r.POST("/verification", verifyEmail(a))                             // Метод для подтверждения почты по токену из письма
+
+	r.POST("/users/:user_id/favorites", addFavorite(a))                   // Метод для добавления объявления в избранное
+	r.GET("/users/:user_id/favorites", listFavorites(a))                  // Метод для получения избранных объявлений
+	r.DELETE("/users/:user_id/favorites/:ad_id", removeFavorite(a))       // Метод для удаления объявления из избранного
+	r.POST("/users/:user_id/searches", createSavedSearch(a))              // Метод для сохранения поиска (фильтров списка объявлений)
+	r.GET("/users/:user_id/searches", listSavedSearches(a))               // Метод для получения сохраненных поисков
+	r.DELETE("/users/:user_id/searches/:search_id", deleteSavedSearch(a)) // Метод для удаления сохраненного поиска
+	r.GET("/users/:user_id/notifications", listNotifications(a))          // Метод для получения уведомлений о новых объявлениях
+
+	r.POST("/ads/:ad_id/conversations", startConversation(a))          // Метод для начала переписки покупателя с автором объявления
+	r.GET("/users/:user_id/conversations", listConversations(a))       // Метод для получения переписок пользователя со счетчиками непрочитанных
+	r.POST("/conversations/:conversation_id/messages", sendMessage(a)) // Метод для отправки сообщения
+	r.GET("/conversations/:conversation_id/messages", listMessages(a)) // Метод для получения истории переписки (offset, limit)
+	r.POST("/users/:user_id/blocks", blockUser(a))                     // Метод для блокировки пользователя
+	r.DELETE("/users/:user_id/blocks/:blocked_id", unblockUser(a))     // Метод для снятия блокировки
+}
+
+func AdminRouter(r *gin.RouterGroup, a app.App) {
+	r.GET("/users", listUsers(a))                       // Метод для получения списка пользователей с фильтрами (role, banned, verified, nickname, email)
+	r.PUT("/users/:user_id/ban", banUser(a))            // Метод для блокировки пользователя (все его объявления снимаются с публикации)
+	r.DELETE("/users/:user_id/ban", unbanUser(a))       // Метод для снятия блокировки пользователя
+	r.PUT("/users/:user_id/role", setUserRole(a))       // Метод для изменения роли пользователя (только для администраторов)
+	r.PUT("/ads/:ad_id/unpublish", forceUnpublishAd(a)) // Метод для снятия с публикации любого объявления
+	r.DELETE("/ads/:ad_id", forceDeleteAd(a))           // Метод для удаления любого объявления
+	r.GET("/ads/flagged", listFlaggedAds(a))            // Метод для получения объявлений, ждущих проверки модератором
+	r.PUT("/ads/:ad_id/approve", approveAd(a))          // Метод для одобрения объявления, помеченного контент-политикой
+	r.GET("/features", listFeatures(a))                 // Метод для проверки флагов функциональности для пользователя (user_id)
+	r.GET("/audit", listAudit(a))                       // Метод для получения журнала аудита с фильтрами (actor_id, action, entity, entity_id, transport, from, to, limit)
+}
+
+func AppRouterV2(r *gin.RouterGroup, a app.App) {
+	r.POST("/ads", createAdV2(a))               // Метод для создания объявления
+	r.GET("/ads/:ad_id", getAdV2(a))            // Метод для получения объявления по ID
+	r.PATCH("/ads/:ad_id", updateAdV2(a))       // Метод для частичного обновления объявления (JSON Merge Patch, update_mask: title, text, published)
+	r.DELETE("/ads/:ad_id", deleteAdV2(a))      // Метод для удаления объявления (user_id в query)
+	r.GET("/ads", listAdsV2(a))                 // Метод для получения списка объявлений с фильтрами в query (published, author_id, date, title, created_from, created_to, changed_since, filter)
+	r.POST("/users", createUserV2(a))           // Метод для создания пользователя
+	r.GET("/users/:user_id", getUserV2(a))      // Метод для получения пользователя по ID
+	r.PATCH("/users/:user_id", updateUserV2(a)) // Метод для частичного обновления пользователя (JSON Merge Patch, update_mask: nickname, email)
+	r.DELETE("/users/:user_id", deleteUserV2(a))
 }
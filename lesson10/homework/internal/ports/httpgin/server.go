@@ -5,30 +5,97 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"homework10/internal/graceful"
+	"homework10/internal/logging"
+	"homework10/internal/tenant"
 )
 
 func LoggerMiddleWare(c *gin.Context) {
 	start := time.Now()
 
-	log.Printf("-- received request -- | protocol: HTTP | method: %s | path: %s\n", c.Request.Method, c.Request.URL.Path)
+	logging.Printf(logging.LevelInfo, "-- received request -- | protocol: HTTP | method: %s | path: %s\n", c.Request.Method, c.Request.URL.Path)
 
 	c.Next()
 
 	latency := time.Since(start)
 	status := c.Writer.Status()
 
-	log.Printf("-- handled request -- | protocol: HTTP | status: %d | latency: %+v | method: %s | path: %s\n", status, latency, c.Request.Method, c.Request.URL.Path)
+	logging.Printf(logging.LevelInfo, "-- handled request -- | protocol: HTTP | status: %d | latency: %+v | method: %s | path: %s\n", status, latency, c.Request.Method, c.Request.URL.Path)
+}
+
+// AdminMiddleware извлекает ID администратора из заголовка X-Admin-ID
+func AdminMiddleware(c *gin.Context) {
+	adminID, err := strconv.ParseInt(c.GetHeader(AdminHeader), 10, 64)
+	if err != nil {
+		errorResponse(c, apperr.Invalid(ErrMissingAdminID))
+		return
+	}
+	c.Set(adminIDKey, adminID)
+	c.Next()
 }
 
 func NewHTTPServer(port string, a app.App) *http.Server {
+	return NewHTTPServerWithOptions(port, a, Options{})
+}
+
+// NewHTTPServerWithTenants создает сервер, различающий арендаторов из реестра reg
+func NewHTTPServerWithTenants(port string, a app.App, reg *tenant.Registry) *http.Server {
+	return NewHTTPServerWithOptions(port, a, Options{Tenants: reg})
+}
+
+// Options - настройки HTTP-сервера
+type Options struct {
+	// Tenants - реестр арендаторов, nil - только арендатор по умолчанию
+	Tenants *tenant.Registry
+	// StringIDs включает отдачу идентификаторов строками: Snowflake ID не помещаются
+	// в 53 бита мантиссы и теряют точность в JS-клиентах
+	StringIDs bool
+	// TrustedProxies - адреса и подсети (CIDR) прокси, которым разрешено передавать адрес клиента
+	// в X-Forwarded-For и X-Real-IP, nil - заголовки не учитываются (см. ParseTrustedProxies)
+	TrustedProxies []string
+}
+
+// ParseTrustedProxies разбирает список доверенных прокси через запятую, пустая строка - нет прокси
+func ParseTrustedProxies(s string) ([]string, error) {
+	var proxies []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", p)
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies, nil
+}
+
+// NewHTTPServerWithOptions создает сервер с настройками opts
+func NewHTTPServerWithOptions(port string, a app.App, opts Options) *http.Server {
+	reg := opts.Tenants
+	if reg == nil {
+		reg, _ = tenant.NewRegistry(nil)
+	}
 	gin.SetMode(gin.ReleaseMode)
 	handler := gin.New()
+	// контекст gin отдает значения и отмену из контекста запроса, в том числе арендатора
+	handler.ContextWithFallback = true
+	// по умолчанию gin доверяет любому прокси, и адрес клиента в журнале аудита можно подделать заголовком
+	if err := handler.SetTrustedProxies(opts.TrustedProxies); err != nil {
+		log.Printf("http: ignoring trusted proxies: %s\n", err.Error())
+		_ = handler.SetTrustedProxies(nil)
+	}
+	handler.Use(IDFormatMiddleware(opts.StringIDs))
 	s := &http.Server{Addr: port, Handler: handler}
 
 	// todo: add your own logic
@@ -36,12 +103,28 @@ func NewHTTPServer(port string, a app.App) *http.Server {
 	api := handler.Group("/api/v1")
 
 	// MiddleWare для логирования и паник
-	api.Use(gin.Logger())
-	api.Use(gin.Recovery())
+	api.Use(gin.LoggerWithWriter(logging.Writer(logging.LevelInfo, gin.DefaultWriter)))
+	api.Use(RecoveryMiddleware())
 
 	api.Use(LoggerMiddleWare)
+	api.Use(AuditMiddleware())
+	api.Use(TenantMiddleware(reg))
 
 	AppRouter(api, a)
+
+	// административные методы требуют заголовок X-Admin-ID, права проверяются в приложении
+	admin := api.Group("/admin")
+	admin.Use(AdminMiddleware)
+	AdminRouter(admin, a)
+
+	// вторая версия API работает поверх того же приложения
+	v2 := handler.Group("/api/v2")
+	v2.Use(gin.LoggerWithWriter(logging.Writer(logging.LevelInfo, gin.DefaultWriter)))
+	v2.Use(RecoveryMiddleware())
+	v2.Use(LoggerMiddleWare)
+	v2.Use(AuditMiddleware())
+	v2.Use(TenantMiddleware(reg))
+	AppRouterV2(v2, a)
 	return s
 }
 
@@ -77,3 +160,32 @@ func RunHTTPServerGracefully(ctx context.Context, server *http.Server) func() er
 		}
 	}
 }
+
+// Component - сервер как компонент graceful.Manager. Если lis не задан, адрес слушается при запуске,
+// поэтому занятый порт обнаруживается до того, как сервис объявит готовность
+func Component(server *http.Server, lis net.Listener) graceful.Component {
+	return graceful.Component{
+		Name:  "http server",
+		Phase: graceful.PhaseStopServers,
+		Start: func(context.Context) error {
+			if lis != nil {
+				return nil
+			}
+			var err error
+			lis, err = net.Listen("tcp", server.Addr)
+			return err
+		},
+		Run: func(context.Context) error {
+			log.Printf("starting http server, listening on %s\n", lis.Addr())
+			if err := server.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("http server can't listen and serve requests: %w", err)
+			}
+			return nil
+		},
+		// Shutdown ждет завершения обрабатываемых запросов
+		Stop: server.Shutdown,
+		Force: func() {
+			_ = server.Close()
+		},
+	}
+}
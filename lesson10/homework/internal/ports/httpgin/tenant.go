@@ -0,0 +1,27 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/tenant"
+)
+
+// TenantHeader задает арендатора запроса явно, без него арендатор определяется по заголовку Host
+const TenantHeader = "X-Tenant-ID"
+
+// TenantMiddleware определяет арендатора, проверяет его лимит запросов и кладет арендатора
+// в контекст запроса, откуда его получают приложение и репозиторий
+func TenantMiddleware(reg *tenant.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, err := reg.Resolve(c.GetHeader(TenantHeader), c.Request.Host)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		if err := reg.Allow(t); err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), t))
+		c.Next()
+	}
+}
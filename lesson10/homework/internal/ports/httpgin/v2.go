@@ -0,0 +1,295 @@
+package httpgin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Вторая версия HTTP API: время в RFC 3339, фильтры списка в query-параметрах,
+// частичные обновления PATCH в формате JSON Merge Patch. Параметр update_mask (поля через запятую)
+// ограничивает изменяемые поля, а поля маски, отсутствующие в теле, сбрасываются.
+
+var ErrInvalidUpdateMask = errors.New("invalid update mask")
+
+type createAdRequestV2 struct {
+	Title    string    `json:"title"`
+	Text     string    `json:"text"`
+	AuthorID requestID `json:"author_id"`
+}
+
+type listAdsQueryV2 struct {
+	Published    *bool   `form:"published"`
+	AuthorID     *int64  `form:"author_id"`
+	Date         *string `form:"date"`
+	Title        *string `form:"title"`
+	CreatedFrom  *string `form:"created_from"`
+	CreatedTo    *string `form:"created_to"`
+	ChangedSince *string `form:"changed_since"`
+	Filter       *string `form:"filter"`
+}
+
+type adResponseV2 struct {
+	ID        jsonID     `json:"id"`
+	Title     string     `json:"title"`
+	Text      string     `json:"text"`
+	AuthorID  jsonID     `json:"author_id"`
+	Published bool       `json:"published"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Flags     []string   `json:"flags,omitempty"`
+}
+
+func newAdResponseV2(f idFormat, ad *ads.Ad) adResponseV2 {
+	return adResponseV2{
+		ID:        f.id(ad.ID),
+		Title:     ad.Title,
+		Text:      ad.Text,
+		AuthorID:  f.id(ad.AuthorID),
+		Published: ad.Published,
+		CreatedAt: ad.DateCreated,
+		UpdatedAt: ad.DateChanged,
+		PublishAt: ad.PublishAt,
+		ExpiresAt: ad.ExpiresAt,
+		Flags:     ad.Flags,
+	}
+}
+
+func AdSuccessResponseV2(f idFormat, ad *ads.Ad) *gin.H {
+	return &gin.H{
+		"data": newAdResponseV2(f, ad),
+	}
+}
+
+func AdListSuccessResponseV2(f idFormat, al *ads.AdList) *gin.H {
+	data := make([]adResponseV2, 0, len(al.Data))
+	for i := range al.Data {
+		data = append(data, newAdResponseV2(f, &al.Data[i]))
+	}
+	return &gin.H{
+		"data": data,
+	}
+}
+
+// updateMask разбирает параметр update_mask, без него возвращается nil и изменяются поля из тела
+func updateMask(c *gin.Context, allowed ...string) (map[string]bool, error) {
+	mask := c.Query("update_mask")
+	if mask == "" {
+		return nil, nil
+	}
+	paths := make(map[string]bool, len(allowed))
+	for _, p := range strings.Split(mask, ",") {
+		p = strings.TrimSpace(p)
+		known := false
+		for _, a := range allowed {
+			known = known || p == a
+		}
+		if !known {
+			return nil, apperr.Invalid(fmt.Errorf("%w: unknown field %q", ErrInvalidUpdateMask, p))
+		}
+		paths[p] = true
+	}
+	return paths, nil
+}
+
+func idParam(c *gin.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		return 0, apperr.Invalid(err)
+	}
+	return id, nil
+}
+
+// Метод для создания объявления
+func createAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createAdRequestV2
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+		ad, err := a.CreateAd(c, reqBody.Title, reqBody.Text, int64(reqBody.AuthorID))
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, AdSuccessResponseV2(responseIDs(c), ad))
+	}
+}
+
+// Метод для получения объявления по id
+func getAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := idParam(c, "ad_id")
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		ad, err := a.GetAd(c, id)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponseV2(responseIDs(c), ad))
+	}
+}
+
+// Метод для частичного обновления объявления: title, text и published
+func updateAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := idParam(c, "ad_id")
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		paths, err := updateMask(c, adPatchFields...)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		ad, err := patchAdRequest(c, a, id, paths)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponseV2(responseIDs(c), ad))
+	}
+}
+
+// Метод для удаления объявления, автор передается в query-параметре user_id
+func deleteAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := idParam(c, "ad_id")
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		uid, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(ErrParameterNotFound))
+			return
+		}
+		if err := a.DeleteAd(c, id, uid); err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Метод для получения списка объявлений с фильтрами в query-параметрах
+func listAdsV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query listAdsQueryV2
+		if err := c.ShouldBindQuery(&query); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+		date, err := app.ParseDate(query.Date)
+		if err != nil {
+			errorResponse(c, apperr.Invalid(err))
+			return
+		}
+		params := app.ListAdsParams{
+			Published: query.Published,
+			Uid:       query.AuthorID,
+			Date:      date,
+			Title:     query.Title,
+		}
+		if err := parseTimeFilters(&params, query.CreatedFrom, query.CreatedTo, query.ChangedSince); err != nil {
+			errorResponse(c, err)
+			return
+		}
+		if params.Filter, err = app.ParseFilter(query.Filter); err != nil {
+			errorResponse(c, apperr.InvalidFields(err, []apperr.FieldViolation{{Field: "filter", Description: err.Error()}}))
+			return
+		}
+		al, err := a.ListAds(c, params)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdListSuccessResponseV2(responseIDs(c), al))
+	}
+}
+
+// Метод для создания пользователя
+func createUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createUserRequest
+		if err := c.ShouldBind(&reqBody); err != nil {
+			errorResponse(c, bindError(err))
+			return
+		}
+		u, err := a.CreateUser(c, reqBody.Nickname, reqBody.Email)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, UserSuccessResponse(responseIDs(c), u))
+	}
+}
+
+// Метод для получения пользователя по id
+func getUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := idParam(c, "user_id")
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		u, err := a.GetUser(c, id)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(responseIDs(c), u))
+	}
+}
+
+// Метод для частичного обновления пользователя: nickname и email
+func updateUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := idParam(c, "user_id")
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		paths, err := updateMask(c, userPatchFields...)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		u, err := patchUserRequest(c, a, id, paths)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(responseIDs(c), u))
+	}
+}
+
+// Метод для удаления пользователя вместе с его объявлениями
+func deleteUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := idParam(c, "user_id")
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		if err := a.DeleteUser(c, id); err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,97 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"homework10/internal/capture"
+)
+
+// Compare возвращает расхождения полученного ответа с записанным: статус, сообщение
+// ошибки gRPC и тело ответа с точностью до порядка ключей и игнорируемых полей
+func (r *Replayer) Compare(want capture.Entry, got capture.Entry) []string {
+	var diffs []string
+	if want.Status != got.Status {
+		diffs = append(diffs, fmt.Sprintf("status: want %d, got %d", want.Status, got.Status))
+	}
+	if want.Error != got.Error {
+		diffs = append(diffs, fmt.Sprintf("error: want %q, got %q", want.Error, got.Error))
+	}
+	if want.Response == nil || got.Response == nil {
+		if !bytes.Equal(want.ResponseBody(), got.ResponseBody()) {
+			diffs = append(diffs, fmt.Sprintf("body: want %s, got %s", want.ResponseBody(), got.ResponseBody()))
+		}
+		return diffs
+	}
+	var w, g interface{}
+	_ = json.Unmarshal(want.Response, &w)
+	_ = json.Unmarshal(got.Response, &g)
+	return r.compareJSON("$", w, g, diffs)
+}
+
+func (r *Replayer) compareJSON(path string, want interface{}, got interface{}, diffs []string) []string {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return append(diffs, fmt.Sprintf("%s: want %s, got %s", path, encode(want), encode(got)))
+		}
+		for _, k := range keys(w, g) {
+			if r.ignore[k] {
+				continue
+			}
+			wv, wok := w[k]
+			gv, gok := g[k]
+			switch {
+			case !gok:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: missing, want %s", path, k, encode(wv)))
+			case !wok:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: unexpected %s", path, k, encode(gv)))
+			default:
+				diffs = r.compareJSON(path+"."+k, wv, gv, diffs)
+			}
+		}
+		return diffs
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			return append(diffs, fmt.Sprintf("%s: want %s, got %s", path, encode(want), encode(got)))
+		}
+		if len(w) != len(g) {
+			return append(diffs, fmt.Sprintf("%s: want %d elements, got %d", path, len(w), len(g)))
+		}
+		for i := range w {
+			diffs = r.compareJSON(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], diffs)
+		}
+		return diffs
+	default:
+		if encode(want) != encode(got) {
+			return append(diffs, fmt.Sprintf("%s: want %s, got %s", path, encode(want), encode(got)))
+		}
+		return diffs
+	}
+}
+
+// keys возвращает объединение ключей двух объектов в алфавитном порядке
+func keys(a map[string]interface{}, b map[string]interface{}) []string {
+	set := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		set[k] = struct{}{}
+	}
+	for k := range b {
+		set[k] = struct{}{}
+	}
+	list := make([]string, 0, len(set))
+	for k := range set {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func encode(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
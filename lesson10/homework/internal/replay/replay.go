@@ -0,0 +1,302 @@
+// Package replay воспроизводит записанные пакетом capture запросы на новом приложении
+// и сравнивает ответы с записанными.
+//
+// HTTP-запросы обрабатывает тот же обработчик gin, что и в сервисе, без сети; вызовы gRPC идут
+// через сервер в памяти (bufconn) с теми же сервисами и перехватчиками, влияющими на ответ
+// (настройки времени выполнения, аудит, арендаторы), что и в cmd/main. Настройки сервиса,
+// от которых зависят ответы, передаются в Options.
+// Ответы сравниваются как JSON, поля со временем по умолчанию не сравниваются.
+// Воспроизведение не повторяет значения, которые сервис генерирует случайно (токены
+// подтверждения почты), и не выдерживает исходные паузы между запросами, поэтому строгие
+// лимиты запросов арендаторов могут дать расхождения.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"homework10/internal/app"
+	"homework10/internal/capture"
+	"homework10/internal/config"
+	grpcPort "homework10/internal/ports/grpc"
+	grpcPortV2 "homework10/internal/ports/grpc/v2"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/tenant"
+)
+
+var ErrUnknownMethod = errors.New("unknown grpc method")
+
+// DefaultIgnore - поля ответов, зависящие от времени воспроизведения
+var DefaultIgnore = []string{
+	"date_created", "date_changed", "date_sent", "expires_at",
+	"created_at", "updated_at", "create_time", "update_time", "expire_time", "time",
+}
+
+// Options - настройки записывавшего сервиса, от которых зависят ответы
+type Options struct {
+	Tenants   *tenant.Registry // арендаторы, nil - только арендатор по умолчанию
+	Config    *config.Store    // настройки времени выполнения (ADS_RUNTIME_CONFIG), nil - по умолчанию
+	StringIDs bool             // сервис отдавал ID строками (ADS_STRING_IDS)
+	Ignore    []string         // поля ответов, которые не сравниваются, nil - DefaultIgnore
+}
+
+// Replayer воспроизводит записи на одном приложении
+type Replayer struct {
+	http    http.Handler
+	server  *grpc.Server
+	lis     *bufconn.Listener
+	conn    *grpc.ClientConn
+	tenants *tenant.Registry
+	ignore  map[string]bool
+}
+
+// New поднимает HTTP-обработчик и сервер gRPC поверх приложения a
+func New(a app.App, opts Options) (*Replayer, error) {
+	tenants := opts.Tenants
+	if tenants == nil {
+		var err error
+		if tenants, err = tenant.NewRegistry(nil); err != nil {
+			return nil, err
+		}
+	}
+	store := opts.Config
+	if store == nil {
+		store = config.NewStore(nil)
+	}
+	ignore := opts.Ignore
+	if ignore == nil {
+		ignore = DefaultIgnore
+	}
+	server := httpgin.NewHTTPServerWithOptions("", a, httpgin.Options{Tenants: tenants, StringIDs: opts.StringIDs})
+	r := &Replayer{
+		http:    httpgin.ConfigHandler(store, server.Handler),
+		lis:     bufconn.Listen(1024 * 1024),
+		tenants: tenants,
+		ignore:  make(map[string]bool, len(ignore)),
+	}
+	for _, f := range ignore {
+		r.ignore[f] = true
+	}
+
+	r.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			grpcPort.UnaryRecoveryInterceptor(),
+			grpcPort.UnaryLocaleInterceptor(),
+			grpcPort.UnaryConfigInterceptor(store),
+			grpcPort.UnaryAuditInterceptor(),
+			grpcPort.UnaryTenantInterceptor(tenants),
+		),
+	)
+	grpcPort.RegisterAdServiceServer(r.server, grpcPort.NewService(a))
+	grpcPort.RegisterAdminServiceServer(r.server, grpcPort.NewAdminService(a))
+	grpcPortV2.RegisterAdServiceServer(r.server, grpcPortV2.NewService(a))
+	go func() {
+		_ = r.server.Serve(r.lis)
+	}()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return r.lis.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		r.server.Stop()
+		return nil, err
+	}
+	r.conn = conn
+	return r, nil
+}
+
+// Close останавливает сервер gRPC
+func (r *Replayer) Close() error {
+	err := r.conn.Close()
+	r.server.Stop()
+	return err
+}
+
+// Diff - расхождение ответа на одну запись
+type Diff struct {
+	Seq         int64
+	Protocol    string
+	Method      string
+	Path        string
+	Differences []string
+}
+
+// Report - итог воспроизведения
+type Report struct {
+	Total   int
+	Matched int
+	Diffs   []Diff
+}
+
+// OK сообщает, что все ответы совпали с записанными
+func (r Report) OK() bool {
+	return len(r.Diffs) == 0
+}
+
+// Write печатает отчет в читаемом виде
+func (r Report) Write(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "replayed %d requests: %d matched, %d differ\n", r.Total, r.Matched, len(r.Diffs))
+	for _, d := range r.Diffs {
+		target := d.Path
+		if target == "" {
+			target = d.Method
+		} else {
+			target = d.Method + " " + target
+		}
+		fmt.Fprintf(&b, "#%d %s %s\n", d.Seq, d.Protocol, target)
+		for _, s := range d.Differences {
+			fmt.Fprintf(&b, "    %s\n", s)
+		}
+	}
+	_, err := w.Write([]byte(b.String()))
+	return err
+}
+
+// Run воспроизводит записи по порядку Seq; ошибка означает, что запись воспроизвести нельзя
+func (r *Replayer) Run(ctx context.Context, entries []capture.Entry) (Report, error) {
+	var report Report
+	for _, e := range entries {
+		got, err := r.Replay(ctx, e)
+		if err != nil {
+			return report, fmt.Errorf("entry %d: %w", e.Seq, err)
+		}
+		report.Total++
+		if diffs := r.Compare(e, got); len(diffs) > 0 {
+			report.Diffs = append(report.Diffs, Diff{Seq: e.Seq, Protocol: e.Protocol, Method: e.Method, Path: e.Path, Differences: diffs})
+			continue
+		}
+		report.Matched++
+	}
+	return report, nil
+}
+
+// Replay выполняет запрос записи и возвращает полученный ответ в виде записи
+func (r *Replayer) Replay(ctx context.Context, e capture.Entry) (capture.Entry, error) {
+	switch e.Protocol {
+	case capture.ProtocolHTTP:
+		return r.replayHTTP(e), nil
+	case capture.ProtocolGRPC:
+		return r.replayGRPC(ctx, e)
+	default:
+		return capture.Entry{}, fmt.Errorf("%w: unknown protocol %q", capture.ErrInvalidEntry, e.Protocol)
+	}
+}
+
+func (r *Replayer) replayHTTP(e capture.Entry) capture.Entry {
+	req := httptest.NewRequest(e.Method, e.Path, bytes.NewReader(e.RequestBody()))
+	for k, v := range e.Header {
+		if k == "Host" {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	r.http.ServeHTTP(rec, req)
+
+	got := capture.Entry{Seq: e.Seq, Protocol: e.Protocol, Method: e.Method, Path: e.Path, Status: rec.Code}
+	got.SetResponse(rec.Body.Bytes())
+	return got
+}
+
+func (r *Replayer) replayGRPC(ctx context.Context, e capture.Entry) (capture.Entry, error) {
+	method, err := lookupMethod(e.Method)
+	if err != nil {
+		return capture.Entry{}, err
+	}
+	in, err := newMessage(method.Input())
+	if err != nil {
+		return capture.Entry{}, err
+	}
+	if body := e.RequestBody(); len(body) > 0 {
+		if err := protojson.Unmarshal(body, in); err != nil {
+			return capture.Entry{}, fmt.Errorf("%w: %s", capture.ErrInvalidEntry, err.Error())
+		}
+	}
+	out, err := newMessage(method.Output())
+	if err != nil {
+		return capture.Entry{}, err
+	}
+
+	ctx = metadata.NewOutgoingContext(ctx, r.metadata(e))
+	callErr := r.conn.Invoke(ctx, e.Method, in, out)
+
+	st := status.Convert(callErr)
+	got := capture.Entry{Seq: e.Seq, Protocol: e.Protocol, Method: e.Method, Status: int(st.Code()), Error: st.Message()}
+	if callErr == nil {
+		body, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(out)
+		if err != nil {
+			return capture.Entry{}, err
+		}
+		got.SetResponse(body)
+	}
+	return got, nil
+}
+
+// metadata переносит записанные метаданные в вызов. :authority задать клиентом нельзя,
+// поэтому арендатор, определенный по нему при записи, передается явно через x-tenant-id
+func (r *Replayer) metadata(e capture.Entry) metadata.MD {
+	md := metadata.MD{}
+	for k, v := range e.Header {
+		if k == ":authority" {
+			continue
+		}
+		md.Set(k, v)
+	}
+	if authority := e.Header[":authority"]; authority != "" && e.Header[grpcPort.TenantMetadataKey] == "" {
+		if t, err := r.tenants.Resolve("", authority); err == nil {
+			md.Set(grpcPort.TenantMetadataKey, string(t.ID))
+		}
+	}
+	return md
+}
+
+// lookupMethod находит описание метода по полному имени вида /package.Service/Method
+func lookupMethod(fullMethod string) (protoreflect.MethodDescriptor, error) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, fullMethod)
+	}
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name[:i]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, fullMethod)
+	}
+	svc, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, fullMethod)
+	}
+	m := svc.Methods().ByName(protoreflect.Name(name[i+1:]))
+	if m == nil || m.IsStreamingClient() || m.IsStreamingServer() {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, fullMethod)
+	}
+	return m, nil
+}
+
+func newMessage(d protoreflect.MessageDescriptor) (proto.Message, error) {
+	t, err := protoregistry.GlobalTypes.FindMessageByName(d.FullName())
+	if err != nil {
+		return nil, err
+	}
+	return t.New().Interface(), nil
+}
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/audit"
+	"homework10/internal/graceful"
+	"homework10/internal/tenant"
+)
+
+const DefaultInterval = time.Second
+
+// Scheduler периодически публикует отложенные объявления и снимает с публикации истекшие.
+// Расписание хранится в самих объявлениях, поэтому после перезапуска
+// все пропущенные события обрабатываются на первом же тике.
+type Scheduler struct {
+	repo     app.Repository
+	matcher  *app.Matcher
+	audit    audit.Sink // nil - изменения не записываются в журнал аудита
+	interval time.Duration
+}
+
+func New(repo app.Repository, interval time.Duration) *Scheduler {
+	return NewWithAudit(repo, nil, interval)
+}
+
+// NewWithAudit создает планировщик, записывающий публикации и снятия с публикации в журнал аудита
+// от имени audit.SystemActorID
+func NewWithAudit(repo app.Repository, sink audit.Sink, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Scheduler{repo: repo, matcher: app.NewMatcher(repo), audit: sink, interval: interval}
+}
+
+// Tick обрабатывает все события расписания, наступившие к моменту now. Если репозиторий
+// разделяет данные арендаторов, расписание обрабатывается у каждого из них.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) error {
+	lister, ok := s.repo.(tenant.Lister)
+	if !ok {
+		return s.tick(ctx, now)
+	}
+	var firstErr error
+	for _, id := range lister.Tenants() {
+		if err := s.tick(tenant.NewContext(ctx, tenant.Tenant{ID: id}), now); err != nil {
+			log.Printf("scheduler: tick failed for tenant %s: %s\n", id, err.Error())
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (s *Scheduler) tick(ctx context.Context, now time.Time) error {
+	published, err := s.repo.PublishScheduledAds(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, c := range published {
+		// объявление, срок которого истек раньше времени публикации, только теряет расписание
+		if !c.After.Published {
+			log.Printf("scheduler: ad %d expired before publication\n", c.After.ID)
+			s.record(ctx, audit.ActionExpire, c)
+			continue
+		}
+		log.Printf("scheduler: published ad %d\n", c.After.ID)
+		s.record(ctx, audit.ActionScheduledPublish, c)
+		if err := s.matcher.AdPublished(ctx, c.After); err != nil {
+			log.Printf("scheduler: failed to match ad %d against saved searches: %s\n", c.After.ID, err.Error())
+		}
+	}
+
+	expired, err := s.repo.ExpireAds(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, c := range expired {
+		log.Printf("scheduler: ad %d expired\n", c.After.ID)
+		s.record(ctx, audit.ActionExpire, c)
+	}
+	return nil
+}
+
+// record пишет изменение объявления в журнал аудита
+func (s *Scheduler) record(ctx context.Context, action audit.Action, c ads.Change) {
+	if s.audit == nil {
+		return
+	}
+	app.RecordSystemAd(ctx, s.audit, action, &c.Before, &c.After)
+}
+
+func RunSchedulerGracefully(ctx context.Context, s *Scheduler) func() error {
+	return func() error {
+		log.Printf("starting scheduler, interval %v\n", s.interval)
+		defer log.Println("close scheduler")
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.Tick(ctx, time.Now().UTC()); err != nil {
+				log.Printf("scheduler tick failed: %s\n", err.Error())
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// Component - планировщик как фоновая задача graceful.Manager: при остановке
+// текущий тик дорабатывает до конца
+func Component(s *Scheduler) graceful.Component {
+	return graceful.Worker("scheduler", graceful.PhaseFlushWorkers, func(ctx context.Context) error {
+		return RunSchedulerGracefully(ctx, s)()
+	})
+}
@@ -0,0 +1,202 @@
+package tenant
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry хранит настройки арендаторов и их ограничители частоты запросов.
+// Арендатор по умолчанию зарегистрирован всегда.
+type Registry struct {
+	mu       sync.RWMutex
+	tenants  map[ID]Config
+	hosts    map[string]ID
+	limiters map[ID]*bucket
+}
+
+// NewRegistry создает реестр; настройки арендатора по умолчанию можно переопределить, указав Default
+func NewRegistry(tenants map[ID]Config) (*Registry, error) {
+	r := &Registry{
+		tenants:  map[ID]Config{Default: {}},
+		hosts:    make(map[string]ID),
+		limiters: make(map[ID]*bucket),
+	}
+	for id, cfg := range tenants {
+		if _, err := ParseID(string(id)); err != nil {
+			return nil, fmt.Errorf("%w: %q", err, id)
+		}
+		for _, h := range cfg.Hosts {
+			h = hostKey(h)
+			if other, ok := r.hosts[h]; ok && other != id {
+				return nil, fmt.Errorf("host %q is assigned to tenants %q and %q", h, other, id)
+			}
+			r.hosts[h] = id
+		}
+		r.tenants[id] = cfg
+	}
+	return r, nil
+}
+
+// Replace атомарно заменяет арендаторов и их настройки настройками из next, например
+// при перезагрузке файла арендаторов. Ограничители частоты сохраняются и перестраиваются
+// при первом запросе, если лимит арендатора изменился; ограничители удаленных арендаторов сбрасываются
+func (r *Registry) Replace(next *Registry) {
+	next.mu.RLock()
+	tenants := make(map[ID]Config, len(next.tenants))
+	for id, cfg := range next.tenants {
+		tenants[id] = cfg
+	}
+	hosts := make(map[string]ID, len(next.hosts))
+	for h, id := range next.hosts {
+		hosts[h] = id
+	}
+	next.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tenants, r.hosts = tenants, hosts
+	for id := range r.limiters {
+		if _, ok := tenants[id]; !ok {
+			delete(r.limiters, id)
+		}
+	}
+}
+
+// fileConfig - формат файла арендаторов:
+//
+//	tenants:
+//	  cars:
+//	    hosts: [cars.example.com]
+//	    limits: {max_title_length: 50}
+//	    rate_limit: {rps: 10, burst: 20}
+type fileConfig struct {
+	Tenants map[ID]Config `yaml:"tenants"`
+}
+
+// Parse читает реестр из YAML
+func Parse(r io.Reader) (*Registry, error) {
+	var cfg fileConfig
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("parse tenants config: %w", err)
+	}
+	return NewRegistry(cfg.Tenants)
+}
+
+// Load читает реестр из YAML-файла
+func Load(path string) (*Registry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// hostKey отбрасывает порт и приводит имя хоста к нижнему регистру
+func hostKey(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// Lookup возвращает арендатора с его настройками
+func (r *Registry) Lookup(id ID) (Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cfg, ok := r.tenants[id]
+	if !ok {
+		return Tenant{}, fmt.Errorf("%w: %q", ErrUnknownTenant, id)
+	}
+	return Tenant{ID: id, Config: cfg}, nil
+}
+
+// Resolve определяет арендатора запроса: явно указанный идентификатор важнее имени хоста,
+// а запрос к незнакомому хосту без идентификатора относится к арендатору по умолчанию
+func (r *Registry) Resolve(id string, host string) (Tenant, error) {
+	if id != "" {
+		parsed, err := ParseID(id)
+		if err != nil {
+			return Tenant{}, fmt.Errorf("%w: %q", err, id)
+		}
+		return r.Lookup(parsed)
+	}
+	r.mu.RLock()
+	byHost, ok := r.hosts[hostKey(host)]
+	r.mu.RUnlock()
+	if !ok {
+		byHost = Default
+	}
+	return r.Lookup(byHost)
+}
+
+// IDs возвращает идентификаторы всех арендаторов в порядке возрастания
+func (r *Registry) IDs() []ID {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]ID, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Allow расходует один запрос из лимита арендатора
+func (r *Registry) Allow(t Tenant) error {
+	limit := t.Config.RateLimit
+	if limit.RPS <= 0 {
+		return nil
+	}
+	now := time.Now()
+	r.mu.Lock()
+	b, ok := r.limiters[t.ID]
+	if !ok || b.limit != limit {
+		b = newBucket(limit, now)
+		r.limiters[t.ID] = b
+	}
+	allowed := b.take(now)
+	r.mu.Unlock()
+	if !allowed {
+		return fmt.Errorf("%w: %q", ErrRateLimited, t.ID)
+	}
+	return nil
+}
+
+// bucket - token bucket: RPS токенов в секунду, не больше Burst (минимум один) в запасе
+type bucket struct {
+	limit  RateLimit
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(limit RateLimit, now time.Time) *bucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{limit: limit, burst: burst, tokens: burst, last: now}
+}
+
+func (b *bucket) take(now time.Time) bool {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.limit.RPS
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,87 @@
+// Package tenant - арендаторы сервиса: несколько независимых площадок объявлений в одном развертывании.
+//
+// Транспорт определяет арендатора запроса (по заголовку, метаданным gRPC или имени хоста),
+// кладет его в контекст, и дальше контекст несет его в приложение и репозиторий.
+// Запросы без арендатора относятся к арендатору по умолчанию.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// ID - идентификатор арендатора
+type ID string
+
+// Default - арендатор запросов, в которых арендатор не указан
+const Default ID = "default"
+
+var (
+	ErrInvalidID     = errors.New("invalid tenant id")
+	ErrUnknownTenant = errors.New("unknown tenant")
+	ErrRateLimited   = errors.New("tenant request rate limit exceeded")
+)
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ParseID проверяет формат идентификатора: строчные латинские буквы, цифры, '-' и '_'
+func ParseID(s string) (ID, error) {
+	if !idPattern.MatchString(s) {
+		return "", ErrInvalidID
+	}
+	return ID(s), nil
+}
+
+// Limits - ограничения валидации арендатора, дополняющие общие ограничения моделей.
+// Нулевое значение не добавляет ограничений.
+type Limits struct {
+	MaxTitleLength    int `yaml:"max_title_length"`
+	MaxTextLength     int `yaml:"max_text_length"`
+	MaxNicknameLength int `yaml:"max_nickname_length"`
+}
+
+// RateLimit - ограничение частоты запросов арендатора (token bucket), нулевой RPS - без ограничения
+type RateLimit struct {
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+// Config - настройки одного арендатора
+type Config struct {
+	Hosts     []string  `yaml:"hosts"`
+	Limits    Limits    `yaml:"limits"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+}
+
+// Tenant - арендатор запроса вместе с его настройками
+type Tenant struct {
+	ID     ID
+	Config Config
+}
+
+type contextKey struct{}
+
+// NewContext возвращает контекст, несущий арендатора t
+func NewContext(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext возвращает арендатора из контекста, без него - арендатора по умолчанию без ограничений
+func FromContext(ctx context.Context) Tenant {
+	if t, ok := ctx.Value(contextKey{}).(Tenant); ok {
+		return t
+	}
+	return Tenant{ID: Default}
+}
+
+// IDFromContext - сокращение для FromContext(ctx).ID
+func IDFromContext(ctx context.Context) ID {
+	return FromContext(ctx).ID
+}
+
+// Lister перечисляет арендаторов, у которых есть данные. Его реализуют хранилища,
+// разделяющие данные арендаторов, чтобы фоновые задачи обходили всех арендаторов.
+type Lister interface {
+	Tenants() []ID
+}
@@ -0,0 +1,312 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/user"
+	"testing"
+	"time"
+)
+
+type AdminSuite struct {
+	suite.Suite
+	Repo    *adrepo.RepositoryMap
+	App     app.App
+	Ctx     context.Context
+	AdminID int64
+	ModerID int64
+	UserID  int64
+}
+
+func (suite *AdminSuite) SetupTest() {
+	suite.Ctx = context.Background()
+	suite.Repo = adrepo.NewRepositoryMap()
+	suite.App = app.NewApp(suite.Repo)
+
+	var err error
+	suite.AdminID, err = suite.Repo.AddUser(suite.Ctx, user.User{Nickname: "admin", Email: "admin@ads.com", Role: user.RoleAdmin})
+	suite.NoError(err)
+	moder, err := suite.App.CreateUser(suite.Ctx, "J.Cole", "foresthill@drive.com")
+	suite.NoError(err)
+	suite.ModerID = moder.ID
+	_, err = suite.App.SetUserRole(suite.Ctx, suite.AdminID, suite.ModerID, user.RoleModerator)
+	suite.NoError(err)
+	u, err := suite.App.CreateUser(suite.Ctx, "Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	suite.UserID = u.ID
+}
+
+func (suite *AdminSuite) TestBanUser() {
+	published, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.NoError(err)
+	_, err = suite.App.ChangeAdStatus(suite.Ctx, published.ID, suite.UserID, true)
+	suite.NoError(err)
+	scheduled, err := suite.App.CreateAd(suite.Ctx, "Swimming", "Self Care", suite.UserID)
+	suite.NoError(err)
+	publishAt := time.Now().UTC().Add(time.Hour)
+	_, err = suite.App.ScheduleAd(suite.Ctx, scheduled.ID, suite.UserID, &publishAt, 0)
+	suite.NoError(err)
+
+	u, err := suite.App.BanUser(suite.Ctx, suite.ModerID, suite.UserID)
+	suite.NoError(err)
+	suite.True(u.Banned)
+
+	ad, err := suite.App.GetAd(suite.Ctx, published.ID)
+	suite.NoError(err)
+	suite.False(ad.Published)
+	ad, err = suite.App.GetAd(suite.Ctx, scheduled.ID)
+	suite.NoError(err)
+	suite.Nil(ad.PublishAt)
+
+	_, err = suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.ErrorIs(err, app.ErrBanned)
+	_, err = suite.App.ChangeAdStatus(suite.Ctx, published.ID, suite.UserID, true)
+	suite.ErrorIs(err, app.ErrForbidden)
+	_, err = suite.App.ScheduleAd(suite.Ctx, scheduled.ID, suite.UserID, &publishAt, 0)
+	suite.ErrorIs(err, app.ErrBanned)
+	// снять объявление с публикации заблокированный пользователь может
+	_, err = suite.App.ChangeAdStatus(suite.Ctx, published.ID, suite.UserID, false)
+	suite.NoError(err)
+
+	u, err = suite.App.UnbanUser(suite.Ctx, suite.ModerID, suite.UserID)
+	suite.NoError(err)
+	suite.False(u.Banned)
+	_, err = suite.App.ChangeAdStatus(suite.Ctx, published.ID, suite.UserID, true)
+	suite.NoError(err)
+}
+
+func (suite *AdminSuite) TestBanUser_Messaging() {
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.ModerID)
+	suite.NoError(err)
+	_, err = suite.App.ChangeAdStatus(suite.Ctx, ad.ID, suite.ModerID, true)
+	suite.NoError(err)
+	conv, err := suite.App.StartConversation(suite.Ctx, ad.ID, suite.UserID)
+	suite.NoError(err)
+
+	_, err = suite.App.BanUser(suite.Ctx, suite.AdminID, suite.UserID)
+	suite.NoError(err)
+
+	_, err = suite.App.SendMessage(suite.Ctx, conv.ID, suite.UserID, "Hello?")
+	suite.ErrorIs(err, app.ErrBanned)
+	_, err = suite.App.StartConversation(suite.Ctx, ad.ID, suite.UserID)
+	suite.ErrorIs(err, app.ErrBanned)
+}
+
+func (suite *AdminSuite) TestPermissions() {
+	_, err := suite.App.ListUsers(suite.Ctx, suite.UserID, app.ListUsersParams{})
+	suite.ErrorIs(err, app.ErrForbidden)
+	_, err = suite.App.BanUser(suite.Ctx, suite.UserID, suite.ModerID)
+	suite.ErrorIs(err, app.ErrForbidden)
+	_, err = suite.App.ForceUnpublishAd(suite.Ctx, suite.UserID, 0)
+	suite.ErrorIs(err, app.ErrForbidden)
+	_, err = suite.App.ListUsers(suite.Ctx, 100, app.ListUsersParams{})
+	suite.ErrorIs(err, app.ErrUserNotFound)
+
+	// модератор не может блокировать равных и старших по роли
+	_, err = suite.App.BanUser(suite.Ctx, suite.ModerID, suite.AdminID)
+	suite.ErrorIs(err, app.ErrForbidden)
+	_, err = suite.App.BanUser(suite.Ctx, suite.ModerID, suite.ModerID)
+	suite.ErrorIs(err, app.ErrForbidden)
+	_, err = suite.App.SetUserRole(suite.Ctx, suite.ModerID, suite.UserID, user.RoleModerator)
+	suite.ErrorIs(err, app.ErrForbidden)
+
+	_, err = suite.App.SetUserRole(suite.Ctx, suite.AdminID, suite.AdminID, user.RoleUser)
+	suite.ErrorIs(err, app.ErrForbidden)
+	_, err = suite.App.SetUserRole(suite.Ctx, suite.AdminID, suite.UserID, user.Role(42))
+	suite.ErrorIs(err, user.ErrInvalidRole)
+	_, err = suite.App.SetUserRole(suite.Ctx, suite.AdminID, 100, user.RoleModerator)
+	suite.ErrorIs(err, app.ErrUserNotFound)
+
+	// заблокированный модератор теряет права
+	_, err = suite.App.BanUser(suite.Ctx, suite.AdminID, suite.ModerID)
+	suite.NoError(err)
+	_, err = suite.App.ListUsers(suite.Ctx, suite.ModerID, app.ListUsersParams{})
+	suite.ErrorIs(err, app.ErrForbidden)
+}
+
+func (suite *AdminSuite) TestForceUnpublishAndDelete() {
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.NoError(err)
+	_, err = suite.App.ChangeAdStatus(suite.Ctx, ad.ID, suite.UserID, true)
+	suite.NoError(err)
+
+	res, err := suite.App.ForceUnpublishAd(suite.Ctx, suite.ModerID, ad.ID)
+	suite.NoError(err)
+	suite.False(res.Published)
+	res, err = suite.App.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.False(res.Published)
+
+	suite.NoError(suite.App.ForceDeleteAd(suite.Ctx, suite.ModerID, ad.ID))
+	_, err = suite.App.GetAd(suite.Ctx, ad.ID)
+	suite.ErrorIs(err, app.ErrAdNotFound)
+	suite.ErrorIs(suite.App.ForceDeleteAd(suite.Ctx, suite.ModerID, ad.ID), app.ErrAdNotFound)
+	_, err = suite.App.ForceUnpublishAd(suite.Ctx, suite.ModerID, ad.ID)
+	suite.ErrorIs(err, app.ErrAdNotFound)
+}
+
+func (suite *AdminSuite) TestListUsers() {
+	ul, err := suite.App.ListUsers(suite.Ctx, suite.ModerID, app.ListUsersParams{})
+	suite.NoError(err)
+	suite.Len(ul, 3)
+
+	role := user.RoleModerator
+	ul, err = suite.App.ListUsers(suite.Ctx, suite.ModerID, app.ListUsersParams{Role: &role})
+	suite.NoError(err)
+	suite.Len(ul, 1)
+	suite.Equal(suite.ModerID, ul[0].ID)
+
+	nickname := "MAC"
+	ul, err = suite.App.ListUsers(suite.Ctx, suite.ModerID, app.ListUsersParams{Nickname: &nickname})
+	suite.NoError(err)
+	suite.Len(ul, 1)
+	suite.Equal(suite.UserID, ul[0].ID)
+
+	_, err = suite.App.BanUser(suite.Ctx, suite.ModerID, suite.UserID)
+	suite.NoError(err)
+	banned := true
+	email := "circles.com"
+	ul, err = suite.App.ListUsers(suite.Ctx, suite.AdminID, app.ListUsersParams{Banned: &banned, Email: &email})
+	suite.NoError(err)
+	suite.Len(ul, 1)
+	suite.Equal(suite.UserID, ul[0].ID)
+}
+
+func TestAdminSuite(t *testing.T) {
+	suite.Run(t, new(AdminSuite))
+}
+
+type AdminHTTPSuite struct {
+	suite.Suite
+	Client  *testClient
+	AdminID int64
+}
+
+func (suite *AdminHTTPSuite) SetupTest() {
+	repo := adrepo.New()
+	id, err := repo.AddUser(context.Background(), user.User{Nickname: "admin", Email: "admin@ads.com", Role: user.RoleAdmin})
+	suite.NoError(err)
+	suite.AdminID = id
+	suite.Client = getTestClientWithRepo(repo)
+}
+
+func (suite *AdminHTTPSuite) TestAdminAPI() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	suite.Equal("user", u.Data.Role)
+	ad, err := suite.Client.createAd(u.Data.ID, "Circles", "Good News")
+	suite.NoError(err)
+	_, err = suite.Client.changeAdStatus(u.Data.ID, ad.Data.ID, true)
+	suite.NoError(err)
+
+	users, err := suite.Client.listUsers(suite.AdminID, map[string]any{"role": "user"})
+	suite.NoError(err)
+	suite.Len(users.Data, 1)
+
+	res, err := suite.Client.forceUnpublishAd(suite.AdminID, ad.Data.ID)
+	suite.NoError(err)
+	suite.False(res.Data.Published)
+
+	banned, err := suite.Client.banUser(suite.AdminID, u.Data.ID)
+	suite.NoError(err)
+	suite.True(banned.Data.Banned)
+	_, err = suite.Client.changeAdStatus(u.Data.ID, ad.Data.ID, true)
+	suite.ErrorIs(err, ErrForbidden)
+	_, err = suite.Client.createAd(u.Data.ID, "Circles", "Good News")
+	suite.ErrorIs(err, ErrForbidden)
+
+	unbanned, err := suite.Client.unbanUser(suite.AdminID, u.Data.ID)
+	suite.NoError(err)
+	suite.False(unbanned.Data.Banned)
+
+	moder, err := suite.Client.setUserRole(suite.AdminID, u.Data.ID, "moderator")
+	suite.NoError(err)
+	suite.Equal("moderator", moder.Data.Role)
+
+	_, err = suite.Client.forceDeleteAd(u.Data.ID, ad.Data.ID)
+	suite.NoError(err)
+	_, err = suite.Client.getAd(ad.Data.ID)
+	suite.ErrorIs(err, ErrNotFound)
+}
+
+func (suite *AdminHTTPSuite) TestAdminAPI_Errors() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+
+	_, err = suite.Client.listUsers(nil, nil)
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.listUsers("abc", nil)
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.listUsers(u.Data.ID, nil)
+	suite.ErrorIs(err, ErrForbidden)
+	_, err = suite.Client.listUsers(suite.AdminID, map[string]any{"role": "king"})
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.listUsers(suite.AdminID, map[string]any{"banned": "maybe"})
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.banUser(suite.AdminID, "abc")
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.banUser(suite.AdminID, u.Data.ID+1)
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.Client.banUser(u.Data.ID, suite.AdminID)
+	suite.ErrorIs(err, ErrForbidden)
+
+	_, err = suite.Client.setUserRole(suite.AdminID, u.Data.ID, "king")
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.forceUnpublishAd(suite.AdminID, 100)
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.Client.forceDeleteAd(suite.AdminID, "abc")
+	suite.ErrorIs(err, ErrBadRequest)
+}
+
+func TestAdminHTTPSuite(t *testing.T) {
+	suite.Run(t, new(AdminHTTPSuite))
+}
+
+func (suite *GRPCSuite) TestGRPCAdmin() {
+	adminID, err := suite.Repo.AddUser(suite.Context, user.User{Nickname: "admin", Email: "admin@ads.com", Role: user.RoleAdmin})
+	suite.NoError(err)
+	u, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "ivanov@yandex.ru"})
+	suite.NoError(err)
+	ad, err := suite.Client.CreateAd(suite.Context, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: &u.Id})
+	suite.NoError(err)
+
+	role := "user"
+	list, err := suite.Admin.ListUsers(suite.Context, &grpcPort.ListUsersRequest{AdminId: &adminID, Role: &role})
+	suite.NoError(err)
+	suite.Len(list.List, 1)
+	suite.Equal(u.Id, list.List[0].Id)
+
+	banned, err := suite.Admin.BanUser(suite.Context, &grpcPort.AdminUserRequest{AdminId: &adminID, UserId: &u.Id})
+	suite.NoError(err)
+	suite.True(banned.Banned)
+	_, err = suite.Client.ChangeAdStatus(suite.Context,
+		&grpcPort.ChangeAdStatusRequest{AdId: &ad.Id, UserId: &u.Id, Published: true})
+	suite.Equal("rpc error: code = PermissionDenied desc = user is banned: forbidden", err.Error())
+
+	unbanned, err := suite.Admin.UnbanUser(suite.Context, &grpcPort.AdminUserRequest{AdminId: &adminID, UserId: &u.Id})
+	suite.NoError(err)
+	suite.False(unbanned.Banned)
+
+	moder, err := suite.Admin.SetUserRole(suite.Context, &grpcPort.SetUserRoleRequest{AdminId: &adminID, UserId: &u.Id, Role: "moderator"})
+	suite.NoError(err)
+	suite.Equal("moderator", moder.Role)
+	_, err = suite.Admin.SetUserRole(suite.Context, &grpcPort.SetUserRoleRequest{AdminId: &adminID, UserId: &u.Id, Role: "king"})
+	suite.Equal("rpc error: code = InvalidArgument desc = invalid user role", err.Error())
+
+	res, err := suite.Admin.ForceUnpublishAd(suite.Context, &grpcPort.AdminAdRequest{AdminId: &u.Id, AdId: &ad.Id})
+	suite.NoError(err)
+	suite.False(res.Published)
+	_, err = suite.Admin.ForceDeleteAd(suite.Context, &grpcPort.AdminAdRequest{AdminId: &adminID, AdId: &ad.Id})
+	suite.NoError(err)
+	_, err = suite.Client.GetAd(suite.Context, &grpcPort.GetAdRequest{AdId: &ad.Id})
+	suite.Equal(ErrAdNotFound.Error(), err.Error())
+
+	_, err = suite.Admin.BanUser(suite.Context, &grpcPort.AdminUserRequest{AdminId: &u.Id, UserId: &adminID})
+	suite.Equal(ErrGRPCForbidden.Error(), err.Error())
+	_, err = suite.Admin.ForceDeleteAd(suite.Context, &grpcPort.AdminAdRequest{AdminId: &adminID})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"homework10/internal/ports/httpgin"
+	"net/http"
+	"net/url"
+)
+
+type usersResponse struct {
+	Data []userData `json:"data"`
+}
+
+func (tc *testClient) adminRequest(method string, adminID any, path string, body any, out any) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("unable to marshal: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, tc.baseURL+"/api/v1/admin"+path, reader)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	if adminID != nil {
+		req.Header.Add(httpgin.AdminHeader, fmt.Sprintf("%v", adminID))
+	}
+
+	return tc.getResponse(req, out)
+}
+
+func (tc *testClient) listUsers(adminID any, query map[string]any) (usersResponse, error) {
+	v := url.Values{}
+	for key, val := range query {
+		v.Add(key, fmt.Sprintf("%v", val))
+	}
+
+	var response usersResponse
+	err := tc.adminRequest(http.MethodGet, adminID, "/users?"+v.Encode(), nil, &response)
+	if err != nil {
+		return usersResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) banUser(adminID any, userID any) (userResponse, error) {
+	var response userResponse
+	err := tc.adminRequest(http.MethodPut, adminID, fmt.Sprintf("/users/%v/ban", userID), nil, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) unbanUser(adminID any, userID any) (userResponse, error) {
+	var response userResponse
+	err := tc.adminRequest(http.MethodDelete, adminID, fmt.Sprintf("/users/%v/ban", userID), nil, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) setUserRole(adminID any, userID any, role any) (userResponse, error) {
+	var response userResponse
+	err := tc.adminRequest(http.MethodPut, adminID, fmt.Sprintf("/users/%v/role", userID), map[string]any{"role": role}, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) forceUnpublishAd(adminID any, adID any) (adResponse, error) {
+	var response adResponse
+	err := tc.adminRequest(http.MethodPut, adminID, fmt.Sprintf("/ads/%v/unpublish", adID), nil, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) forceDeleteAd(adminID any, adID any) (adResponse, error) {
+	var response adResponse
+	err := tc.adminRequest(http.MethodDelete, adminID, fmt.Sprintf("/ads/%v", adID), nil, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listFlaggedAds(adminID any) (adsResponse, error) {
+	var response adsResponse
+	err := tc.adminRequest(http.MethodGet, adminID, "/ads/flagged", nil, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) approveAd(adminID any, adID any) (adResponse, error) {
+	var response adResponse
+	err := tc.adminRequest(http.MethodPut, adminID, fmt.Sprintf("/ads/%v/approve", adID), nil, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/yaml.v3"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adsctl"
+	"homework10/internal/app"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/user"
+	"homework10/pkg/client"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type adsctlAd struct {
+	ID        int64  `json:"id"`
+	Title     string `json:"title"`
+	AuthorID  int64  `json:"author_id"`
+	Published bool   `json:"published"`
+}
+
+type adsctlUser struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+type AdsctlSuite struct {
+	suite.Suite
+	Repo       *adrepo.RepositoryMap
+	ConfigPath string
+	Dial       func(ctx context.Context, p adsctl.Profile) (client.AdsClient, error)
+	cleanup    func()
+}
+
+func (suite *AdsctlSuite) SetupTest() {
+	suite.Repo = adrepo.NewRepositoryMap()
+	srv := httptest.NewServer(httpgin.NewHTTPServer(":18080", app.NewApp(suite.Repo)).Handler)
+	suite.cleanup = srv.Close
+
+	suite.ConfigPath = filepath.Join(suite.T().TempDir(), "config.yaml")
+	cfg := &adsctl.Config{
+		Current: "test",
+		Profiles: map[string]adsctl.Profile{
+			"test":    {Transport: adsctl.TransportHTTP, Address: srv.URL, Timeout: time.Second},
+			"offline": {Transport: adsctl.TransportHTTP, Address: "http://127.0.0.1:1", Timeout: 100 * time.Millisecond},
+		},
+	}
+	suite.Require().NoError(cfg.Save(suite.ConfigPath))
+}
+
+func (suite *AdsctlSuite) TearDownTest() {
+	suite.cleanup()
+}
+
+// run выполняет команду и возвращает ее вывод
+func (suite *AdsctlSuite) run(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := adsctl.NewRootCommand(adsctl.Options{Out: &out, Dial: suite.Dial})
+	cmd.SetArgs(append([]string{"--config", suite.ConfigPath}, args...))
+	cmd.SetErr(&out)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func (suite *AdsctlSuite) mustRun(args ...string) string {
+	out, err := suite.run(args...)
+	suite.Require().NoError(err, out)
+	return out
+}
+
+func (suite *AdsctlSuite) TestAdLifecycle() {
+	var u adsctlUser
+	out := suite.mustRun("user", "create", "--nickname", "Mac Miller", "--email", "swimming@circles.com", "-o", "json")
+	suite.Require().NoError(json.Unmarshal([]byte(out), &u))
+	suite.Equal("Mac Miller", u.Nickname)
+
+	var ad adsctlAd
+	out = suite.mustRun("ad", "create", "--user", fmt.Sprint(u.ID), "--title", "Circles", "--text", "Good News", "-o", "json")
+	suite.Require().NoError(json.Unmarshal([]byte(out), &ad))
+	suite.Equal(u.ID, ad.AuthorID)
+	suite.False(ad.Published)
+
+	out = suite.mustRun("ad", "publish", fmt.Sprint(ad.ID), "--user", fmt.Sprint(u.ID), "-o", "yaml")
+	var published adsctlAd
+	suite.Require().NoError(yaml.Unmarshal([]byte(out), &published))
+	suite.True(published.Published)
+
+	out = suite.mustRun("ad", "update", fmt.Sprint(ad.ID), "--user", fmt.Sprint(u.ID), "--title", "Swimming", "--text", "Blue World")
+	suite.Contains(out, "ID")
+	suite.Contains(out, "TITLE")
+	suite.Contains(out, "Swimming")
+
+	var list []adsctlAd
+	out = suite.mustRun("ad", "list", "--published", "--user", fmt.Sprint(u.ID), "-o", "json")
+	suite.Require().NoError(json.Unmarshal([]byte(out), &list))
+	suite.Len(list, 1)
+
+	out = suite.mustRun("ad", "list", "--published=false", "-o", "json")
+	suite.Require().NoError(json.Unmarshal([]byte(out), &list))
+	suite.Len(list, 0)
+
+	suite.mustRun("ad", "delete", fmt.Sprint(ad.ID), "--user", fmt.Sprint(u.ID))
+	_, err := suite.run("ad", "get", fmt.Sprint(ad.ID))
+	suite.ErrorIs(err, app.ErrAdNotFound)
+}
+
+func (suite *AdsctlSuite) TestUsers() {
+	adminID, err := suite.Repo.AddUser(context.Background(), user.User{Nickname: "admin", Email: "admin@ads.com", Role: user.RoleAdmin})
+	suite.Require().NoError(err)
+
+	out := suite.mustRun("user", "create", "--nickname", "Mac", "--email", "mac@circles.com", "-o", "json")
+	var u adsctlUser
+	suite.Require().NoError(json.Unmarshal([]byte(out), &u))
+
+	out = suite.mustRun("user", "update", fmt.Sprint(u.ID), "--nickname", "Miller", "--email", "miller@circles.com", "-o", "json")
+	suite.Require().NoError(json.Unmarshal([]byte(out), &u))
+	suite.Equal("Miller", u.Nickname)
+
+	_, err = suite.run("user", "list")
+	suite.Error(err)
+
+	var list []adsctlUser
+	out = suite.mustRun("user", "list", "--admin", fmt.Sprint(adminID), "--role", "user", "-o", "json")
+	suite.Require().NoError(json.Unmarshal([]byte(out), &list))
+	suite.Require().Len(list, 1)
+	suite.Equal(u.ID, list[0].ID)
+
+	suite.mustRun("config", "set-profile", "test", "--admin-id", fmt.Sprint(adminID))
+	out = suite.mustRun("user", "list")
+	suite.Contains(out, "NICKNAME")
+	suite.Contains(out, "admin@ads.com")
+
+	suite.mustRun("user", "delete", fmt.Sprint(u.ID))
+	_, err = suite.run("user", "get", fmt.Sprint(u.ID))
+	suite.ErrorIs(err, app.ErrUserNotFound)
+}
+
+func (suite *AdsctlSuite) TestProfiles() {
+	_, err := suite.run("--profile", "offline", "user", "get", "1")
+	suite.ErrorIs(err, client.ErrUnavailable)
+
+	_, err = suite.run("--profile", "missing", "user", "get", "1")
+	suite.ErrorIs(err, adsctl.ErrUnknownProfile)
+	_, err = suite.run("config", "use", "missing")
+	suite.ErrorIs(err, adsctl.ErrUnknownProfile)
+
+	suite.mustRun("config", "use", "offline")
+	cfg, err := adsctl.LoadConfig(suite.ConfigPath)
+	suite.Require().NoError(err)
+	suite.Equal("offline", cfg.Current)
+	_, err = suite.run("user", "get", "1")
+	suite.ErrorIs(err, client.ErrUnavailable)
+
+	suite.mustRun("config", "set-profile", "grpc", "--transport", "grpc", "--address", "localhost:50054", "--timeout", "2s")
+	cfg, err = adsctl.LoadConfig(suite.ConfigPath)
+	suite.Require().NoError(err)
+	suite.Equal(adsctl.Profile{Transport: adsctl.TransportGRPC, Address: "localhost:50054", Timeout: 2 * time.Second}, cfg.Profiles["grpc"])
+
+	_, err = suite.run("config", "set-profile", "bad", "--transport", "ftp", "--address", "x")
+	suite.ErrorIs(err, adsctl.ErrUnknownTransport)
+	_, err = suite.run("user", "get", "1", "-o", "xml", "--profile", "test")
+	suite.ErrorIs(err, adsctl.ErrUnknownOutput)
+
+	out := suite.mustRun("config", "view")
+	suite.Contains(out, "current: offline")
+	suite.Contains(out, "localhost:50054")
+}
+
+func (suite *AdsctlSuite) TestCompletion() {
+	out := suite.mustRun("completion", "bash")
+	suite.Contains(out, "adsctl")
+
+	out = suite.mustRun("__complete", "--profile", "")
+	suite.Contains(out, "offline")
+	suite.Contains(out, "test")
+
+	out = suite.mustRun("__complete", "config", "use", "")
+	suite.Contains(out, "offline")
+}
+
+func TestAdsctl(t *testing.T) {
+	suite.Run(t, new(AdsctlSuite))
+}
+
+func TestAdsctl_GRPC(t *testing.T) {
+	s := new(AdsctlSuite)
+	s.Dial = func(ctx context.Context, p adsctl.Profile) (client.AdsClient, error) {
+		c, cleanup := newGRPCTestClient(s.Repo, client.DefaultConfig(), &failFirst{})
+		return closer{AdsClient: c, cleanup: cleanup}, nil
+	}
+	t.Run("AdLifecycle", func(t *testing.T) {
+		s.SetT(t)
+		s.SetupTest()
+		defer s.TearDownTest()
+		s.TestAdLifecycle()
+	})
+}
+
+// closer останавливает тестовый сервер вместе с закрытием клиента
+type closer struct {
+	client.AdsClient
+	cleanup func()
+}
+
+func (c closer) Close() error {
+	c.cleanup()
+	return nil
+}
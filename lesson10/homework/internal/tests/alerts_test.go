@@ -0,0 +1,432 @@
+package tests
+
+import (
+	"github.com/stretchr/testify/mock"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/user"
+	"time"
+)
+
+func (suite *HTTPSuite) TestFavorites() {
+	seller, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	buyer, err := suite.Client.createUser("J.Cole", "foresthill@drive.com")
+	suite.NoError(err)
+
+	ad, err := suite.Client.createAd(seller.Data.ID, "Circles", "Good News")
+	suite.NoError(err)
+
+	_, err = suite.Client.addFavorite(buyer.Data.ID, ad.Data.ID)
+	suite.ErrorIs(err, ErrForbidden)
+
+	_, err = suite.Client.changeAdStatus(seller.Data.ID, ad.Data.ID, true)
+	suite.NoError(err)
+
+	fav, err := suite.Client.addFavorite(buyer.Data.ID, ad.Data.ID)
+	suite.NoError(err)
+	suite.Equal(ad.Data.ID, fav.Data.ID)
+
+	favs, err := suite.Client.listFavorites(buyer.Data.ID)
+	suite.NoError(err)
+	suite.Len(favs.Data, 1)
+	suite.Equal(ad.Data.ID, favs.Data[0].ID)
+
+	// снятое с публикации объявление пропадает из избранного
+	_, err = suite.Client.changeAdStatus(seller.Data.ID, ad.Data.ID, false)
+	suite.NoError(err)
+	favs, err = suite.Client.listFavorites(buyer.Data.ID)
+	suite.NoError(err)
+	suite.Len(favs.Data, 0)
+
+	_, err = suite.Client.removeFavorite(buyer.Data.ID, ad.Data.ID)
+	suite.NoError(err)
+	_, err = suite.Client.removeFavorite(buyer.Data.ID, ad.Data.ID)
+	suite.ErrorIs(err, ErrNotFound)
+}
+
+func (suite *HTTPSuite) TestFavorites_Errors() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+
+	_, err = suite.Client.addFavorite(u.Data.ID, 100)
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.Client.addFavorite("abc", 100)
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.addFavorite(u.Data.ID, "abc")
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.listFavorites(u.Data.ID + 1)
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.Client.listFavorites("abc")
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.removeFavorite("abc", 0)
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.removeFavorite(u.Data.ID, "abc")
+	suite.ErrorIs(err, ErrBadRequest)
+}
+
+func (suite *HTTPSuite) TestSavedSearches() {
+	seller, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	buyer, err := suite.Client.createUser("J.Cole", "foresthill@drive.com")
+	suite.NoError(err)
+
+	search, err := suite.Client.createSavedSearch(buyer.Data.ID, map[string]any{"title": "Circles"})
+	suite.NoError(err)
+	suite.Equal(buyer.Data.ID, search.Data.UserID)
+	suite.Equal("Circles", *search.Data.Title)
+	suite.Nil(search.Data.AuthorID)
+
+	_, err = suite.Client.createSavedSearch(buyer.Data.ID, map[string]any{"author_id": seller.Data.ID, "date": "2023-04-20"})
+	suite.NoError(err)
+
+	searches, err := suite.Client.listSavedSearches(buyer.Data.ID)
+	suite.NoError(err)
+	suite.Len(searches.Data, 2)
+	suite.Equal(seller.Data.ID, *searches.Data[1].AuthorID)
+	suite.Equal("2023-04-20", *searches.Data[1].Date)
+
+	_, err = suite.Client.deleteSavedSearch(seller.Data.ID, search.Data.ID)
+	suite.ErrorIs(err, ErrForbidden)
+	_, err = suite.Client.deleteSavedSearch(buyer.Data.ID, search.Data.ID)
+	suite.NoError(err)
+	_, err = suite.Client.deleteSavedSearch(buyer.Data.ID, search.Data.ID)
+	suite.ErrorIs(err, ErrNotFound)
+
+	searches, err = suite.Client.listSavedSearches(buyer.Data.ID)
+	suite.NoError(err)
+	suite.Len(searches.Data, 1)
+}
+
+func (suite *HTTPSuite) TestSavedSearches_Errors() {
+	_, err := suite.Client.createSavedSearch(0, map[string]any{"title": "Circles"})
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.Client.createSavedSearch("abc", map[string]any{"title": "Circles"})
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.createSavedSearch(0, map[string]any{"date": "abc"})
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.createSavedSearch(0, map[string]any{"title": 1})
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.listSavedSearches(0)
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.Client.listSavedSearches("abc")
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.deleteSavedSearch("abc", 0)
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.deleteSavedSearch(0, "abc")
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.listNotifications(0)
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.Client.listNotifications("abc")
+	suite.ErrorIs(err, ErrBadRequest)
+}
+
+func (suite *HTTPSuite) TestNotifications() {
+	seller, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	buyer, err := suite.Client.createUser("J.Cole", "foresthill@drive.com")
+	suite.NoError(err)
+	other, err := suite.Client.createUser("Kendrick", "money@trees.com")
+	suite.NoError(err)
+
+	search, err := suite.Client.createSavedSearch(buyer.Data.ID, map[string]any{"author_id": seller.Data.ID})
+	suite.NoError(err)
+	_, err = suite.Client.createSavedSearch(buyer.Data.ID, map[string]any{"title": "Circles"})
+	suite.NoError(err)
+	_, err = suite.Client.createSavedSearch(other.Data.ID, map[string]any{"title": "Swimming"})
+	suite.NoError(err)
+	// собственные объявления не должны приводить к уведомлениям
+	_, err = suite.Client.createSavedSearch(seller.Data.ID, map[string]any{"title": "Circles"})
+	suite.NoError(err)
+
+	ad, err := suite.Client.createAd(seller.Data.ID, "Circles", "Good News")
+	suite.NoError(err)
+
+	notifications, err := suite.Client.listNotifications(buyer.Data.ID)
+	suite.NoError(err)
+	suite.Len(notifications.Data, 0)
+
+	_, err = suite.Client.changeAdStatus(seller.Data.ID, ad.Data.ID, true)
+	suite.NoError(err)
+	// повторная публикация не дублирует уведомление
+	_, err = suite.Client.changeAdStatus(seller.Data.ID, ad.Data.ID, true)
+	suite.NoError(err)
+
+	notifications, err = suite.Client.listNotifications(buyer.Data.ID)
+	suite.NoError(err)
+	suite.Len(notifications.Data, 1)
+	suite.Equal(ad.Data.ID, notifications.Data[0].AdID)
+	suite.Equal(search.Data.ID, notifications.Data[0].SearchID)
+
+	notifications, err = suite.Client.listNotifications(other.Data.ID)
+	suite.NoError(err)
+	suite.Len(notifications.Data, 0)
+
+	notifications, err = suite.Client.listNotifications(seller.Data.ID)
+	suite.NoError(err)
+	suite.Len(notifications.Data, 0)
+}
+
+func (suite *SchedulerSuite) TestScheduler_NotifiesSavedSearches() {
+	buyer, err := suite.App.CreateUser(suite.Ctx, "J.Cole", "foresthill@drive.com")
+	suite.NoError(err)
+	_, err = suite.App.CreateSavedSearch(suite.Ctx, buyer.ID, app.ListAdsParams{Uid: &suite.UserID})
+	suite.NoError(err)
+
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.NoError(err)
+	publishAt := time.Now().UTC().Add(time.Hour)
+	_, err = suite.App.ScheduleAd(suite.Ctx, ad.ID, suite.UserID, &publishAt, 0)
+	suite.NoError(err)
+
+	suite.NoError(suite.Scheduler.Tick(suite.Ctx, publishAt))
+
+	nl, err := suite.App.ListNotifications(suite.Ctx, buyer.ID)
+	suite.NoError(err)
+	suite.Len(nl, 1)
+	suite.Equal(ad.ID, nl[0].AdID)
+}
+
+func (suite *AppTestSuite) TestMatcher_AdPublished() {
+	title := "Circles"
+	other := "Swimming"
+	ad := ads.Ad{ID: 1, Title: title, AuthorID: 0, Published: true}
+	suite.Repo.On("GetSavedSearches", suite.Ctx).
+		Return([]app.SavedSearch{
+			{ID: 0, UserID: 1, Params: app.ListAdsParams{Title: &title}},
+			{ID: 1, UserID: 1, Params: app.ListAdsParams{}},
+			{ID: 2, UserID: 2, Params: app.ListAdsParams{Title: &other}},
+			{ID: 3, UserID: 0, Params: app.ListAdsParams{}},
+			{ID: 4, UserID: 3, Params: app.ListAdsParams{}},
+		}, nil).
+		Once()
+	suite.Repo.On("AddNotification", suite.Ctx, mock.MatchedBy(func(n app.Notification) bool {
+		return n.UserID == 1 && n.SearchID == 0 && n.AdID == 1
+	})).
+		Return(int64(0), nil).
+		Once()
+	suite.Repo.On("AddNotification", suite.Ctx, mock.MatchedBy(func(n app.Notification) bool {
+		return n.UserID == 3 && n.SearchID == 4 && n.AdID == 1
+	})).
+		Return(int64(1), nil).
+		Once()
+
+	suite.NoError(app.NewMatcher(suite.Repo).AdPublished(suite.Ctx, ad))
+}
+
+func (suite *AppTestSuite) TestMatcher_RepoErrors() {
+	ad := ads.Ad{ID: 1, Title: "Circles", AuthorID: 0, Published: true}
+	matcher := app.NewMatcher(suite.Repo)
+
+	suite.Repo.On("GetSavedSearches", suite.Ctx).
+		Return(nil, ErrMock).
+		Once()
+	suite.ErrorIs(matcher.AdPublished(suite.Ctx, ad), ErrMock)
+
+	suite.Repo.On("GetSavedSearches", suite.Ctx).
+		Return([]app.SavedSearch{{ID: 0, UserID: 1}}, nil).
+		Once()
+	suite.Repo.On("AddNotification", suite.Ctx, mock.AnythingOfType("app.Notification")).
+		Return(int64(0), ErrMock).
+		Once()
+	suite.ErrorIs(matcher.AdPublished(suite.Ctx, ad), ErrMock)
+}
+
+func (suite *AppTestSuite) TestApp_ChangeAdStatus_MatcherErrorIgnored() {
+	id := int64(0)
+	suite.Repo.On("GetAdByID", suite.Ctx, id).
+		Return(&ads.Ad{AuthorID: 1}, nil).
+		Once()
+	suite.Repo.On("GetUserByID", suite.Ctx, int64(1)).
+		Return(&user.User{ID: 1}, nil).
+		Once()
+	suite.Repo.On("UpdateAdStatus", suite.Ctx, id, true, mock.AnythingOfType("time.Time")).
+		Return(nil).
+		Once()
+	suite.Repo.On("GetSavedSearches", suite.Ctx).
+		Return(nil, ErrMock).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	ad, err := service.ChangeAdStatus(suite.Ctx, id, int64(1), true)
+	suite.NoError(err)
+	suite.True(ad.Published)
+}
+
+func (suite *AppTestSuite) TestApp_AlertsRepoErrors() {
+	service := app.NewApp(suite.Repo)
+
+	suite.Repo.On("GetAdByID", suite.Ctx, int64(0)).
+		Return(nil, app.ErrAdNotFound).
+		Once()
+	_, err := service.AddFavorite(suite.Ctx, 1, 0)
+	suite.ErrorIs(err, app.ErrAdNotFound)
+
+	suite.Repo.On("GetAdByID", suite.Ctx, int64(1)).
+		Return(&ads.Ad{AuthorID: 1}, nil).
+		Once()
+	suite.Repo.On("AddFavorite", suite.Ctx, int64(1), int64(1)).
+		Return(ErrMock).
+		Once()
+	_, err = service.AddFavorite(suite.Ctx, 1, 1)
+	suite.ErrorIs(err, ErrMock)
+
+	suite.Repo.On("GetFavorites", suite.Ctx, int64(1)).
+		Return(nil, ErrMock).
+		Once()
+	_, err = service.ListFavorites(suite.Ctx, 1)
+	suite.ErrorIs(err, ErrMock)
+
+	suite.Repo.On("AddSavedSearch", suite.Ctx, mock.AnythingOfType("app.SavedSearch")).
+		Return(int64(0), ErrMock).
+		Once()
+	_, err = service.CreateSavedSearch(suite.Ctx, 1, app.ListAdsParams{})
+	suite.ErrorIs(err, ErrMock)
+
+	suite.Repo.On("GetUserSavedSearches", suite.Ctx, int64(1)).
+		Return(nil, ErrMock).
+		Once()
+	_, err = service.ListSavedSearches(suite.Ctx, 1)
+	suite.ErrorIs(err, ErrMock)
+
+	suite.Repo.On("GetSavedSearchByID", suite.Ctx, int64(0)).
+		Return(nil, app.ErrSearchNotFound).
+		Once()
+	suite.ErrorIs(service.DeleteSavedSearch(suite.Ctx, 0, 1), app.ErrSearchNotFound)
+
+	suite.Repo.On("GetSavedSearchByID", suite.Ctx, int64(1)).
+		Return(&app.SavedSearch{ID: 1, UserID: 1}, nil).
+		Once()
+	suite.Repo.On("DeleteSavedSearchByID", suite.Ctx, int64(1)).
+		Return(ErrMock).
+		Once()
+	suite.ErrorIs(service.DeleteSavedSearch(suite.Ctx, 1, 1), ErrMock)
+
+	suite.Repo.On("GetNotifications", suite.Ctx, int64(1)).
+		Return(nil, ErrMock).
+		Once()
+	_, err = service.ListNotifications(suite.Ctx, 1)
+	suite.ErrorIs(err, ErrMock)
+}
+
+func (suite *RepoSuite) TestRepo_DeleteUserCascadesAlerts() {
+	seller, err := suite.Repo.AddUser(suite.Ctx, U)
+	suite.NoError(err)
+	buyer, err := suite.Repo.AddUser(suite.Ctx, user.User{Nickname: "J.Cole", Email: "foresthill@drive.com"})
+	suite.NoError(err)
+	adID, err := suite.Repo.AddAd(suite.Ctx, ads.Ad{Title: "Circles", Text: "Good News", AuthorID: seller})
+	suite.NoError(err)
+
+	suite.NoError(suite.Repo.AddFavorite(suite.Ctx, buyer, adID))
+	_, err = suite.Repo.AddSavedSearch(suite.Ctx, app.SavedSearch{UserID: buyer})
+	suite.NoError(err)
+	_, err = suite.Repo.AddNotification(suite.Ctx, app.Notification{UserID: buyer, AdID: adID})
+	suite.NoError(err)
+
+	// удаленное объявление пропадает из избранного
+	suite.NoError(suite.Repo.DeleteUserByID(suite.Ctx, seller))
+	fl, err := suite.Repo.GetFavorites(suite.Ctx, buyer)
+	suite.NoError(err)
+	suite.Len(fl.Data, 0)
+
+	suite.NoError(suite.Repo.DeleteUserByID(suite.Ctx, buyer))
+	sl, err := suite.Repo.GetSavedSearches(suite.Ctx)
+	suite.NoError(err)
+	suite.Len(sl, 0)
+
+	suite.ErrorIs(suite.Repo.AddFavorite(suite.Ctx, buyer, adID), app.ErrUserNotFound)
+	suite.ErrorIs(suite.Repo.DeleteFavorite(suite.Ctx, buyer, adID), app.ErrUserNotFound)
+	_, err = suite.Repo.GetNotifications(suite.Ctx, buyer)
+	suite.ErrorIs(err, app.ErrUserNotFound)
+	_, err = suite.Repo.AddNotification(suite.Ctx, app.Notification{UserID: buyer})
+	suite.ErrorIs(err, app.ErrUserNotFound)
+	_, err = suite.Repo.GetUserSavedSearches(suite.Ctx, buyer)
+	suite.ErrorIs(err, app.ErrUserNotFound)
+}
+
+func (suite *GRPCSuite) TestGRPCFavoritesAndSearches() {
+	seller, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "ivanov@yandex.ru"})
+	suite.NoError(err)
+	buyer, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "Ivan", Email: "olegov@yandex.ru"})
+	suite.NoError(err)
+
+	title := "hello"
+	search, err := suite.Client.CreateSavedSearch(suite.Context,
+		&grpcPort.CreateSavedSearchRequest{UserId: &buyer.Id, Title: &title})
+	suite.NoError(err)
+	suite.Equal(title, search.GetTitle())
+
+	searches, err := suite.Client.ListSavedSearches(suite.Context, &grpcPort.ListSavedSearchesRequest{UserId: &buyer.Id})
+	suite.NoError(err)
+	suite.Len(searches.List, 1)
+
+	ad, err := suite.Client.CreateAd(suite.Context, &grpcPort.CreateAdRequest{Title: title, Text: "world", UserId: &seller.Id})
+	suite.NoError(err)
+	_, err = suite.Client.ChangeAdStatus(suite.Context,
+		&grpcPort.ChangeAdStatusRequest{AdId: &ad.Id, UserId: &seller.Id, Published: true})
+	suite.NoError(err)
+
+	notifications, err := suite.Client.ListNotifications(suite.Context, &grpcPort.ListNotificationsRequest{UserId: &buyer.Id})
+	suite.NoError(err)
+	suite.Len(notifications.List, 1)
+	suite.Equal(ad.Id, notifications.List[0].AdId)
+	suite.Equal(search.Id, notifications.List[0].SearchId)
+
+	_, err = suite.Client.AddFavorite(suite.Context, &grpcPort.FavoriteRequest{UserId: &buyer.Id, AdId: &ad.Id})
+	suite.NoError(err)
+	favorites, err := suite.Client.ListFavorites(suite.Context, &grpcPort.ListFavoritesRequest{UserId: &buyer.Id})
+	suite.NoError(err)
+	suite.Len(favorites.List, 1)
+
+	_, err = suite.Client.RemoveFavorite(suite.Context, &grpcPort.FavoriteRequest{UserId: &buyer.Id, AdId: &ad.Id})
+	suite.NoError(err)
+	_, err = suite.Client.RemoveFavorite(suite.Context, &grpcPort.FavoriteRequest{UserId: &buyer.Id, AdId: &ad.Id})
+	suite.Equal(ErrAdNotFound.Error(), err.Error())
+
+	_, err = suite.Client.DeleteSavedSearch(suite.Context, &grpcPort.DeleteSavedSearchRequest{SearchId: &search.Id, UserId: &seller.Id})
+	suite.Equal(ErrGRPCForbidden.Error(), err.Error())
+	_, err = suite.Client.DeleteSavedSearch(suite.Context, &grpcPort.DeleteSavedSearchRequest{SearchId: &search.Id, UserId: &buyer.Id})
+	suite.NoError(err)
+}
+
+func (suite *GRPCSuite) TestGRPCAlerts_Errors() {
+	var id int64 = 100
+	bad := "abc"
+
+	_, err := suite.Client.AddFavorite(suite.Context, &grpcPort.FavoriteRequest{UserId: &id})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+	_, err = suite.Client.AddFavorite(suite.Context, &grpcPort.FavoriteRequest{UserId: &id, AdId: &id})
+	suite.Equal(ErrAdNotFound.Error(), err.Error())
+	_, err = suite.Client.RemoveFavorite(suite.Context, &grpcPort.FavoriteRequest{AdId: &id})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+	_, err = suite.Client.ListFavorites(suite.Context, &grpcPort.ListFavoritesRequest{})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+	_, err = suite.Client.ListFavorites(suite.Context, &grpcPort.ListFavoritesRequest{UserId: &id})
+	suite.Equal(ErrUserNotFound.Error(), err.Error())
+
+	_, err = suite.Client.CreateSavedSearch(suite.Context, &grpcPort.CreateSavedSearchRequest{})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+	_, err = suite.Client.CreateSavedSearch(suite.Context, &grpcPort.CreateSavedSearchRequest{UserId: &id, Date: &bad})
+	suite.Equal(ErrDateMock.Error(), err.Error())
+	_, err = suite.Client.CreateSavedSearch(suite.Context, &grpcPort.CreateSavedSearchRequest{UserId: &id})
+	suite.Equal(ErrUserNotFound.Error(), err.Error())
+	_, err = suite.Client.ListSavedSearches(suite.Context, &grpcPort.ListSavedSearchesRequest{})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+	_, err = suite.Client.ListSavedSearches(suite.Context, &grpcPort.ListSavedSearchesRequest{UserId: &id})
+	suite.Equal(ErrUserNotFound.Error(), err.Error())
+	_, err = suite.Client.DeleteSavedSearch(suite.Context, &grpcPort.DeleteSavedSearchRequest{UserId: &id})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+	_, err = suite.Client.DeleteSavedSearch(suite.Context, &grpcPort.DeleteSavedSearchRequest{UserId: &id, SearchId: &id})
+	suite.Equal("rpc error: code = NotFound desc = saved search with such id does not exist", err.Error())
+
+	_, err = suite.Client.ListNotifications(suite.Context, &grpcPort.ListNotificationsRequest{})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+	_, err = suite.Client.ListNotifications(suite.Context, &grpcPort.ListNotificationsRequest{UserId: &id})
+	suite.Equal(ErrUserNotFound.Error(), err.Error())
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type savedSearchData struct {
+	ID          int64   `json:"id"`
+	UserID      int64   `json:"user_id"`
+	Published   *bool   `json:"published"`
+	AuthorID    *int64  `json:"author_id"`
+	Date        *string `json:"date"`
+	Title       *string `json:"title"`
+	DateCreated string  `json:"date_created"`
+}
+
+type savedSearchResponse struct {
+	Data savedSearchData `json:"data"`
+}
+
+type savedSearchesResponse struct {
+	Data []savedSearchData `json:"data"`
+}
+
+type notificationData struct {
+	ID          int64  `json:"id"`
+	UserID      int64  `json:"user_id"`
+	AdID        int64  `json:"ad_id"`
+	SearchID    int64  `json:"search_id"`
+	DateCreated string `json:"date_created"`
+}
+
+type notificationsResponse struct {
+	Data []notificationData `json:"data"`
+}
+
+func (tc *testClient) addFavorite(userID any, adID any) (adResponse, error) {
+	body := map[string]any{
+		"ad_id": adID,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/favorites", userID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listFavorites(userID any) (adsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/favorites", userID), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) removeFavorite(userID any, adID any) (adResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/favorites/%v", userID, adID), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) createSavedSearch(userID any, filters map[string]any) (savedSearchResponse, error) {
+	data, err := json.Marshal(filters)
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/searches", userID), bytes.NewReader(data))
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response savedSearchResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listSavedSearches(userID any) (savedSearchesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/searches", userID), nil)
+	if err != nil {
+		return savedSearchesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response savedSearchesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) deleteSavedSearch(userID any, searchID any) (savedSearchResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/searches/%v", userID, searchID), nil)
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response savedSearchResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listNotifications(userID any) (notificationsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/notifications", userID), nil)
+	if err != nil {
+		return notificationsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response notificationsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return notificationsResponse{}, err
+	}
+
+	return response, nil
+}
@@ -9,7 +9,9 @@ import (
 	"homework10/internal/app"
 	"homework10/internal/tests/mocks"
 	"homework10/internal/user"
+	"reflect"
 	"testing"
+	"time"
 )
 
 type AppTestSuite struct {
@@ -25,6 +27,9 @@ func (suite *AppTestSuite) SetupTest() {
 
 func (suite *AppTestSuite) TestApp_CreateAd() {
 	id := int64(13)
+	suite.Repo.On("GetUserByID", suite.Ctx, int64(1)).
+		Return(&user.User{ID: 1}, nil).
+		Once()
 	suite.Repo.On("AddAd", suite.Ctx, mock.AnythingOfType("ads.Ad")).
 		Return(id, nil).
 		Once()
@@ -38,9 +43,8 @@ func (suite *AppTestSuite) TestApp_CreateAd() {
 }
 
 func (suite *AppTestSuite) TestApp_CreateAd_NonExistentUser() {
-	id := int64(13)
-	suite.Repo.On("AddAd", suite.Ctx, mock.AnythingOfType("ads.Ad")).
-		Return(id, app.ErrUserNotFound).
+	suite.Repo.On("GetUserByID", suite.Ctx, int64(1)).
+		Return(nil, app.ErrUserNotFound).
 		Once()
 	service := app.NewApp(suite.Repo)
 	_, err := service.CreateAd(suite.Ctx, "title", "text", 1)
@@ -140,9 +144,15 @@ func (suite *AppTestSuite) TestApp_ChangeAdStatus() {
 	suite.Repo.On("GetAdByID", suite.Ctx, id).
 		Return(&ads.Ad{AuthorID: 1}, nil).
 		Once()
+	suite.Repo.On("GetUserByID", suite.Ctx, int64(1)).
+		Return(&user.User{ID: 1}, nil).
+		Once()
 	suite.Repo.On("UpdateAdStatus", suite.Ctx, id, true, mock.AnythingOfType("time.Time")).
 		Return(nil).
 		Once()
+	suite.Repo.On("GetSavedSearches", suite.Ctx).
+		Return([]app.SavedSearch{}, nil).
+		Once()
 
 	service := app.NewApp(suite.Repo)
 	_, err := service.ChangeAdStatus(suite.Ctx, id, int64(1), true)
@@ -178,6 +188,9 @@ func (suite *AppTestSuite) TestApp_ChangeAdStatus_RepoError() {
 	suite.Repo.On("GetAdByID", suite.Ctx, id).
 		Return(&ads.Ad{AuthorID: 1}, nil).
 		Once()
+	suite.Repo.On("GetUserByID", suite.Ctx, int64(1)).
+		Return(&user.User{ID: 1}, nil).
+		Once()
 	suite.Repo.On("UpdateAdStatus", suite.Ctx, id, true, mock.AnythingOfType("time.Time")).
 		Return(ErrMock).
 		Once()
@@ -191,7 +204,7 @@ func (suite *AppTestSuite) TestApp_ChangeAdStatus_RepoError() {
 func (suite *AppTestSuite) TestApp_ListAds() {
 	pub := true
 	params := app.ListAdsParams{Published: &pub}
-	suite.Repo.On("GetAdList", suite.Ctx, params).
+	suite.Repo.On("GetAdList", suite.Ctx, mock.MatchedBy(activeListParams(params))).
 		Return(nil, nil).
 		Once()
 
@@ -204,7 +217,7 @@ func (suite *AppTestSuite) TestApp_ListAds() {
 func (suite *AppTestSuite) TestApp_ListAds_AllNil() {
 	params := app.ListAdsParams{}
 	pub := true
-	suite.Repo.On("GetAdList", suite.Ctx, app.ListAdsParams{Published: &pub}).
+	suite.Repo.On("GetAdList", suite.Ctx, mock.MatchedBy(activeListParams(app.ListAdsParams{Published: &pub}))).
 		Return(nil, nil).
 		Once()
 
@@ -217,7 +230,7 @@ func (suite *AppTestSuite) TestApp_ListAds_AllNil() {
 func (suite *AppTestSuite) TestApp_ListAds_RepoError() {
 	params := app.ListAdsParams{}
 	pub := true
-	suite.Repo.On("GetAdList", suite.Ctx, app.ListAdsParams{Published: &pub}).
+	suite.Repo.On("GetAdList", suite.Ctx, mock.MatchedBy(activeListParams(app.ListAdsParams{Published: &pub}))).
 		Return(nil, ErrMock).
 		Once()
 
@@ -232,6 +245,9 @@ func (suite *AppTestSuite) TestApp_CreateUser() {
 	suite.Repo.On("AddUser", suite.Ctx, mock.AnythingOfType("user.User")).
 		Return(id, nil).
 		Once()
+	suite.Repo.On("AddVerificationToken", suite.Ctx, mock.AnythingOfType("app.VerificationToken")).
+		Return(nil).
+		Once()
 	service := app.NewApp(suite.Repo)
 	u, err := service.CreateUser(suite.Ctx, "Mac Miller", "swimming@circles.com")
 	suite.Nil(err)
@@ -290,6 +306,9 @@ func (suite *AppTestSuite) TestApp_UpdateUser() {
 	suite.Repo.On("UpdateUser", suite.Ctx, id, name, email).
 		Return(nil).
 		Once()
+	suite.Repo.On("AddVerificationToken", suite.Ctx, mock.AnythingOfType("app.VerificationToken")).
+		Return(nil).
+		Once()
 
 	service := app.NewApp(suite.Repo)
 	_, err := service.UpdateUser(suite.Ctx, id, name, email)
@@ -342,6 +361,96 @@ func (suite *AppTestSuite) TestApp_UpdateUser_RepoError() {
 	suite.ErrorIs(err, ErrMock)
 }
 
+func (suite *AppTestSuite) TestApp_PatchAd() {
+	id := int64(0)
+	title := "new title"
+	text := "text"
+	suite.Repo.On("GetAdByID", suite.Ctx, id).
+		Return(&ads.Ad{AuthorID: 1, Title: "title", Text: "text"}, nil).
+		Once()
+	// текст не изменился, поэтому в репозиторий передается только заголовок
+	suite.Repo.On("PatchAd", suite.Ctx, id, app.AdPatch{Title: &title}, mock.AnythingOfType("time.Time")).
+		Return(nil).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	ad, err := service.PatchAd(suite.Ctx, id, int64(1), app.AdPatch{Title: &title, Text: &text})
+	suite.Nil(err)
+	suite.Equal("new title", ad.Title)
+	suite.Equal("text", ad.Text)
+}
+
+func (suite *AppTestSuite) TestApp_PatchAd_NoChanges() {
+	id := int64(0)
+	title := "title"
+	suite.Repo.On("GetAdByID", suite.Ctx, id).
+		Return(&ads.Ad{AuthorID: 1, Title: "title", Text: "text"}, nil).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	ad, err := service.PatchAd(suite.Ctx, id, int64(1), app.AdPatch{Title: &title})
+	suite.Nil(err)
+	suite.Equal("title", ad.Title)
+}
+
+func (suite *AppTestSuite) TestApp_PatchAd_InvalidMerged() {
+	id := int64(0)
+	title := ""
+	suite.Repo.On("GetAdByID", suite.Ctx, id).
+		Return(&ads.Ad{AuthorID: 1, Title: "title", Text: "text"}, nil).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	_, err := service.PatchAd(suite.Ctx, id, int64(1), app.AdPatch{Title: &title})
+	suite.Error(err)
+	suite.ErrorAs(err, &validator.ValidationErrors{})
+}
+
+func (suite *AppTestSuite) TestApp_PatchAd_Forbidden() {
+	id := int64(0)
+	title := "new title"
+	suite.Repo.On("GetAdByID", suite.Ctx, id).
+		Return(&ads.Ad{AuthorID: 0}, nil).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	_, err := service.PatchAd(suite.Ctx, id, int64(1), app.AdPatch{Title: &title})
+	suite.ErrorIs(err, app.ErrForbidden)
+}
+
+func (suite *AppTestSuite) TestApp_PatchUser() {
+	id := int64(0)
+	email := "good@news.com"
+	suite.Repo.On("GetUserByID", suite.Ctx, id).
+		Return(&user.User{Nickname: "Mac Miller", Email: "swimming@circles.com", Verified: true}, nil).
+		Once()
+	suite.Repo.On("PatchUser", suite.Ctx, id, app.UserPatch{Email: &email}).
+		Return(nil).
+		Once()
+	suite.Repo.On("AddVerificationToken", suite.Ctx, mock.AnythingOfType("app.VerificationToken")).
+		Return(nil).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	u, err := service.PatchUser(suite.Ctx, id, app.UserPatch{Email: &email})
+	suite.Nil(err)
+	suite.Equal("Mac Miller", u.Nickname)
+	suite.Equal(email, u.Email)
+	suite.False(u.Verified)
+}
+
+func (suite *AppTestSuite) TestApp_PatchUser_InvalidMerged() {
+	id := int64(0)
+	email := "not-an-email"
+	suite.Repo.On("GetUserByID", suite.Ctx, id).
+		Return(&user.User{Nickname: "Mac Miller", Email: "swimming@circles.com"}, nil).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	_, err := service.PatchUser(suite.Ctx, id, app.UserPatch{Email: &email})
+	suite.Error(err)
+}
+
 func (suite *AppTestSuite) TestApp_DeleteUser() {
 	id := int64(0)
 	suite.Repo.On("DeleteUserByID", suite.Ctx, id).
@@ -418,6 +527,137 @@ func (suite *AppTestSuite) TestApp_DeleteAd_RepoError() {
 	suite.ErrorIs(err, ErrMock)
 }
 
+// activeListParams проверяет, что приложение добавило к фильтрам исключение истекших объявлений
+func activeListParams(want app.ListAdsParams) func(app.ListAdsParams) bool {
+	return func(got app.ListAdsParams) bool {
+		if got.ActiveAt == nil || time.Since(*got.ActiveAt) > time.Minute {
+			return false
+		}
+		got.ActiveAt = nil
+		return reflect.DeepEqual(want, got)
+	}
+}
+
+func (suite *AppTestSuite) TestApp_ListAds_KeepsActiveAt() {
+	activeAt := time.Date(2023, 4, 20, 0, 0, 0, 0, time.UTC)
+	params := app.ListAdsParams{ActiveAt: &activeAt}
+	pub := true
+	suite.Repo.On("GetAdList", suite.Ctx, app.ListAdsParams{Published: &pub, ActiveAt: &activeAt}).
+		Return(&ads.AdList{}, nil).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	_, err := service.ListAds(suite.Ctx, params)
+	suite.NoError(err)
+}
+
+func (suite *AppTestSuite) TestApp_ChangeAdStatus_CancelsSchedule() {
+	id := int64(0)
+	publishAt := time.Now().Add(time.Hour)
+	suite.Repo.On("GetAdByID", suite.Ctx, id).
+		Return(&ads.Ad{AuthorID: 1, PublishAt: &publishAt}, nil).
+		Once()
+	suite.Repo.On("GetUserByID", suite.Ctx, int64(1)).
+		Return(&user.User{ID: 1}, nil).
+		Once()
+	suite.Repo.On("UpdateAdStatus", suite.Ctx, id, true, mock.AnythingOfType("time.Time")).
+		Return(nil).
+		Once()
+	suite.Repo.On("UpdateAdSchedule", suite.Ctx, id, (*time.Time)(nil), (*time.Time)(nil), mock.AnythingOfType("time.Time")).
+		Return(nil).
+		Once()
+	suite.Repo.On("GetSavedSearches", suite.Ctx).
+		Return([]app.SavedSearch{}, nil).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	ad, err := service.ChangeAdStatus(suite.Ctx, id, int64(1), true)
+	suite.NoError(err)
+	suite.True(ad.Published)
+	suite.Nil(ad.PublishAt)
+}
+
+func (suite *AppTestSuite) TestApp_ScheduleAd() {
+	id := int64(0)
+	publishAt := time.Now().Add(time.Hour)
+	expiresAt := publishAt.UTC().AddDate(0, 0, 7)
+	suite.Repo.On("GetAdByID", suite.Ctx, id).
+		Return(&ads.Ad{AuthorID: 1}, nil).
+		Once()
+	suite.Repo.On("GetUserByID", suite.Ctx, int64(1)).
+		Return(&user.User{ID: 1}, nil).
+		Once()
+	suite.Repo.On("UpdateAdSchedule", suite.Ctx, id,
+		mock.MatchedBy(func(t *time.Time) bool { return t != nil && t.Equal(publishAt) }),
+		mock.MatchedBy(func(t *time.Time) bool { return t != nil && t.Equal(expiresAt) }),
+		mock.AnythingOfType("time.Time")).
+		Return(nil).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	ad, err := service.ScheduleAd(suite.Ctx, id, int64(1), &publishAt, 7)
+	suite.NoError(err)
+	suite.False(ad.Published)
+	suite.True(publishAt.Equal(*ad.PublishAt))
+	suite.True(expiresAt.Equal(*ad.ExpiresAt))
+}
+
+func (suite *AppTestSuite) TestApp_ScheduleAd_ExpiryOnly() {
+	id := int64(0)
+	suite.Repo.On("GetAdByID", suite.Ctx, id).
+		Return(&ads.Ad{AuthorID: 1, Published: true}, nil).
+		Once()
+	suite.Repo.On("UpdateAdSchedule", suite.Ctx, id, (*time.Time)(nil),
+		mock.AnythingOfType("*time.Time"), mock.AnythingOfType("time.Time")).
+		Return(nil).
+		Once()
+
+	service := app.NewApp(suite.Repo)
+	ad, err := service.ScheduleAd(suite.Ctx, id, int64(1), nil, 3)
+	suite.NoError(err)
+	suite.True(ad.Published)
+	suite.Nil(ad.PublishAt)
+	suite.Equal(ad.DateChanged.AddDate(0, 0, 3), *ad.ExpiresAt)
+}
+
+func (suite *AppTestSuite) TestApp_ScheduleAd_Errors() {
+	publishAt := time.Now().Add(time.Hour)
+	service := app.NewApp(suite.Repo)
+
+	_, err := service.ScheduleAd(suite.Ctx, 0, 1, nil, -1)
+	suite.ErrorIs(err, app.ErrInvalidSchedule)
+
+	suite.Repo.On("GetAdByID", suite.Ctx, int64(1)).
+		Return(nil, app.ErrAdNotFound).
+		Once()
+	_, err = service.ScheduleAd(suite.Ctx, 1, 1, &publishAt, 1)
+	suite.ErrorIs(err, app.ErrAdNotFound)
+
+	suite.Repo.On("GetAdByID", suite.Ctx, int64(2)).
+		Return(&ads.Ad{AuthorID: 0}, nil).
+		Once()
+	_, err = service.ScheduleAd(suite.Ctx, 2, 1, &publishAt, 1)
+	suite.ErrorIs(err, app.ErrForbidden)
+
+	suite.Repo.On("GetAdByID", suite.Ctx, int64(3)).
+		Return(&ads.Ad{AuthorID: 1, Published: true}, nil).
+		Once()
+	_, err = service.ScheduleAd(suite.Ctx, 3, 1, &publishAt, 1)
+	suite.ErrorIs(err, app.ErrInvalidSchedule)
+
+	suite.Repo.On("GetAdByID", suite.Ctx, int64(4)).
+		Return(&ads.Ad{AuthorID: 1}, nil).
+		Once()
+	suite.Repo.On("GetUserByID", suite.Ctx, int64(1)).
+		Return(&user.User{ID: 1}, nil).
+		Once()
+	suite.Repo.On("UpdateAdSchedule", suite.Ctx, int64(4), mock.Anything, mock.Anything, mock.Anything).
+		Return(ErrMock).
+		Once()
+	_, err = service.ScheduleAd(suite.Ctx, 4, 1, &publishAt, 1)
+	suite.ErrorIs(err, ErrMock)
+}
+
 func TestAppSuite(t *testing.T) {
 	suite.Run(t, new(AppTestSuite))
 }
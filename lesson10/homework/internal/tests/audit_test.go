@@ -0,0 +1,426 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"homework10/internal/audit"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/scheduler"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+)
+
+func auditActions(entries []audit.Entry) []audit.Action {
+	actions := make([]audit.Action, 0, len(entries))
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+func TestAuditSelect(t *testing.T) {
+	start := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	var entries []audit.Entry
+	for i := int64(1); i <= 5; i++ {
+		entries = append(entries, audit.Entry{
+			ID:       i,
+			Time:     start.Add(time.Duration(i) * time.Hour),
+			Tenant:   tenant.Default,
+			ActorID:  i % 2,
+			Action:   audit.ActionUpdate,
+			Entity:   audit.EntityAd,
+			EntityID: 10 + i%2,
+		})
+	}
+
+	// последние записи идут первыми
+	got := audit.Select(entries, audit.Filter{})
+	require.Len(t, got, 5)
+	assert.Equal(t, int64(5), got[0].ID)
+	assert.Equal(t, int64(1), got[4].ID)
+
+	actor := int64(1)
+	got = audit.Select(entries, audit.Filter{ActorID: &actor, Limit: 2})
+	require.Len(t, got, 2)
+	assert.Equal(t, []int64{5, 3}, []int64{got[0].ID, got[1].ID})
+
+	from, to := start.Add(2*time.Hour), start.Add(4*time.Hour)
+	got = audit.Select(entries, audit.Filter{From: &from, To: &to})
+	require.Len(t, got, 2)
+	assert.Equal(t, []int64{3, 2}, []int64{got[0].ID, got[1].ID})
+
+	entity := audit.EntityUser
+	assert.Empty(t, audit.Select(entries, audit.Filter{Entity: &entity}))
+	assert.Empty(t, audit.Select(entries, audit.Filter{Tenant: "acme"}))
+}
+
+func TestAuditFileSink(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := audit.OpenFile(path)
+	require.NoError(t, err)
+
+	e := audit.Entry{Time: time.Now().UTC(), ActorID: 1, Action: audit.ActionCreate, Entity: audit.EntityAd, EntityID: 1,
+		After: audit.Snapshot(map[string]string{"title": "hello"})}
+	require.NoError(t, sink.Write(ctx, &e))
+	assert.Equal(t, int64(1), e.ID)
+	e = audit.Entry{Time: time.Now().UTC(), ActorID: 2, Action: audit.ActionDelete, Entity: audit.EntityAd, EntityID: 1}
+	require.NoError(t, sink.Write(ctx, &e))
+	require.NoError(t, sink.Close())
+
+	// после повторного открытия нумерация продолжается, а старые записи сохраняются
+	sink, err = audit.OpenFile(path)
+	require.NoError(t, err)
+	defer sink.Close()
+	e = audit.Entry{Time: time.Now().UTC(), ActorID: 1, Action: audit.ActionBan, Entity: audit.EntityUser, EntityID: 2}
+	require.NoError(t, sink.Write(ctx, &e))
+	assert.Equal(t, int64(3), e.ID)
+
+	got, err := sink.Query(ctx, audit.Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, []audit.Action{audit.ActionBan, audit.ActionDelete, audit.ActionCreate}, auditActions(got))
+	assert.JSONEq(t, `{"title":"hello"}`, string(got[2].After))
+	assert.Nil(t, got[2].Before)
+
+	actor := int64(1)
+	got, err = sink.Query(ctx, audit.Filter{ActorID: &actor, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, int64(3), got[0].ID)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), "\n"))
+
+	_, err = audit.Read(strings.NewReader("{\"id\":1}\nnot json\n"))
+	assert.ErrorIs(t, err, audit.ErrInvalidEntry)
+	broken := filepath.Join(t.TempDir(), "broken.jsonl")
+	writeFile(t, broken, "not json\n")
+	_, err = audit.OpenFile(broken)
+	assert.ErrorIs(t, err, audit.ErrInvalidEntry)
+}
+
+func TestAuditApp(t *testing.T) {
+	repo := adrepo.NewRepositoryMap()
+	sink := adrepo.NewAuditTable()
+	a := app.NewAppWithAudit(repo, nil, sink)
+	ctx := audit.NewContext(context.Background(), audit.Source{Transport: audit.TransportHTTP, ClientIP: "10.0.0.1"})
+	adminID, err := repo.AddUser(ctx, user.User{Nickname: "admin", Email: "admin@ads.com", Role: user.RoleAdmin})
+	require.NoError(t, err)
+
+	u, err := a.CreateUser(ctx, "Mac Miller", "swimming@circles.com")
+	require.NoError(t, err)
+	_, err = a.UpdateUser(ctx, u.ID, "Malcolm", "swimming@circles.com")
+	require.NoError(t, err)
+	ad, err := a.CreateAd(ctx, "Circles", "Good News", u.ID)
+	require.NoError(t, err)
+	_, err = a.UpdateAd(ctx, ad.ID, u.ID, "Circles", "Blue World")
+	require.NoError(t, err)
+	_, err = a.ChangeAdStatus(ctx, ad.ID, u.ID, true)
+	require.NoError(t, err)
+	_, err = a.ForceUnpublishAd(ctx, adminID, ad.ID)
+	require.NoError(t, err)
+	_, err = a.BanUser(ctx, adminID, u.ID)
+	require.NoError(t, err)
+	require.NoError(t, a.DeleteAd(ctx, ad.ID, u.ID))
+	require.NoError(t, a.DeleteUser(ctx, u.ID))
+
+	// неудачные изменения в журнал не попадают
+	_, err = a.UpdateAd(ctx, ad.ID, u.ID, "Circles", "Hand Me Downs")
+	assert.Error(t, err)
+
+	entries, err := a.ListAudit(ctx, adminID, audit.Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, []audit.Action{
+		audit.ActionDelete, audit.ActionDelete, audit.ActionBan, audit.ActionForceUnpublish,
+		audit.ActionChangeStatus, audit.ActionUpdate, audit.ActionCreate, audit.ActionUpdate, audit.ActionCreate,
+	}, auditActions(entries))
+	for _, e := range entries {
+		assert.Equal(t, audit.TransportHTTP, e.Transport)
+		assert.Equal(t, "10.0.0.1", e.ClientIP)
+		assert.Equal(t, tenant.Default, e.Tenant)
+	}
+
+	created := entries[6]
+	assert.Equal(t, audit.EntityAd, created.Entity)
+	assert.Equal(t, ad.ID, created.EntityID)
+	assert.Equal(t, u.ID, created.ActorID)
+	assert.Nil(t, created.Before)
+	var snap struct {
+		Title     string `json:"title"`
+		Text      string `json:"text"`
+		Published bool   `json:"published"`
+		Banned    bool   `json:"banned"`
+		Nickname  string `json:"nickname"`
+	}
+	require.NoError(t, json.Unmarshal(created.After, &snap))
+	assert.Equal(t, "Good News", snap.Text)
+
+	published := entries[4]
+	require.NoError(t, json.Unmarshal(published.Before, &snap))
+	assert.False(t, snap.Published)
+	require.NoError(t, json.Unmarshal(published.After, &snap))
+	assert.True(t, snap.Published)
+
+	banned := entries[2]
+	assert.Equal(t, adminID, banned.ActorID)
+	assert.Equal(t, audit.EntityUser, banned.Entity)
+	require.NoError(t, json.Unmarshal(banned.After, &snap))
+	assert.True(t, snap.Banned)
+
+	deleted := entries[0]
+	assert.Equal(t, audit.EntityUser, deleted.Entity)
+	assert.Nil(t, deleted.After)
+	require.NoError(t, json.Unmarshal(deleted.Before, &snap))
+	assert.Equal(t, "Malcolm", snap.Nickname)
+
+	entity, action := audit.EntityAd, audit.ActionUpdate
+	entries, err = a.ListAudit(ctx, adminID, audit.Filter{Entity: &entity, Action: &action})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ad.ID, entries[0].EntityID)
+
+	// администратор видит только записи своего арендатора
+	other := tenant.NewContext(ctx, tenant.Tenant{ID: "acme"})
+	_, err = a.CreateUser(other, "J.Cole", "foresthill@drive.com")
+	require.NoError(t, err)
+	entries, err = a.ListAudit(ctx, adminID, audit.Filter{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 9)
+	entries, err = a.ListAudit(other, adminID, audit.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, tenant.ID("acme"), entries[0].Tenant)
+
+	from, to := time.Now(), time.Now().Add(-time.Hour)
+	_, err = a.ListAudit(ctx, adminID, audit.Filter{From: &from, To: &to})
+	assert.ErrorIs(t, err, app.ErrInvalidAuditRange)
+	_, err = a.ListAudit(ctx, adminID, audit.Filter{Limit: audit.MaxLimit + 1})
+	assert.ErrorIs(t, err, app.ErrInvalidPage)
+	plain, err := repo.AddUser(ctx, user.User{Nickname: "plain", Email: "plain@ads.com"})
+	require.NoError(t, err)
+	_, err = a.ListAudit(ctx, plain, audit.Filter{})
+	assert.ErrorIs(t, err, app.ErrForbidden)
+
+	// без журнала приложение работает как прежде
+	entries, err = app.NewApp(repo).ListAudit(ctx, adminID, audit.Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestAuditHTTP(t *testing.T) {
+	repo := adrepo.New()
+	moderID, err := repo.AddUser(context.Background(), user.User{Nickname: "moder", Email: "moder@ads.com", Role: user.RoleModerator})
+	require.NoError(t, err)
+	client := getTestClientWithApp(app.NewAppWithAudit(repo, nil, adrepo.NewAuditTable()))
+
+	u, err := client.createUser("Mac Miller", "swimming@circles.com")
+	require.NoError(t, err)
+	ad, err := client.createAd(u.Data.ID, "Circles", "Good News")
+	require.NoError(t, err)
+	_, err = client.changeAdStatus(u.Data.ID, ad.Data.ID, true)
+	require.NoError(t, err)
+
+	type auditData struct {
+		ID        int64           `json:"id"`
+		Time      string          `json:"time"`
+		ActorID   int64           `json:"actor_id"`
+		Action    string          `json:"action"`
+		Entity    string          `json:"entity"`
+		EntityID  int64           `json:"entity_id"`
+		Before    json.RawMessage `json:"before"`
+		After     json.RawMessage `json:"after"`
+		Transport string          `json:"transport"`
+		ClientIP  string          `json:"client_ip"`
+	}
+	var out struct {
+		Data []auditData `json:"data"`
+	}
+	require.NoError(t, client.adminRequest(http.MethodGet, moderID, "/audit", nil, &out))
+	require.Len(t, out.Data, 3)
+	for _, e := range out.Data {
+		assert.Equal(t, "http", e.Transport)
+		assert.Equal(t, "127.0.0.1", e.ClientIP)
+		_, err := time.Parse(time.RFC3339Nano, e.Time)
+		assert.NoError(t, err)
+	}
+
+	out.Data = nil
+	require.NoError(t, client.adminRequest(http.MethodGet, moderID, "/audit?entity=ad&action=create", nil, &out))
+	require.Len(t, out.Data, 1)
+	assert.Equal(t, ad.Data.ID, out.Data[0].EntityID)
+	assert.Equal(t, u.Data.ID, out.Data[0].ActorID)
+	assert.Nil(t, out.Data[0].Before)
+	assert.Contains(t, string(out.Data[0].After), `"title":"Circles"`)
+
+	out.Data = nil
+	path := fmt.Sprintf("/audit?actor_id=%d&from=-1h&limit=1", u.Data.ID)
+	require.NoError(t, client.adminRequest(http.MethodGet, moderID, path, nil, &out))
+	require.Len(t, out.Data, 1)
+	assert.Equal(t, "change_status", out.Data[0].Action)
+
+	assert.ErrorIs(t, client.adminRequest(http.MethodGet, moderID, "/audit?from=yesterday", nil, &out), ErrBadRequest)
+	req, err := http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/admin/audit?from=-1h&to=-2h", nil)
+	require.NoError(t, err)
+	req.Header.Set(httpgin.AdminHeader, fmt.Sprint(moderID))
+	resp, err := client.client.Do(req)
+	require.NoError(t, err)
+	var p problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, p.Status)
+	assert.Equal(t, apperr.CodeInvalidAuditRange, p.Code)
+	assert.NotContains(t, p.Detail, "created_from")
+	assert.ErrorIs(t, client.adminRequest(http.MethodGet, moderID, "/audit?limit=5000", nil, &out), ErrBadRequest)
+	assert.ErrorIs(t, client.adminRequest(http.MethodGet, u.Data.ID, "/audit", nil, &out), ErrForbidden)
+}
+
+func TestAuditHTTPTrustedProxies(t *testing.T) {
+	clientIP := func(opts httpgin.Options) string {
+		sink := adrepo.NewAuditTable()
+		server := httptest.NewServer(httpgin.NewHTTPServerWithOptions(":18080", app.NewAppWithAudit(adrepo.New(), nil, sink), opts).Handler)
+		defer server.Close()
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/users", strings.NewReader(`{"nickname":"Nas","email":"illmatic@qb.com"}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		req.Header.Set("X-Real-IP", "203.0.113.8")
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		entries, err := sink.Query(context.Background(), audit.Filter{})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		return entries[0].ClientIP
+	}
+
+	// без доверенных прокси подделанный заголовок не попадает в журнал
+	assert.Equal(t, "127.0.0.1", clientIP(httpgin.Options{}))
+	assert.Equal(t, "127.0.0.1", clientIP(httpgin.Options{TrustedProxies: []string{"10.0.0.0/8"}}))
+	assert.Equal(t, "203.0.113.7", clientIP(httpgin.Options{TrustedProxies: []string{"127.0.0.1"}}))
+
+	proxies, err := httpgin.ParseTrustedProxies(" 10.0.0.0/8, 127.0.0.1 ,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "127.0.0.1"}, proxies)
+	_, err = httpgin.ParseTrustedProxies("localhost")
+	assert.Error(t, err)
+}
+
+func TestAuditScheduler(t *testing.T) {
+	ctx := context.Background()
+	repo := adrepo.New()
+	sink := adrepo.NewAuditTable()
+	a := app.NewAppWithAudit(repo, nil, sink)
+	uid, err := repo.AddUser(ctx, user.User{Nickname: "Mac Miller", Email: "swimming@circles.com"})
+	require.NoError(t, err)
+	ad, err := a.CreateAd(ctx, "Circles", "Good News", uid)
+	require.NoError(t, err)
+	publishAt := time.Now().UTC().Add(time.Hour)
+	ad, err = a.ScheduleAd(ctx, ad.ID, uid, &publishAt, 1)
+	require.NoError(t, err)
+
+	// объявление, которое истечет раньше, чем наступит время публикации
+	missed, err := a.CreateAd(ctx, "Swimming", "Self Care", uid)
+	require.NoError(t, err)
+	missedAt := ad.ExpiresAt.Add(time.Hour)
+	missed, err = a.ScheduleAd(ctx, missed.ID, uid, &missedAt, 0)
+	require.NoError(t, err)
+	expiresAt := *ad.ExpiresAt
+	require.NoError(t, repo.UpdateAdSchedule(ctx, missed.ID, &missedAt, &expiresAt, time.Now().UTC()))
+
+	s := scheduler.NewWithAudit(repo, sink, time.Millisecond)
+	require.NoError(t, s.Tick(ctx, publishAt))
+	require.NoError(t, s.Tick(ctx, expiresAt))
+	require.NoError(t, s.Tick(ctx, missedAt))
+
+	system := audit.SystemActorID
+	entries, err := sink.Query(ctx, audit.Filter{ActorID: &system})
+	require.NoError(t, err)
+	assert.Equal(t, []audit.Action{audit.ActionExpire, audit.ActionExpire, audit.ActionScheduledPublish}, auditActions(entries))
+	for i, e := range entries {
+		assert.Equal(t, audit.EntityAd, e.Entity)
+		assert.Equal(t, tenant.Default, e.Tenant)
+		if i == 0 {
+			assert.Equal(t, missed.ID, e.EntityID)
+		} else {
+			assert.Equal(t, ad.ID, e.EntityID)
+		}
+	}
+	// снимок "до" - настоящее объявление до изменения, с израсходованным временем публикации
+	published := entries[2]
+	assert.Contains(t, string(published.Before), `"published":false`)
+	assert.Contains(t, string(published.Before), `"publish_at"`)
+	assert.Contains(t, string(published.After), `"published":true`)
+	assert.NotContains(t, string(published.After), `"publish_at"`)
+	assert.Contains(t, string(entries[1].Before), `"published":true`)
+	assert.Contains(t, string(entries[1].After), `"published":false`)
+	assert.Contains(t, string(entries[0].Before), `"publish_at"`)
+	assert.NotContains(t, string(entries[0].After), `"publish_at"`)
+	assert.Contains(t, string(entries[0].After), `"published":false`)
+}
+
+func TestAuditGRPC(t *testing.T) {
+	repo := adrepo.New()
+	moderID, err := repo.AddUser(context.Background(), user.User{Nickname: "moder", Email: "moder@ads.com", Role: user.RoleModerator})
+	require.NoError(t, err)
+	a := app.NewAppWithAudit(repo, nil, adrepo.NewAuditTable())
+	svc := grpcPort.NewService(a)
+	admin := grpcPort.NewAdminService(a)
+
+	// перехватчик подставляет транспорт и адрес клиента из peer
+	interceptor := grpcPort.UnaryAuditInterceptor()
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(192, 168, 1, 5), Port: 40000}})
+	call := func(req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+		return interceptor(ctx, req, &grpc.UnaryServerInfo{}, handler)
+	}
+	resp, err := call(&grpcPort.CreateUserRequest{Name: "Mac Miller", Email: "swimming@circles.com"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return svc.CreateUser(ctx, req.(*grpcPort.CreateUserRequest))
+	})
+	require.NoError(t, err)
+	uid := resp.(*grpcPort.UserResponse).Id
+
+	_, err = admin.ListAudit(ctx, &grpcPort.ListAuditRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	transport := string(audit.TransportGRPC)
+	list, err := admin.ListAudit(ctx, &grpcPort.ListAuditRequest{AdminId: &moderID, Transport: &transport})
+	require.NoError(t, err)
+	require.Len(t, list.List, 1)
+	e := list.List[0]
+	assert.Equal(t, "create", e.Action)
+	assert.Equal(t, "user", e.Entity)
+	assert.Equal(t, uid, e.EntityId)
+	assert.Equal(t, "192.168.1.5", e.ClientIp)
+	assert.Empty(t, e.Before)
+	assert.Contains(t, e.After, `"nickname":"Mac Miller"`)
+
+	bad := "abc"
+	_, err = admin.ListAudit(ctx, &grpcPort.ListAuditRequest{AdminId: &moderID, From: &bad})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = admin.ListAudit(ctx, &grpcPort.ListAuditRequest{AdminId: &uid})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -2,12 +2,14 @@ package tests
 
 import (
 	"context"
+	"fmt"
 	"homework10/internal/adapters/adrepo"
 	"homework10/internal/ads"
 	"homework10/internal/app"
 	"homework10/internal/user"
 	"log"
 	"testing"
+	"time"
 )
 
 var (
@@ -20,7 +22,7 @@ func BenchmarkRepoCreateUser(b *testing.B) {
 	ctx := context.Background()
 	repo := adrepo.New()
 	for i := 0; i < b.N; i++ {
-		id, err := repo.AddUser(ctx, U)
+		id, err := repo.AddUser(ctx, user.User{Nickname: fmt.Sprintf("%s %d", U.Nickname, i), Email: fmt.Sprintf("%d%s", i, U.Email)})
 		if err != nil {
 			log.Fatalf("Function returned error: %v", err)
 		}
@@ -119,3 +121,125 @@ func BenchmarkRepoListAds1000(b *testing.B) {
 		BenchSink++
 	}
 }
+
+// fillBenchRepo создает users авторов и по perUser объявлений у каждого, половина опубликована
+func fillBenchRepo(b *testing.B, repo app.Repository, users int, perUser int) []int64 {
+	ctx := context.Background()
+	date := time.Date(2023, time.May, 20, 12, 0, 0, 0, time.UTC)
+	uids := make([]int64, 0, users)
+	for i := 0; i < users; i++ {
+		uid, err := repo.AddUser(ctx, user.User{Nickname: fmt.Sprintf("%s %d", U.Nickname, i), Email: fmt.Sprintf("%d%s", i, U.Email)})
+		if err != nil {
+			b.Fatalf("Function returned error: %v", err)
+		}
+		uids = append(uids, uid)
+		for j := 0; j < perUser; j++ {
+			ad := Ad
+			ad.AuthorID = uid
+			ad.Published = j%2 == 0
+			ad.DateCreated = date.AddDate(0, 0, -j%30)
+			if _, err := repo.AddAd(ctx, ad); err != nil {
+				b.Fatalf("Function returned error: %v", err)
+			}
+		}
+	}
+	return uids
+}
+
+var benchRepos = []struct {
+	name string
+	new  func() app.Repository
+}{
+	{"Map", adrepo.New},
+	{"Sharded", func() app.Repository { return adrepo.NewShardedRepository(adrepo.DefaultShards) }},
+}
+
+func BenchmarkRepoListAdsByAuthor(b *testing.B) {
+	for _, r := range benchRepos {
+		b.Run(r.name, func(b *testing.B) {
+			repo := r.new()
+			uids := fillBenchRepo(b, repo, 100, 100)
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				al, err := repo.GetAdList(ctx, app.ListAdsParams{Uid: &uids[i%len(uids)]})
+				if err != nil {
+					b.Fatalf("Function returned error: %v", err)
+				}
+				BenchSink += int64(len(al.Data))
+			}
+		})
+	}
+}
+
+func BenchmarkRepoListAdsByDate(b *testing.B) {
+	date := time.Date(2023, time.May, 20, 0, 0, 0, 0, time.UTC)
+	for _, r := range benchRepos {
+		b.Run(r.name, func(b *testing.B) {
+			repo := r.new()
+			fillBenchRepo(b, repo, 100, 100)
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				al, err := repo.GetAdList(ctx, app.ListAdsParams{Date: &date})
+				if err != nil {
+					b.Fatalf("Function returned error: %v", err)
+				}
+				BenchSink += int64(len(al.Data))
+			}
+		})
+	}
+}
+
+func BenchmarkRepoGetAdParallel(b *testing.B) {
+	for _, r := range benchRepos {
+		b.Run(r.name, func(b *testing.B) {
+			repo := r.new()
+			fillBenchRepo(b, repo, 10, 1000)
+			ctx := context.Background()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				var id int64
+				for pb.Next() {
+					ad, err := repo.GetAdByID(ctx, id%10000)
+					if err != nil {
+						b.Errorf("Function returned error: %v", err)
+						return
+					}
+					id += ad.ID + 7
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkRepoMixedParallel(b *testing.B) {
+	for _, r := range benchRepos {
+		b.Run(r.name, func(b *testing.B) {
+			repo := r.new()
+			uids := fillBenchRepo(b, repo, 10, 1000)
+			ctx := context.Background()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					i++
+					id := int64(i*31) % 10000
+					var err error
+					switch i % 10 {
+					case 0:
+						err = repo.UpdateAdStatus(ctx, id, i%20 == 0, time.Now())
+					case 1:
+						_, err = repo.GetAdList(ctx, app.ListAdsParams{Uid: &uids[i%len(uids)]})
+					default:
+						_, err = repo.GetAdByID(ctx, id)
+					}
+					if err != nil {
+						b.Errorf("Function returned error: %v", err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/cache"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/user"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+type CacheSuite struct {
+	suite.Suite
+	Inner *adrepo.RepositoryMap
+	Cache *cache.Repository
+	Ctx   context.Context
+	Now   time.Time
+	UID   int64
+}
+
+func (suite *CacheSuite) SetupTest() {
+	suite.Ctx = context.Background()
+	suite.Now = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	suite.Inner = adrepo.NewRepositoryMap()
+	suite.Cache = cache.New(suite.Inner, cache.Config{
+		Capacity:       2,
+		ListCapacity:   2,
+		TTL:            time.Minute,
+		ListAdmitAfter: 2,
+		Clock:          func() time.Time { return suite.Now },
+	})
+
+	uid, err := suite.Cache.AddUser(suite.Ctx, user.User{Nickname: "Mac Miller", Email: "swimming@circles.com"})
+	suite.NoError(err)
+	suite.UID = uid
+}
+
+func (suite *CacheSuite) addAd(title string, published bool) int64 {
+	id, err := suite.Cache.AddAd(suite.Ctx, ads.Ad{Title: title, Text: "text", AuthorID: suite.UID})
+	suite.NoError(err)
+	if published {
+		suite.NoError(suite.Cache.UpdateAdStatus(suite.Ctx, id, true, suite.Now))
+	}
+	return id
+}
+
+func (suite *CacheSuite) TestGetAd_HitMiss() {
+	id := suite.addAd("Circles", false)
+
+	_, err := suite.Cache.GetAdByID(suite.Ctx, id)
+	suite.NoError(err)
+	ad, err := suite.Cache.GetAdByID(suite.Ctx, id)
+	suite.NoError(err)
+	suite.Equal("Circles", ad.Title)
+	suite.Equal(cache.Counter{Hits: 1, Misses: 1, Size: 1}, suite.Cache.Stats().Ads)
+
+	// чтение обслуживается кэшем: изменение в обход декоратора не видно
+	suite.NoError(suite.Inner.UpdateAdContent(suite.Ctx, id, "Swimming", "text", suite.Now))
+	ad, err = suite.Cache.GetAdByID(suite.Ctx, id)
+	suite.NoError(err)
+	suite.Equal("Circles", ad.Title)
+
+	_, err = suite.Cache.GetAdByID(suite.Ctx, id+1)
+	suite.ErrorIs(err, app.ErrAdNotFound)
+	suite.Equal(1, suite.Cache.Stats().Ads.Size)
+}
+
+func (suite *CacheSuite) TestGetAd_TTL() {
+	id := suite.addAd("Circles", false)
+
+	_, err := suite.Cache.GetAdByID(suite.Ctx, id)
+	suite.NoError(err)
+	suite.Now = suite.Now.Add(time.Minute)
+	_, err = suite.Cache.GetAdByID(suite.Ctx, id)
+	suite.NoError(err)
+	suite.Equal(uint64(2), suite.Cache.Stats().Ads.Misses)
+}
+
+func (suite *CacheSuite) TestGetAd_LRU() {
+	first := suite.addAd("Circles", false)
+	second := suite.addAd("Swimming", false)
+	third := suite.addAd("Faces", false)
+
+	for _, id := range []int64{first, second, first, third} {
+		_, err := suite.Cache.GetAdByID(suite.Ctx, id)
+		suite.NoError(err)
+	}
+	// second использовался давнее всех и был вытеснен
+	_, err := suite.Cache.GetAdByID(suite.Ctx, first)
+	suite.NoError(err)
+	_, err = suite.Cache.GetAdByID(suite.Ctx, second)
+	suite.NoError(err)
+	suite.Equal(cache.Counter{Hits: 2, Misses: 4, Size: 2}, suite.Cache.Stats().Ads)
+}
+
+func (suite *CacheSuite) TestGetAd_Invalidation() {
+	id := suite.addAd("Circles", false)
+	get := func() *ads.Ad {
+		ad, err := suite.Cache.GetAdByID(suite.Ctx, id)
+		suite.NoError(err)
+		return ad
+	}
+
+	get()
+	suite.NoError(suite.Cache.UpdateAdContent(suite.Ctx, id, "Swimming", "text", suite.Now))
+	suite.Equal("Swimming", get().Title)
+
+	suite.NoError(suite.Cache.UpdateAdStatus(suite.Ctx, id, true, suite.Now))
+	suite.True(get().Published)
+
+	expiresAt := suite.Now.Add(time.Hour)
+	suite.NoError(suite.Cache.UpdateAdSchedule(suite.Ctx, id, nil, &expiresAt, suite.Now))
+	suite.Equal(expiresAt, *get().ExpiresAt)
+
+	_, err := suite.Cache.ExpireAds(suite.Ctx, expiresAt)
+	suite.NoError(err)
+	suite.False(get().Published)
+
+	suite.NoError(suite.Cache.DeleteAdByID(suite.Ctx, id))
+	_, err = suite.Cache.GetAdByID(suite.Ctx, id)
+	suite.ErrorIs(err, app.ErrAdNotFound)
+}
+
+func (suite *CacheSuite) TestGetAd_PublishScheduled() {
+	published := suite.addAd("Circles", false)
+	expired := suite.addAd("Swimming", false)
+	publishAt := suite.Now.Add(time.Minute)
+	suite.NoError(suite.Cache.UpdateAdSchedule(suite.Ctx, published, &publishAt, nil, suite.Now))
+	suite.NoError(suite.Cache.UpdateAdSchedule(suite.Ctx, expired, &publishAt, &publishAt, suite.Now))
+
+	for _, id := range []int64{published, expired} {
+		_, err := suite.Cache.GetAdByID(suite.Ctx, id)
+		suite.NoError(err)
+	}
+
+	_, err := suite.Cache.PublishScheduledAds(suite.Ctx, publishAt)
+	suite.NoError(err)
+
+	ad, err := suite.Cache.GetAdByID(suite.Ctx, published)
+	suite.NoError(err)
+	suite.True(ad.Published)
+	// просроченное объявление не публикуется, но теряет расписание
+	ad, err = suite.Cache.GetAdByID(suite.Ctx, expired)
+	suite.NoError(err)
+	suite.False(ad.Published)
+	suite.Nil(ad.PublishAt)
+}
+
+func (suite *CacheSuite) TestGetUser_Invalidation() {
+	_, err := suite.Cache.GetUserByID(suite.Ctx, suite.UID)
+	suite.NoError(err)
+
+	suite.NoError(suite.Cache.SetUserBanned(suite.Ctx, suite.UID, true))
+	u, err := suite.Cache.GetUserByID(suite.Ctx, suite.UID)
+	suite.NoError(err)
+	suite.True(u.Banned)
+
+	suite.NoError(suite.Cache.SetUserRole(suite.Ctx, suite.UID, user.RoleModerator))
+	suite.NoError(suite.Cache.UpdateUser(suite.Ctx, suite.UID, "Larry Fisherman", "circles@swimming.com"))
+	u, err = suite.Cache.GetUserByID(suite.Ctx, suite.UID)
+	suite.NoError(err)
+	suite.Equal(user.RoleModerator, u.Role)
+	suite.Equal("Larry Fisherman", u.Nickname)
+	_, err = suite.Cache.GetUserByID(suite.Ctx, suite.UID)
+	suite.NoError(err)
+	suite.Equal(cache.Counter{Hits: 1, Misses: 3, Size: 1}, suite.Cache.Stats().Users)
+
+	id := suite.addAd("Circles", true)
+	_, err = suite.Cache.GetAdByID(suite.Ctx, id)
+	suite.NoError(err)
+	suite.NoError(suite.Cache.DeleteUserByID(suite.Ctx, suite.UID))
+	_, err = suite.Cache.GetUserByID(suite.Ctx, suite.UID)
+	suite.ErrorIs(err, app.ErrUserNotFound)
+	_, err = suite.Cache.GetAdByID(suite.Ctx, id)
+	suite.ErrorIs(err, app.ErrAdNotFound)
+}
+
+func (suite *CacheSuite) TestGetAdList_Popular() {
+	suite.addAd("Circles", true)
+	pub := true
+	params := app.ListAdsParams{Published: &pub}
+
+	for i := 0; i < 3; i++ {
+		al, err := suite.Cache.GetAdList(suite.Ctx, params)
+		suite.NoError(err)
+		suite.Len(al.Data, 1)
+	}
+	// первый запрос не кэшируется, второй попадает в кэш, третий читается из него
+	suite.Equal(cache.Counter{Hits: 1, Misses: 2, Size: 1}, suite.Cache.Stats().Lists)
+}
+
+func (suite *CacheSuite) TestGetAdList_ActiveAt() {
+	id := suite.addAd("Circles", true)
+	expiresAt := suite.Now.Add(time.Hour)
+	suite.NoError(suite.Cache.UpdateAdSchedule(suite.Ctx, id, nil, &expiresAt, suite.Now))
+
+	pub := true
+	for i := 0; i < 2; i++ {
+		now := suite.Now
+		al, err := suite.Cache.GetAdList(suite.Ctx, app.ListAdsParams{Published: &pub, ActiveAt: &now})
+		suite.NoError(err)
+		suite.Len(al.Data, 1)
+	}
+
+	al, err := suite.Cache.GetAdList(suite.Ctx, app.ListAdsParams{Published: &pub, ActiveAt: &expiresAt})
+	suite.NoError(err)
+	suite.Len(al.Data, 0)
+	suite.Equal(uint64(1), suite.Cache.Stats().Lists.Hits)
+}
+
+func (suite *CacheSuite) TestGetAdList_PreciseInvalidation() {
+	other, err := suite.Cache.AddUser(suite.Ctx, user.User{Nickname: "J.Cole", Email: "foresthill@drive.com"})
+	suite.NoError(err)
+	suite.addAd("Circles", true)
+	otherAd, err := suite.Cache.AddAd(suite.Ctx, ads.Ad{Title: "Forest Hills", Text: "text", AuthorID: other})
+	suite.NoError(err)
+
+	pub := true
+	mine := app.ListAdsParams{Published: &pub, Uid: &suite.UID}
+	all := app.ListAdsParams{Published: &pub}
+	list := func(params app.ListAdsParams) int {
+		al, err := suite.Cache.GetAdList(suite.Ctx, params)
+		suite.NoError(err)
+		return len(al.Data)
+	}
+	for i := 0; i < 2; i++ {
+		suite.Equal(1, list(mine))
+		suite.Equal(1, list(all))
+	}
+	suite.Equal(2, suite.Cache.Stats().Lists.Size)
+
+	// объявление другого автора не влияет на список mine
+	suite.NoError(suite.Cache.UpdateAdStatus(suite.Ctx, otherAd, true, suite.Now))
+	suite.Equal(1, suite.Cache.Stats().Lists.Size)
+	suite.Equal(1, list(mine))
+	suite.Equal(2, list(all))
+	suite.Equal(uint64(1), suite.Cache.Stats().Lists.Hits)
+
+	// неопубликованное объявление не попадает ни в один из списков
+	_, err = suite.Cache.AddAd(suite.Ctx, ads.Ad{Title: "Draft", Text: "text", AuthorID: suite.UID})
+	suite.NoError(err)
+	suite.Equal(1, list(mine))
+	suite.Equal(uint64(2), suite.Cache.Stats().Lists.Hits)
+}
+
+// TestConcurrentWriters проверяет, что после завершения всех записей кэш не хранит
+// устаревших данных, даже если чтения шли параллельно с записями
+func (suite *CacheSuite) TestConcurrentWriters() {
+	const (
+		nAds     = 4
+		nWriters = 8
+		nWrites  = 200
+	)
+	suite.Cache = cache.New(suite.Inner, cache.Config{Capacity: 100, ListCapacity: 10, TTL: time.Hour, ListAdmitAfter: 1})
+	ids := make([]int64, nAds)
+	for i := range ids {
+		ids[i] = suite.addAd("v0", false)
+	}
+	pub := true
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, id := range ids {
+					_, _ = suite.Cache.GetAdByID(suite.Ctx, id)
+				}
+				_, _ = suite.Cache.GetAdList(suite.Ctx, app.ListAdsParams{Published: &pub})
+				_, _ = suite.Cache.GetAdList(suite.Ctx, app.ListAdsParams{})
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+	for w := 0; w < nWriters; w++ {
+		writers.Add(1)
+		go func(w int) {
+			defer writers.Done()
+			for i := 0; i < nWrites; i++ {
+				id := ids[(w+i)%nAds]
+				_ = suite.Cache.UpdateAdContent(suite.Ctx, id, fmt.Sprintf("v%d-%d", w, i), "text", suite.Now)
+				_ = suite.Cache.UpdateAdStatus(suite.Ctx, id, i%2 == 0, suite.Now)
+			}
+		}(w)
+	}
+	writers.Wait()
+	close(stop)
+	wg.Wait()
+
+	for _, id := range ids {
+		cached, err := suite.Cache.GetAdByID(suite.Ctx, id)
+		suite.NoError(err)
+		actual, err := suite.Inner.GetAdByID(suite.Ctx, id)
+		suite.NoError(err)
+		suite.Equal(*actual, *cached)
+	}
+	for _, params := range []app.ListAdsParams{{Published: &pub}, {}} {
+		cached, err := suite.Cache.GetAdList(suite.Ctx, params)
+		suite.NoError(err)
+		actual, err := suite.Inner.GetAdList(suite.Ctx, params)
+		suite.NoError(err)
+		suite.Equal(sortedAds(actual.Data), sortedAds(cached.Data))
+	}
+	suite.NotZero(suite.Cache.Stats().Ads.Hits)
+}
+
+func (suite *CacheSuite) TestApp() {
+	a := app.NewApp(suite.Cache)
+	ad, err := a.CreateAd(suite.Ctx, "Circles", "Good News", suite.UID)
+	suite.NoError(err)
+	for i := 0; i < 2; i++ {
+		al, err := a.ListAds(suite.Ctx, app.ListAdsParams{})
+		suite.NoError(err)
+		suite.Len(al.Data, 0)
+	}
+
+	_, err = a.ChangeAdStatus(suite.Ctx, ad.ID, suite.UID, true)
+	suite.NoError(err)
+	al, err := a.ListAds(suite.Ctx, app.ListAdsParams{})
+	suite.NoError(err)
+	suite.Len(al.Data, 1)
+
+	_, err = a.UpdateAd(suite.Ctx, ad.ID, suite.UID, "Swimming", "Self Care")
+	suite.NoError(err)
+	res, err := a.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.Equal("Swimming", res.Title)
+}
+
+func sortedAds(al []ads.Ad) []ads.Ad {
+	res := make([]ads.Ad, len(al))
+	copy(res, al)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+func TestCacheSuite(t *testing.T) {
+	suite.Run(t, new(CacheSuite))
+}
@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/capture"
+	"homework10/internal/config"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/replay"
+	"homework10/internal/tenant"
+)
+
+// recordingClients поднимает HTTP и gRPC поверх одного приложения с записью запросов в rec
+func recordingClients(t *testing.T, a app.App, store *config.Store, rec *capture.Recorder) (*testClient, grpcPort.AdServiceClient) {
+	server := httpgin.NewHTTPServer(":18080", a)
+	testServer := httptest.NewServer(httpgin.RecordHandler(rec, httpgin.ConfigHandler(store, server.Handler)))
+	t.Cleanup(testServer.Close)
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		grpcPort.UnaryCaptureInterceptor(rec),
+		grpcPort.UnaryRecoveryInterceptor(),
+		grpcPort.UnaryLocaleInterceptor(),
+		grpcPort.UnaryConfigInterceptor(store),
+	))
+	grpcPort.RegisterAdServiceServer(srv, grpcPort.NewService(a))
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return &testClient{client: testServer.Client(), baseURL: testServer.URL}, grpcPort.NewAdServiceClient(conn)
+}
+
+// recordSession записывает запросы обоих протоколов, включая ошибочные
+func recordSession(t *testing.T) []capture.Entry {
+	var buf bytes.Buffer
+	rec := capture.NewRecorder(&buf)
+	client, grpcClient := recordingClients(t, app.NewApp(adrepo.New()), config.NewStore(nil), rec)
+	ctx := context.Background()
+
+	u, err := client.createUser("Nas", "illmatic@qb.com")
+	require.NoError(t, err)
+	ad, err := client.createAd(u.Data.ID, "hello", "world")
+	require.NoError(t, err)
+	_, err = client.changeAdStatus(u.Data.ID, ad.Data.ID, true)
+	require.NoError(t, err)
+	_, err = client.getAd(100)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	req, err := http.NewRequest(http.MethodPost, client.baseURL+"/api/v1/ads", strings.NewReader("{not json"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	require.ErrorIs(t, client.getResponse(req, nil), ErrBadRequest)
+
+	gu, err := grpcClient.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Mobb Deep", Email: "shook@ones.com"})
+	require.NoError(t, err)
+	_, err = grpcClient.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "infamous", Text: "quiet storm", UserId: &gu.Id})
+	require.NoError(t, err)
+	missing := int64(100)
+	_, err = grpcClient.GetAd(ctx, &grpcPort.GetAdRequest{AdId: &missing})
+	require.Error(t, err)
+
+	require.NoError(t, rec.Err())
+	entries, err := capture.Read(&buf)
+	require.NoError(t, err)
+	return entries
+}
+
+func newReplayer(t *testing.T) *replay.Replayer {
+	reg, err := tenant.NewRegistry(nil)
+	require.NoError(t, err)
+	r, err := replay.New(app.NewApp(adrepo.New()), replay.Options{Tenants: reg})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+func TestCaptureRecord(t *testing.T) {
+	entries := recordSession(t)
+	require.Len(t, entries, 8)
+
+	for i, e := range entries {
+		assert.Equal(t, int64(i+1), e.Seq)
+		assert.False(t, e.Time.IsZero())
+	}
+
+	create := entries[1]
+	assert.Equal(t, capture.ProtocolHTTP, create.Protocol)
+	assert.Equal(t, http.MethodPost, create.Method)
+	assert.Equal(t, "/api/v1/ads", create.Path)
+	assert.Equal(t, "application/json", create.Header["Content-Type"])
+	assert.Equal(t, http.StatusOK, create.Status)
+	assert.JSONEq(t, `{"title":"hello","text":"world","user_id":0}`, string(create.Request))
+
+	notFound := entries[3]
+	assert.Equal(t, http.StatusNotFound, notFound.Status)
+
+	invalid := entries[4]
+	assert.Nil(t, invalid.Request)
+	assert.Equal(t, "{not json", invalid.RequestRaw)
+
+	grpcCreate := entries[6]
+	assert.Equal(t, capture.ProtocolGRPC, grpcCreate.Protocol)
+	assert.Equal(t, "/ad.AdService/CreateAd", grpcCreate.Method)
+	assert.JSONEq(t, `{"title":"infamous","text":"quiet storm","user_id":"1"}`, string(grpcCreate.Request))
+	assert.Contains(t, string(grpcCreate.Response), `"title":"infamous"`)
+
+	grpcMissing := entries[7]
+	assert.Equal(t, 5, grpcMissing.Status) // codes.NotFound
+	assert.NotEmpty(t, grpcMissing.Error)
+	assert.Nil(t, grpcMissing.Response)
+}
+
+func TestCaptureFileRoundTrip(t *testing.T) {
+	entries := recordSession(t)
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		require.NoError(t, err)
+		buf.Write(append(line, '\n'))
+	}
+	read, err := capture.Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, entries, read)
+
+	_, err = capture.Read(strings.NewReader(`{"seq":1,"protocol":"ftp"}`))
+	assert.ErrorIs(t, err, capture.ErrInvalidEntry)
+}
+
+func TestReplayMatches(t *testing.T) {
+	entries := recordSession(t)
+
+	report, err := newReplayer(t).Run(context.Background(), entries)
+	require.NoError(t, err)
+	assert.True(t, report.OK(), "%+v", report.Diffs)
+	assert.Equal(t, len(entries), report.Total)
+	assert.Equal(t, len(entries), report.Matched)
+}
+
+func TestReplayReportsDiffs(t *testing.T) {
+	entries := recordSession(t)
+	entries[1].Response = json.RawMessage(strings.Replace(string(entries[1].Response), `"hello"`, `"goodbye"`, 1))
+	entries[3].Status = http.StatusOK
+	entries[7].Error = "something else"
+
+	report, err := newReplayer(t).Run(context.Background(), entries)
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, len(entries)-3, report.Matched)
+	require.Len(t, report.Diffs, 3)
+
+	assert.Equal(t, int64(2), report.Diffs[0].Seq)
+	assert.Equal(t, []string{`$.data.title: want "goodbye", got "hello"`}, report.Diffs[0].Differences)
+	assert.Equal(t, []string{"status: want 200, got 404"}, report.Diffs[1].Differences)
+	assert.Equal(t, int64(8), report.Diffs[2].Seq)
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	assert.Contains(t, out.String(), "replayed 8 requests: 5 matched, 3 differ")
+	assert.Contains(t, out.String(), "#2 http POST /api/v1/ads")
+}
+
+func TestReplayUnknownMethod(t *testing.T) {
+	_, err := newReplayer(t).Replay(context.Background(), capture.Entry{Protocol: capture.ProtocolGRPC, Method: "/ad.AdService/Nope"})
+	assert.ErrorIs(t, err, replay.ErrUnknownMethod)
+}
+
+func TestReplayRuntimeConfig(t *testing.T) {
+	store := config.NewStore(&config.Config{Limits: tenant.Limits{MaxTitleLength: 3}})
+	var buf bytes.Buffer
+	client, _ := recordingClients(t, app.NewApp(adrepo.New()), store, capture.NewRecorder(&buf))
+
+	u, err := client.createUser("Nas", "illmatic@qb.com")
+	require.NoError(t, err)
+	_, err = client.createAd(u.Data.ID, "hello", "world")
+	require.ErrorIs(t, err, ErrBadRequest)
+
+	entries, err := capture.Read(&buf)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	r, err := replay.New(app.NewApp(adrepo.New()), replay.Options{Config: store})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = r.Close() })
+	report, err := r.Run(context.Background(), entries)
+	require.NoError(t, err)
+	assert.True(t, report.OK(), "%+v", report.Diffs)
+
+	report, err = newReplayer(t).Run(context.Background(), entries)
+	require.NoError(t, err)
+	require.Len(t, report.Diffs, 1)
+	assert.Equal(t, int64(2), report.Diffs[0].Seq)
+}
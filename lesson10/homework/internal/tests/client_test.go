@@ -0,0 +1,303 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/TobbyMax/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+	"homework10/pkg/client"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failFirst - сколько первых запросов сервер отклоняет как временно недоступный
+type failFirst struct {
+	remaining atomic.Int32
+	calls     atomic.Int32
+}
+
+func (f *failFirst) fail() bool {
+	f.calls.Add(1)
+	return f.remaining.Add(-1) >= 0
+}
+
+func newHTTPTestClient(repo app.Repository, cfg client.Config, f *failFirst) (client.AdsClient, func()) {
+	handler := httpgin.NewHTTPServer(":18080", app.NewApp(repo)).Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.fail() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	c := client.NewHTTPClient(srv.URL, cfg, srv.Client())
+	return c, func() {
+		_ = c.Close()
+		srv.Close()
+	}
+}
+
+func newGRPCTestClient(repo app.Repository, cfg client.Config, f *failFirst) (client.AdsClient, func()) {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if f.fail() {
+				return nil, status.Error(codes.Unavailable, "try again later")
+			}
+			return handler(ctx, req)
+		},
+		grpcPort.UnaryRecoveryInterceptor(),
+	))
+	grpcPort.RegisterAdServiceServer(srv, grpcPort.NewService(app.NewApp(repo)))
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	c, err := client.DialGRPC(context.Background(), "bufnet", cfg,
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		panic(err)
+	}
+	return c, func() {
+		_ = c.Close()
+		srv.Stop()
+	}
+}
+
+type ClientSuite struct {
+	suite.Suite
+	NewClient func(repo app.Repository, cfg client.Config, f *failFirst) (client.AdsClient, func())
+
+	Repo    *adrepo.RepositoryMap
+	Flaky   *failFirst
+	Client  client.AdsClient
+	Ctx     context.Context
+	cleanup func()
+}
+
+func (suite *ClientSuite) SetupTest() {
+	suite.Ctx = context.Background()
+	suite.Repo = adrepo.NewRepositoryMap()
+	suite.Flaky = &failFirst{}
+	cfg := client.Config{Timeout: time.Second, MaxRetries: 2, Backoff: time.Millisecond}
+	suite.Client, suite.cleanup = suite.NewClient(suite.Repo, cfg, suite.Flaky)
+}
+
+func (suite *ClientSuite) TearDownTest() {
+	suite.cleanup()
+}
+
+func (suite *ClientSuite) TestAdsAndUsers() {
+	u, err := suite.Client.CreateUser(suite.Ctx, "Mac Miller", "swimming@circles.com")
+	suite.Require().NoError(err)
+	suite.Equal("Mac Miller", u.Nickname)
+
+	ad, err := suite.Client.CreateAd(suite.Ctx, u.ID, "Circles", "Good News")
+	suite.Require().NoError(err)
+	suite.Equal(u.ID, ad.AuthorID)
+	suite.False(ad.DateCreated.IsZero())
+
+	ad, err = suite.Client.ChangeAdStatus(suite.Ctx, ad.ID, u.ID, true)
+	suite.NoError(err)
+	suite.True(ad.Published)
+
+	ad, err = suite.Client.UpdateAd(suite.Ctx, ad.ID, u.ID, "Swimming", "Self Care")
+	suite.NoError(err)
+	got, err := suite.Client.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.Equal("Swimming", got.Title)
+	suite.Equal("Self Care", got.Text)
+
+	published := true
+	list, err := suite.Client.ListAds(suite.Ctx, app.ListAdsParams{Published: &published, Uid: &u.ID, Date: &got.DateCreated})
+	suite.NoError(err)
+	suite.Len(list, 1)
+
+	u, err = suite.Client.UpdateUser(suite.Ctx, u.ID, "Larry Fisherman", "larry@circles.com")
+	suite.NoError(err)
+	suite.Equal("Larry Fisherman", u.Nickname)
+
+	suite.NoError(suite.Client.DeleteAd(suite.Ctx, ad.ID, u.ID))
+	_, err = suite.Client.GetAd(suite.Ctx, ad.ID)
+	suite.ErrorIs(err, app.ErrAdNotFound)
+
+	suite.NoError(suite.Client.DeleteUser(suite.Ctx, u.ID))
+	_, err = suite.Client.GetUser(suite.Ctx, u.ID)
+	suite.ErrorIs(err, app.ErrUserNotFound)
+}
+
+func (suite *ClientSuite) TestErrorMapping() {
+	author, err := suite.Client.CreateUser(suite.Ctx, "author", "author@mail.com")
+	suite.Require().NoError(err)
+	other, err := suite.Client.CreateUser(suite.Ctx, "other", "other@mail.com")
+	suite.Require().NoError(err)
+	ad, err := suite.Client.CreateAd(suite.Ctx, author.ID, "title", "text")
+	suite.Require().NoError(err)
+
+	_, err = suite.Client.UpdateAd(suite.Ctx, ad.ID, other.ID, "new", "new")
+	suite.ErrorIs(err, app.ErrForbidden)
+
+	_, err = suite.Client.CreateAd(suite.Ctx, author.ID, "", "text")
+	suite.ErrorAs(err, &validator.ValidationErrors{})
+	var validationErr *client.Error
+	suite.Require().ErrorAs(err, &validationErr)
+	suite.Equal(apperr.CodeValidationFailed, validationErr.Reason)
+	suite.Require().Len(validationErr.Fields, 1)
+	suite.Equal("title", validationErr.Fields[0].Field)
+
+	_, err = suite.Client.CreateUser(suite.Ctx, "AUTHOR", "new@mail.com")
+	suite.ErrorIs(err, app.ErrAlreadyExists)
+
+	_, err = suite.Client.CreateAd(suite.Ctx, other.ID+100, "title", "text")
+	suite.ErrorIs(err, app.ErrUserNotFound)
+
+	suite.NoError(suite.Repo.SetUserBanned(suite.Ctx, author.ID, true))
+	_, err = suite.Client.CreateAd(suite.Ctx, author.ID, "title", "text")
+	suite.ErrorIs(err, app.ErrBanned)
+	suite.ErrorIs(err, app.ErrForbidden)
+
+	var clientErr *client.Error
+	suite.ErrorAs(err, &clientErr)
+	suite.NotZero(clientErr.Code)
+	suite.Equal(apperr.CodeUserBanned, clientErr.Reason)
+}
+
+func (suite *ClientSuite) TestRetries() {
+	u, err := suite.Client.CreateUser(suite.Ctx, "Mac Miller", "swimming@circles.com")
+	suite.Require().NoError(err)
+
+	suite.Flaky.remaining.Store(2)
+	suite.Flaky.calls.Store(0)
+	got, err := suite.Client.GetUser(suite.Ctx, u.ID)
+	suite.NoError(err)
+	suite.Equal(u.ID, got.ID)
+	suite.Equal(int32(3), suite.Flaky.calls.Load())
+
+	// повторов больше, чем разрешено
+	suite.Flaky.remaining.Store(3)
+	suite.Flaky.calls.Store(0)
+	_, err = suite.Client.GetUser(suite.Ctx, u.ID)
+	suite.ErrorIs(err, client.ErrUnavailable)
+	suite.Equal(int32(3), suite.Flaky.calls.Load())
+
+	// создание не повторяется
+	suite.Flaky.remaining.Store(1)
+	suite.Flaky.calls.Store(0)
+	_, err = suite.Client.CreateAd(suite.Ctx, u.ID, "title", "text")
+	suite.ErrorIs(err, client.ErrUnavailable)
+	suite.Equal(int32(1), suite.Flaky.calls.Load())
+
+	// удаление тоже: повтор после потерянного ответа вернул бы NotFound
+	ad, err := suite.Client.CreateAd(suite.Ctx, u.ID, "title", "text")
+	suite.Require().NoError(err)
+	suite.Flaky.remaining.Store(1)
+	suite.Flaky.calls.Store(0)
+	suite.ErrorIs(suite.Client.DeleteAd(suite.Ctx, ad.ID, u.ID), client.ErrUnavailable)
+	suite.Flaky.remaining.Store(1)
+	suite.ErrorIs(suite.Client.DeleteUser(suite.Ctx, u.ID), client.ErrUnavailable)
+	suite.Equal(int32(2), suite.Flaky.calls.Load())
+}
+
+func (suite *ClientSuite) TestCanceledContext() {
+	ctx, cancel := context.WithCancel(suite.Ctx)
+	cancel()
+	_, err := suite.Client.GetUser(ctx, 0)
+	suite.Error(err)
+	suite.NotErrorIs(err, client.ErrUnavailable)
+}
+
+func TestHTTPClient(t *testing.T) {
+	suite.Run(t, &ClientSuite{NewClient: newHTTPTestClient})
+}
+
+func TestGRPCClient(t *testing.T) {
+	suite.Run(t, &ClientSuite{NewClient: newGRPCTestClient})
+}
+
+func TestHTTPClient_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	c := client.NewHTTPClient(srv.URL, client.Config{Timeout: 20 * time.Millisecond}, srv.Client())
+	start := time.Now()
+	_, err := c.GetAd(context.Background(), 0)
+	if !errors.Is(err, client.ErrUnavailable) || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("expected timeout, got %v after %v", err, time.Since(start))
+	}
+}
+
+// TestClientExternalModule собирает программу из testdata/clientsdk как отдельный модуль:
+// ей недоступны пакеты internal, поэтому она проверяет, что клиент самодостаточен
+func TestClientExternalModule(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a separate module")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool is not available")
+	}
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	require.NoError(t, err)
+
+	newModule := func(source string) string {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "go.mod"), fmt.Sprintf(
+			"module example.com/clientsdk\n\ngo 1.19\n\nrequire homework10 v0.0.0\n\nreplace homework10 => %s\n", root))
+		sum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+		require.NoError(t, err)
+		writeFile(t, filepath.Join(dir, "go.sum"), string(sum))
+		writeFile(t, filepath.Join(dir, "main.go"), source)
+		return dir
+	}
+	goCmd := func(dir string, args ...string) (string, error) {
+		cmd := exec.Command(goBin, args...)
+		cmd.Dir = dir
+		// зависимости берутся из кэша модулей, сеть не нужна
+		cmd.Env = append(os.Environ(), "GOPROXY=off", "GOFLAGS=-mod=mod", "GOWORK=off")
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	source, err := os.ReadFile(filepath.Join("testdata", "clientsdk", "main.go"))
+	require.NoError(t, err)
+	dir := newModule(string(source))
+	bin := filepath.Join(dir, "clientsdk")
+	out, err := goCmd(dir, "build", "-o", bin, ".")
+	require.NoError(t, err, out)
+
+	srv := httptest.NewServer(httpgin.NewHTTPServer("", app.NewApp(adrepo.New())).Handler)
+	defer srv.Close()
+	run, err := exec.Command(bin, srv.URL).CombinedOutput()
+	assert.NoError(t, err, string(run))
+
+	// проверка не вырождена: внутренние пакеты такому модулю действительно недоступны
+	dir = newModule("package main\n\nimport \"homework10/internal/app\"\n\nvar _ = app.ErrAdNotFound\n\nfunc main() {}\n")
+	out, err = goCmd(dir, "build", "-o", os.DevNull, ".")
+	assert.Error(t, err)
+	assert.Contains(t, out, "use of internal package")
+}
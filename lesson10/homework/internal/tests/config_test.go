@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/config"
+	"homework10/internal/logging"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/tenant"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// resetLogLevel возвращает уровень журнала по умолчанию после теста
+func resetLogLevel(t *testing.T) {
+	t.Cleanup(func() { logging.SetLevel(logging.LevelInfo) })
+}
+
+func TestConfigParse(t *testing.T) {
+	c, err := config.Parse(strings.NewReader(`
+log_level: warn
+limits: {max_title_length: 20}
+features: {new_list_filter: true}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, logging.LevelWarn, c.LogLevel)
+	assert.Equal(t, 20, c.Limits.MaxTitleLength)
+	assert.True(t, c.Features.Enabled("new_list_filter", 0))
+	assert.False(t, c.Features.Enabled("moderation", 0))
+
+	c, err = config.Parse(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Equal(t, config.Default(), c)
+
+	for _, bad := range []string{
+		"log_level: loud",
+		"log_levle: warn",
+		"limits: {max_text_length: -1}",
+		"features: {\"New Filter\": true}",
+	} {
+		_, err = config.Parse(strings.NewReader(bad))
+		assert.ErrorIs(t, err, config.ErrInvalidConfig, bad)
+	}
+}
+
+func TestConfigLimitsFor(t *testing.T) {
+	c := &config.Config{Limits: tenant.Limits{MaxTitleLength: 20, MaxTextLength: 100}}
+	cars := tenant.Tenant{ID: "cars", Config: tenant.Config{Limits: tenant.Limits{MaxTitleLength: 10, MaxTextLength: 500, MaxNicknameLength: 8}}}
+	assert.Equal(t, tenant.Limits{MaxTitleLength: 10, MaxTextLength: 100, MaxNicknameLength: 8}, c.LimitsFor(cars))
+	assert.Equal(t, c.Limits, c.LimitsFor(tenant.Tenant{ID: tenant.Default}))
+}
+
+func TestLoggingLevel(t *testing.T) {
+	resetLogLevel(t)
+	var buf bytes.Buffer
+	w := logging.Writer(logging.LevelInfo, &buf)
+
+	logging.SetLevel(logging.LevelWarn)
+	assert.False(t, logging.Enabled(logging.LevelInfo))
+	assert.True(t, logging.Enabled(logging.LevelError))
+	n, err := w.Write([]byte("skipped\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 8, n)
+
+	logging.SetLevel(logging.LevelDebug)
+	_, err = w.Write([]byte("written\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "written\n", buf.String())
+
+	_, err = logging.ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestConfigReload(t *testing.T) {
+	resetLogLevel(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "runtime.yaml")
+	writeFile(t, path, "log_level: warn\nfeatures: {moderation: true}\n")
+
+	store := config.NewStore(nil)
+	old := store.Load()
+	r := config.NewReloader(store, path, nil, "")
+	require.NoError(t, r.Reload())
+	assert.Equal(t, logging.LevelWarn, logging.GetLevel())
+	assert.True(t, store.Load().Features.Enabled("moderation", 0))
+	// прежний снимок не изменяется
+	assert.False(t, old.Features.Enabled("moderation", 0))
+
+	// ошибочный файл не публикуется
+	prev := store.Load()
+	writeFile(t, path, "log_level: loud\n")
+	assert.ErrorIs(t, r.Reload(), config.ErrInvalidConfig)
+	assert.Same(t, prev, store.Load())
+	assert.Equal(t, logging.LevelWarn, logging.GetLevel())
+
+	// ошибка применения откатывает снимок и уровень журнала
+	errBoom := errors.New("boom")
+	r.OnReload(func(c *config.Config) error {
+		if c.Features.Enabled("broken", 0) {
+			return errBoom
+		}
+		return nil
+	})
+	writeFile(t, path, "log_level: debug\nfeatures: {broken: true}\n")
+	assert.ErrorIs(t, r.Reload(), errBoom)
+	assert.Same(t, prev, store.Load())
+	assert.Equal(t, logging.LevelWarn, logging.GetLevel())
+}
+
+func TestConfigReloadTenants(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tenants.yaml")
+	writeFile(t, path, "tenants:\n  cars:\n    hosts: [cars.example.com]\n    rate_limit: {rps: 0.001, burst: 1}\n")
+	reg, err := tenant.Load(path)
+	require.NoError(t, err)
+	r := config.NewReloader(config.NewStore(nil), "", reg, path)
+
+	cars, err := reg.Lookup("cars")
+	require.NoError(t, err)
+	require.NoError(t, reg.Allow(cars))
+	require.ErrorIs(t, reg.Allow(cars), tenant.ErrRateLimited)
+
+	writeFile(t, path, "tenants:\n  cars:\n    hosts: [auto.example.com]\n    rate_limit: {rps: 0.001, burst: 3}\n  flats: {}\n")
+	require.NoError(t, r.Reload())
+	assert.Equal(t, []tenant.ID{"cars", tenant.Default, "flats"}, reg.IDs())
+	byHost, err := reg.Resolve("", "auto.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, tenant.ID("cars"), byHost.ID)
+	byHost, err = reg.Resolve("", "cars.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, tenant.Default, byHost.ID)
+
+	// новый лимит действует без перезапуска
+	cars, err = reg.Lookup("cars")
+	require.NoError(t, err)
+	assert.NoError(t, reg.Allow(cars))
+
+	// ошибочный файл арендаторов не меняет ни арендаторов, ни настройки
+	writeFile(t, path, "tenants:\n  Bad Tenant: {}\n")
+	assert.Error(t, r.Reload())
+	_, err = reg.Lookup("flats")
+	assert.NoError(t, err)
+}
+
+func TestConfigWatch(t *testing.T) {
+	resetLogLevel(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "runtime.yaml")
+	writeFile(t, path, "features: {a: true}\n")
+	store := config.NewStore(nil)
+	r := config.NewReloader(store, path, nil, "")
+	require.NoError(t, r.Reload())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Watch(ctx, signals, 10*time.Millisecond)
+	}()
+
+	// изменение файла подхватывается опросом
+	writeFile(t, path, "features: {a: true, bb: true}\n")
+	assert.Eventually(t, func() bool { return store.Load().Features.Enabled("bb", 0) }, time.Second, 5*time.Millisecond)
+
+	// по сигналу файл перечитывается, даже если его размер не изменился
+	writeFile(t, path, "features: {a: true, cc: true}\n")
+	signals <- syscall.SIGHUP
+	assert.Eventually(t, func() bool { return store.Load().Features.Enabled("cc", 0) }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestConfigHTTP(t *testing.T) {
+	store := config.NewStore(nil)
+	a := app.NewApp(adrepo.New())
+	server := httptest.NewServer(httpgin.ConfigHandler(store, httpgin.NewHTTPServer("", a).Handler))
+	defer server.Close()
+
+	post := func(path string, body any) (int, map[string]any) {
+		resp, err := http.Post(server.URL+"/api/v1"+path, "application/json", jsonBody(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var out map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return resp.StatusCode, out
+	}
+
+	code, u := post("/users", map[string]any{"nickname": "mac", "email": "mac@mail.com"})
+	require.Equal(t, http.StatusOK, code)
+	uid := u["data"].(map[string]any)["id"]
+	ad := map[string]any{"user_id": uid, "title": "Long enough title", "text": "text"}
+	code, _ = post("/ads", ad)
+	assert.Equal(t, http.StatusOK, code)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "runtime.yaml")
+	writeFile(t, path, "limits: {max_title_length: 10}\n")
+	require.NoError(t, config.NewReloader(store, path, nil, "").Reload())
+
+	code, p := post("/ads", ad)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "title", p["errors"].([]any)[0].(map[string]any)["field"])
+}
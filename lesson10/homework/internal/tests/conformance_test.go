@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/cache"
+	"homework10/internal/app"
+	"homework10/internal/idgen"
+	"homework10/internal/tenant"
+	"homework10/internal/tests/repotest"
+	"testing"
+)
+
+func TestConformance_RepositoryMap(t *testing.T) {
+	repotest.Run(t, func() app.Repository { return adrepo.NewRepositoryMap() })
+}
+
+func TestConformance_Sharded(t *testing.T) {
+	repotest.Run(t, func() app.Repository { return adrepo.NewShardedRepository(4) })
+}
+
+func TestConformance_ShardedSnowflake(t *testing.T) {
+	repotest.Run(t, func() app.Repository {
+		sf, err := idgen.NewSnowflake(1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return adrepo.NewShardedRepositoryWithIDs(4, adrepo.SharedIDs(sf))
+	})
+}
+
+func TestConformance_Cache(t *testing.T) {
+	repotest.Run(t, func() app.Repository { return cache.New(adrepo.NewRepositoryMap(), cache.DefaultConfig()) })
+}
+
+func TestConformance_Tenants(t *testing.T) {
+	repotest.Run(t, func() app.Repository {
+		return adrepo.NewTenantRepository(func(tenant.ID) app.Repository { return adrepo.NewRepositoryMap() })
+	})
+}
@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/TobbyMax/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/tests/mocks"
+	"homework10/internal/user"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type ErrorsSuite struct {
+	suite.Suite
+}
+
+func (suite *ErrorsSuite) TestClassify() {
+	tests := []struct {
+		name string
+		err  error
+		kind apperr.Kind
+		code apperr.Code
+	}{
+		{"banned before forbidden", fmt.Errorf("create ad: %w", app.ErrBanned), apperr.KindForbidden, apperr.CodeUserBanned},
+		{"blocked", app.ErrBlocked, apperr.KindForbidden, apperr.CodeUserBlocked},
+		{"forbidden", app.ErrForbidden, apperr.KindForbidden, apperr.CodeForbidden},
+		{"already verified", app.ErrAlreadyVerified, apperr.KindAlreadyExists, apperr.CodeAlreadyVerified},
+		{"ad not found", app.ErrAdNotFound, apperr.KindNotFound, apperr.CodeAdNotFound},
+		{"user not found", app.ErrUserNotFound, apperr.KindNotFound, apperr.CodeUserNotFound},
+		{"invalid role", user.ErrInvalidRole, apperr.KindInvalidArgument, apperr.CodeInvalidRole},
+		{"validation", validator.ValidationErrors{{Err: user.ErrInvalidEmail}}, apperr.KindInvalidArgument, apperr.CodeValidationFailed},
+		{"invalid argument", apperr.Invalid(ErrMock), apperr.KindInvalidArgument, apperr.CodeInvalidArgument},
+		{"invalid keeps app error", apperr.Invalid(app.ErrAdNotFound), apperr.KindNotFound, apperr.CodeAdNotFound},
+		{"internal", ErrMock, apperr.KindInternal, apperr.CodeInternal},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			e := apperr.Classify(tt.err)
+			suite.Equal(tt.kind, e.Kind)
+			suite.Equal(tt.code, e.Code)
+			message := tt.err.Error()
+			if tt.kind == apperr.KindInternal {
+				message = apperr.InternalMessage
+			}
+			suite.Equal(message, e.Message)
+		})
+	}
+
+	suite.ErrorIs(apperr.CodeUserBanned.Err(), app.ErrBanned)
+	suite.Nil(apperr.CodeInternal.Err())
+	suite.ErrorIs(apperr.Invalid(ErrMock), ErrMock)
+}
+
+func (suite *ErrorsSuite) TestViolations() {
+	type request struct {
+		Title      string `validate:"min:1"`
+		AuthorName string `validate:"min:1"`
+	}
+	err := validator.Validate(request{})
+	var verrs validator.ValidationErrors
+	suite.Require().ErrorAs(err, &verrs)
+
+	fields := apperr.Classify(err).Fields
+	suite.Require().Len(fields, 2)
+	suite.Equal("title", fields[0].Field)
+	suite.Equal("author_name", fields[1].Field)
+	suite.Contains(fields[0].Description, "min")
+
+	fields = apperr.Violations(validator.ValidationErrors{{Err: user.ErrInvalidEmail}})
+	suite.Equal([]apperr.FieldViolation{{Field: "email", Description: user.ErrInvalidEmail.Error()}}, fields)
+
+	suite.Equal("author_id", apperr.FieldName("AuthorID"))
+	suite.Equal("publish_at", apperr.FieldName("PublishAt"))
+	suite.Equal("id", apperr.FieldName("ID"))
+}
+
+func TestErrors(t *testing.T) {
+	suite.Run(t, new(ErrorsSuite))
+}
+
+type problem struct {
+	Type     string                  `json:"type"`
+	Title    string                  `json:"title"`
+	Status   int                     `json:"status"`
+	Detail   string                  `json:"detail"`
+	Instance string                  `json:"instance"`
+	Code     apperr.Code             `json:"code"`
+	Errors   []apperr.FieldViolation `json:"errors"`
+}
+
+func (suite *HTTPSuite) problemRequest(method string, path string, body any) problem {
+	var reader io.Reader
+	if body != nil {
+		reader = jsonBody(body)
+	}
+	req, err := http.NewRequest(method, suite.Client.baseURL+path, reader)
+	suite.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := suite.Client.client.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	suite.Equal(httpgin.ProblemContentType, strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+	var p problem
+	suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&p))
+	suite.Equal(resp.StatusCode, p.Status)
+	suite.Equal(http.StatusText(resp.StatusCode), p.Title)
+	return p
+}
+
+func (suite *HTTPSuite) TestProblemDetails() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.Require().NoError(err)
+
+	p := suite.problemRequest(http.MethodPost, "/api/v1/ads", map[string]any{"user_id": u.Data.ID, "title": "", "text": "text"})
+	suite.Equal(http.StatusBadRequest, p.Status)
+	suite.Equal(apperr.CodeValidationFailed, p.Code)
+	suite.Equal("urn:ads:problem:validation-failed", p.Type)
+	suite.Equal("/api/v1/ads", p.Instance)
+	suite.Require().Len(p.Errors, 1)
+	suite.Equal("title", p.Errors[0].Field)
+
+	p = suite.problemRequest(http.MethodPost, "/api/v1/users", map[string]any{"nickname": "", "email": "not-an-email"})
+	suite.Equal(apperr.CodeValidationFailed, p.Code)
+	fields := make([]string, 0, len(p.Errors))
+	for _, f := range p.Errors {
+		fields = append(fields, f.Field)
+	}
+	suite.Contains(fields, "email")
+
+	p = suite.problemRequest(http.MethodPost, "/api/v1/ads", map[string]any{"user_id": u.Data.ID + 100, "title": "title", "text": "text"})
+	suite.Equal(http.StatusNotFound, p.Status)
+	suite.Equal(apperr.CodeUserNotFound, p.Code)
+	suite.Equal(app.ErrUserNotFound.Error(), p.Detail)
+
+	p = suite.problemRequest(http.MethodGet, "/api/v1/ads/abc", nil)
+	suite.Equal(http.StatusBadRequest, p.Status)
+	suite.Equal(apperr.CodeInvalidArgument, p.Code)
+	suite.Empty(p.Errors)
+
+	p = suite.problemRequest(http.MethodGet, "/api/v1/admin/users", nil)
+	suite.Equal(apperr.CodeInvalidArgument, p.Code)
+	suite.Equal(httpgin.ErrMissingAdminID.Error(), p.Detail)
+}
+
+func TestInternalErrorDetails(t *testing.T) {
+	a := mocks.NewApp(t)
+	a.On("GetAd", mock.Anything, int64(1)).Run(func(mock.Arguments) {
+		panic("dial tcp 10.0.0.5:5432: password authentication failed")
+	})
+	a.On("GetUser", mock.Anything, int64(1)).Return(nil, fmt.Errorf("query users: %w", ErrMock))
+	srv := httptest.NewServer(httpgin.NewHTTPServer("", a).Handler)
+	defer srv.Close()
+
+	// ни значение паники, ни текст внутренней ошибки не попадают в ответ
+	for _, path := range []string{"/api/v1/ads/1", "/api/v1/users/1"} {
+		resp, err := srv.Client().Get(srv.URL + path)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode, path)
+		assert.NotContains(t, string(body), "10.0.0.5", path)
+		assert.NotContains(t, string(body), ErrMock.Error(), path)
+		var p problem
+		require.NoError(t, json.Unmarshal(body, &p), path)
+		assert.Equal(t, apperr.CodeInternal, p.Code, path)
+		assert.Equal(t, apperr.InternalMessage, p.Detail, path)
+	}
+
+	st := status.Convert(grpcPort.StatusError(ErrMock))
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, apperr.InternalMessage, st.Message())
+}
+
+func (suite *GRPCSuite) TestGRPCErrorDetails() {
+	u, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "Mac Miller", Email: "swimming@circles.com"})
+	suite.Require().NoError(err)
+
+	_, err = suite.Client.CreateAd(suite.Context, &grpcPort.CreateAdRequest{UserId: &u.Id, Title: "", Text: "text"})
+	st := status.Convert(err)
+	suite.Equal(codes.InvalidArgument, st.Code())
+	var info *errdetails.ErrorInfo
+	var badRequest *errdetails.BadRequest
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.BadRequest:
+			badRequest = d
+		}
+	}
+	suite.Require().NotNil(info)
+	suite.Equal(string(apperr.CodeValidationFailed), info.Reason)
+	suite.Equal(grpcPort.ErrorDomain, info.Domain)
+	suite.Require().NotNil(badRequest)
+	suite.Require().Len(badRequest.FieldViolations, 1)
+	suite.Equal("title", badRequest.FieldViolations[0].Field)
+
+	id := u.Id + 100
+	_, err = suite.Client.GetUser(suite.Context, &grpcPort.GetUserRequest{Id: &id})
+	st = status.Convert(err)
+	suite.Equal(codes.NotFound, st.Code())
+	suite.Require().Len(st.Details(), 1)
+	suite.Equal(string(apperr.CodeUserNotFound), st.Details()[0].(*errdetails.ErrorInfo).Reason)
+}
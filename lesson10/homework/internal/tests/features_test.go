@@ -0,0 +1,254 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/config"
+	"homework10/internal/features"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/user"
+)
+
+func rollout(p float64) *float64 {
+	return &p
+}
+
+func TestFeatureEvaluate(t *testing.T) {
+	set := features.Set{
+		"on":      {Enabled: true},
+		"off":     {Enabled: false, Users: []int64{1}},
+		"nobody":  {Enabled: true, Rollout: rollout(0), Users: []int64{1}},
+		"all":     {Enabled: true, Rollout: rollout(100)},
+		"partial": {Enabled: true, Rollout: rollout(50)},
+	}
+	for name, tc := range map[string]struct {
+		flag    string
+		uid     int64
+		enabled bool
+		reason  features.Reason
+	}{
+		"unknown":       {"missing", 1, false, features.ReasonUnknown},
+		"enabled":       {"on", 2, true, features.ReasonEnabled},
+		"disabled user": {"off", 1, false, features.ReasonDisabled},
+		"listed user":   {"nobody", 1, true, features.ReasonUser},
+		"zero rollout":  {"nobody", 2, false, features.ReasonExcluded},
+		"full rollout":  {"all", 2, true, features.ReasonRollout},
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := set.Evaluate(tc.flag, tc.uid)
+			assert.Equal(t, tc.enabled, e.Enabled)
+			assert.Equal(t, tc.reason, e.Reason)
+			assert.Equal(t, tc.enabled, set.Enabled(tc.flag, tc.uid))
+		})
+	}
+
+	e := set.Evaluate("partial", 42)
+	assert.Equal(t, e.Bucket < 50, e.Enabled)
+	assert.Equal(t, e, set.Evaluate("partial", 42))
+
+	var names []string
+	for _, e := range set.EvaluateAll(1) {
+		names = append(names, e.Name)
+	}
+	assert.Equal(t, []string{"all", "nobody", "off", "on", "partial"}, names)
+}
+
+func TestFeatureRollout(t *testing.T) {
+	const users = 10000
+	count := func(set features.Set, name string) (int, map[int64]bool) {
+		in := make(map[int64]bool)
+		for uid := int64(1); uid <= users; uid++ {
+			if set.Enabled(name, uid) {
+				in[uid] = true
+			}
+		}
+		return len(in), in
+	}
+
+	n10, in10 := count(features.Set{"f": {Enabled: true, Rollout: rollout(10)}}, "f")
+	assert.InDelta(t, users/10, n10, users/100)
+	n30, in30 := count(features.Set{"f": {Enabled: true, Rollout: rollout(30)}}, "f")
+	assert.InDelta(t, users*3/10, n30, users/100)
+	// увеличение доли не исключает пользователей, уже получивших флаг
+	for uid := range in10 {
+		assert.True(t, in30[uid], uid)
+	}
+
+	// разные флаги включаются для разных пользователей
+	_, other := count(features.Set{"g": {Enabled: true, Rollout: rollout(10)}}, "g")
+	common := 0
+	for uid := range in10 {
+		if other[uid] {
+			common++
+		}
+	}
+	assert.Less(t, common, n10/2)
+}
+
+func TestFeaturesConfig(t *testing.T) {
+	c, err := config.Parse(strings.NewReader(`
+features:
+  premoderation: true
+  new_list_filter: {enabled: true, rollout: 12.5, users: [7]}
+  old_search: false
+`))
+	require.NoError(t, err)
+	assert.Equal(t, features.Flag{Enabled: true}, c.Features[features.Premoderation])
+	assert.Equal(t, features.Flag{Enabled: true, Rollout: rollout(12.5), Users: []int64{7}}, c.Features["new_list_filter"])
+	assert.Equal(t, features.Flag{}, c.Features["old_search"])
+
+	for _, bad := range []string{
+		"features: {a: {enabled: true, rollout: 150}}",
+		"features: {a: {enabled: true, rolout: 10}}",
+		"features: {a: {enabled: true, users: [-1]}}",
+		"features: {a: maybe}",
+	} {
+		_, err = config.Parse(strings.NewReader(bad))
+		assert.ErrorIs(t, err, config.ErrInvalidConfig, bad)
+	}
+
+	// флаги снимка доступны из контекста запроса
+	ctx := config.NewContext(context.Background(), c)
+	assert.True(t, features.Enabled(ctx, features.Premoderation, 1))
+	assert.True(t, features.Enabled(ctx, "new_list_filter", 7))
+	assert.False(t, features.Enabled(context.Background(), features.Premoderation, 1))
+}
+
+func TestFeaturePremoderation(t *testing.T) {
+	repo := adrepo.NewRepositoryMap()
+	a := app.NewApp(repo)
+	background := context.Background()
+	moderID, err := repo.AddUser(background, user.User{Nickname: "moder", Email: "moder@ads.com", Role: user.RoleModerator})
+	require.NoError(t, err)
+	author, err := a.CreateUser(background, "Mac Miller", "swimming@circles.com")
+	require.NoError(t, err)
+	other, err := a.CreateUser(background, "J.Cole", "foresthill@drive.com")
+	require.NoError(t, err)
+
+	ctx := config.NewContext(background, &config.Config{Features: features.Set{
+		features.Premoderation: {Enabled: true, Rollout: rollout(0), Users: []int64{author.ID}},
+	}})
+
+	ad, err := a.CreateAd(ctx, "Circles", "Good News", author.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{app.PremoderationFlag}, ad.Flags)
+	_, err = a.ChangeAdStatus(ctx, ad.ID, author.ID, true)
+	assert.ErrorIs(t, err, app.ErrUnderReview)
+
+	// пометка сохраняется при изменении объявления до одобрения
+	ad, err = a.UpdateAd(ctx, ad.ID, author.ID, "Circles", "Blue World")
+	require.NoError(t, err)
+	assert.Equal(t, []string{app.PremoderationFlag}, ad.Flags)
+
+	ad, err = a.ApproveAd(ctx, moderID, ad.ID)
+	require.NoError(t, err)
+	assert.Empty(t, ad.Flags)
+	ad, err = a.UpdateAd(ctx, ad.ID, author.ID, "Circles", "Hand Me Downs")
+	require.NoError(t, err)
+	assert.Empty(t, ad.Flags)
+	_, err = a.ChangeAdStatus(ctx, ad.ID, author.ID, true)
+	assert.NoError(t, err)
+
+	// флаг не включен для других авторов
+	ad, err = a.CreateAd(ctx, "Forest Hills", "Drive", other.ID)
+	require.NoError(t, err)
+	assert.Empty(t, ad.Flags)
+}
+
+func TestFeaturesHTTP(t *testing.T) {
+	repo := adrepo.New()
+	moderID, err := repo.AddUser(context.Background(), user.User{Nickname: "moder", Email: "moder@ads.com", Role: user.RoleModerator})
+	require.NoError(t, err)
+	store := config.NewStore(nil)
+	server := httptest.NewServer(httpgin.ConfigHandler(store, httpgin.NewHTTPServer("", app.NewApp(repo)).Handler))
+	defer server.Close()
+
+	type featureData struct {
+		Name    string        `json:"name"`
+		Enabled bool          `json:"enabled"`
+		Reason  string        `json:"reason"`
+		Bucket  float64       `json:"bucket"`
+		Flag    features.Flag `json:"flag"`
+	}
+	listFeatures := func(adminID int64, query string) (int, []featureData) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/admin/features"+query, nil)
+		require.NoError(t, err)
+		req.Header.Set(httpgin.AdminHeader, fmt.Sprint(adminID))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var out struct {
+			Data []featureData `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return resp.StatusCode, out.Data
+	}
+
+	code, list := listFeatures(moderID, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, list)
+
+	path := filepath.Join(t.TempDir(), "runtime.yaml")
+	writeFile(t, path, "features: {premoderation: {enabled: true, rollout: 0, users: [7]}, search: true}\n")
+	require.NoError(t, config.NewReloader(store, path, nil, "").Reload())
+
+	code, list = listFeatures(moderID, "?user_id=7")
+	assert.Equal(t, http.StatusOK, code)
+	require.Len(t, list, 2)
+	assert.Equal(t, features.Premoderation, list[0].Name)
+	assert.True(t, list[0].Enabled)
+	assert.Equal(t, string(features.ReasonUser), list[0].Reason)
+	assert.Equal(t, features.Flag{Enabled: true, Rollout: rollout(0), Users: []int64{7}}, list[0].Flag)
+	assert.Equal(t, features.Bucket(features.Premoderation, 7), list[0].Bucket)
+	assert.Equal(t, string(features.ReasonEnabled), list[1].Reason)
+
+	_, list = listFeatures(moderID, "?user_id=8")
+	assert.False(t, list[0].Enabled)
+	assert.Equal(t, string(features.ReasonExcluded), list[0].Reason)
+
+	code, _ = listFeatures(moderID, "?user_id=abc")
+	assert.Equal(t, http.StatusBadRequest, code)
+	u, err := repo.AddUser(context.Background(), user.User{Nickname: "mac", Email: "mac@ads.com"})
+	require.NoError(t, err)
+	code, _ = listFeatures(u, "")
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+func TestFeaturesGRPC(t *testing.T) {
+	repo := adrepo.New()
+	moderID, err := repo.AddUser(context.Background(), user.User{Nickname: "moder", Email: "moder@ads.com", Role: user.RoleModerator})
+	require.NoError(t, err)
+	admin := grpcPort.NewAdminService(app.NewApp(repo))
+	ctx := config.NewContext(context.Background(), &config.Config{Features: features.Set{"search": {Enabled: true, Rollout: rollout(100)}}})
+
+	_, err = admin.ListFeatures(ctx, &grpcPort.AdminUserRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	uid := int64(3)
+	resp, err := admin.ListFeatures(ctx, &grpcPort.AdminUserRequest{AdminId: &moderID, UserId: &uid})
+	require.NoError(t, err)
+	require.Len(t, resp.List, 1)
+	assert.Equal(t, "search", resp.List[0].Name)
+	assert.True(t, resp.List[0].Enabled)
+	assert.Equal(t, string(features.ReasonRollout), resp.List[0].Reason)
+	assert.True(t, resp.List[0].FlagEnabled)
+	assert.Equal(t, 100.0, resp.List[0].GetRollout())
+
+	_, err = admin.ListFeatures(ctx, &grpcPort.AdminUserRequest{AdminId: &uid})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"homework10/internal/ads"
+	"homework10/internal/filter"
+	grpcPort "homework10/internal/ports/grpc"
+	grpcPortV2 "homework10/internal/ports/grpc/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type FilterSuite struct {
+	suite.Suite
+}
+
+func (suite *FilterSuite) TestParse() {
+	for in, want := range map[string]string{
+		`published = true AND author_id IN (1,2) AND title ~ "bike"`: `((published = true AND author_id IN (1, 2)) AND title ~ "bike")`,
+		`id > 5 or not (text != "x" and id <= -1)`:                   `(id > 5 OR NOT (text != "x" AND id <= -1))`,
+		`a_or_b = 1`: "",
+		`created_at >= "2023-05-20T15:00:00+03:00"`: `created_at >= "2023-05-20T12:00:00Z"`,
+		`updated_at < "2023-05-20"`:                 `updated_at < "2023-05-20T00:00:00Z"`,
+		`title IN ("a", "b\"c")`:                    `title IN ("a", "b\"c")`,
+	} {
+		expr, err := filter.Parse(in)
+		if want == "" {
+			suite.ErrorIs(err, filter.ErrUnknownField, in)
+			continue
+		}
+		if suite.NoError(err, in) {
+			suite.Equal(want, expr.String(), in)
+			// канонический вид разбирается в то же выражение
+			again, err := filter.Parse(expr.String())
+			suite.NoError(err)
+			suite.Equal(expr, again)
+		}
+	}
+}
+
+func (suite *FilterSuite) TestParseErrors() {
+	for in, want := range map[string]error{
+		``:                                 filter.ErrSyntax,
+		`published = true AND`:             filter.ErrSyntax,
+		`(published = true`:                filter.ErrSyntax,
+		`title = "open`:                    filter.ErrSyntax,
+		`title = 'single'`:                 filter.ErrSyntax,
+		`published == true`:                filter.ErrInvalidValue,
+		`title ~ 5`:                        filter.ErrInvalidValue,
+		`published < true`:                 filter.ErrInvalidValue,
+		`author_id ~ "1"`:                  filter.ErrInvalidValue,
+		`author_id = 99999999999999999999`: filter.ErrInvalidValue,
+		`created_at > "yesterday"`:         filter.ErrInvalidValue,
+		`created_at IN ("2023-05-20")`:     filter.ErrInvalidValue,
+		`password = "x"`:                   filter.ErrUnknownField,
+		`id = 1; DROP TABLE ads`:           filter.ErrSyntax,
+		strings.Repeat("(", 20) + "id = 1" + strings.Repeat(")", 20): filter.ErrTooComplex,
+		strings.Repeat("id = 1 OR ", 70) + "id = 1":                  filter.ErrTooComplex,
+		"id IN (" + strings.Repeat("1, ", 120) + "1)":                filter.ErrTooComplex,
+		`title = "` + strings.Repeat("x", filter.MaxLength) + `"`:    filter.ErrTooComplex,
+	} {
+		_, err := filter.Parse(in)
+		suite.ErrorIs(err, want, in)
+	}
+}
+
+func (suite *FilterSuite) TestEval() {
+	created := time.Date(2023, time.May, 20, 12, 0, 0, 0, time.UTC)
+	ad := ads.Ad{ID: 7, AuthorID: 2, Title: "Red Bike", Text: "fast", Published: true, DateCreated: created}
+	for in, want := range map[string]bool{
+		`title ~ "bike"`:                                 true,
+		`title = "red bike"`:                             false,
+		`author_id IN (1, 3)`:                            false,
+		`published = true AND author_id IN (1, 2)`:       true,
+		`published = false OR id >= 7`:                   true,
+		`NOT text = "fast"`:                              false,
+		`created_at < "2023-05-21"`:                      true,
+		`updated_at = "2023-05-20T15:00:00+03:00"`:       true,
+		`updated_at > "2023-05-20T12:00:00Z"`:            false,
+		`title IN ("Blue Bike", "Red Bike") AND id != 8`: true,
+	} {
+		expr, err := filter.Parse(in)
+		suite.Require().NoError(err, in)
+		suite.Equal(want, expr.Eval(ad), in)
+	}
+}
+
+func (suite *FilterSuite) TestSQL() {
+	may20 := time.Date(2023, time.May, 20, 0, 0, 0, 0, time.UTC)
+	for name, tc := range map[string]struct {
+		expr  string
+		where string
+		args  []any
+	}{
+		"nested": {
+			`published = true AND (author_id IN (1, 2) OR NOT title ~ "bike") AND updated_at != "2023-05-20"`,
+			"((published = $1 AND (author_id IN ($2, $3) OR NOT strpos(lower(title), lower($4)) > 0))" +
+				" AND COALESCE(date_changed, date_created) <> $5)",
+			[]any{true, int64(1), int64(2), "bike", may20},
+		},
+		"comparisons": {
+			`id >= 10 AND id < 20 OR created_at <= "2023-05-20" AND author_id > 3`,
+			"((id >= $1 AND id < $2) OR (date_created <= $3 AND author_id > $4))",
+			[]any{int64(10), int64(20), may20, int64(3)},
+		},
+		"string list": {
+			`NOT (text IN ("a", "b", "c") AND author_id = 7)`,
+			"NOT (text IN ($1, $2, $3) AND author_id = $4)",
+			[]any{"a", "b", "c", int64(7)},
+		},
+		// литералы никогда не попадают в текст запроса
+		"injection": {
+			`title = "x' OR 1=1 --"`,
+			"title = $1",
+			[]any{"x' OR 1=1 --"},
+		},
+	} {
+		suite.Run(name, func() {
+			expr, err := filter.Parse(tc.expr)
+			suite.Require().NoError(err)
+			where, args := filter.SQL(expr)
+			suite.Equal(tc.where, where)
+			suite.Equal(tc.args, args)
+
+			// параметры нумеруются подряд в порядке появления, по одному на аргумент
+			placeholders := regexp.MustCompile(`\$\d+`).FindAllString(where, -1)
+			suite.Len(placeholders, len(args))
+			for i, p := range placeholders {
+				suite.Equal(fmt.Sprintf("$%d", i+1), p)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	suite.Run(t, new(FilterSuite))
+}
+
+func (suite *HTTPSuite) TestListAdsByFilter() {
+	mac, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.Require().NoError(err)
+	kdot, err := suite.Client.createUser("Kendrick", "good@kid.com")
+	suite.Require().NoError(err)
+	ad, err := suite.Client.createAd(mac.Data.ID, "Red Bike", "fast")
+	suite.Require().NoError(err)
+	_, err = suite.Client.changeAdStatus(mac.Data.ID, ad.Data.ID, true)
+	suite.Require().NoError(err)
+	_, err = suite.Client.createAd(kdot.Data.ID, "Blue bike", "slow")
+	suite.Require().NoError(err)
+	_, err = suite.Client.createAd(kdot.Data.ID, "Car", "slow")
+	suite.Require().NoError(err)
+
+	var list adsResponse
+	suite.Equal(http.StatusOK, suite.requestV1(map[string]any{"filter": `title ~ "bike"`}, &list))
+	suite.Len(list.Data, 2)
+	suite.Equal(http.StatusOK, suite.requestV1(map[string]any{
+		"filter": fmt.Sprintf(`published = true AND author_id IN (%d, %d)`, mac.Data.ID, kdot.Data.ID)}, &list))
+	suite.Require().Len(list.Data, 1)
+	suite.Equal(ad.Data.ID, list.Data[0].ID)
+	// выражение дополняет обычные фильтры
+	suite.Equal(http.StatusOK, suite.requestV1(map[string]any{"user_id": kdot.Data.ID, "filter": `NOT title ~ "bike"`}, &list))
+	suite.Require().Len(list.Data, 1)
+	suite.Equal("Car", list.Data[0].Title)
+
+	p := suite.problemRequest(http.MethodGet, "/api/v1/ads", map[string]any{"filter": `owner = 1`})
+	suite.Equal(http.StatusBadRequest, p.Status)
+	suite.Require().Len(p.Errors, 1)
+	suite.Equal("filter", p.Errors[0].Field)
+
+	var v2 struct{ Data []adDataV2 }
+	query := url.Values{"filter": {`text = "slow" AND title ~ "BIKE"`}}
+	suite.Equal(http.StatusOK, suite.requestV2(http.MethodGet, "/ads?"+query.Encode(), nil, &v2))
+	suite.Require().Len(v2.Data, 1)
+	suite.Equal("Blue bike", v2.Data[0].Title)
+	suite.Equal(http.StatusBadRequest, suite.requestV2(http.MethodGet, "/ads?"+url.Values{"filter": {"title ~"}}.Encode(), nil, nil))
+}
+
+func (suite *GRPCSuite) TestGRPCListAdsByFilter() {
+	u, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "J.Cole", Email: "foresthill@drive.com"})
+	suite.Require().NoError(err)
+	_, err = suite.Client.CreateAd(suite.Context, &grpcPort.CreateAdRequest{UserId: &u.Id, Title: "GOMD", Text: "Role Modelz"})
+	suite.Require().NoError(err)
+	_, err = suite.Client.CreateAd(suite.Context, &grpcPort.CreateAdRequest{UserId: &u.Id, Title: "Crooked Smile", Text: "Born Sinner"})
+	suite.Require().NoError(err)
+
+	expr := `text ~ "sinner" OR title = "none"`
+	ads, err := suite.Client.ListAds(suite.Context, &grpcPort.ListAdRequest{Filter: &expr})
+	suite.Require().NoError(err)
+	suite.Require().Len(ads.List, 1)
+	suite.Equal("Crooked Smile", ads.List[0].Title)
+
+	invalid := `title ~ "a" AND`
+	_, err = suite.Client.ListAds(suite.Context, &grpcPort.ListAdRequest{Filter: &invalid})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (suite *GRPCV2Suite) TestListAdsByFilter() {
+	u, err := suite.Client.CreateUser(suite.Ctx, &grpcPortV2.CreateUserRequest{Nickname: "Mac Miller", Email: "swimming@circles.com"})
+	suite.Require().NoError(err)
+	_, err = suite.Client.CreateAd(suite.Ctx, &grpcPortV2.CreateAdRequest{AuthorId: u.Id, Title: "Circles", Text: "Good News"})
+	suite.Require().NoError(err)
+
+	expr := fmt.Sprintf("author_id = %d", u.Id.Value)
+	list, err := suite.Client.ListAds(suite.Ctx, &grpcPortV2.ListAdsRequest{Filter: &expr})
+	suite.Require().NoError(err)
+	suite.Len(list.Ads, 1)
+
+	invalid := "published = 1"
+	_, err = suite.Client.ListAds(suite.Ctx, &grpcPortV2.ListAdsRequest{Filter: &invalid})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+}
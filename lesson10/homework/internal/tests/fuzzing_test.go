@@ -16,6 +16,8 @@ func FuzzCreateUserCheck(f *testing.F) {
 			switch {
 			case errors.Is(err, ErrForbidden):
 				fallthrough
+			case errors.Is(err, ErrConflict):
+				fallthrough
 			case errors.Is(err, ErrBadRequest):
 				return
 			default:
@@ -21,7 +21,7 @@ var (
 	ErrGRPCForbidden   = errors.New("rpc error: code = PermissionDenied desc = forbidden")
 	ErrInvalidEmail    = errors.New("rpc error: code = InvalidArgument desc = mail: missing '@' or angle-addr")
 	ErrMissingArgument = errors.New("rpc error: code = InvalidArgument desc = required argument is missing")
-	ErrMockInternal    = errors.New("rpc error: code = Internal desc = mock error")
+	ErrMockInternal    = errors.New("rpc error: code = Internal desc = internal error")
 	ErrValidationMock  = errors.New("rpc error: code = InvalidArgument desc = ")
 	ErrDateMock        = errors.New("rpc error: code = InvalidArgument desc = parsing time \"abc\" as \"2006-01-02\": cannot parse \"abc\" as \"2006\"")
 )
@@ -30,6 +30,7 @@ type GRPCSuite struct {
 	suite.Suite
 	Repo     *adrepo.RepositoryMap
 	Client   grpcPort.AdServiceClient
+	Admin    grpcPort.AdminServiceClient
 	Conn     *grpc.ClientConn
 	Context  context.Context
 	Cancel   context.CancelFunc
@@ -42,13 +43,22 @@ func (suite *GRPCSuite) SetupSuite() {
 	log.Println("Setting Up Test")
 
 	suite.Lis = bufconn.Listen(1024 * 1024)
-	suite.Server = grpc.NewServer(grpc.ChainUnaryInterceptor(
-		grpcPort.UnaryLoggerInterceptor,
-		grpcPort.UnaryRecoveryInterceptor(),
-	))
+	suite.Server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			grpcPort.UnaryLoggerInterceptor,
+			grpcPort.UnaryRecoveryInterceptor(),
+			grpcPort.UnaryLocaleInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			grpcPort.StreamLoggerInterceptor,
+			grpcPort.StreamRecoveryInterceptor(),
+			grpcPort.StreamLocaleInterceptor(),
+		),
+	)
 	suite.Repo = adrepo.NewRepositoryMap()
 	svc := grpcPort.NewService(app.NewApp(suite.Repo))
 	grpcPort.RegisterAdServiceServer(suite.Server, svc)
+	grpcPort.RegisterAdminServiceServer(suite.Server, grpcPort.NewAdminService(app.NewApp(suite.Repo)))
 
 	suite.Context, suite.Cancel = context.WithTimeout(context.Background(), 30*time.Second)
 	go func() {
@@ -64,6 +74,7 @@ func (suite *GRPCSuite) SetupSuite() {
 	suite.Conn = conn
 
 	suite.Client = grpcPort.NewAdServiceClient(suite.Conn)
+	suite.Admin = grpcPort.NewAdminServiceClient(suite.Conn)
 }
 
 func (suite *GRPCSuite) SetupTest() {
@@ -492,14 +492,14 @@ func (suite *HTTPMockSuite) TestHandler_CreateAd() {
 			wantErr:  false,
 		},
 		{
-			name: "failed dependency",
+			name: "user not found",
 			args: args{
 				err: app.ErrUserNotFound,
 			},
 			needMock: true,
 			wantErr:  true,
 			checkErr: func(err error) bool {
-				suite.ErrorIs(err, ErrFailedDependency)
+				suite.ErrorIs(err, ErrNotFound)
 				return true
 			},
 		},
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework10/internal/apperr"
+	"homework10/internal/i18n"
+	grpcPort "homework10/internal/ports/grpc"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	for header, want := range map[string]i18n.Locale{
+		"":                        i18n.En,
+		"ru":                      i18n.Ru,
+		"ru-RU,ru;q=0.9,en;q=0.8": i18n.Ru,
+		"en-US,ru;q=0.5":          i18n.En,
+		"de, ru;q=0.3, en;q=0.2":  i18n.Ru,
+		"fr, de":                  i18n.En,
+		"*":                       i18n.En,
+		"ru;q=0, en;q=0.1":        i18n.En,
+		"en;q=0.4, RU;q=0.7":      i18n.Ru,
+		"ru;q=abc":                i18n.En,
+	} {
+		assert.Equal(t, want, i18n.Negotiate(header), header)
+	}
+}
+
+func TestCatalogCoversCodes(t *testing.T) {
+	for _, code := range []apperr.Code{
+		apperr.CodeInternal, apperr.CodeInvalidArgument, apperr.CodeValidationFailed, apperr.CodeInvalidSchedule,
+		apperr.CodeInvalidPage, apperr.CodeInvalidTimeRange, apperr.CodeInvalidAuditRange, apperr.CodeInvalidRole, apperr.CodeTokenExpired,
+		apperr.CodeAdNotFound, apperr.CodeUserNotFound, apperr.CodeSearchNotFound, apperr.CodeConversationNotFound,
+		apperr.CodeTokenNotFound, apperr.CodeAlreadyExists, apperr.CodeAlreadyVerified, apperr.CodeForbidden,
+		apperr.CodeUserBanned, apperr.CodeUserBlocked, apperr.CodeInvalidTenant, apperr.CodeTenantNotFound,
+		apperr.CodeRateLimited, apperr.CodePolicyViolation, apperr.CodeAdUnderReview,
+	} {
+		_, ok := i18n.Message(i18n.Ru, string(code), "x")
+		assert.True(t, ok, code)
+	}
+}
+
+func (suite *HTTPSuite) localizedProblem(method string, path string, body any, language string) (problem, string) {
+	req, err := http.NewRequest(method, suite.Client.baseURL+path, jsonBody(body))
+	suite.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", language)
+	resp, err := suite.Client.client.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	var p problem
+	suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&p))
+	return p, resp.Header.Get("Content-Language")
+}
+
+func (suite *HTTPSuite) TestLocalizedProblem() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.Require().NoError(err)
+
+	body := map[string]any{"user_id": u.Data.ID, "title": "", "text": "text"}
+	p, language := suite.localizedProblem(http.MethodPost, "/api/v1/ads", body, "ru-RU,ru;q=0.9,en;q=0.8")
+	suite.Equal("ru", language)
+	suite.Equal(apperr.CodeValidationFailed, p.Code)
+	suite.Equal("запрос не прошел проверку", p.Detail)
+	suite.Require().Len(p.Errors, 1)
+	suite.Equal("title", p.Errors[0].Field)
+	suite.Equal("поле title должно содержать не меньше 1 символов, получено 0", p.Errors[0].Description)
+
+	p, language = suite.localizedProblem(http.MethodPost, "/api/v1/users", map[string]any{"nickname": "Kendrick", "email": "good"}, "ru")
+	suite.Equal("ru", language)
+	suite.Require().Len(p.Errors, 1)
+	suite.True(strings.HasPrefix(p.Errors[0].Description, "поле email"), p.Errors[0].Description)
+
+	p, _ = suite.localizedProblem(http.MethodGet, "/api/v1/ads/abc", nil, "ru")
+	suite.Equal(apperr.CodeInvalidArgument, p.Code)
+	suite.True(strings.HasPrefix(p.Detail, "неверный аргумент запроса: "), p.Detail)
+
+	// без заголовка и для неизвестных языков ответ остается на английском
+	p, language = suite.localizedProblem(http.MethodPost, "/api/v1/ads", body, "de")
+	suite.Equal("en", language)
+	suite.Require().Len(p.Errors, 1)
+	suite.NotContains(p.Errors[0].Description, "поле")
+}
+
+func (suite *GRPCSuite) TestGRPCLocalizedStatus() {
+	u, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "Mac Miller", Email: "swimming@circles.com"})
+	suite.Require().NoError(err)
+
+	ctx := metadata.AppendToOutgoingContext(suite.Context, grpcPort.LocaleMetadataKey, "ru")
+	_, err = suite.Client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: &u.Id, Title: "", Text: "text"})
+	st := status.Convert(err)
+	suite.Equal(codes.InvalidArgument, st.Code())
+	suite.Equal("запрос не прошел проверку", st.Message())
+	var info *errdetails.ErrorInfo
+	var badRequest *errdetails.BadRequest
+	var localized *errdetails.LocalizedMessage
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.BadRequest:
+			badRequest = d
+		case *errdetails.LocalizedMessage:
+			localized = d
+		}
+	}
+	suite.Require().NotNil(info)
+	suite.Equal(string(apperr.CodeValidationFailed), info.Reason)
+	suite.Require().NotNil(badRequest)
+	suite.Require().Len(badRequest.FieldViolations, 1)
+	suite.Equal("поле title должно содержать не меньше 1 символов, получено 0", badRequest.FieldViolations[0].Description)
+	suite.Require().NotNil(localized)
+	suite.Equal("ru", localized.Locale)
+	suite.Equal(st.Message(), localized.Message)
+
+	id := u.Id + 100
+	_, err = suite.Client.GetUser(suite.Context, &grpcPort.GetUserRequest{Id: &id})
+	st = status.Convert(err)
+	suite.Equal(codes.NotFound, st.Code())
+	suite.Len(st.Details(), 1)
+	_, err = suite.Client.GetUser(ctx, &grpcPort.GetUserRequest{Id: &id})
+	suite.Equal("пользователь с таким id не существует", status.Convert(err).Message())
+}
@@ -0,0 +1,336 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/idgen"
+	"homework10/internal/messages"
+	grpcPort "homework10/internal/ports/grpc"
+	grpcPortV2 "homework10/internal/ports/grpc/v2"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/user"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type IDGenSuite struct {
+	suite.Suite
+	Now time.Time
+}
+
+func (suite *IDGenSuite) SetupTest() {
+	suite.Now = time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (suite *IDGenSuite) clock() time.Time {
+	return suite.Now
+}
+
+func (suite *IDGenSuite) TestSequence_Concurrent() {
+	seq := idgen.NewSequence(10)
+	const workers, perWorker = 8, 1000
+	ids := make(chan int64, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				ids <- seq.Next()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]struct{})
+	for id := range ids {
+		suite.GreaterOrEqual(id, int64(10))
+		seen[id] = struct{}{}
+	}
+	suite.Len(seen, workers*perWorker)
+	suite.Equal(int64(10+workers*perWorker), seq.Next())
+}
+
+func (suite *IDGenSuite) TestSnowflake_Monotonic() {
+	sf, err := idgen.NewSnowflake(7, suite.clock)
+	suite.NoError(err)
+
+	prev := sf.Next()
+	suite.Equal(suite.Now, idgen.Time(prev))
+	// счетчик внутри одной миллисекунды переполняется
+	for i := 0; i < 5000; i++ {
+		id := sf.Next()
+		suite.Greater(id, prev)
+		prev = id
+	}
+	// часы перевели назад
+	suite.Now = suite.Now.Add(-time.Second)
+	id := sf.Next()
+	suite.Greater(id, prev)
+	prev = id
+
+	suite.Now = suite.Now.Add(time.Hour)
+	id = sf.Next()
+	suite.Greater(id, prev)
+	suite.Equal(suite.Now, idgen.Time(id))
+}
+
+func (suite *IDGenSuite) TestSnowflake_NodesDoNotCollide() {
+	a, err := idgen.NewSnowflake(1, suite.clock)
+	suite.NoError(err)
+	b, err := idgen.NewSnowflake(2, suite.clock)
+	suite.NoError(err)
+	suite.NotEqual(a.Next(), b.Next())
+}
+
+func (suite *IDGenSuite) TestSnowflake_InvalidNode() {
+	_, err := idgen.NewSnowflake(idgen.MaxNode+1, nil)
+	suite.ErrorIs(err, idgen.ErrInvalidNode)
+	_, err = idgen.NewSnowflake(-1, nil)
+	suite.ErrorIs(err, idgen.ErrInvalidNode)
+}
+
+func TestIDGen(t *testing.T) {
+	suite.Run(t, new(IDGenSuite))
+}
+
+// IDReuseSuite проверяет, что после удаления идентификаторы не выдаются повторно
+type IDReuseSuite struct {
+	suite.Suite
+	Ctx   context.Context
+	Repos map[string]func() app.Repository
+}
+
+func (suite *IDReuseSuite) SetupSuite() {
+	suite.Ctx = context.Background()
+	suite.Repos = map[string]func() app.Repository{
+		"map":     func() app.Repository { return adrepo.NewRepositoryMap() },
+		"sharded": func() app.Repository { return adrepo.NewShardedRepository(4) },
+		"snowflake": func() app.Repository {
+			sf, err := idgen.NewSnowflake(1, nil)
+			suite.NoError(err)
+			return adrepo.NewShardedRepositoryWithIDs(4, adrepo.SharedIDs(sf))
+		},
+	}
+}
+
+func (suite *IDReuseSuite) TestAdIDs() {
+	for name, newRepo := range suite.Repos {
+		suite.Run(name, func() {
+			repo := newRepo()
+			uid, err := repo.AddUser(suite.Ctx, user.User{Nickname: "Mac Miller", Email: "swimmig@circles.com"})
+			suite.NoError(err)
+			first, err := repo.AddAd(suite.Ctx, ads.Ad{Title: "first", AuthorID: uid})
+			suite.NoError(err)
+			second, err := repo.AddAd(suite.Ctx, ads.Ad{Title: "second", AuthorID: uid})
+			suite.NoError(err)
+			suite.NoError(repo.DeleteAdByID(suite.Ctx, first))
+
+			third, err := repo.AddAd(suite.Ctx, ads.Ad{Title: "third", AuthorID: uid})
+			suite.NoError(err)
+			suite.Greater(third, second)
+			ad, err := repo.GetAdByID(suite.Ctx, second)
+			suite.NoError(err)
+			suite.Equal("second", ad.Title)
+		})
+	}
+}
+
+func (suite *IDReuseSuite) TestUserIDs() {
+	for name, newRepo := range suite.Repos {
+		suite.Run(name, func() {
+			repo := newRepo()
+			first, err := repo.AddUser(suite.Ctx, user.User{Nickname: "first", Email: "first@mail.com"})
+			suite.NoError(err)
+			second, err := repo.AddUser(suite.Ctx, user.User{Nickname: "second", Email: "second@mail.com"})
+			suite.NoError(err)
+			// неудачная вставка не расходует идентификатор впустую и не ломает таблицу
+			_, err = repo.AddUser(suite.Ctx, user.User{Nickname: "second", Email: "other@mail.com"})
+			suite.ErrorIs(err, app.ErrAlreadyExists)
+			suite.NoError(repo.DeleteUserByID(suite.Ctx, first))
+
+			third, err := repo.AddUser(suite.Ctx, user.User{Nickname: "third", Email: "third@mail.com"})
+			suite.NoError(err)
+			suite.Greater(third, second)
+			u, err := repo.GetUserByID(suite.Ctx, second)
+			suite.NoError(err)
+			suite.Equal("second", u.Nickname)
+		})
+	}
+}
+
+// TestEntityIDs проверяет, что поиски, уведомления, переписки и сообщения получают ID
+// от того же генератора, что и объявления с пользователями
+func (suite *IDReuseSuite) TestEntityIDs() {
+	sf, err := idgen.NewSnowflake(1, nil)
+	suite.NoError(err)
+	repo := adrepo.NewShardedRepositoryWithIDs(4, adrepo.SharedIDs(sf))
+
+	buyer, err := repo.AddUser(suite.Ctx, user.User{Nickname: "buyer", Email: "buyer@mail.com"})
+	suite.NoError(err)
+	seller, err := repo.AddUser(suite.Ctx, user.User{Nickname: "seller", Email: "seller@mail.com"})
+	suite.NoError(err)
+	ad, err := repo.AddAd(suite.Ctx, ads.Ad{Title: "ad", AuthorID: seller})
+	suite.NoError(err)
+	search, err := repo.AddSavedSearch(suite.Ctx, app.SavedSearch{UserID: buyer})
+	suite.NoError(err)
+	notification, err := repo.AddNotification(suite.Ctx, app.Notification{UserID: buyer, AdID: ad, SearchID: search})
+	suite.NoError(err)
+	conv, err := repo.AddConversation(suite.Ctx, messages.Conversation{AdID: ad, BuyerID: buyer, SellerID: seller})
+	suite.NoError(err)
+	msg, err := repo.AddMessage(suite.Ctx, messages.Message{ConversationID: conv, SenderID: buyer, RecipientID: seller})
+	suite.NoError(err)
+
+	ids := []int64{buyer, seller, ad, search, notification, conv, msg}
+	for i := 1; i < len(ids); i++ {
+		suite.Greater(ids[i], ids[i-1])
+	}
+}
+
+func TestIDReuse(t *testing.T) {
+	suite.Run(t, new(IDReuseSuite))
+}
+
+type StringIDsSuite struct {
+	suite.Suite
+	Client  *testClient
+	Numeric *testClient
+}
+
+func (suite *StringIDsSuite) SetupTest() {
+	sf, err := idgen.NewSnowflake(3, nil)
+	suite.NoError(err)
+	a := app.NewApp(adrepo.NewShardedRepositoryWithIDs(4, adrepo.SharedIDs(sf)))
+	server := httptest.NewServer(httpgin.NewHTTPServerWithOptions("", a, httpgin.Options{StringIDs: true}).Handler)
+	suite.T().Cleanup(server.Close)
+	suite.Client = &testClient{client: server.Client(), baseURL: server.URL}
+	// второй сервер того же процесса и приложения отдает ID числами
+	suite.Numeric = getTestClientWithApp(a)
+}
+
+func (suite *StringIDsSuite) TestCreateAd_StringIDs() {
+	req, err := http.NewRequest(http.MethodPost, suite.Client.baseURL+"/api/v1/users",
+		jsonBody(map[string]any{"nickname": "Mac Miller", "email": "swimmig@circles.com"}))
+	suite.NoError(err)
+	req.Header.Add("Content-Type", "application/json")
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	suite.NoError(suite.Client.getResponse(req, &created))
+	suite.Greater(len(created.Data.ID), 15, "snowflake id exceeds float64 precision")
+
+	// строковый ID принимается в теле запроса и возвращается строкой
+	req, err = http.NewRequest(http.MethodPost, suite.Client.baseURL+"/api/v1/ads",
+		jsonBody(map[string]any{"user_id": created.Data.ID, "title": "hello", "text": "world"}))
+	suite.NoError(err)
+	req.Header.Add("Content-Type", "application/json")
+	var ad struct {
+		Data struct {
+			ID       string `json:"id"`
+			AuthorID string `json:"author_id"`
+		} `json:"data"`
+	}
+	suite.NoError(suite.Client.getResponse(req, &ad))
+	suite.Equal(created.Data.ID, ad.Data.AuthorID)
+
+	req, err = http.NewRequest(http.MethodGet, suite.Client.baseURL+"/api/v1/ads/"+ad.Data.ID, nil)
+	suite.NoError(err)
+	var got struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	suite.NoError(suite.Client.getResponse(req, &got))
+	suite.Equal(ad.Data.ID, got.Data.ID)
+}
+
+func (suite *StringIDsSuite) TestStringIDs_PerServer() {
+	u, err := suite.Numeric.createUser("Mac Miller", "swimmig@circles.com")
+	suite.NoError(err)
+	ad, err := suite.Numeric.createAd(u.Data.ID, "hello", "world")
+	suite.NoError(err)
+
+	get := func(tc *testClient) map[string]any {
+		resp, err := tc.client.Get(fmt.Sprintf("%s/api/v1/ads/%d", tc.baseURL, ad.Data.ID))
+		suite.NoError(err)
+		defer resp.Body.Close()
+		decoder := json.NewDecoder(resp.Body)
+		decoder.UseNumber()
+		var out struct {
+			Data map[string]any `json:"data"`
+		}
+		suite.NoError(decoder.Decode(&out))
+		return out.Data
+	}
+	strs, nums := get(suite.Client), get(suite.Numeric)
+
+	// ответы отличаются только форматом идентификаторов
+	for _, key := range []string{"id", "author_id"} {
+		suite.IsType("", strs[key], key)
+		suite.IsType(json.Number(""), nums[key], key)
+		suite.Equal(fmt.Sprint(nums[key]), strs[key], key)
+		delete(strs, key)
+		delete(nums, key)
+	}
+	suite.Equal(nums, strs)
+}
+
+func TestStringIDs(t *testing.T) {
+	suite.Run(t, new(StringIDsSuite))
+}
+
+func TestProtoStringIDs(t *testing.T) {
+	// все идентификаторы в proto помечены для передачи строками в JSON и JS-клиентах
+	idField := func(name string) bool {
+		return name == "id" || name == "value" || name == "users" || strings.HasSuffix(name, "_id")
+	}
+	for _, file := range []protoreflect.FileDescriptor{grpcPort.File_service_proto, grpcPortV2.File_v2_service_proto} {
+		messages := file.Messages()
+		for i := 0; i < messages.Len(); i++ {
+			fields := messages.Get(i).Fields()
+			for j := 0; j < fields.Len(); j++ {
+				f := fields.Get(j)
+				if f.Kind() != protoreflect.Int64Kind || !idField(string(f.Name())) {
+					continue
+				}
+				opts := f.Options().(*descriptorpb.FieldOptions)
+				assert.Equal(t, descriptorpb.FieldOptions_JS_STRING, opts.GetJstype(), f.FullName())
+			}
+		}
+	}
+
+	id := int64(1)<<60 + 1
+	data, err := protojson.Marshal(&grpcPort.AdResponse{Id: id, AuthorId: id})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), fmt.Sprintf(`"id":"%d"`, id))
+	var ad grpcPort.AdResponse
+	require.NoError(t, protojson.Unmarshal(data, &ad))
+	assert.Equal(t, id, ad.AuthorId)
+}
+
+func jsonBody(v any) io.Reader {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("unable to marshal: %v", err))
+	}
+	return bytes.NewReader(data)
+}
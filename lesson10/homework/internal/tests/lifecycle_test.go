@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/graceful"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+)
+
+// events записывает порядок вызова хуков
+type events struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (e *events) add(s string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.list = append(e.list, s)
+}
+
+func (e *events) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.list...)
+}
+
+func recorded(ev *events, name string, phase graceful.Phase) graceful.Component {
+	return graceful.Component{
+		Name:  name,
+		Phase: phase,
+		Start: func(context.Context) error {
+			ev.add("start " + name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			ev.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func TestLifecyclePhases(t *testing.T) {
+	ev := &events{}
+	m := graceful.NewManager(graceful.Config{ShutdownTimeout: time.Second, DrainDelay: 10 * time.Millisecond})
+	m.Register(recorded(ev, "repo", graceful.PhaseCloseRepository))
+	m.Register(recorded(ev, "worker", graceful.PhaseFlushWorkers))
+	m.Register(recorded(ev, "server", graceful.PhaseStopServers))
+	m.Register(recorded(ev, "health", graceful.PhaseReadinessOff))
+
+	probe := httptest.NewServer(m.ReadinessHandler())
+	defer probe.Close()
+	m.Register(graceful.Component{Name: "probe", Phase: graceful.PhaseDrain, Stop: func(context.Context) error {
+		// на время ожидания сервис уже не готов
+		resp, err := http.Get(probe.URL)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		ev.add("probe " + http.StatusText(resp.StatusCode))
+		return nil
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for !m.Ready() {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	report, err := m.Run(ctx)
+	require.NoError(t, err)
+	require.NoError(t, report.Err())
+	assert.False(t, report.DeadlineExceeded)
+	assert.False(t, m.Ready())
+
+	assert.Equal(t, []string{
+		"start repo", "start worker", "start server", "start health",
+		"stop health", "probe Service Unavailable", "stop server", "stop worker", "stop repo",
+	}, ev.get())
+
+	var names []string
+	for _, c := range report.Components {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"health", "drain delay", "probe", "server", "worker", "repo"}, names)
+	assert.GreaterOrEqual(t, report.Components[1].Duration, 10*time.Millisecond)
+}
+
+func TestLifecycleForcedStop(t *testing.T) {
+	ev := &events{}
+	m := graceful.NewManager(graceful.Config{ShutdownTimeout: 50 * time.Millisecond})
+	release := make(chan struct{})
+	m.Register(graceful.Component{
+		Name:  "stuck",
+		Phase: graceful.PhaseStopServers,
+		Stop: func(context.Context) error {
+			<-release
+			return nil
+		},
+		Force: func() {
+			ev.add("force stuck")
+			close(release)
+		},
+	})
+	m.Register(recorded(ev, "repo", graceful.PhaseCloseRepository))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := m.Run(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.DeadlineExceeded)
+	require.Len(t, report.Components, 2)
+	assert.True(t, report.Components[0].Forced)
+	assert.NoError(t, report.Components[0].Err)
+	// следующие фазы выполняются и после истечения срока
+	assert.Equal(t, []string{"start repo", "force stuck", "stop repo"}, ev.get())
+}
+
+func TestLifecycleNotStopped(t *testing.T) {
+	m := graceful.NewManager(graceful.Config{ShutdownTimeout: 10 * time.Millisecond})
+	m.Register(graceful.Component{Name: "hung", Stop: func(context.Context) error {
+		select {}
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := m.Run(ctx)
+	require.NoError(t, err)
+	assert.ErrorIs(t, report.Err(), graceful.ErrNotStopped)
+}
+
+func TestLifecycleComponentFailure(t *testing.T) {
+	ev := &events{}
+	m := graceful.NewManager(graceful.DefaultConfig())
+	errBoom := errors.New("boom")
+	m.Register(recorded(ev, "repo", graceful.PhaseCloseRepository))
+	m.Register(graceful.Component{Name: "server", Run: func(context.Context) error { return errBoom }})
+
+	report, err := m.Run(context.Background())
+	assert.ErrorIs(t, err, errBoom)
+	assert.NoError(t, report.Err())
+	assert.Equal(t, []string{"start repo", "stop repo"}, ev.get())
+}
+
+func TestLifecycleStartFailure(t *testing.T) {
+	ev := &events{}
+	m := graceful.NewManager(graceful.DefaultConfig())
+	errBoom := errors.New("boom")
+	m.Register(recorded(ev, "repo", graceful.PhaseCloseRepository))
+	m.Register(graceful.Component{Name: "server", Start: func(context.Context) error { return errBoom }})
+	m.Register(recorded(ev, "worker", graceful.PhaseFlushWorkers))
+
+	_, err := m.Run(context.Background())
+	assert.ErrorIs(t, err, errBoom)
+	assert.False(t, m.Ready())
+	// не запущенные компоненты не останавливаются
+	assert.Equal(t, []string{"start repo", "stop repo"}, ev.get())
+}
+
+func TestLifecycleWorker(t *testing.T) {
+	m := graceful.NewManager(graceful.DefaultConfig())
+	flushed := false
+	m.Register(graceful.Worker("worker", graceful.PhaseFlushWorkers, func(ctx context.Context) error {
+		<-ctx.Done()
+		flushed = true
+		return ctx.Err()
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	report, err := m.Run(ctx)
+	require.NoError(t, err)
+	require.NoError(t, report.Err())
+	assert.True(t, flushed)
+}
+
+func TestLifecycleServers(t *testing.T) {
+	a := app.NewApp(adrepo.New())
+	m := graceful.NewManager(graceful.Config{ShutdownTimeout: time.Second})
+
+	grpcLis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	grpcPort.RegisterAdServiceServer(grpcServer, grpcPort.NewService(a))
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, hs)
+
+	httpLis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	httpServer := httpgin.NewHTTPServer("", a)
+
+	m.Register(grpcPort.ReadinessComponent(hs))
+	m.Register(grpcPort.Component(grpcServer, grpcLis))
+	m.Register(httpgin.Component(httpServer, httpLis))
+
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return grpcLis.Dial()
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	healthClient := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	var report graceful.Report
+	var runErr error
+	go func() {
+		defer close(done)
+		report, runErr = m.Run(ctx)
+	}()
+	require.Eventually(t, m.Ready, time.Second, time.Millisecond)
+
+	resp, err := healthClient.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	httpResp, err := http.Get("http://" + httpLis.Addr().String() + "/api/v1/ads")
+	require.NoError(t, err)
+	_ = httpResp.Body.Close()
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+	cancel()
+	<-done
+	require.NoError(t, runErr)
+	require.NoError(t, report.Err())
+	require.Len(t, report.Components, 3)
+	assert.Equal(t, "grpc health", report.Components[0].Name)
+
+	_, err = http.Get("http://" + httpLis.Addr().String() + "/api/v1/ads")
+	assert.Error(t, err)
+}
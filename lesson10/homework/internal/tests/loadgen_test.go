@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/loadgen"
+	"homework10/internal/user"
+	"homework10/pkg/client"
+)
+
+func TestParseMix(t *testing.T) {
+	mix, err := loadgen.ParseMix("get_ad=80, list_ads=20,create_ad=0")
+	require.NoError(t, err)
+	assert.Equal(t, loadgen.Mix{loadgen.OpGetAd: 80, loadgen.OpListAds: 20, loadgen.OpCreateAd: 0}, mix)
+
+	for _, s := range []string{"get_ad", "get_ad=x", "get_ad=-1", "delete_all=1", "get_ad=0", ""} {
+		_, err := loadgen.ParseMix(s)
+		assert.ErrorIs(t, err, loadgen.ErrInvalidConfig, s)
+	}
+}
+
+func TestLoadgenConfig(t *testing.T) {
+	cfg := loadgen.DefaultConfig()
+	cfg.RPS = 0
+	_, err := loadgen.New(nil, cfg)
+	assert.ErrorIs(t, err, loadgen.ErrInvalidConfig)
+}
+
+func runLoad(t *testing.T, newClient func(repo app.Repository, cfg client.Config, f *failFirst) (client.AdsClient, func())) {
+	repo := adrepo.New()
+	c, closeClient := newClient(repo, client.Config{Timeout: time.Second}, &failFirst{})
+	defer closeClient()
+
+	runner, err := loadgen.New(c, loadgen.Config{RPS: 200, Concurrency: 4, Duration: 300 * time.Millisecond, Users: 3, Seed: 1})
+	require.NoError(t, err)
+	report, err := runner.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Greater(t, report.Requests, 10)
+	assert.Zero(t, report.Errors, "%+v", report.Ops)
+	assert.Greater(t, report.Throughput, 0.0)
+
+	total := 0
+	for _, op := range report.Ops {
+		total += op.Requests
+		l := op.Latency
+		assert.True(t, l.P50 <= l.P90 && l.P90 <= l.P99 && l.P99 <= l.Max, op.Op)
+	}
+	assert.Equal(t, report.Requests, total)
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	assert.Contains(t, out.String(), "OP")
+	assert.Contains(t, out.String(), "total")
+
+	// все созданные пользователи и объявления дошли до репозитория
+	ads, err := repo.GetAdList(context.Background(), app.ListAdsParams{})
+	require.NoError(t, err)
+	for _, op := range report.Ops {
+		if op.Op == loadgen.OpCreateAd {
+			assert.GreaterOrEqual(t, len(ads.Data), op.Requests)
+		}
+	}
+}
+
+func TestLoadgenHTTP(t *testing.T) {
+	runLoad(t, newHTTPTestClient)
+}
+
+func TestLoadgenGRPC(t *testing.T) {
+	runLoad(t, newGRPCTestClient)
+}
+
+func TestLoadgenErrors(t *testing.T) {
+	c, closeClient := newHTTPTestClient(adrepo.New(), client.Config{Timeout: time.Second}, &failFirst{})
+	defer closeClient()
+
+	// объявления несуществующих пользователей не создаются, ошибки группируются по коду
+	runner, err := loadgen.New(&missingUsers{AdsClient: c}, loadgen.Config{
+		RPS: 100, Concurrency: 2, Duration: 200 * time.Millisecond, Users: 1, Seed: 1,
+		Mix: loadgen.Mix{loadgen.OpCreateAd: 1},
+	})
+	require.NoError(t, err)
+	report, err := runner.Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, report.Ops, 1)
+	op := report.Ops[0]
+	assert.Equal(t, loadgen.OpCreateAd, op.Op)
+	assert.Equal(t, op.Requests, op.Errors)
+	assert.Equal(t, map[string]int{"USER_NOT_FOUND": op.Errors}, op.ByError)
+}
+
+// missingUsers возвращает пользователей с несуществующими ID
+type missingUsers struct {
+	client.AdsClient
+}
+
+func (m *missingUsers) CreateUser(ctx context.Context, nickname string, email string) (*user.User, error) {
+	u, err := m.AdsClient.CreateUser(ctx, nickname, email)
+	if err != nil {
+		return nil, err
+	}
+	u.ID += 1000
+	return u, nil
+}
@@ -0,0 +1,295 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/messages"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/user"
+	"testing"
+	"time"
+)
+
+func (suite *HTTPSuite) TestMessaging() {
+	seller, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	buyer, err := suite.Client.createUser("J.Cole", "foresthill@drive.com")
+	suite.NoError(err)
+
+	ad, err := suite.Client.createAd(seller.Data.ID, "Circles", "Good News")
+	suite.NoError(err)
+
+	_, err = suite.Client.startConversation(ad.Data.ID, buyer.Data.ID)
+	suite.ErrorIs(err, ErrForbidden)
+
+	_, err = suite.Client.changeAdStatus(seller.Data.ID, ad.Data.ID, true)
+	suite.NoError(err)
+
+	conv, err := suite.Client.startConversation(ad.Data.ID, buyer.Data.ID)
+	suite.NoError(err)
+	suite.Equal(ad.Data.ID, conv.Data.AdID)
+	suite.Equal(buyer.Data.ID, conv.Data.BuyerID)
+	suite.Equal(seller.Data.ID, conv.Data.SellerID)
+
+	// повторный запрос возвращает ту же переписку
+	again, err := suite.Client.startConversation(ad.Data.ID, buyer.Data.ID)
+	suite.NoError(err)
+	suite.Equal(conv.Data.ID, again.Data.ID)
+
+	msg, err := suite.Client.sendMessage(conv.Data.ID, buyer.Data.ID, "Is it still available?")
+	suite.NoError(err)
+	suite.Equal(buyer.Data.ID, msg.Data.SenderID)
+	suite.Equal(seller.Data.ID, msg.Data.RecipientID)
+	suite.False(msg.Data.Read)
+
+	_, err = suite.Client.sendMessage(conv.Data.ID, buyer.Data.ID, "Hello?")
+	suite.NoError(err)
+
+	convs, err := suite.Client.listConversations(seller.Data.ID)
+	suite.NoError(err)
+	suite.Len(convs.Data, 1)
+	suite.Equal(2, convs.Data[0].Unread)
+
+	msgs, err := suite.Client.listMessages(conv.Data.ID, map[string]any{"user_id": seller.Data.ID, "limit": 1})
+	suite.NoError(err)
+	suite.Len(msgs.Data, 1)
+	suite.Equal("Is it still available?", msgs.Data[0].Text)
+
+	convs, err = suite.Client.listConversations(seller.Data.ID)
+	suite.NoError(err)
+	suite.Equal(1, convs.Data[0].Unread)
+
+	msgs, err = suite.Client.listMessages(conv.Data.ID, map[string]any{"user_id": seller.Data.ID, "offset": 1})
+	suite.NoError(err)
+	suite.Len(msgs.Data, 1)
+	suite.Equal("Hello?", msgs.Data[0].Text)
+
+	convs, err = suite.Client.listConversations(seller.Data.ID)
+	suite.NoError(err)
+	suite.Equal(0, convs.Data[0].Unread)
+
+	// сообщения собеседника не помечаются прочитанными отправителем
+	convs, err = suite.Client.listConversations(buyer.Data.ID)
+	suite.NoError(err)
+	suite.Len(convs.Data, 1)
+	suite.Equal(0, convs.Data[0].Unread)
+}
+
+func (suite *HTTPSuite) TestMessaging_Block() {
+	seller, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	buyer, err := suite.Client.createUser("J.Cole", "foresthill@drive.com")
+	suite.NoError(err)
+
+	ad, err := suite.Client.createAd(seller.Data.ID, "Circles", "Good News")
+	suite.NoError(err)
+	_, err = suite.Client.changeAdStatus(seller.Data.ID, ad.Data.ID, true)
+	suite.NoError(err)
+
+	conv, err := suite.Client.startConversation(ad.Data.ID, buyer.Data.ID)
+	suite.NoError(err)
+
+	_, err = suite.Client.blockUser(seller.Data.ID, buyer.Data.ID)
+	suite.NoError(err)
+
+	_, err = suite.Client.sendMessage(conv.Data.ID, buyer.Data.ID, "Hello?")
+	suite.ErrorIs(err, ErrForbidden)
+	_, err = suite.Client.sendMessage(conv.Data.ID, seller.Data.ID, "Hello?")
+	suite.ErrorIs(err, ErrForbidden)
+
+	_, err = suite.Client.unblockUser(seller.Data.ID, buyer.Data.ID)
+	suite.NoError(err)
+	_, err = suite.Client.unblockUser(seller.Data.ID, buyer.Data.ID)
+	suite.ErrorIs(err, ErrNotFound)
+
+	_, err = suite.Client.sendMessage(conv.Data.ID, buyer.Data.ID, "Hello?")
+	suite.NoError(err)
+}
+
+func (suite *HTTPSuite) TestMessaging_Errors() {
+	seller, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	buyer, err := suite.Client.createUser("J.Cole", "foresthill@drive.com")
+	suite.NoError(err)
+	stranger, err := suite.Client.createUser("Kendrick", "good@kid.com")
+	suite.NoError(err)
+
+	ad, err := suite.Client.createAd(seller.Data.ID, "Circles", "Good News")
+	suite.NoError(err)
+	_, err = suite.Client.changeAdStatus(seller.Data.ID, ad.Data.ID, true)
+	suite.NoError(err)
+
+	_, err = suite.Client.startConversation(ad.Data.ID, seller.Data.ID)
+	suite.ErrorIs(err, ErrForbidden)
+	_, err = suite.Client.startConversation(ad.Data.ID+1, buyer.Data.ID)
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.Client.startConversation("abc", buyer.Data.ID)
+	suite.ErrorIs(err, ErrBadRequest)
+
+	conv, err := suite.Client.startConversation(ad.Data.ID, buyer.Data.ID)
+	suite.NoError(err)
+
+	_, err = suite.Client.sendMessage(conv.Data.ID, stranger.Data.ID, "Hello?")
+	suite.ErrorIs(err, ErrForbidden)
+	_, err = suite.Client.sendMessage(conv.Data.ID, buyer.Data.ID, "")
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.sendMessage(conv.Data.ID+1, buyer.Data.ID, "Hello?")
+	suite.ErrorIs(err, ErrNotFound)
+
+	_, err = suite.Client.listMessages(conv.Data.ID, map[string]any{"user_id": stranger.Data.ID})
+	suite.ErrorIs(err, ErrForbidden)
+	_, err = suite.Client.listMessages(conv.Data.ID, map[string]any{})
+	suite.ErrorIs(err, ErrBadRequest)
+	_, err = suite.Client.listMessages(conv.Data.ID, map[string]any{"user_id": buyer.Data.ID, "offset": -1})
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.blockUser(buyer.Data.ID, buyer.Data.ID)
+	suite.ErrorIs(err, ErrForbidden)
+	_, err = suite.Client.blockUser(buyer.Data.ID, stranger.Data.ID+1)
+	suite.ErrorIs(err, ErrNotFound)
+
+	_, err = suite.Client.listConversations(stranger.Data.ID + 1)
+	suite.ErrorIs(err, ErrNotFound)
+}
+
+func (suite *GRPCSuite) TestGRPCMessaging() {
+	seller, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "ivanov@yandex.ru"})
+	suite.NoError(err)
+	buyer, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "Ivan", Email: "olegov@yandex.ru"})
+	suite.NoError(err)
+
+	ad, err := suite.Client.CreateAd(suite.Context, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: &seller.Id})
+	suite.NoError(err)
+	_, err = suite.Client.ChangeAdStatus(suite.Context,
+		&grpcPort.ChangeAdStatusRequest{AdId: &ad.Id, UserId: &seller.Id, Published: true})
+	suite.NoError(err)
+
+	ctx, cancel := context.WithCancel(suite.Context)
+	defer cancel()
+	stream, err := suite.Client.SubscribeMessages(ctx, &grpcPort.SubscribeMessagesRequest{UserId: &seller.Id})
+	suite.NoError(err)
+
+	conv, err := suite.Client.StartConversation(suite.Context,
+		&grpcPort.StartConversationRequest{AdId: &ad.Id, UserId: &buyer.Id})
+	suite.NoError(err)
+	suite.Equal(seller.Id, conv.SellerId)
+
+	// подписка регистрируется асинхронно, поэтому отправляем, пока сообщение не дойдет
+	received := make(chan *grpcPort.MessageResponse)
+	go func() {
+		msg, err := stream.Recv()
+		if err == nil {
+			received <- msg
+		}
+		close(received)
+	}()
+
+	var msg *grpcPort.MessageResponse
+	suite.Eventually(func() bool {
+		_, err := suite.Client.SendMessage(suite.Context,
+			&grpcPort.SendMessageRequest{ConversationId: &conv.Id, UserId: &buyer.Id, Text: "ping"})
+		suite.NoError(err)
+		select {
+		case msg = <-received:
+			return true
+		case <-time.After(10 * time.Millisecond):
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+	suite.NotNil(msg)
+	suite.Equal("ping", msg.Text)
+	suite.Equal(buyer.Id, msg.SenderId)
+
+	list, err := suite.Client.ListMessages(suite.Context,
+		&grpcPort.ListMessagesRequest{ConversationId: &conv.Id, UserId: &seller.Id, Limit: 1})
+	suite.NoError(err)
+	suite.Len(list.List, 1)
+
+	_, err = suite.Client.BlockUser(suite.Context, &grpcPort.BlockUserRequest{UserId: &seller.Id, BlockedId: &buyer.Id})
+	suite.NoError(err)
+	_, err = suite.Client.SendMessage(suite.Context,
+		&grpcPort.SendMessageRequest{ConversationId: &conv.Id, UserId: &buyer.Id, Text: "ping"})
+	suite.Equal("rpc error: code = PermissionDenied desc = user is blocked: forbidden", err.Error())
+
+	_, err = suite.Client.UnblockUser(suite.Context, &grpcPort.BlockUserRequest{UserId: &seller.Id, BlockedId: &buyer.Id})
+	suite.NoError(err)
+
+	convs, err := suite.Client.ListConversations(suite.Context, &grpcPort.ListConversationsRequest{UserId: &buyer.Id})
+	suite.NoError(err)
+	suite.Len(convs.List, 1)
+
+	_, err = suite.Client.SendMessage(suite.Context, &grpcPort.SendMessageRequest{UserId: &buyer.Id})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+}
+
+type MessagingRepoSuite struct {
+	suite.Suite
+	Repo *adrepo.RepositoryMap
+	Ctx  context.Context
+}
+
+func (suite *MessagingRepoSuite) SetupTest() {
+	suite.Ctx = context.Background()
+	suite.Repo = adrepo.NewRepositoryMap()
+}
+
+func (suite *MessagingRepoSuite) TestDeleteUser_RemovesConversations() {
+	seller, err := suite.Repo.AddUser(suite.Ctx, user.User{Nickname: "Mac Miller", Email: "swimmig@circles.com"})
+	suite.NoError(err)
+	buyer, err := suite.Repo.AddUser(suite.Ctx, user.User{Nickname: "J.Cole", Email: "foresthill@drive.com"})
+	suite.NoError(err)
+
+	convID, err := suite.Repo.AddConversation(suite.Ctx, messages.Conversation{AdID: 0, BuyerID: buyer, SellerID: seller})
+	suite.NoError(err)
+	_, err = suite.Repo.AddMessage(suite.Ctx, messages.Message{ConversationID: convID, SenderID: buyer, RecipientID: seller, Text: "hi"})
+	suite.NoError(err)
+	suite.NoError(suite.Repo.AddBlock(suite.Ctx, seller, buyer))
+
+	suite.NoError(suite.Repo.DeleteUserByID(suite.Ctx, buyer))
+
+	_, err = suite.Repo.GetConversationByID(suite.Ctx, convID)
+	suite.ErrorIs(err, app.ErrConversationNotFound)
+	convs, err := suite.Repo.GetUserConversations(suite.Ctx, seller)
+	suite.NoError(err)
+	suite.Len(convs, 0)
+	blocked, err := suite.Repo.IsBlocked(suite.Ctx, seller, buyer)
+	suite.NoError(err)
+	suite.False(blocked)
+}
+
+func TestMessagingRepoSuite(t *testing.T) {
+	suite.Run(t, new(MessagingRepoSuite))
+}
+
+func (suite *AppTestSuite) TestApp_StartConversation_RepoError() {
+	suite.Repo.On("GetAdByID", suite.Ctx, int64(1)).
+		Return(&ads.Ad{ID: 1, AuthorID: 2, Published: true}, nil).
+		Once()
+	suite.Repo.On("GetUserByID", suite.Ctx, int64(3)).
+		Return(&user.User{ID: 3}, nil).
+		Once()
+	suite.Repo.On("IsBlocked", suite.Ctx, int64(2), int64(3)).
+		Return(false, nil).
+		Once()
+	suite.Repo.On("FindConversation", suite.Ctx, int64(1), int64(3)).
+		Return(nil, app.ErrConversationNotFound).
+		Once()
+	suite.Repo.On("AddConversation", suite.Ctx, mock.AnythingOfType("messages.Conversation")).
+		Return(int64(0), ErrDateMock).
+		Once()
+	service := app.NewApp(suite.Repo)
+	_, err := service.StartConversation(suite.Ctx, 1, 3)
+	suite.ErrorIs(err, ErrDateMock)
+}
+
+func (suite *AppTestSuite) TestApp_ListMessages_InvalidPage() {
+	service := app.NewApp(suite.Repo)
+	_, err := service.ListMessages(suite.Ctx, 1, 1, -1, 0)
+	suite.ErrorIs(err, app.ErrInvalidPage)
+	_, err = service.ListMessages(suite.Ctx, 1, 1, 0, -1)
+	suite.ErrorIs(err, app.ErrInvalidPage)
+}
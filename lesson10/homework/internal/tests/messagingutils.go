@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+type conversationData struct {
+	ID          int64  `json:"id"`
+	AdID        int64  `json:"ad_id"`
+	BuyerID     int64  `json:"buyer_id"`
+	SellerID    int64  `json:"seller_id"`
+	Unread      int    `json:"unread"`
+	DateCreated string `json:"date_created"`
+	DateChanged string `json:"date_changed"`
+}
+
+type conversationResponse struct {
+	Data conversationData `json:"data"`
+}
+
+type conversationsResponse struct {
+	Data []conversationData `json:"data"`
+}
+
+type messageData struct {
+	ID             int64  `json:"id"`
+	ConversationID int64  `json:"conversation_id"`
+	SenderID       int64  `json:"sender_id"`
+	RecipientID    int64  `json:"recipient_id"`
+	Text           string `json:"text"`
+	Read           bool   `json:"read"`
+	DateSent       string `json:"date_sent"`
+}
+
+type messageResponse struct {
+	Data messageData `json:"data"`
+}
+
+type messagesResponse struct {
+	Data []messageData `json:"data"`
+}
+
+func (tc *testClient) startConversation(adID any, userID any) (conversationResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return conversationResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%v/conversations", adID), bytes.NewReader(data))
+	if err != nil {
+		return conversationResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response conversationResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return conversationResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listConversations(userID any) (conversationsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/conversations", userID), nil)
+	if err != nil {
+		return conversationsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response conversationsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return conversationsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) sendMessage(convID any, userID any, text any) (messageResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"text":    text,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/conversations/%v/messages", convID), bytes.NewReader(data))
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response messageResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messageResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listMessages(convID any, query map[string]any) (messagesResponse, error) {
+	v := url.Values{}
+	for key, val := range query {
+		v.Add(key, fmt.Sprintf("%v", val))
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/conversations/%v/messages?%s", convID, v.Encode()), nil)
+	if err != nil {
+		return messagesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response messagesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messagesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) blockUser(userID any, blockedID any) (userResponse, error) {
+	body := map[string]any{
+		"blocked_id": blockedID,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/blocks", userID), bytes.NewReader(data))
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) unblockUser(userID any, blockedID any) (userResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/blocks/%v", userID, blockedID), nil)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
@@ -6,10 +6,18 @@ import (
 	ads "homework10/internal/ads"
 	app "homework10/internal/app"
 
+	audit "homework10/internal/audit"
+
 	context "context"
 
+	features "homework10/internal/features"
+
+	messages "homework10/internal/messages"
+
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	user "homework10/internal/user"
 )
 
@@ -18,6 +26,98 @@ type App struct {
 	mock.Mock
 }
 
+// AddFavorite provides a mock function with given fields: ctx, uid, adID
+func (_m *App) AddFavorite(ctx context.Context, uid int64, adID int64) (*ads.Ad, error) {
+	ret := _m.Called(ctx, uid, adID)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*ads.Ad, error)); ok {
+		return rf(ctx, uid, adID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *ads.Ad); ok {
+		r0 = rf(ctx, uid, adID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, uid, adID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ApproveAd provides a mock function with given fields: ctx, adminID, id
+func (_m *App) ApproveAd(ctx context.Context, adminID int64, id int64) (*ads.Ad, error) {
+	ret := _m.Called(ctx, adminID, id)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*ads.Ad, error)); ok {
+		return rf(ctx, adminID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *ads.Ad); ok {
+		r0 = rf(ctx, adminID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, adminID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BanUser provides a mock function with given fields: ctx, adminID, id
+func (_m *App) BanUser(ctx context.Context, adminID int64, id int64) (*user.User, error) {
+	ret := _m.Called(ctx, adminID, id)
+
+	var r0 *user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*user.User, error)); ok {
+		return rf(ctx, adminID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *user.User); ok {
+		r0 = rf(ctx, adminID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*user.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, adminID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BlockUser provides a mock function with given fields: ctx, uid, blockedID
+func (_m *App) BlockUser(ctx context.Context, uid int64, blockedID int64) error {
+	ret := _m.Called(ctx, uid, blockedID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, uid, blockedID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ChangeAdStatus provides a mock function with given fields: ctx, id, uid, published
 func (_m *App) ChangeAdStatus(ctx context.Context, id int64, uid int64, published bool) (*ads.Ad, error) {
 	ret := _m.Called(ctx, id, uid, published)
@@ -70,6 +170,32 @@ func (_m *App) CreateAd(ctx context.Context, title string, text string, uid int6
 	return r0, r1
 }
 
+// CreateSavedSearch provides a mock function with given fields: ctx, uid, params
+func (_m *App) CreateSavedSearch(ctx context.Context, uid int64, params app.ListAdsParams) (*app.SavedSearch, error) {
+	ret := _m.Called(ctx, uid, params)
+
+	var r0 *app.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, app.ListAdsParams) (*app.SavedSearch, error)); ok {
+		return rf(ctx, uid, params)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, app.ListAdsParams) *app.SavedSearch); ok {
+		r0 = rf(ctx, uid, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*app.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, app.ListAdsParams) error); ok {
+		r1 = rf(ctx, uid, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateUser provides a mock function with given fields: ctx, nickname, email
 func (_m *App) CreateUser(ctx context.Context, nickname string, email string) (*user.User, error) {
 	ret := _m.Called(ctx, nickname, email)
@@ -110,6 +236,20 @@ func (_m *App) DeleteAd(ctx context.Context, id int64, uid int64) error {
 	return r0
 }
 
+// DeleteSavedSearch provides a mock function with given fields: ctx, id, uid
+func (_m *App) DeleteSavedSearch(ctx context.Context, id int64, uid int64) error {
+	ret := _m.Called(ctx, id, uid)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, id, uid)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteUser provides a mock function with given fields: ctx, id
 func (_m *App) DeleteUser(ctx context.Context, id int64) error {
 	ret := _m.Called(ctx, id)
@@ -124,6 +264,46 @@ func (_m *App) DeleteUser(ctx context.Context, id int64) error {
 	return r0
 }
 
+// ForceDeleteAd provides a mock function with given fields: ctx, adminID, id
+func (_m *App) ForceDeleteAd(ctx context.Context, adminID int64, id int64) error {
+	ret := _m.Called(ctx, adminID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, adminID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ForceUnpublishAd provides a mock function with given fields: ctx, adminID, id
+func (_m *App) ForceUnpublishAd(ctx context.Context, adminID int64, id int64) (*ads.Ad, error) {
+	ret := _m.Called(ctx, adminID, id)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*ads.Ad, error)); ok {
+		return rf(ctx, adminID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *ads.Ad); ok {
+		r0 = rf(ctx, adminID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, adminID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAd provides a mock function with given fields: ctx, id
 func (_m *App) GetAd(ctx context.Context, id int64) (*ads.Ad, error) {
 	ret := _m.Called(ctx, id)
@@ -202,6 +382,490 @@ func (_m *App) ListAds(ctx context.Context, params app.ListAdsParams) (*ads.AdLi
 	return r0, r1
 }
 
+// ListAudit provides a mock function with given fields: ctx, adminID, f
+func (_m *App) ListAudit(ctx context.Context, adminID int64, f audit.Filter) ([]audit.Entry, error) {
+	ret := _m.Called(ctx, adminID, f)
+
+	var r0 []audit.Entry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, audit.Filter) ([]audit.Entry, error)); ok {
+		return rf(ctx, adminID, f)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, audit.Filter) []audit.Entry); ok {
+		r0 = rf(ctx, adminID, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]audit.Entry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, audit.Filter) error); ok {
+		r1 = rf(ctx, adminID, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListConversations provides a mock function with given fields: ctx, uid
+func (_m *App) ListConversations(ctx context.Context, uid int64) ([]messages.Conversation, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 []messages.Conversation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]messages.Conversation, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []messages.Conversation); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]messages.Conversation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListFavorites provides a mock function with given fields: ctx, uid
+func (_m *App) ListFavorites(ctx context.Context, uid int64) (*ads.AdList, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 *ads.AdList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*ads.AdList, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *ads.AdList); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.AdList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListFeatures provides a mock function with given fields: ctx, adminID, uid
+func (_m *App) ListFeatures(ctx context.Context, adminID int64, uid int64) ([]features.Evaluation, error) {
+	ret := _m.Called(ctx, adminID, uid)
+
+	var r0 []features.Evaluation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) ([]features.Evaluation, error)); ok {
+		return rf(ctx, adminID, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) []features.Evaluation); ok {
+		r0 = rf(ctx, adminID, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]features.Evaluation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, adminID, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListFlaggedAds provides a mock function with given fields: ctx, adminID
+func (_m *App) ListFlaggedAds(ctx context.Context, adminID int64) (*ads.AdList, error) {
+	ret := _m.Called(ctx, adminID)
+
+	var r0 *ads.AdList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*ads.AdList, error)); ok {
+		return rf(ctx, adminID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *ads.AdList); ok {
+		r0 = rf(ctx, adminID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.AdList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, adminID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListMessages provides a mock function with given fields: ctx, convID, uid, offset, limit
+func (_m *App) ListMessages(ctx context.Context, convID int64, uid int64, offset int, limit int) ([]messages.Message, error) {
+	ret := _m.Called(ctx, convID, uid, offset, limit)
+
+	var r0 []messages.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int, int) ([]messages.Message, error)); ok {
+		return rf(ctx, convID, uid, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int, int) []messages.Message); ok {
+		r0 = rf(ctx, convID, uid, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]messages.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int, int) error); ok {
+		r1 = rf(ctx, convID, uid, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListNotifications provides a mock function with given fields: ctx, uid
+func (_m *App) ListNotifications(ctx context.Context, uid int64) ([]app.Notification, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 []app.Notification
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]app.Notification, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []app.Notification); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]app.Notification)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSavedSearches provides a mock function with given fields: ctx, uid
+func (_m *App) ListSavedSearches(ctx context.Context, uid int64) ([]app.SavedSearch, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 []app.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]app.SavedSearch, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []app.SavedSearch); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]app.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUsers provides a mock function with given fields: ctx, adminID, params
+func (_m *App) ListUsers(ctx context.Context, adminID int64, params app.ListUsersParams) ([]user.User, error) {
+	ret := _m.Called(ctx, adminID, params)
+
+	var r0 []user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, app.ListUsersParams) ([]user.User, error)); ok {
+		return rf(ctx, adminID, params)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, app.ListUsersParams) []user.User); ok {
+		r0 = rf(ctx, adminID, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, app.ListUsersParams) error); ok {
+		r1 = rf(ctx, adminID, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchAd provides a mock function with given fields: ctx, id, uid, patch
+func (_m *App) PatchAd(ctx context.Context, id int64, uid int64, patch app.AdPatch) (*ads.Ad, error) {
+	ret := _m.Called(ctx, id, uid, patch)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, app.AdPatch) (*ads.Ad, error)); ok {
+		return rf(ctx, id, uid, patch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, app.AdPatch) *ads.Ad); ok {
+		r0 = rf(ctx, id, uid, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, app.AdPatch) error); ok {
+		r1 = rf(ctx, id, uid, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchUser provides a mock function with given fields: ctx, id, patch
+func (_m *App) PatchUser(ctx context.Context, id int64, patch app.UserPatch) (*user.User, error) {
+	ret := _m.Called(ctx, id, patch)
+
+	var r0 *user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, app.UserPatch) (*user.User, error)); ok {
+		return rf(ctx, id, patch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, app.UserPatch) *user.User); ok {
+		r0 = rf(ctx, id, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*user.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, app.UserPatch) error); ok {
+		r1 = rf(ctx, id, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveFavorite provides a mock function with given fields: ctx, uid, adID
+func (_m *App) RemoveFavorite(ctx context.Context, uid int64, adID int64) error {
+	ret := _m.Called(ctx, uid, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, uid, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RequestEmailVerification provides a mock function with given fields: ctx, id
+func (_m *App) RequestEmailVerification(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScheduleAd provides a mock function with given fields: ctx, id, uid, publishAt, expiresIn
+func (_m *App) ScheduleAd(ctx context.Context, id int64, uid int64, publishAt *time.Time, expiresIn int) (*ads.Ad, error) {
+	ret := _m.Called(ctx, id, uid, publishAt, expiresIn)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, *time.Time, int) (*ads.Ad, error)); ok {
+		return rf(ctx, id, uid, publishAt, expiresIn)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, *time.Time, int) *ads.Ad); ok {
+		r0 = rf(ctx, id, uid, publishAt, expiresIn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, *time.Time, int) error); ok {
+		r1 = rf(ctx, id, uid, publishAt, expiresIn)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendMessage provides a mock function with given fields: ctx, convID, uid, text
+func (_m *App) SendMessage(ctx context.Context, convID int64, uid int64, text string) (*messages.Message, error) {
+	ret := _m.Called(ctx, convID, uid, text)
+
+	var r0 *messages.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) (*messages.Message, error)); ok {
+		return rf(ctx, convID, uid, text)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) *messages.Message); ok {
+		r0 = rf(ctx, convID, uid, text)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*messages.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string) error); ok {
+		r1 = rf(ctx, convID, uid, text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserRole provides a mock function with given fields: ctx, adminID, id, role
+func (_m *App) SetUserRole(ctx context.Context, adminID int64, id int64, role user.Role) (*user.User, error) {
+	ret := _m.Called(ctx, adminID, id, role)
+
+	var r0 *user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, user.Role) (*user.User, error)); ok {
+		return rf(ctx, adminID, id, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, user.Role) *user.User); ok {
+		r0 = rf(ctx, adminID, id, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*user.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, user.Role) error); ok {
+		r1 = rf(ctx, adminID, id, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartConversation provides a mock function with given fields: ctx, adID, uid
+func (_m *App) StartConversation(ctx context.Context, adID int64, uid int64) (*messages.Conversation, error) {
+	ret := _m.Called(ctx, adID, uid)
+
+	var r0 *messages.Conversation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*messages.Conversation, error)); ok {
+		return rf(ctx, adID, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *messages.Conversation); ok {
+		r0 = rf(ctx, adID, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*messages.Conversation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, adID, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubscribeMessages provides a mock function with given fields: ctx, uid
+func (_m *App) SubscribeMessages(ctx context.Context, uid int64) (<-chan messages.Message, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 <-chan messages.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (<-chan messages.Message, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) <-chan messages.Message); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan messages.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnbanUser provides a mock function with given fields: ctx, adminID, id
+func (_m *App) UnbanUser(ctx context.Context, adminID int64, id int64) (*user.User, error) {
+	ret := _m.Called(ctx, adminID, id)
+
+	var r0 *user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*user.User, error)); ok {
+		return rf(ctx, adminID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *user.User); ok {
+		r0 = rf(ctx, adminID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*user.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, adminID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnblockUser provides a mock function with given fields: ctx, uid, blockedID
+func (_m *App) UnblockUser(ctx context.Context, uid int64, blockedID int64) error {
+	ret := _m.Called(ctx, uid, blockedID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, uid, blockedID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateAd provides a mock function with given fields: ctx, id, uid, title, text
 func (_m *App) UpdateAd(ctx context.Context, id int64, uid int64, title string, text string) (*ads.Ad, error) {
 	ret := _m.Called(ctx, id, uid, title, text)
@@ -254,6 +918,32 @@ func (_m *App) UpdateUser(ctx context.Context, id int64, nickname string, email
 	return r0, r1
 }
 
+// VerifyEmail provides a mock function with given fields: ctx, token
+func (_m *App) VerifyEmail(ctx context.Context, token string) (*user.User, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 *user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*user.User, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *user.User); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*user.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewApp interface {
 	mock.TestingT
 	Cleanup(func())
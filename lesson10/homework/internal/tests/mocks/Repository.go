@@ -8,6 +8,8 @@ import (
 
 	context "context"
 
+	messages "homework10/internal/messages"
+
 	mock "github.com/stretchr/testify/mock"
 
 	time "time"
@@ -44,6 +46,130 @@ func (_m *Repository) AddAd(ctx context.Context, ad ads.Ad) (int64, error) {
 	return r0, r1
 }
 
+// AddBlock provides a mock function with given fields: ctx, uid, blockedID
+func (_m *Repository) AddBlock(ctx context.Context, uid int64, blockedID int64) error {
+	ret := _m.Called(ctx, uid, blockedID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, uid, blockedID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddConversation provides a mock function with given fields: ctx, c
+func (_m *Repository) AddConversation(ctx context.Context, c messages.Conversation) (int64, error) {
+	ret := _m.Called(ctx, c)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, messages.Conversation) (int64, error)); ok {
+		return rf(ctx, c)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, messages.Conversation) int64); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, messages.Conversation) error); ok {
+		r1 = rf(ctx, c)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddFavorite provides a mock function with given fields: ctx, uid, adID
+func (_m *Repository) AddFavorite(ctx context.Context, uid int64, adID int64) error {
+	ret := _m.Called(ctx, uid, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, uid, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddMessage provides a mock function with given fields: ctx, m
+func (_m *Repository) AddMessage(ctx context.Context, m messages.Message) (int64, error) {
+	ret := _m.Called(ctx, m)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, messages.Message) (int64, error)); ok {
+		return rf(ctx, m)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, messages.Message) int64); ok {
+		r0 = rf(ctx, m)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, messages.Message) error); ok {
+		r1 = rf(ctx, m)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddNotification provides a mock function with given fields: ctx, n
+func (_m *Repository) AddNotification(ctx context.Context, n app.Notification) (int64, error) {
+	ret := _m.Called(ctx, n)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, app.Notification) (int64, error)); ok {
+		return rf(ctx, n)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, app.Notification) int64); ok {
+		r0 = rf(ctx, n)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, app.Notification) error); ok {
+		r1 = rf(ctx, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddSavedSearch provides a mock function with given fields: ctx, s
+func (_m *Repository) AddSavedSearch(ctx context.Context, s app.SavedSearch) (int64, error) {
+	ret := _m.Called(ctx, s)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, app.SavedSearch) (int64, error)); ok {
+		return rf(ctx, s)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, app.SavedSearch) int64); ok {
+		r0 = rf(ctx, s)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, app.SavedSearch) error); ok {
+		r1 = rf(ctx, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AddUser provides a mock function with given fields: ctx, u
 func (_m *Repository) AddUser(ctx context.Context, u user.User) (int64, error) {
 	ret := _m.Called(ctx, u)
@@ -68,6 +194,44 @@ func (_m *Repository) AddUser(ctx context.Context, u user.User) (int64, error) {
 	return r0, r1
 }
 
+// AddVerificationToken provides a mock function with given fields: ctx, t
+func (_m *Repository) AddVerificationToken(ctx context.Context, t app.VerificationToken) error {
+	ret := _m.Called(ctx, t)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, app.VerificationToken) error); ok {
+		r0 = rf(ctx, t)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CountUnreadMessages provides a mock function with given fields: ctx, convID, uid
+func (_m *Repository) CountUnreadMessages(ctx context.Context, convID int64, uid int64) (int, error) {
+	ret := _m.Called(ctx, convID, uid)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (int, error)); ok {
+		return rf(ctx, convID, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) int); ok {
+		r0 = rf(ctx, convID, uid)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, convID, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteAdByID provides a mock function with given fields: ctx, id
 func (_m *Repository) DeleteAdByID(ctx context.Context, id int64) error {
 	ret := _m.Called(ctx, id)
@@ -82,6 +246,48 @@ func (_m *Repository) DeleteAdByID(ctx context.Context, id int64) error {
 	return r0
 }
 
+// DeleteBlock provides a mock function with given fields: ctx, uid, blockedID
+func (_m *Repository) DeleteBlock(ctx context.Context, uid int64, blockedID int64) error {
+	ret := _m.Called(ctx, uid, blockedID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, uid, blockedID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteFavorite provides a mock function with given fields: ctx, uid, adID
+func (_m *Repository) DeleteFavorite(ctx context.Context, uid int64, adID int64) error {
+	ret := _m.Called(ctx, uid, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, uid, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteSavedSearchByID provides a mock function with given fields: ctx, id
+func (_m *Repository) DeleteSavedSearchByID(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteUserByID provides a mock function with given fields: ctx, id
 func (_m *Repository) DeleteUserByID(ctx context.Context, id int64) error {
 	ret := _m.Called(ctx, id)
@@ -96,6 +302,72 @@ func (_m *Repository) DeleteUserByID(ctx context.Context, id int64) error {
 	return r0
 }
 
+// DeleteVerificationToken provides a mock function with given fields: ctx, token
+func (_m *Repository) DeleteVerificationToken(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExpireAds provides a mock function with given fields: ctx, now
+func (_m *Repository) ExpireAds(ctx context.Context, now time.Time) ([]ads.Change, error) {
+	ret := _m.Called(ctx, now)
+
+	var r0 []ads.Change
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]ads.Change, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []ads.Change); ok {
+		r0 = rf(ctx, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Change)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindConversation provides a mock function with given fields: ctx, adID, buyerID
+func (_m *Repository) FindConversation(ctx context.Context, adID int64, buyerID int64) (*messages.Conversation, error) {
+	ret := _m.Called(ctx, adID, buyerID)
+
+	var r0 *messages.Conversation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*messages.Conversation, error)); ok {
+		return rf(ctx, adID, buyerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *messages.Conversation); ok {
+		r0 = rf(ctx, adID, buyerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*messages.Conversation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, adID, buyerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAdByID provides a mock function with given fields: ctx, id
 func (_m *Repository) GetAdByID(ctx context.Context, id int64) (*ads.Ad, error) {
 	ret := _m.Called(ctx, id)
@@ -148,6 +420,162 @@ func (_m *Repository) GetAdList(ctx context.Context, params app.ListAdsParams) (
 	return r0, r1
 }
 
+// GetConversationByID provides a mock function with given fields: ctx, id
+func (_m *Repository) GetConversationByID(ctx context.Context, id int64) (*messages.Conversation, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *messages.Conversation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*messages.Conversation, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *messages.Conversation); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*messages.Conversation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFavorites provides a mock function with given fields: ctx, uid
+func (_m *Repository) GetFavorites(ctx context.Context, uid int64) (*ads.AdList, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 *ads.AdList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*ads.AdList, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *ads.AdList); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.AdList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessages provides a mock function with given fields: ctx, convID, offset, limit
+func (_m *Repository) GetMessages(ctx context.Context, convID int64, offset int, limit int) ([]messages.Message, error) {
+	ret := _m.Called(ctx, convID, offset, limit)
+
+	var r0 []messages.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int, int) ([]messages.Message, error)); ok {
+		return rf(ctx, convID, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int, int) []messages.Message); ok {
+		r0 = rf(ctx, convID, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]messages.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int, int) error); ok {
+		r1 = rf(ctx, convID, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNotifications provides a mock function with given fields: ctx, uid
+func (_m *Repository) GetNotifications(ctx context.Context, uid int64) ([]app.Notification, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 []app.Notification
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]app.Notification, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []app.Notification); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]app.Notification)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSavedSearchByID provides a mock function with given fields: ctx, id
+func (_m *Repository) GetSavedSearchByID(ctx context.Context, id int64) (*app.SavedSearch, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *app.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*app.SavedSearch, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *app.SavedSearch); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*app.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSavedSearches provides a mock function with given fields: ctx
+func (_m *Repository) GetSavedSearches(ctx context.Context) ([]app.SavedSearch, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []app.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]app.SavedSearch, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []app.SavedSearch); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]app.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserByID provides a mock function with given fields: ctx, id
 func (_m *Repository) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
 	ret := _m.Called(ctx, id)
@@ -174,6 +602,258 @@ func (_m *Repository) GetUserByID(ctx context.Context, id int64) (*user.User, er
 	return r0, r1
 }
 
+// GetUserConversations provides a mock function with given fields: ctx, uid
+func (_m *Repository) GetUserConversations(ctx context.Context, uid int64) ([]messages.Conversation, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 []messages.Conversation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]messages.Conversation, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []messages.Conversation); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]messages.Conversation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserList provides a mock function with given fields: ctx, params
+func (_m *Repository) GetUserList(ctx context.Context, params app.ListUsersParams) ([]user.User, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, app.ListUsersParams) ([]user.User, error)); ok {
+		return rf(ctx, params)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, app.ListUsersParams) []user.User); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, app.ListUsersParams) error); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserSavedSearches provides a mock function with given fields: ctx, uid
+func (_m *Repository) GetUserSavedSearches(ctx context.Context, uid int64) ([]app.SavedSearch, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 []app.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]app.SavedSearch, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []app.SavedSearch); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]app.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVerificationToken provides a mock function with given fields: ctx, token
+func (_m *Repository) GetVerificationToken(ctx context.Context, token string) (*app.VerificationToken, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 *app.VerificationToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*app.VerificationToken, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *app.VerificationToken); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*app.VerificationToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsBlocked provides a mock function with given fields: ctx, uid, blockedID
+func (_m *Repository) IsBlocked(ctx context.Context, uid int64, blockedID int64) (bool, error) {
+	ret := _m.Called(ctx, uid, blockedID)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (bool, error)); ok {
+		return rf(ctx, uid, blockedID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) bool); ok {
+		r0 = rf(ctx, uid, blockedID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, uid, blockedID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkMessagesRead provides a mock function with given fields: ctx, convID, uid, upTo
+func (_m *Repository) MarkMessagesRead(ctx context.Context, convID int64, uid int64, upTo int64) error {
+	ret := _m.Called(ctx, convID, uid, upTo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) error); ok {
+		r0 = rf(ctx, convID, uid, upTo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PatchAd provides a mock function with given fields: ctx, id, patch, date
+func (_m *Repository) PatchAd(ctx context.Context, id int64, patch app.AdPatch, date time.Time) error {
+	ret := _m.Called(ctx, id, patch, date)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, app.AdPatch, time.Time) error); ok {
+		r0 = rf(ctx, id, patch, date)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PatchUser provides a mock function with given fields: ctx, id, patch
+func (_m *Repository) PatchUser(ctx context.Context, id int64, patch app.UserPatch) error {
+	ret := _m.Called(ctx, id, patch)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, app.UserPatch) error); ok {
+		r0 = rf(ctx, id, patch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PublishScheduledAds provides a mock function with given fields: ctx, now
+func (_m *Repository) PublishScheduledAds(ctx context.Context, now time.Time) ([]ads.Change, error) {
+	ret := _m.Called(ctx, now)
+
+	var r0 []ads.Change
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]ads.Change, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []ads.Change); ok {
+		r0 = rf(ctx, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Change)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetAdFlags provides a mock function with given fields: ctx, id, flags
+func (_m *Repository) SetAdFlags(ctx context.Context, id int64, flags []string) error {
+	ret := _m.Called(ctx, id, flags)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []string) error); ok {
+		r0 = rf(ctx, id, flags)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserBanned provides a mock function with given fields: ctx, id, banned
+func (_m *Repository) SetUserBanned(ctx context.Context, id int64, banned bool) error {
+	ret := _m.Called(ctx, id, banned)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) error); ok {
+		r0 = rf(ctx, id, banned)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserRole provides a mock function with given fields: ctx, id, role
+func (_m *Repository) SetUserRole(ctx context.Context, id int64, role user.Role) error {
+	ret := _m.Called(ctx, id, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, user.Role) error); ok {
+		r0 = rf(ctx, id, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserVerified provides a mock function with given fields: ctx, id, email
+func (_m *Repository) SetUserVerified(ctx context.Context, id int64, email string) error {
+	ret := _m.Called(ctx, id, email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, id, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateAdContent provides a mock function with given fields: ctx, id, title, text, date
 func (_m *Repository) UpdateAdContent(ctx context.Context, id int64, title string, text string, date time.Time) error {
 	ret := _m.Called(ctx, id, title, text, date)
@@ -188,6 +868,20 @@ func (_m *Repository) UpdateAdContent(ctx context.Context, id int64, title strin
 	return r0
 }
 
+// UpdateAdSchedule provides a mock function with given fields: ctx, id, publishAt, expiresAt, date
+func (_m *Repository) UpdateAdSchedule(ctx context.Context, id int64, publishAt *time.Time, expiresAt *time.Time, date time.Time) error {
+	ret := _m.Called(ctx, id, publishAt, expiresAt, date)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *time.Time, *time.Time, time.Time) error); ok {
+		r0 = rf(ctx, id, publishAt, expiresAt, date)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateAdStatus provides a mock function with given fields: ctx, id, published, date
 func (_m *Repository) UpdateAdStatus(ctx context.Context, id int64, published bool, date time.Time) error {
 	ret := _m.Called(ctx, id, published, date)
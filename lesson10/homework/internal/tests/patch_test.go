@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"homework10/internal/apperr"
+	"homework10/internal/ports/httpgin"
+	"net/http"
+	"strings"
+)
+
+// patch отправляет тело как JSON Merge Patch и разбирает ответ в out при успехе или в problem при ошибке
+func (suite *HTTPSuite) patch(path string, body string, out any) (int, problem) {
+	req, err := http.NewRequest(http.MethodPatch, suite.Client.baseURL+path, strings.NewReader(body))
+	suite.Require().NoError(err)
+	req.Header.Set("Content-Type", httpgin.MergePatchContentType)
+	resp, err := suite.Client.client.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	var p problem
+	if resp.StatusCode >= http.StatusBadRequest {
+		suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&p))
+	} else if out != nil {
+		suite.Require().NoError(json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode, p
+}
+
+func (suite *HTTPSuite) TestPatchAd() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.Require().NoError(err)
+	ad, err := suite.Client.createAd(u.Data.ID, "Circles", "Good News")
+	suite.Require().NoError(err)
+	path := fmt.Sprintf("/api/v1/ads/%d", ad.Data.ID)
+
+	var resp adResponse
+	code, _ := suite.patch(path, fmt.Sprintf(`{"user_id": %d, "text": "Blue World"}`, u.Data.ID), &resp)
+	suite.Equal(http.StatusOK, code)
+	suite.Equal("Circles", resp.Data.Title)
+	suite.Equal("Blue World", resp.Data.Text)
+	suite.False(resp.Data.Published)
+
+	code, _ = suite.patch(path, fmt.Sprintf(`{"user_id": "%d", "published": true}`, u.Data.ID), &resp)
+	suite.Equal(http.StatusOK, code)
+	suite.True(resp.Data.Published)
+	suite.Equal("Blue World", resp.Data.Text)
+
+	// проверяется объявление после слияния
+	code, p := suite.patch(path, fmt.Sprintf(`{"user_id": %d, "title": ""}`, u.Data.ID), nil)
+	suite.Equal(http.StatusBadRequest, code)
+	suite.Equal(apperr.CodeValidationFailed, p.Code)
+
+	code, p = suite.patch(path, fmt.Sprintf(`{"user_id": %d, "text": null}`, u.Data.ID), nil)
+	suite.Equal(http.StatusBadRequest, code)
+	suite.Require().Len(p.Errors, 1)
+	suite.Equal("text", p.Errors[0].Field)
+
+	code, p = suite.patch(path, fmt.Sprintf(`{"user_id": %d, "author_id": 5}`, u.Data.ID), nil)
+	suite.Equal(http.StatusBadRequest, code)
+	suite.Require().Len(p.Errors, 1)
+	suite.Equal("author_id", p.Errors[0].Field)
+
+	code, _ = suite.patch(path, `{"title": "no author"}`, nil)
+	suite.Equal(http.StatusBadRequest, code)
+	code, _ = suite.patch(path, `["title"]`, nil)
+	suite.Equal(http.StatusBadRequest, code)
+	code, _ = suite.patch(path, fmt.Sprintf(`{"user_id": %d, "title": "hacked"}`, u.Data.ID+1), nil)
+	suite.Equal(http.StatusForbidden, code)
+
+	got, err := suite.Client.getAd(ad.Data.ID)
+	suite.Require().NoError(err)
+	suite.Equal("Circles", got.Data.Title)
+	suite.Equal("Blue World", got.Data.Text)
+	suite.True(got.Data.Published)
+}
+
+func (suite *HTTPSuite) TestPatchUser() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.Require().NoError(err)
+	_, err = suite.Client.createUser("Kendrick", "good@kid.com")
+	suite.Require().NoError(err)
+	path := fmt.Sprintf("/api/v1/users/%d", u.Data.ID)
+
+	var resp userResponse
+	code, _ := suite.patch(path, `{"nickname": "Malcolm"}`, &resp)
+	suite.Equal(http.StatusOK, code)
+	suite.Equal("Malcolm", resp.Data.Nickname)
+	suite.Equal("swimming@circles.com", resp.Data.Email)
+
+	code, p := suite.patch(path, `{"email": "good@kid.com"}`, nil)
+	suite.Equal(http.StatusConflict, code)
+	suite.Equal(apperr.CodeAlreadyExists, p.Code)
+
+	code, _ = suite.patch(path, `{"email": 42}`, nil)
+	suite.Equal(http.StatusBadRequest, code)
+	code, _ = suite.patch(path, `{}`, &resp)
+	suite.Equal(http.StatusOK, code)
+	suite.Equal("Malcolm", resp.Data.Nickname)
+}
@@ -0,0 +1,296 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"homework10/internal/policy"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/user"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testPolicy = `
+banned_words:
+  action: reject
+  words: ["casino", "Easy Money"]
+links:
+  action: flag
+phones:
+  action: flag
+duplicates:
+  action: reject
+spam:
+  flag_score: 0.5
+  reject_score: 0.9
+`
+
+func newTestPolicy(t *testing.T) *policy.Engine {
+	e, err := policy.Read(strings.NewReader(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// rules возвращает имена правил нарушений вместе с полями: "banned_word/title"
+func rules(violations []policy.Violation) []string {
+	names := make([]string, 0, len(violations))
+	for _, v := range violations {
+		names = append(names, v.Rule+"/"+v.Field)
+	}
+	return names
+}
+
+func TestPolicyCheck(t *testing.T) {
+	e := newTestPolicy(t)
+	assert.Equal(t, []string{"banned_word", "link", "phone", "duplicate", "spam"}, e.Rules())
+
+	for name, tc := range map[string]struct {
+		title, text string
+		rejected    []string
+		flagged     []string
+	}{
+		"clean":           {"Red bike", "Almost new, 1500 rub", nil, nil},
+		"banned title":    {"Casino night", "Come and play", []string{"banned_word/title"}, nil},
+		"banned phrase":   {"Job", "Make EASY money fast", []string{"banned_word/text"}, nil},
+		"whole words":     {"Casinos", "easymoney", nil, nil},
+		"link":            {"Bike", "Photos at www.example.com", nil, []string{"link/text"}},
+		"bare domain":     {"Bike", "details: bikes.ru", nil, []string{"link/text"}},
+		"phone":           {"Bike", "Call +7 (999) 123-45-67", nil, []string{"phone/text"}},
+		"short number":    {"Bike", "Model 2023-05, size 28", nil, nil},
+		"spam flagged":    {"BUY NOW", "BEST DEAL EVER!!!", nil, []string{"spam/"}},
+		"spam rejected":   {"BUY NOW!!!", "BEST DEAL EVER!!! VISIT http://a.ru http://b.ru", []string{"spam/"}, []string{"link/text"}},
+		"repeated symbol": {"Bike", "sooooo cheap!!!", nil, nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			d, err := e.Check(context.Background(), ads.Ad{Title: tc.title, Text: tc.text}, nil)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tc.rejected, rules(d.Rejections))
+			assert.ElementsMatch(t, tc.flagged, rules(d.Flags))
+			if len(tc.rejected) > 0 {
+				assert.ErrorIs(t, d.Err(), policy.ErrRejected)
+			} else {
+				assert.NoError(t, d.Err())
+			}
+		})
+	}
+}
+
+func TestPolicyDuplicates(t *testing.T) {
+	e := newTestPolicy(t)
+	existing := []ads.Ad{
+		{ID: 1, AuthorID: 7, Title: "Red bike", Text: "Almost new, ridden twice, city tyres"},
+		{ID: 2, AuthorID: 7, Title: "Blue car", Text: "Runs well"},
+	}
+	calls := 0
+	authorAds := func(_ context.Context, authorID int64) ([]ads.Ad, error) {
+		calls++
+		assert.Equal(t, int64(7), authorID)
+		return existing, nil
+	}
+
+	d, err := e.Check(context.Background(), ads.Ad{AuthorID: 7, Title: "RED BIKE", Text: "almost new; ridden twice - city tyres"}, authorAds)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"duplicate/"}, rules(d.Rejections))
+	assert.Contains(t, d.Rejections[0].Reason, "ad 1")
+
+	d, err = e.Check(context.Background(), ads.Ad{AuthorID: 7, Title: "Red bike", Text: "Brand new carbon frame, never ridden"}, authorAds)
+	assert.NoError(t, err)
+	assert.Empty(t, d.Rejections)
+	assert.Equal(t, 2, calls)
+
+	assert.InDelta(t, 1.0/3, policy.Similarity(policy.Shingles("a b c d", 3), policy.Shingles("A, b c e", 3)), 1e-9)
+	assert.Equal(t, 1.0, policy.Similarity(policy.Shingles("a b", 3), policy.Shingles("A B", 3)))
+	assert.Zero(t, policy.Similarity(policy.Shingles("", 3), policy.Shingles("a", 3)))
+}
+
+func TestPolicyConfig(t *testing.T) {
+	e, err := policy.Read(strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Empty(t, e.Rules())
+
+	for _, cfg := range []string{
+		"links: {action: block}",
+		"spam: {reject_score: 2}",
+		"duplicates: {action: flag, threshold: 1.5}",
+		"banned_words: [casino]",
+	} {
+		_, err := policy.Read(strings.NewReader(cfg))
+		assert.ErrorIs(t, err, policy.ErrInvalidConfig, cfg)
+	}
+}
+
+func TestSpamScore(t *testing.T) {
+	assert.Zero(t, policy.SpamScore("Red bike, almost new"))
+	assert.InDelta(t, 0.5, policy.SpamScore("CHEAP BIKES HERE!!!"), 1e-9)
+	assert.InDelta(t, 0.3, policy.SpamScore("bike bike bike bike bike for sale sale in the city"), 1e-9)
+	assert.InDelta(t, 0.9, policy.SpamScore("BUY!!!!! www.a.com www.b.com www.c.com +7 999 123 45 67"), 1e-9)
+	assert.Equal(t, 1.0, policy.SpamScore("BUY NOW CHEAP!!!!! HTTP://A.RU HTTP://B.RU"))
+}
+
+type PolicySuite struct {
+	suite.Suite
+	Ctx     context.Context
+	App     app.App
+	ModerID int64
+	UserID  int64
+}
+
+func (suite *PolicySuite) SetupTest() {
+	suite.Ctx = context.Background()
+	repo := adrepo.NewRepositoryMap()
+	suite.App = app.NewAppWithPolicy(repo, newTestPolicy(suite.T()))
+
+	var err error
+	suite.ModerID, err = repo.AddUser(suite.Ctx, user.User{Nickname: "moder", Email: "moder@ads.com", Role: user.RoleModerator})
+	suite.Require().NoError(err)
+	u, err := suite.App.CreateUser(suite.Ctx, "Mac Miller", "swimming@circles.com")
+	suite.Require().NoError(err)
+	suite.UserID = u.ID
+}
+
+func (suite *PolicySuite) TestRejected() {
+	_, err := suite.App.CreateAd(suite.Ctx, "Casino", "Good News", suite.UserID)
+	suite.ErrorIs(err, policy.ErrRejected)
+	e := apperr.Classify(err)
+	suite.Equal(apperr.CodePolicyViolation, e.Code)
+	suite.Require().Len(e.Fields, 1)
+	suite.Equal("title", e.Fields[0].Field)
+
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.Require().NoError(err)
+	suite.Empty(ad.Flags)
+	_, err = suite.App.CreateAd(suite.Ctx, "circles", "good news!", suite.UserID)
+	suite.ErrorIs(err, policy.ErrRejected)
+
+	text := "easy money"
+	_, err = suite.App.PatchAd(suite.Ctx, ad.ID, suite.UserID, app.AdPatch{Text: &text})
+	suite.ErrorIs(err, policy.ErrRejected)
+	ad, err = suite.App.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.Equal("Good News", ad.Text)
+
+	// объявление не считается дубликатом самого себя
+	ad, err = suite.App.UpdateAd(suite.Ctx, ad.ID, suite.UserID, "Circles", "Good news!")
+	suite.NoError(err)
+	suite.Equal("Good news!", ad.Text)
+}
+
+func (suite *PolicySuite) TestFlagged() {
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Listen at www.circles.com", suite.UserID)
+	suite.Require().NoError(err)
+	suite.Equal([]string{"link: text contains a link"}, ad.Flags)
+
+	_, err = suite.App.ChangeAdStatus(suite.Ctx, ad.ID, suite.UserID, true)
+	suite.ErrorIs(err, app.ErrUnderReview)
+	suite.Equal(apperr.CodeAdUnderReview, apperr.Classify(err).Code)
+	published := true
+	_, err = suite.App.PatchAd(suite.Ctx, ad.ID, suite.UserID, app.AdPatch{Published: &published})
+	suite.ErrorIs(err, app.ErrUnderReview)
+
+	flagged, err := suite.App.ListFlaggedAds(suite.Ctx, suite.ModerID)
+	suite.NoError(err)
+	suite.Require().Len(flagged.Data, 1)
+	suite.Equal(ad.ID, flagged.Data[0].ID)
+	_, err = suite.App.ListFlaggedAds(suite.Ctx, suite.UserID)
+	suite.ErrorIs(err, app.ErrForbidden)
+	_, err = suite.App.ApproveAd(suite.Ctx, suite.UserID, ad.ID)
+	suite.ErrorIs(err, app.ErrForbidden)
+
+	ad, err = suite.App.ApproveAd(suite.Ctx, suite.ModerID, ad.ID)
+	suite.NoError(err)
+	suite.Empty(ad.Flags)
+	ad, err = suite.App.ChangeAdStatus(suite.Ctx, ad.ID, suite.UserID, true)
+	suite.NoError(err)
+	suite.True(ad.Published)
+
+	// новое нарушение в опубликованном объявлении снимает его с публикации до проверки
+	ad, err = suite.App.UpdateAd(suite.Ctx, ad.ID, suite.UserID, "Circles", "Call 8 800 555 35 35")
+	suite.NoError(err)
+	suite.False(ad.Published)
+	suite.Equal([]string{"phone: text contains a phone number"}, ad.Flags)
+	stored, err := suite.App.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.False(stored.Published)
+	suite.Equal(ad.Flags, stored.Flags)
+
+	// исправленное объявление снова можно публиковать
+	text := "Good News"
+	ad, err = suite.App.PatchAd(suite.Ctx, ad.ID, suite.UserID, app.AdPatch{Text: &text, Published: &published})
+	suite.NoError(err)
+	suite.True(ad.Published)
+	suite.Empty(ad.Flags)
+	flagged, err = suite.App.ListFlaggedAds(suite.Ctx, suite.ModerID)
+	suite.NoError(err)
+	suite.Empty(flagged.Data)
+}
+
+func TestPolicySuite(t *testing.T) {
+	suite.Run(t, new(PolicySuite))
+}
+
+func TestPolicyHTTP(t *testing.T) {
+	repo := adrepo.New()
+	moderID, err := repo.AddUser(context.Background(), user.User{Nickname: "moder", Email: "moder@ads.com", Role: user.RoleModerator})
+	assert.NoError(t, err)
+	client := getTestClientWithApp(app.NewAppWithPolicy(repo, newTestPolicy(t)))
+
+	u, err := client.createUser("Mac Miller", "swimming@circles.com")
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, client.baseURL+"/api/v1/ads",
+		jsonBody(map[string]any{"user_id": u.Data.ID, "title": "Circles", "text": "easy money"}))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.client.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	var p problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+	assert.Equal(t, http.StatusBadRequest, p.Status)
+	assert.Equal(t, apperr.CodePolicyViolation, p.Code)
+	assert.Equal(t, []apperr.FieldViolation{{Field: "text", Description: `text contains banned word "easy money"`}}, p.Errors)
+
+	ad, err := client.createAd(u.Data.ID, "Circles", "www.circles.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"link: text contains a link"}, ad.Data.Flags)
+	_, err = client.changeAdStatus(u.Data.ID, ad.Data.ID, true)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	flagged, err := client.listFlaggedAds(moderID)
+	assert.NoError(t, err)
+	assert.Len(t, flagged.Data, 1)
+	_, err = client.listFlaggedAds(u.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	approved, err := client.approveAd(moderID, ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, approved.Data.Flags)
+	_, err = client.approveAd(moderID, "abc")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	published, err := client.changeAdStatus(u.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+	assert.True(t, published.Data.Published)
+}
+
+func (suite *GRPCSuite) TestGRPCModeration() {
+	_, err := suite.Admin.ListFlaggedAds(suite.Context, &grpcPort.ListFlaggedAdsRequest{})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = suite.Admin.ApproveAd(suite.Context, &grpcPort.AdminAdRequest{})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+
+	u, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "J.Cole", Email: "foresthill@drive.com"})
+	suite.Require().NoError(err)
+	_, err = suite.Admin.ListFlaggedAds(suite.Context, &grpcPort.ListFlaggedAdsRequest{AdminId: &u.Id})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"fmt"
 	"github.com/stretchr/testify/suite"
 	"homework10/internal/adapters/adrepo"
 	"homework10/internal/ads"
@@ -36,8 +37,8 @@ func (suite *RepoSuite) TestRepo_AddUser() {
 }
 
 func (suite *RepoSuite) TestRepo_AddMultipleUsers() {
-	u := user.User{Nickname: "Mac Miller", Email: "swimmig@circles.com"}
 	for i := 0; i < 100; i++ {
+		u := user.User{Nickname: fmt.Sprintf("Mac Miller %d", i), Email: fmt.Sprintf("swimmig%d@circles.com", i)}
 		id, err := suite.Repo.AddUser(suite.Ctx, u)
 		suite.NoError(err)
 		suite.Equal(int64(i), id)
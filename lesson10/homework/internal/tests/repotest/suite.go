@@ -0,0 +1,575 @@
+// Package repotest содержит общий набор тестов, которому должна удовлетворять любая реализация app.Repository.
+//
+// Чтобы покрыть новое хранилище, достаточно запустить набор с фабрикой:
+//
+//	func TestMyRepo(t *testing.T) {
+//		repotest.Run(t, func() app.Repository { return myrepo.New() })
+//	}
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/filter"
+	"homework10/internal/messages"
+	"homework10/internal/user"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Factory создает пустой репозиторий, вызывается перед каждым тестом
+type Factory func() app.Repository
+
+// RepositorySuite не полагается на конкретные значения ID и порядок GetAdList,
+// поэтому подходит для любых генераторов идентификаторов
+type RepositorySuite struct {
+	suite.Suite
+	NewRepository Factory
+
+	Repo app.Repository
+	Ctx  context.Context
+	Date time.Time
+}
+
+func Run(t *testing.T, factory Factory) {
+	suite.Run(t, &RepositorySuite{NewRepository: factory})
+}
+
+func (s *RepositorySuite) SetupTest() {
+	s.Repo = s.NewRepository()
+	s.Ctx = context.Background()
+	s.Date = time.Date(2023, time.May, 20, 12, 0, 0, 0, time.UTC)
+}
+
+func (s *RepositorySuite) addUser(name string) int64 {
+	id, err := s.Repo.AddUser(s.Ctx, user.User{Nickname: name, Email: name + "@mail.com"})
+	s.Require().NoError(err)
+	return id
+}
+
+func (s *RepositorySuite) addAd(ad ads.Ad) int64 {
+	if ad.DateCreated.IsZero() {
+		ad.DateCreated = s.Date
+	}
+	ad.DateChanged = ad.DateCreated
+	id, err := s.Repo.AddAd(s.Ctx, ad)
+	s.Require().NoError(err)
+	return id
+}
+
+func (s *RepositorySuite) listIDs(params app.ListAdsParams) []int64 {
+	al, err := s.Repo.GetAdList(s.Ctx, params)
+	s.Require().NoError(err)
+	ids := make([]int64, 0, len(al.Data))
+	for _, ad := range al.Data {
+		ids = append(ids, ad.ID)
+	}
+	return ids
+}
+
+func (s *RepositorySuite) TestUser_CRUD() {
+	id, err := s.Repo.AddUser(s.Ctx, user.User{Nickname: "Mac Miller", Email: "swimming@circles.com"})
+	s.NoError(err)
+
+	u, err := s.Repo.GetUserByID(s.Ctx, id)
+	s.NoError(err)
+	s.Equal(user.User{ID: id, Nickname: "Mac Miller", Email: "swimming@circles.com"}, *u)
+
+	s.NoError(s.Repo.UpdateUser(s.Ctx, id, "KDot", "money@trees.com"))
+	u, err = s.Repo.GetUserByID(s.Ctx, id)
+	s.NoError(err)
+	s.Equal("KDot", u.Nickname)
+	s.Equal("money@trees.com", u.Email)
+
+	s.NoError(s.Repo.DeleteUserByID(s.Ctx, id))
+	_, err = s.Repo.GetUserByID(s.Ctx, id)
+	s.ErrorIs(err, app.ErrUserNotFound)
+}
+
+func (s *RepositorySuite) TestUser_Errors() {
+	id := s.addUser("mac")
+	missing := id + 1000
+
+	_, err := s.Repo.GetUserByID(s.Ctx, missing)
+	s.ErrorIs(err, app.ErrUserNotFound)
+	s.ErrorIs(s.Repo.UpdateUser(s.Ctx, missing, "x", "x@mail.com"), app.ErrUserNotFound)
+	s.ErrorIs(s.Repo.DeleteUserByID(s.Ctx, missing), app.ErrUserNotFound)
+	s.ErrorIs(s.Repo.SetUserRole(s.Ctx, missing, user.RoleAdmin), app.ErrUserNotFound)
+	s.ErrorIs(s.Repo.SetUserBanned(s.Ctx, missing, true), app.ErrUserNotFound)
+}
+
+func (s *RepositorySuite) TestUser_Unique() {
+	id := s.addUser("mac")
+	other := s.addUser("kdot")
+
+	_, err := s.Repo.AddUser(s.Ctx, user.User{Nickname: "MAC", Email: "new@mail.com"})
+	s.ErrorIs(err, app.ErrAlreadyExists)
+	_, err = s.Repo.AddUser(s.Ctx, user.User{Nickname: "new", Email: " Mac@Mail.com"})
+	s.ErrorIs(err, app.ErrAlreadyExists)
+	s.ErrorIs(s.Repo.UpdateUser(s.Ctx, other, "Mac", "kdot@mail.com"), app.ErrAlreadyExists)
+
+	// свои же почту и никнейм можно сохранить повторно
+	s.NoError(s.Repo.UpdateUser(s.Ctx, id, "Mac", "MAC@mail.com"))
+	// после удаления почта освобождается
+	s.NoError(s.Repo.DeleteUserByID(s.Ctx, id))
+	s.addUser("mac")
+}
+
+func (s *RepositorySuite) TestUser_Verification() {
+	id := s.addUser("mac")
+	token := app.VerificationToken{Token: "token", UserID: id, Email: "mac@mail.com", ExpiresAt: s.Date}
+	s.NoError(s.Repo.AddVerificationToken(s.Ctx, token))
+
+	got, err := s.Repo.GetVerificationToken(s.Ctx, "token")
+	s.NoError(err)
+	s.Equal(token, *got)
+
+	s.ErrorIs(s.Repo.SetUserVerified(s.Ctx, id, "old@mail.com"), app.ErrTokenNotFound)
+	s.NoError(s.Repo.SetUserVerified(s.Ctx, id, "mac@mail.com"))
+	u, err := s.Repo.GetUserByID(s.Ctx, id)
+	s.NoError(err)
+	s.True(u.Verified)
+
+	// смена почты сбрасывает подтверждение
+	s.NoError(s.Repo.UpdateUser(s.Ctx, id, "mac", "new@mail.com"))
+	u, err = s.Repo.GetUserByID(s.Ctx, id)
+	s.NoError(err)
+	s.False(u.Verified)
+
+	_, err = s.Repo.GetVerificationToken(s.Ctx, "missing")
+	s.ErrorIs(err, app.ErrTokenNotFound)
+	s.ErrorIs(s.Repo.DeleteVerificationToken(s.Ctx, "missing"), app.ErrTokenNotFound)
+	s.ErrorIs(s.Repo.AddVerificationToken(s.Ctx, app.VerificationToken{Token: "t", UserID: id + 1000}), app.ErrUserNotFound)
+}
+
+func (s *RepositorySuite) TestUser_Patch() {
+	id := s.addUser("mac")
+	s.addUser("kdot")
+	s.NoError(s.Repo.SetUserVerified(s.Ctx, id, "mac@mail.com"))
+
+	nickname := "Malcolm"
+	s.NoError(s.Repo.PatchUser(s.Ctx, id, app.UserPatch{Nickname: &nickname}))
+	u, err := s.Repo.GetUserByID(s.Ctx, id)
+	s.NoError(err)
+	s.Equal("Malcolm", u.Nickname)
+	s.Equal("mac@mail.com", u.Email)
+	s.True(u.Verified)
+
+	// прежний никнейм освобождается, а занятая почта отклоняется
+	_, err = s.Repo.AddUser(s.Ctx, user.User{Nickname: "mac", Email: "other@mail.com"})
+	s.NoError(err)
+	taken := "kdot@mail.com"
+	s.ErrorIs(s.Repo.PatchUser(s.Ctx, id, app.UserPatch{Email: &taken}), app.ErrAlreadyExists)
+
+	email := "new@mail.com"
+	s.NoError(s.Repo.PatchUser(s.Ctx, id, app.UserPatch{Email: &email}))
+	u, err = s.Repo.GetUserByID(s.Ctx, id)
+	s.NoError(err)
+	s.Equal("Malcolm", u.Nickname)
+	s.False(u.Verified)
+	_, err = s.Repo.AddUser(s.Ctx, user.User{Nickname: "other", Email: "mac@mail.com"})
+	s.NoError(err)
+
+	s.ErrorIs(s.Repo.PatchUser(s.Ctx, id+1000, app.UserPatch{Email: &email}), app.ErrUserNotFound)
+}
+
+func (s *RepositorySuite) TestUser_List() {
+	mac := s.addUser("mac")
+	kdot := s.addUser("kdot")
+	s.NoError(s.Repo.SetUserRole(s.Ctx, kdot, user.RoleModerator))
+	s.NoError(s.Repo.SetUserBanned(s.Ctx, mac, true))
+
+	ul, err := s.Repo.GetUserList(s.Ctx, app.ListUsersParams{})
+	s.NoError(err)
+	s.Len(ul, 2)
+
+	banned := true
+	ul, err = s.Repo.GetUserList(s.Ctx, app.ListUsersParams{Banned: &banned})
+	s.NoError(err)
+	s.Len(ul, 1)
+	s.Equal(mac, ul[0].ID)
+
+	role := user.RoleModerator
+	ul, err = s.Repo.GetUserList(s.Ctx, app.ListUsersParams{Role: &role})
+	s.NoError(err)
+	s.Len(ul, 1)
+	s.Equal(kdot, ul[0].ID)
+}
+
+func (s *RepositorySuite) TestAd_CRUD() {
+	uid := s.addUser("mac")
+	id := s.addAd(ads.Ad{Title: "title", Text: "text", AuthorID: uid})
+
+	ad, err := s.Repo.GetAdByID(s.Ctx, id)
+	s.NoError(err)
+	s.Equal(ads.Ad{ID: id, Title: "title", Text: "text", AuthorID: uid, DateCreated: s.Date, DateChanged: s.Date}, *ad)
+
+	later := s.Date.Add(time.Hour)
+	s.NoError(s.Repo.UpdateAdStatus(s.Ctx, id, true, later))
+	s.NoError(s.Repo.UpdateAdContent(s.Ctx, id, "new title", "new text", later))
+	expires := later.Add(time.Hour)
+	s.NoError(s.Repo.UpdateAdSchedule(s.Ctx, id, nil, &expires, later))
+
+	ad, err = s.Repo.GetAdByID(s.Ctx, id)
+	s.NoError(err)
+	s.True(ad.Published)
+	s.Equal("new title", ad.Title)
+	s.Equal("new text", ad.Text)
+	s.Equal(later, ad.DateChanged)
+	s.Equal(&expires, ad.ExpiresAt)
+
+	s.NoError(s.Repo.DeleteAdByID(s.Ctx, id))
+	_, err = s.Repo.GetAdByID(s.Ctx, id)
+	s.ErrorIs(err, app.ErrAdNotFound)
+}
+
+func (s *RepositorySuite) TestAd_Patch() {
+	uid := s.addUser("mac")
+	id := s.addAd(ads.Ad{Title: "title", Text: "text", AuthorID: uid})
+
+	later := s.Date.Add(time.Hour)
+	title, published := "new title", true
+	s.NoError(s.Repo.PatchAd(s.Ctx, id, app.AdPatch{Title: &title, Published: &published}, later))
+
+	ad, err := s.Repo.GetAdByID(s.Ctx, id)
+	s.NoError(err)
+	s.Equal(ads.Ad{ID: id, Title: "new title", Text: "text", AuthorID: uid, Published: true, DateCreated: s.Date, DateChanged: later}, *ad)
+	s.Equal([]int64{id}, s.listIDs(app.ListAdsParams{Published: &published}))
+
+	s.ErrorIs(s.Repo.PatchAd(s.Ctx, id+1000, app.AdPatch{Title: &title}, later), app.ErrAdNotFound)
+}
+
+func (s *RepositorySuite) TestAd_Flags() {
+	uid := s.addUser("mac")
+	id := s.addAd(ads.Ad{Title: "title", Text: "text", AuthorID: uid, Flags: []string{"link: text contains a link"}})
+
+	ad, err := s.Repo.GetAdByID(s.Ctx, id)
+	s.NoError(err)
+	s.Equal([]string{"link: text contains a link"}, ad.Flags)
+
+	s.NoError(s.Repo.SetAdFlags(s.Ctx, id, []string{"spam: spam score 0.60"}))
+	ad, err = s.Repo.GetAdByID(s.Ctx, id)
+	s.NoError(err)
+	s.Equal([]string{"spam: spam score 0.60"}, ad.Flags)
+	s.Equal(s.Date, ad.DateChanged)
+
+	s.NoError(s.Repo.SetAdFlags(s.Ctx, id, nil))
+	al, err := s.Repo.GetAdList(s.Ctx, app.ListAdsParams{Uid: &uid})
+	s.NoError(err)
+	s.Require().Len(al.Data, 1)
+	s.Empty(al.Data[0].Flags)
+}
+
+func (s *RepositorySuite) TestAd_Errors() {
+	uid := s.addUser("mac")
+	id := s.addAd(ads.Ad{AuthorID: uid})
+	missing := id + 1000
+
+	_, err := s.Repo.AddAd(s.Ctx, ads.Ad{AuthorID: uid + 1000})
+	s.ErrorIs(err, app.ErrUserNotFound)
+	_, err = s.Repo.GetAdByID(s.Ctx, missing)
+	s.ErrorIs(err, app.ErrAdNotFound)
+	s.ErrorIs(s.Repo.UpdateAdStatus(s.Ctx, missing, true, s.Date), app.ErrAdNotFound)
+	s.ErrorIs(s.Repo.UpdateAdContent(s.Ctx, missing, "t", "t", s.Date), app.ErrAdNotFound)
+	s.ErrorIs(s.Repo.UpdateAdSchedule(s.Ctx, missing, nil, nil, s.Date), app.ErrAdNotFound)
+	s.ErrorIs(s.Repo.SetAdFlags(s.Ctx, missing, nil), app.ErrAdNotFound)
+	s.ErrorIs(s.Repo.DeleteAdByID(s.Ctx, missing), app.ErrAdNotFound)
+}
+
+func (s *RepositorySuite) TestAd_IDsNotReused() {
+	uid := s.addUser("mac")
+	first := s.addAd(ads.Ad{Title: "first", AuthorID: uid})
+	second := s.addAd(ads.Ad{Title: "second", AuthorID: uid})
+	s.NoError(s.Repo.DeleteAdByID(s.Ctx, first))
+	third := s.addAd(ads.Ad{Title: "third", AuthorID: uid})
+	s.NotEqual(first, third)
+	s.NotEqual(second, third)
+
+	ad, err := s.Repo.GetAdByID(s.Ctx, second)
+	s.NoError(err)
+	s.Equal("second", ad.Title)
+}
+
+func (s *RepositorySuite) TestAdList_Filters() {
+	mac, kdot := s.addUser("mac"), s.addUser("kdot")
+	yesterday := s.Date.AddDate(0, 0, -1)
+	expired := s.Date.Add(-time.Minute)
+	a0 := s.addAd(ads.Ad{Title: "a", AuthorID: mac, Published: true})
+	a1 := s.addAd(ads.Ad{Title: "b", AuthorID: mac, DateCreated: yesterday})
+	a2 := s.addAd(ads.Ad{Title: "a", AuthorID: kdot, Published: true, DateCreated: yesterday})
+	a3 := s.addAd(ads.Ad{Title: "b", AuthorID: kdot, Published: true})
+	s.NoError(s.Repo.UpdateAdSchedule(s.Ctx, a3, nil, &expired, s.Date))
+
+	published, unpublished := true, false
+	title := "a"
+	missing := kdot + 1000
+	expr, err := filter.Parse(fmt.Sprintf(`title ~ "A" OR (author_id = %d AND NOT published = true)`, mac))
+	s.Require().NoError(err)
+	for name, tc := range map[string]struct {
+		params app.ListAdsParams
+		want   []int64
+	}{
+		"all":         {app.ListAdsParams{}, []int64{a0, a1, a2, a3}},
+		"published":   {app.ListAdsParams{Published: &published}, []int64{a0, a2, a3}},
+		"unpublished": {app.ListAdsParams{Published: &unpublished}, []int64{a1}},
+		"author":      {app.ListAdsParams{Uid: &mac}, []int64{a0, a1}},
+		"no author":   {app.ListAdsParams{Uid: &missing}, []int64{}},
+		"date":        {app.ListAdsParams{Date: &yesterday}, []int64{a1, a2}},
+		"title":       {app.ListAdsParams{Title: &title}, []int64{a0, a2}},
+		"active":      {app.ListAdsParams{Published: &published, ActiveAt: &s.Date}, []int64{a0, a2}},
+		"combined":    {app.ListAdsParams{Published: &published, Uid: &kdot, Date: &s.Date}, []int64{a3}},
+		"expression":  {app.ListAdsParams{Filter: expr}, []int64{a0, a1, a2}},
+		"expr+fields": {app.ListAdsParams{Filter: expr, Uid: &kdot}, []int64{a2}},
+	} {
+		s.Run(name, func() {
+			s.ElementsMatch(tc.want, s.listIDs(tc.params))
+		})
+	}
+}
+
+func (s *RepositorySuite) TestAdList_FollowsUpdates() {
+	uid := s.addUser("mac")
+	id := s.addAd(ads.Ad{AuthorID: uid})
+	published := true
+
+	s.Empty(s.listIDs(app.ListAdsParams{Published: &published}))
+	s.NoError(s.Repo.UpdateAdStatus(s.Ctx, id, true, s.Date))
+	s.Equal([]int64{id}, s.listIDs(app.ListAdsParams{Published: &published}))
+	s.NoError(s.Repo.DeleteAdByID(s.Ctx, id))
+	s.Empty(s.listIDs(app.ListAdsParams{Uid: &uid}))
+}
+
+func (s *RepositorySuite) TestAdList_TimeRange() {
+	uid := s.addUser("mac")
+	yesterday := s.Date.AddDate(0, 0, -1)
+	hour := s.Date.Add(time.Hour)
+	a0 := s.addAd(ads.Ad{AuthorID: uid, DateCreated: yesterday})
+	a1 := s.addAd(ads.Ad{AuthorID: uid})
+	a2 := s.addAd(ads.Ad{AuthorID: uid, DateCreated: hour})
+	changed := s.Date.Add(2 * time.Hour)
+	s.NoError(s.Repo.UpdateAdStatus(s.Ctx, a0, true, changed))
+
+	// тот же момент в другом часовом поясе
+	local := s.Date.In(time.FixedZone("UTC+3", 3*60*60))
+	halfHour := s.Date.Add(30 * time.Minute)
+	for name, tc := range map[string]struct {
+		params app.ListAdsParams
+		want   []int64
+	}{
+		"from":          {app.ListAdsParams{CreatedFrom: &s.Date}, []int64{a1, a2}},
+		"to":            {app.ListAdsParams{CreatedTo: &s.Date}, []int64{a0}},
+		"between":       {app.ListAdsParams{CreatedFrom: &s.Date, CreatedTo: &hour}, []int64{a1}},
+		"time zone":     {app.ListAdsParams{CreatedFrom: &local}, []int64{a1, a2}},
+		"changed since": {app.ListAdsParams{ChangedSince: &halfHour}, []int64{a0, a2}},
+		"with date":     {app.ListAdsParams{Date: &s.Date, CreatedFrom: &halfHour}, []int64{a2}},
+	} {
+		s.Run(name, func() {
+			s.ElementsMatch(tc.want, s.listIDs(tc.params))
+		})
+	}
+
+	// изменение переносит объявление на следующий день
+	tomorrow := s.Date.AddDate(0, 0, 1)
+	s.NoError(s.Repo.PatchAd(s.Ctx, a1, app.AdPatch{}, tomorrow))
+	s.Equal([]int64{a1}, s.listIDs(app.ListAdsParams{ChangedSince: &tomorrow}))
+	s.NoError(s.Repo.UpdateAdSchedule(s.Ctx, a2, &tomorrow, nil, changed))
+	_, err := s.Repo.PublishScheduledAds(s.Ctx, tomorrow.Add(time.Hour))
+	s.NoError(err)
+	s.ElementsMatch([]int64{a1, a2}, s.listIDs(app.ListAdsParams{ChangedSince: &tomorrow}))
+}
+
+func (s *RepositorySuite) TestAd_ScheduleAndExpire() {
+	uid := s.addUser("mac")
+	due := s.Date.Add(-time.Minute)
+	future := s.Date.Add(time.Hour)
+	scheduled := s.addAd(ads.Ad{AuthorID: uid, PublishAt: &due})
+	s.addAd(ads.Ad{AuthorID: uid, PublishAt: &future})
+	expiring := s.addAd(ads.Ad{AuthorID: uid, Published: true, ExpiresAt: &due})
+	missed := s.addAd(ads.Ad{AuthorID: uid, PublishAt: &due, ExpiresAt: &due})
+
+	changes, err := s.Repo.PublishScheduledAds(s.Ctx, s.Date)
+	s.NoError(err)
+	s.Len(changes, 2)
+	s.Equal(scheduled, changes[0].After.ID)
+	s.Equal(&due, changes[0].Before.PublishAt)
+	s.False(changes[0].Before.Published)
+	s.True(changes[0].After.Published)
+	s.Nil(changes[0].After.PublishAt)
+	ad, err := s.Repo.GetAdByID(s.Ctx, scheduled)
+	s.NoError(err)
+	s.True(ad.Published)
+	s.Nil(ad.PublishAt)
+
+	// истекшее объявление теряет расписание, но не публикуется
+	s.Equal(missed, changes[1].After.ID)
+	s.Equal(&due, changes[1].Before.PublishAt)
+	s.False(changes[1].After.Published)
+	s.Nil(changes[1].After.PublishAt)
+
+	changes, err = s.Repo.ExpireAds(s.Ctx, s.Date)
+	s.NoError(err)
+	s.Len(changes, 1)
+	s.Equal(expiring, changes[0].After.ID)
+	s.True(changes[0].Before.Published)
+	s.False(changes[0].After.Published)
+	ad, err = s.Repo.GetAdByID(s.Ctx, expiring)
+	s.NoError(err)
+	s.False(ad.Published)
+}
+
+func (s *RepositorySuite) TestAlerts() {
+	mac, kdot := s.addUser("mac"), s.addUser("kdot")
+	ad := s.addAd(ads.Ad{AuthorID: kdot})
+
+	s.NoError(s.Repo.AddFavorite(s.Ctx, mac, ad))
+	fav, err := s.Repo.GetFavorites(s.Ctx, mac)
+	s.NoError(err)
+	s.Len(fav.Data, 1)
+	s.ErrorIs(s.Repo.AddFavorite(s.Ctx, mac, ad+1000), app.ErrAdNotFound)
+	s.ErrorIs(s.Repo.AddFavorite(s.Ctx, kdot+1000, ad), app.ErrUserNotFound)
+	s.NoError(s.Repo.DeleteFavorite(s.Ctx, mac, ad))
+	s.ErrorIs(s.Repo.DeleteFavorite(s.Ctx, mac, ad), app.ErrAdNotFound)
+
+	search, err := s.Repo.AddSavedSearch(s.Ctx, app.SavedSearch{UserID: mac})
+	s.NoError(err)
+	got, err := s.Repo.GetSavedSearchByID(s.Ctx, search)
+	s.NoError(err)
+	s.Equal(mac, got.UserID)
+	_, err = s.Repo.AddNotification(s.Ctx, app.Notification{UserID: mac, AdID: ad, SearchID: search})
+	s.NoError(err)
+	nl, err := s.Repo.GetNotifications(s.Ctx, mac)
+	s.NoError(err)
+	s.Len(nl, 1)
+
+	s.NoError(s.Repo.DeleteSavedSearchByID(s.Ctx, search))
+	_, err = s.Repo.GetSavedSearchByID(s.Ctx, search)
+	s.ErrorIs(err, app.ErrSearchNotFound)
+	s.ErrorIs(s.Repo.DeleteSavedSearchByID(s.Ctx, search), app.ErrSearchNotFound)
+}
+
+func (s *RepositorySuite) TestMessaging() {
+	buyer, seller := s.addUser("buyer"), s.addUser("seller")
+	ad := s.addAd(ads.Ad{AuthorID: seller})
+
+	conv, err := s.Repo.AddConversation(s.Ctx, messages.Conversation{AdID: ad, BuyerID: buyer, SellerID: seller})
+	s.NoError(err)
+	found, err := s.Repo.FindConversation(s.Ctx, ad, buyer)
+	s.NoError(err)
+	s.Equal(conv, found.ID)
+
+	for i := 0; i < 3; i++ {
+		_, err = s.Repo.AddMessage(s.Ctx, messages.Message{ConversationID: conv, SenderID: buyer, RecipientID: seller, Text: fmt.Sprint(i), DateSent: s.Date})
+		s.NoError(err)
+	}
+	ml, err := s.Repo.GetMessages(s.Ctx, conv, 1, 10)
+	s.NoError(err)
+	s.Len(ml, 2)
+	unread, err := s.Repo.CountUnreadMessages(s.Ctx, conv, seller)
+	s.NoError(err)
+	s.Equal(3, unread)
+	s.NoError(s.Repo.MarkMessagesRead(s.Ctx, conv, seller, ml[1].ID))
+	unread, err = s.Repo.CountUnreadMessages(s.Ctx, conv, seller)
+	s.NoError(err)
+	s.Equal(0, unread)
+
+	s.NoError(s.Repo.AddBlock(s.Ctx, seller, buyer))
+	blocked, err := s.Repo.IsBlocked(s.Ctx, seller, buyer)
+	s.NoError(err)
+	s.True(blocked)
+	s.NoError(s.Repo.DeleteBlock(s.Ctx, seller, buyer))
+	blocked, err = s.Repo.IsBlocked(s.Ctx, seller, buyer)
+	s.NoError(err)
+	s.False(blocked)
+
+	_, err = s.Repo.GetConversationByID(s.Ctx, conv+1000)
+	s.ErrorIs(err, app.ErrConversationNotFound)
+	_, err = s.Repo.AddMessage(s.Ctx, messages.Message{ConversationID: conv + 1000})
+	s.ErrorIs(err, app.ErrConversationNotFound)
+	_, err = s.Repo.AddConversation(s.Ctx, messages.Conversation{BuyerID: seller + 1000, SellerID: seller})
+	s.ErrorIs(err, app.ErrUserNotFound)
+}
+
+func (s *RepositorySuite) TestDeleteUser_Cascade() {
+	mac, kdot := s.addUser("mac"), s.addUser("kdot")
+	macAd := s.addAd(ads.Ad{AuthorID: mac})
+	kdotAd := s.addAd(ads.Ad{AuthorID: kdot})
+	s.NoError(s.Repo.AddFavorite(s.Ctx, kdot, macAd))
+	s.NoError(s.Repo.AddFavorite(s.Ctx, kdot, kdotAd))
+	s.NoError(s.Repo.AddVerificationToken(s.Ctx, app.VerificationToken{Token: "token", UserID: mac}))
+	search, err := s.Repo.AddSavedSearch(s.Ctx, app.SavedSearch{UserID: mac})
+	s.NoError(err)
+	conv, err := s.Repo.AddConversation(s.Ctx, messages.Conversation{AdID: kdotAd, BuyerID: mac, SellerID: kdot})
+	s.NoError(err)
+	s.NoError(s.Repo.AddBlock(s.Ctx, kdot, mac))
+
+	s.NoError(s.Repo.DeleteUserByID(s.Ctx, mac))
+
+	_, err = s.Repo.GetAdByID(s.Ctx, macAd)
+	s.ErrorIs(err, app.ErrAdNotFound)
+	s.Empty(s.listIDs(app.ListAdsParams{Uid: &mac}))
+	s.Equal([]int64{kdotAd}, s.listIDs(app.ListAdsParams{}))
+	fav, err := s.Repo.GetFavorites(s.Ctx, kdot)
+	s.NoError(err)
+	s.Len(fav.Data, 1)
+	_, err = s.Repo.GetVerificationToken(s.Ctx, "token")
+	s.ErrorIs(err, app.ErrTokenNotFound)
+	_, err = s.Repo.GetSavedSearchByID(s.Ctx, search)
+	s.ErrorIs(err, app.ErrSearchNotFound)
+	_, err = s.Repo.GetConversationByID(s.Ctx, conv)
+	s.ErrorIs(err, app.ErrConversationNotFound)
+	blocked, err := s.Repo.IsBlocked(s.Ctx, kdot, mac)
+	s.NoError(err)
+	s.False(blocked)
+	_, err = s.Repo.AddAd(s.Ctx, ads.Ad{AuthorID: mac})
+	s.ErrorIs(err, app.ErrUserNotFound)
+}
+
+func (s *RepositorySuite) TestConcurrentAccess() {
+	const workers, perWorker = 8, 50
+	uids := make([]int64, workers)
+	for i := range uids {
+		uids[i] = s.addUser(fmt.Sprintf("user%d", i))
+	}
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		ids = make(map[int64]struct{})
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(2)
+		go func(uid int64) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id, err := s.Repo.AddAd(s.Ctx, ads.Ad{AuthorID: uid, DateCreated: s.Date})
+				s.NoError(err)
+				s.NoError(s.Repo.UpdateAdStatus(s.Ctx, id, i%2 == 0, s.Date))
+				mu.Lock()
+				ids[id] = struct{}{}
+				mu.Unlock()
+			}
+		}(uids[w])
+		go func(uid int64) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				al, err := s.Repo.GetAdList(s.Ctx, app.ListAdsParams{Uid: &uid})
+				s.NoError(err)
+				for _, ad := range al.Data {
+					s.Equal(uid, ad.AuthorID)
+				}
+				_, err = s.Repo.GetUserByID(s.Ctx, uid)
+				s.NoError(err)
+			}
+		}(uids[w])
+	}
+	wg.Wait()
+
+	published := true
+	s.Len(ids, workers*perWorker)
+	s.Len(s.listIDs(app.ListAdsParams{}), workers*perWorker)
+	s.Len(s.listIDs(app.ListAdsParams{Published: &published}), workers*perWorker/2)
+}
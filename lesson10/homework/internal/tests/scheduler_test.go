@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/scheduler"
+	"homework10/internal/user"
+	"log"
+	"testing"
+	"time"
+)
+
+type SchedulerSuite struct {
+	suite.Suite
+	Repo      *adrepo.RepositoryMap
+	App       app.App
+	Scheduler *scheduler.Scheduler
+	Ctx       context.Context
+	UserID    int64
+}
+
+func (suite *SchedulerSuite) SetupTest() {
+	log.Println("Setting Up Test")
+	suite.Ctx = context.Background()
+	suite.Repo = adrepo.NewRepositoryMap()
+	suite.App = app.NewApp(suite.Repo)
+	suite.Scheduler = scheduler.New(suite.Repo, time.Millisecond)
+
+	uid, err := suite.Repo.AddUser(suite.Ctx, user.User{Nickname: "Mac Miller", Email: "swimmig@circles.com"})
+	suite.NoError(err)
+	suite.UserID = uid
+}
+
+func (suite *SchedulerSuite) TestScheduler_Publish() {
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.NoError(err)
+
+	publishAt := time.Now().UTC().Add(time.Hour)
+	_, err = suite.App.ScheduleAd(suite.Ctx, ad.ID, suite.UserID, &publishAt, 0)
+	suite.NoError(err)
+
+	suite.NoError(suite.Scheduler.Tick(suite.Ctx, publishAt.Add(-time.Minute)))
+	res, err := suite.App.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.False(res.Published)
+	suite.NotNil(res.PublishAt)
+
+	suite.NoError(suite.Scheduler.Tick(suite.Ctx, publishAt))
+	res, err = suite.App.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.True(res.Published)
+	suite.Nil(res.PublishAt)
+	suite.Equal(publishAt, res.DateChanged)
+}
+
+func (suite *SchedulerSuite) TestScheduler_Expire() {
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.NoError(err)
+	_, err = suite.App.ChangeAdStatus(suite.Ctx, ad.ID, suite.UserID, true)
+	suite.NoError(err)
+
+	ad, err = suite.App.ScheduleAd(suite.Ctx, ad.ID, suite.UserID, nil, 2)
+	suite.NoError(err)
+	suite.NotNil(ad.ExpiresAt)
+
+	suite.NoError(suite.Scheduler.Tick(suite.Ctx, ad.ExpiresAt.Add(-time.Second)))
+	res, err := suite.App.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.True(res.Published)
+
+	suite.NoError(suite.Scheduler.Tick(suite.Ctx, *ad.ExpiresAt))
+	res, err = suite.App.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.False(res.Published)
+}
+
+func (suite *SchedulerSuite) TestScheduler_PublishAndExpire() {
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.NoError(err)
+
+	publishAt := time.Now().UTC().Add(time.Hour)
+	ad, err = suite.App.ScheduleAd(suite.Ctx, ad.ID, suite.UserID, &publishAt, 1)
+	suite.NoError(err)
+	suite.Equal(publishAt.AddDate(0, 0, 1), *ad.ExpiresAt)
+
+	// сервис был выключен все это время: объявление не должно появиться
+	suite.NoError(suite.Scheduler.Tick(suite.Ctx, ad.ExpiresAt.Add(time.Hour)))
+	res, err := suite.App.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.False(res.Published)
+	suite.Nil(res.PublishAt)
+}
+
+func (suite *SchedulerSuite) TestScheduler_SurvivesRestart() {
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.NoError(err)
+
+	publishAt := time.Now().UTC().Add(-time.Minute)
+	_, err = suite.App.ScheduleAd(suite.Ctx, ad.ID, suite.UserID, &publishAt, 0)
+	suite.NoError(err)
+
+	ctx, cancel := context.WithCancel(suite.Ctx)
+	done := make(chan error)
+	go func() {
+		done <- scheduler.RunSchedulerGracefully(ctx, scheduler.New(suite.Repo, time.Hour))()
+	}()
+
+	suite.Eventually(func() bool {
+		res, err := suite.App.GetAd(suite.Ctx, ad.ID)
+		return err == nil && res.Published
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	suite.ErrorIs(<-done, context.Canceled)
+}
+
+func (suite *SchedulerSuite) TestListAds_ExcludesExpired() {
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.NoError(err)
+	_, err = suite.App.ChangeAdStatus(suite.Ctx, ad.ID, suite.UserID, true)
+	suite.NoError(err)
+	ad, err = suite.App.ScheduleAd(suite.Ctx, ad.ID, suite.UserID, nil, 1)
+	suite.NoError(err)
+
+	al, err := suite.App.ListAds(suite.Ctx, app.ListAdsParams{})
+	suite.NoError(err)
+	suite.Len(al.Data, 1)
+
+	after := ad.ExpiresAt.Add(time.Second)
+	al, err = suite.App.ListAds(suite.Ctx, app.ListAdsParams{ActiveAt: &after})
+	suite.NoError(err)
+	suite.Len(al.Data, 0)
+}
+
+func (suite *SchedulerSuite) TestScheduler_ManualStatusCancelsSchedule() {
+	ad, err := suite.App.CreateAd(suite.Ctx, "Circles", "Good News", suite.UserID)
+	suite.NoError(err)
+
+	publishAt := time.Now().UTC().Add(time.Hour)
+	_, err = suite.App.ScheduleAd(suite.Ctx, ad.ID, suite.UserID, &publishAt, 0)
+	suite.NoError(err)
+	_, err = suite.App.ChangeAdStatus(suite.Ctx, ad.ID, suite.UserID, false)
+	suite.NoError(err)
+
+	suite.NoError(suite.Scheduler.Tick(suite.Ctx, publishAt.Add(time.Minute)))
+	res, err := suite.App.GetAd(suite.Ctx, ad.ID)
+	suite.NoError(err)
+	suite.False(res.Published)
+}
+
+func TestSchedulerSuite(t *testing.T) {
+	suite.Run(t, new(SchedulerSuite))
+}
+
+func (suite *HTTPSuite) TestScheduleAd() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	ad, err := suite.Client.createAd(u.Data.ID, "Circles", "Good News")
+	suite.NoError(err)
+
+	publishAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	resp, err := suite.Client.scheduleAd(u.Data.ID, ad.Data.ID, publishAt.Format(time.RFC3339), 30)
+	suite.NoError(err)
+	suite.False(resp.Data.Published)
+	suite.Equal(app.FormatDate(publishAt), *resp.Data.PublishAt)
+	suite.Equal(app.FormatDate(publishAt.AddDate(0, 0, 30)), *resp.Data.ExpiresAt)
+
+	resp, err = suite.Client.scheduleAd(u.Data.ID, ad.Data.ID, nil, 0)
+	suite.NoError(err)
+	suite.Nil(resp.Data.PublishAt)
+	suite.Nil(resp.Data.ExpiresAt)
+}
+
+func (suite *HTTPSuite) TestScheduleAd_Errors() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	ad, err := suite.Client.createAd(u.Data.ID, "Circles", "Good News")
+	suite.NoError(err)
+
+	_, err = suite.Client.scheduleAd(u.Data.ID, ad.Data.ID, "tomorrow", 1)
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.scheduleAd(u.Data.ID, ad.Data.ID, nil, -1)
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.scheduleAd(u.Data.ID, "abc", nil, 1)
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.scheduleAd("abc", ad.Data.ID, nil, 1)
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.scheduleAd(u.Data.ID+1, ad.Data.ID, nil, 1)
+	suite.ErrorIs(err, ErrForbidden)
+
+	_, err = suite.Client.scheduleAd(u.Data.ID, ad.Data.ID+1, nil, 1)
+	suite.ErrorIs(err, ErrNotFound)
+}
+
+func (suite *GRPCSuite) TestGRPCScheduleAd() {
+	u, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "ivanov@yandex.ru"})
+	suite.NoError(err)
+	ad, err := suite.Client.CreateAd(suite.Context, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: &u.Id})
+	suite.NoError(err)
+
+	publishAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	publishAtStr := publishAt.Format(time.RFC3339)
+	res, err := suite.Client.ScheduleAd(suite.Context,
+		&grpcPort.ScheduleAdRequest{AdId: &ad.Id, UserId: &u.Id, PublishAt: &publishAtStr, ExpiresIn: 1})
+	suite.NoError(err)
+	suite.Equal(app.FormatDate(publishAt), res.GetPublishAt())
+	suite.Equal(app.FormatDate(publishAt.AddDate(0, 0, 1)), res.GetExpiresAt())
+
+	_, err = suite.Client.ScheduleAd(suite.Context, &grpcPort.ScheduleAdRequest{AdId: &ad.Id})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+
+	bad := "abc"
+	_, err = suite.Client.ScheduleAd(suite.Context,
+		&grpcPort.ScheduleAdRequest{AdId: &ad.Id, UserId: &u.Id, PublishAt: &bad})
+	suite.Error(err)
+
+	_, err = suite.Client.ScheduleAd(suite.Context,
+		&grpcPort.ScheduleAdRequest{AdId: &ad.Id, UserId: &u.Id, ExpiresIn: -1})
+	suite.Equal("rpc error: code = InvalidArgument desc = invalid ad schedule", err.Error())
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"fmt"
 	"github.com/stretchr/testify/suite"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
@@ -12,6 +13,7 @@ import (
 	"homework10/internal/graceful"
 	grpcSvc "homework10/internal/ports/grpc"
 	"homework10/internal/ports/httpgin"
+	"homework10/internal/scheduler"
 	"homework10/internal/tests/mocks"
 	"log"
 	"net"
@@ -29,6 +31,7 @@ type ServerSuite struct {
 	ClientGRPC grpcSvc.AdServiceClient
 	Lis        *bufconn.Listener
 	SigQuit    chan os.Signal
+	Done       chan struct{}
 
 	CtxClient    context.Context
 	CancelClient context.CancelFunc
@@ -52,13 +55,20 @@ func (suite *ServerSuite) DialGRPC() {
 
 func (suite *ServerSuite) SetupTest() {
 	log.Println("Setting Up Test")
-	appSvc := app.NewApp(adrepo.New())
+	repo := adrepo.New()
+	appSvc := app.NewApp(repo)
 	suite.Lis = bufconn.Listen(1024 * 1024)
 	svc := grpcSvc.NewService(appSvc)
-	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
-		grpcSvc.UnaryLoggerInterceptor,
-		grpcSvc.UnaryRecoveryInterceptor(),
-	))
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			grpcSvc.UnaryLoggerInterceptor,
+			grpcSvc.UnaryRecoveryInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			grpcSvc.StreamLoggerInterceptor,
+			grpcSvc.StreamRecoveryInterceptor(),
+		),
+	)
 	grpcSvc.RegisterAdServiceServer(grpcServer, svc)
 
 	httpServer := httpgin.NewHTTPServer(":18080", appSvc)
@@ -69,7 +79,10 @@ func (suite *ServerSuite) SetupTest() {
 	eg.Go(graceful.CaptureSignal(ctx, suite.SigQuit))
 	eg.Go(grpcSvc.RunGRPCServerGracefully(ctx, suite.Lis, grpcServer))
 	eg.Go(httpgin.RunHTTPServerGracefully(ctx, httpServer))
+	eg.Go(scheduler.RunSchedulerGracefully(ctx, scheduler.New(repo, scheduler.DefaultInterval)))
+	suite.Done = make(chan struct{})
 	go func() {
+		defer close(suite.Done)
 		if err := eg.Wait(); err != nil {
 			log.Printf("gracefully shutting down the servers: %s\n", err.Error())
 		}
@@ -87,8 +100,8 @@ func (suite *ServerSuite) SetupTest() {
 
 func (suite *ServerSuite) TestUser() {
 	for id := 0; id < 100; id++ {
-		name := "Mac Miller"
-		email := "swimming@circles.com"
+		name := fmt.Sprintf("Mac Miller %d", id)
+		email := fmt.Sprintf("swimming%d@circles.com", id)
 		if id%2 == 0 {
 			resp, err := suite.ClientHTTP.createUser(name, email)
 
@@ -109,8 +122,8 @@ func (suite *ServerSuite) TestUser() {
 
 func (suite *ServerSuite) TestAds() {
 	for id := 0; id < 100; id++ {
-		name := "Mac Miller"
-		email := "swimming@circles.com"
+		name := fmt.Sprintf("Mac Miller %d", id)
+		email := fmt.Sprintf("swimming%d@circles.com", id)
 
 		title := "Spins"
 		text := "K.I.D.S."
@@ -145,6 +158,8 @@ func (suite *ServerSuite) TearDownTest() {
 	}
 	suite.CancelClient()
 	suite.SigQuit <- syscall.SIGINT
+	// следующий тест поднимает сервер на том же порту
+	<-suite.Done
 }
 
 func TestServerSuite(t *testing.T) {
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/user"
+	"log"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ShardedRepoSuite прогоняет базовые тесты репозитория на шардированной реализации
+type ShardedRepoSuite struct {
+	RepoSuite
+}
+
+func (suite *ShardedRepoSuite) SetupTest() {
+	log.Println("Setting Up Test")
+	suite.Ctx = context.Background()
+	suite.Repo = adrepo.NewShardedRepository(4)
+}
+
+func TestShardedRepo(t *testing.T) {
+	suite.Run(t, new(ShardedRepoSuite))
+}
+
+type ShardedIndexSuite struct {
+	suite.Suite
+	Repo *adrepo.ShardedRepository
+	Ctx  context.Context
+	Date time.Time
+}
+
+func (suite *ShardedIndexSuite) SetupTest() {
+	suite.Ctx = context.Background()
+	suite.Repo = adrepo.NewShardedRepository(adrepo.DefaultShards)
+	suite.Date = time.Date(2023, time.May, 20, 12, 0, 0, 0, time.UTC)
+}
+
+func (suite *ShardedIndexSuite) addUser(i int) int64 {
+	id, err := suite.Repo.AddUser(suite.Ctx, user.User{Nickname: fmt.Sprintf("user %d", i), Email: fmt.Sprintf("user%d@mail.com", i)})
+	suite.NoError(err)
+	return id
+}
+
+func (suite *ShardedIndexSuite) addAd(uid int64, published bool, created time.Time) int64 {
+	id, err := suite.Repo.AddAd(suite.Ctx, ads.Ad{Title: "title", Text: "text", AuthorID: uid, Published: published, DateCreated: created})
+	suite.NoError(err)
+	return id
+}
+
+func (suite *ShardedIndexSuite) list(params app.ListAdsParams) []int64 {
+	al, err := suite.Repo.GetAdList(suite.Ctx, params)
+	suite.NoError(err)
+	ids := make([]int64, 0, len(al.Data))
+	for _, ad := range al.Data {
+		ids = append(ids, ad.ID)
+	}
+	return ids
+}
+
+func (suite *ShardedIndexSuite) TestGetAdList_Indexes() {
+	u0, u1 := suite.addUser(0), suite.addUser(1)
+	yesterday := suite.Date.AddDate(0, 0, -1)
+	a0 := suite.addAd(u0, true, suite.Date)
+	a1 := suite.addAd(u0, false, yesterday)
+	a2 := suite.addAd(u1, true, yesterday)
+	a3 := suite.addAd(u1, false, suite.Date)
+
+	published, unpublished := true, false
+	other := int64(100)
+	suite.Equal([]int64{a0, a1, a2, a3}, suite.list(app.ListAdsParams{}))
+	suite.Equal([]int64{a0, a1}, suite.list(app.ListAdsParams{Uid: &u0}))
+	suite.Equal([]int64{a0, a2}, suite.list(app.ListAdsParams{Published: &published}))
+	suite.Equal([]int64{a1, a2}, suite.list(app.ListAdsParams{Date: &yesterday}))
+	suite.Equal([]int64{a3}, suite.list(app.ListAdsParams{Uid: &u1, Published: &unpublished, Date: &suite.Date}))
+	suite.Empty(suite.list(app.ListAdsParams{Uid: &other}))
+}
+
+func (suite *ShardedIndexSuite) TestGetAdList_IndexFollowsUpdates() {
+	uid := suite.addUser(0)
+	id := suite.addAd(uid, false, suite.Date)
+	published, unpublished := true, false
+
+	suite.NoError(suite.Repo.UpdateAdStatus(suite.Ctx, id, true, suite.Date))
+	suite.Equal([]int64{id}, suite.list(app.ListAdsParams{Published: &published}))
+	suite.Empty(suite.list(app.ListAdsParams{Published: &unpublished}))
+
+	changes, err := suite.Repo.ExpireAds(suite.Ctx, suite.Date)
+	suite.NoError(err)
+	suite.Empty(changes)
+	expires := suite.Date.Add(-time.Hour)
+	suite.NoError(suite.Repo.UpdateAdSchedule(suite.Ctx, id, nil, &expires, suite.Date))
+	changes, err = suite.Repo.ExpireAds(suite.Ctx, suite.Date)
+	suite.NoError(err)
+	suite.Len(changes, 1)
+	suite.Empty(suite.list(app.ListAdsParams{Published: &published}))
+	suite.Equal([]int64{id}, suite.list(app.ListAdsParams{Published: &unpublished}))
+
+	publishAt := suite.Date.Add(-time.Minute)
+	suite.NoError(suite.Repo.UpdateAdSchedule(suite.Ctx, id, &publishAt, nil, suite.Date))
+	changes, err = suite.Repo.PublishScheduledAds(suite.Ctx, suite.Date)
+	suite.NoError(err)
+	suite.Len(changes, 1)
+	suite.Equal([]int64{id}, suite.list(app.ListAdsParams{Published: &published}))
+
+	suite.NoError(suite.Repo.DeleteAdByID(suite.Ctx, id))
+	suite.Empty(suite.list(app.ListAdsParams{Published: &published}))
+	suite.Empty(suite.list(app.ListAdsParams{Uid: &uid}))
+}
+
+func (suite *ShardedIndexSuite) TestIDsAreNotReusedAfterDelete() {
+	uid := suite.addUser(0)
+	a0 := suite.addAd(uid, false, suite.Date)
+	a1 := suite.addAd(uid, false, suite.Date)
+	suite.NoError(suite.Repo.DeleteAdByID(suite.Ctx, a0))
+	a2 := suite.addAd(uid, false, suite.Date)
+	suite.NotEqual(a1, a2)
+	ad, err := suite.Repo.GetAdByID(suite.Ctx, a1)
+	suite.NoError(err)
+	suite.Equal(a1, ad.ID)
+}
+
+func (suite *ShardedIndexSuite) TestDeleteUser_Cascade() {
+	u0, u1 := suite.addUser(0), suite.addUser(1)
+	a0 := suite.addAd(u0, true, suite.Date)
+	a1 := suite.addAd(u1, true, suite.Date)
+	suite.NoError(suite.Repo.AddFavorite(suite.Ctx, u1, a0))
+	suite.NoError(suite.Repo.AddFavorite(suite.Ctx, u1, a1))
+
+	suite.NoError(suite.Repo.DeleteUserByID(suite.Ctx, u0))
+	_, err := suite.Repo.GetAdByID(suite.Ctx, a0)
+	suite.ErrorIs(err, app.ErrAdNotFound)
+	suite.Empty(suite.list(app.ListAdsParams{Uid: &u0}))
+	suite.Equal([]int64{a1}, suite.list(app.ListAdsParams{}))
+
+	fav, err := suite.Repo.GetFavorites(suite.Ctx, u1)
+	suite.NoError(err)
+	suite.Len(fav.Data, 1)
+	suite.Equal(a1, fav.Data[0].ID)
+
+	_, err = suite.Repo.AddAd(suite.Ctx, ads.Ad{AuthorID: u0})
+	suite.ErrorIs(err, app.ErrUserNotFound)
+	suite.ErrorIs(suite.Repo.AddFavorite(suite.Ctx, u1, a0), app.ErrAdNotFound)
+}
+
+func (suite *ShardedIndexSuite) TestConcurrentAccess() {
+	const writers, perWriter = 8, 50
+	uids := make([]int64, writers)
+	for i := range uids {
+		uids[i] = suite.addUser(i)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(2)
+		go func(uid int64) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				id, err := suite.Repo.AddAd(suite.Ctx, ads.Ad{AuthorID: uid, DateCreated: suite.Date})
+				suite.NoError(err)
+				suite.NoError(suite.Repo.UpdateAdStatus(suite.Ctx, id, i%2 == 0, suite.Date))
+			}
+		}(uids[w])
+		go func(uid int64) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				al, err := suite.Repo.GetAdList(suite.Ctx, app.ListAdsParams{Uid: &uid})
+				suite.NoError(err)
+				for _, ad := range al.Data {
+					suite.Equal(uid, ad.AuthorID)
+				}
+			}
+		}(uids[w])
+	}
+	wg.Wait()
+
+	published := true
+	suite.Len(suite.list(app.ListAdsParams{}), writers*perWriter)
+	suite.Len(suite.list(app.ListAdsParams{Published: &published}), writers*perWriter/2)
+	for _, uid := range uids {
+		uid := uid
+		suite.Len(suite.list(app.ListAdsParams{Uid: &uid}), perWriter)
+	}
+}
+
+func TestShardedIndex(t *testing.T) {
+	suite.Run(t, new(ShardedIndexSuite))
+}
@@ -0,0 +1,308 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/scheduler"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+	"homework10/pkg/client"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const tenantsConfig = `
+tenants:
+  cars:
+    hosts: [cars.example.com]
+    limits:
+      max_title_length: 10
+      max_nickname_length: 8
+  flats:
+    hosts: [Flats.Example.com]
+    rate_limit:
+      rps: 0.001
+      burst: 2
+`
+
+type TenantSuite struct {
+	suite.Suite
+	Registry *tenant.Registry
+	Repo     *adrepo.TenantRepository
+	App      app.App
+	URL      string
+	Client   *http.Client
+}
+
+func (suite *TenantSuite) SetupTest() {
+	reg, err := tenant.Parse(strings.NewReader(tenantsConfig))
+	suite.Require().NoError(err)
+	suite.Registry = reg
+	suite.Repo = adrepo.NewTenantRepository(func(tenant.ID) app.Repository { return adrepo.NewRepositoryMap() })
+	suite.App = app.NewApp(suite.Repo)
+
+	server := httptest.NewServer(httpgin.NewHTTPServerWithTenants(":18080", suite.App, reg).Handler)
+	suite.T().Cleanup(server.Close)
+	suite.URL = server.URL
+	suite.Client = server.Client()
+}
+
+func (suite *TenantSuite) ctx(id tenant.ID) context.Context {
+	t, err := suite.Registry.Lookup(id)
+	suite.Require().NoError(err)
+	return tenant.NewContext(context.Background(), t)
+}
+
+// request выполняет запрос к первой версии API от имени арендатора (заголовком) или хоста
+func (suite *TenantSuite) request(method string, path string, tenantID string, host string, body any) (int, map[string]any) {
+	var req *http.Request
+	var err error
+	if body != nil {
+		req, err = http.NewRequest(method, suite.URL+"/api/v1"+path, jsonBody(body))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req, err = http.NewRequest(method, suite.URL+"/api/v1"+path, nil)
+	}
+	suite.Require().NoError(err)
+	if tenantID != "" {
+		req.Header.Set(httpgin.TenantHeader, tenantID)
+	}
+	if host != "" {
+		req.Host = host
+	}
+	resp, err := suite.Client.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	var out map[string]any
+	suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&out))
+	return resp.StatusCode, out
+}
+
+func (suite *TenantSuite) TestRegistry() {
+	t, err := suite.Registry.Resolve("", "cars.example.com:18080")
+	suite.NoError(err)
+	suite.Equal(tenant.ID("cars"), t.ID)
+	suite.Equal(10, t.Config.Limits.MaxTitleLength)
+
+	t, err = suite.Registry.Resolve("", "flats.example.com")
+	suite.NoError(err)
+	suite.Equal(tenant.ID("flats"), t.ID)
+
+	// заголовок важнее хоста, незнакомый хост относится к арендатору по умолчанию
+	t, err = suite.Registry.Resolve("flats", "cars.example.com")
+	suite.NoError(err)
+	suite.Equal(tenant.ID("flats"), t.ID)
+	t, err = suite.Registry.Resolve("", "localhost")
+	suite.NoError(err)
+	suite.Equal(tenant.Default, t.ID)
+
+	_, err = suite.Registry.Resolve("boats", "")
+	suite.ErrorIs(err, tenant.ErrUnknownTenant)
+	_, err = suite.Registry.Resolve("Bad Tenant!", "")
+	suite.ErrorIs(err, tenant.ErrInvalidID)
+
+	suite.Equal([]tenant.ID{"cars", tenant.Default, "flats"}, suite.Registry.IDs())
+	suite.Equal(tenant.Default, tenant.IDFromContext(context.Background()))
+
+	_, err = tenant.NewRegistry(map[tenant.ID]tenant.Config{
+		"a": {Hosts: []string{"same.com"}},
+		"b": {Hosts: []string{"SAME.com:80"}},
+	})
+	suite.Error(err)
+	_, err = tenant.Parse(strings.NewReader("tenants: [broken"))
+	suite.Error(err)
+}
+
+func (suite *TenantSuite) TestRateLimit() {
+	flats, err := suite.Registry.Lookup("flats")
+	suite.Require().NoError(err)
+	suite.NoError(suite.Registry.Allow(flats))
+	suite.NoError(suite.Registry.Allow(flats))
+	suite.ErrorIs(suite.Registry.Allow(flats), tenant.ErrRateLimited)
+
+	// лимит одного арендатора не влияет на других
+	cars, err := suite.Registry.Lookup("cars")
+	suite.Require().NoError(err)
+	for i := 0; i < 10; i++ {
+		suite.NoError(suite.Registry.Allow(cars))
+	}
+}
+
+func (suite *TenantSuite) TestRepositoryIsolation() {
+	cars, flats := suite.ctx("cars"), suite.ctx("flats")
+	id, err := suite.Repo.AddUser(cars, user.User{Nickname: "mac", Email: "mac@mail.com"})
+	suite.NoError(err)
+
+	_, err = suite.Repo.GetUserByID(flats, id)
+	suite.ErrorIs(err, app.ErrUserNotFound)
+	// уникальность почты и никнейма проверяется внутри арендатора
+	_, err = suite.Repo.AddUser(flats, user.User{Nickname: "mac", Email: "mac@mail.com"})
+	suite.NoError(err)
+	suite.Equal([]tenant.ID{"cars", "flats"}, suite.Repo.Tenants())
+}
+
+func (suite *TenantSuite) TestHTTP() {
+	code, u := suite.request(http.MethodPost, "/users", "cars", "", map[string]any{"nickname": "mac", "email": "mac@mail.com"})
+	suite.Require().Equal(http.StatusOK, code)
+	uid := u["data"].(map[string]any)["id"]
+	path := fmt.Sprintf("/users/%v", uid)
+
+	code, _ = suite.request(http.MethodGet, path, "", "cars.example.com", nil)
+	suite.Equal(http.StatusOK, code)
+	code, _ = suite.request(http.MethodGet, path, "", "", nil)
+	suite.Equal(http.StatusNotFound, code)
+
+	// ограничения арендатора дополняют общие правила валидации
+	code, p := suite.request(http.MethodPost, "/ads", "cars", "", map[string]any{"user_id": uid, "title": "Long enough title", "text": "text"})
+	suite.Equal(http.StatusBadRequest, code)
+	suite.Equal(string(apperr.CodeValidationFailed), p["code"])
+	suite.Equal("title", p["errors"].([]any)[0].(map[string]any)["field"])
+	code, _ = suite.request(http.MethodPost, "/ads", "cars", "", map[string]any{"user_id": uid, "title": "Short", "text": "text"})
+	suite.Equal(http.StatusOK, code)
+	code, _ = suite.request(http.MethodPatch, path, "cars", "", map[string]any{"nickname": "Malcolm McCormick"})
+	suite.Equal(http.StatusBadRequest, code)
+
+	code, p = suite.request(http.MethodGet, "/ads", "boats", "", nil)
+	suite.Equal(http.StatusNotFound, code)
+	suite.Equal(string(apperr.CodeTenantNotFound), p["code"])
+	code, p = suite.request(http.MethodGet, "/ads", "Bad Tenant", "", nil)
+	suite.Equal(http.StatusBadRequest, code)
+	suite.Equal(string(apperr.CodeInvalidTenant), p["code"])
+
+	for i := 0; i < 2; i++ {
+		code, _ = suite.request(http.MethodGet, "/ads", "", "flats.example.com", nil)
+		suite.Equal(http.StatusOK, code)
+	}
+	code, p = suite.request(http.MethodGet, "/ads", "flats", "", nil)
+	suite.Equal(http.StatusTooManyRequests, code)
+	suite.Equal(string(apperr.CodeRateLimited), p["code"])
+}
+
+func (suite *TenantSuite) TestGRPC() {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcPort.UnaryTenantInterceptor(suite.Registry)),
+		grpc.ChainStreamInterceptor(grpcPort.StreamTenantInterceptor(suite.Registry)),
+	)
+	grpcPort.RegisterAdServiceServer(server, grpcPort.NewService(suite.App))
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	suite.Require().NoError(err)
+	defer conn.Close()
+	client := grpcPort.NewAdServiceClient(conn)
+
+	cars := metadata.AppendToOutgoingContext(context.Background(), grpcPort.TenantMetadataKey, "cars")
+	u, err := client.CreateUser(cars, &grpcPort.CreateUserRequest{Name: "mac", Email: "mac@mail.com"})
+	suite.Require().NoError(err)
+
+	_, err = client.GetUser(cars, &grpcPort.GetUserRequest{Id: &u.Id})
+	suite.NoError(err)
+	_, err = client.GetUser(context.Background(), &grpcPort.GetUserRequest{Id: &u.Id})
+	suite.Equal(codes.NotFound, status.Code(err))
+
+	_, err = client.CreateAd(cars, &grpcPort.CreateAdRequest{UserId: &u.Id, Title: "Long enough title", Text: "text"})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+
+	boats := metadata.AppendToOutgoingContext(context.Background(), grpcPort.TenantMetadataKey, "boats")
+	_, err = client.GetUser(boats, &grpcPort.GetUserRequest{Id: &u.Id})
+	suite.Equal(codes.NotFound, status.Code(err))
+
+	flats := metadata.AppendToOutgoingContext(context.Background(), grpcPort.TenantMetadataKey, "flats")
+	for i := 0; i < 2; i++ {
+		_, err = client.ListAds(flats, &grpcPort.ListAdRequest{})
+		suite.NoError(err)
+	}
+	_, err = client.ListAds(flats, &grpcPort.ListAdRequest{})
+	suite.Equal(codes.ResourceExhausted, status.Code(err))
+}
+
+func (suite *TenantSuite) TestMessagesIsolation() {
+	cars, flats := suite.ctx("cars"), suite.ctx("flats")
+	// пользователи разных арендаторов получают одинаковые ID
+	seller, err := suite.App.CreateUser(cars, "seller", "seller@mail.com")
+	suite.Require().NoError(err)
+	buyer, err := suite.App.CreateUser(cars, "buyer", "buyer@mail.com")
+	suite.Require().NoError(err)
+	other, err := suite.App.CreateUser(flats, "seller", "seller@mail.com")
+	suite.Require().NoError(err)
+	_, err = suite.App.CreateUser(flats, "buyer", "buyer@mail.com")
+	suite.Require().NoError(err)
+	suite.Require().Equal(seller.ID, other.ID)
+
+	subCtx, cancel := context.WithCancel(flats)
+	defer cancel()
+	ch, err := suite.App.SubscribeMessages(subCtx, other.ID)
+	suite.Require().NoError(err)
+
+	ad, err := suite.App.CreateAd(cars, "Circles", "Good News", seller.ID)
+	suite.Require().NoError(err)
+	_, err = suite.App.ChangeAdStatus(cars, ad.ID, seller.ID, true)
+	suite.Require().NoError(err)
+	conv, err := suite.App.StartConversation(cars, ad.ID, buyer.ID)
+	suite.Require().NoError(err)
+	_, err = suite.App.SendMessage(cars, conv.ID, buyer.ID, "hi")
+	suite.Require().NoError(err)
+
+	select {
+	case m := <-ch:
+		suite.Failf("message leaked to another tenant", "%+v", m)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (suite *TenantSuite) TestScheduler() {
+	cars := suite.ctx("cars")
+	u, err := suite.App.CreateUser(cars, "mac", "mac@mail.com")
+	suite.Require().NoError(err)
+	ad, err := suite.App.CreateAd(cars, "Circles", "Good News", u.ID)
+	suite.Require().NoError(err)
+	publishAt := time.Now().UTC().Add(time.Hour)
+	_, err = suite.App.ScheduleAd(cars, ad.ID, u.ID, &publishAt, 0)
+	suite.Require().NoError(err)
+
+	// планировщик обходит всех арендаторов, даже если контекст тика без арендатора
+	suite.NoError(scheduler.New(suite.Repo, time.Millisecond).Tick(context.Background(), publishAt))
+	res, err := suite.App.GetAd(cars, ad.ID)
+	suite.NoError(err)
+	suite.True(res.Published)
+}
+
+func (suite *TenantSuite) TestClient() {
+	cars := client.NewHTTPClient(suite.URL, client.Config{Tenant: "cars"}, suite.Client)
+	defer cars.Close()
+	def := client.NewHTTPClient(suite.URL, client.Config{}, suite.Client)
+	defer def.Close()
+
+	u, err := cars.CreateUser(context.Background(), "mac", "mac@mail.com")
+	suite.Require().NoError(err)
+	_, err = cars.GetUser(context.Background(), u.ID)
+	suite.NoError(err)
+	_, err = def.GetUser(context.Background(), u.ID)
+	suite.ErrorIs(err, app.ErrUserNotFound)
+}
+
+func TestTenants(t *testing.T) {
+	suite.Run(t, new(TenantSuite))
+}
@@ -0,0 +1,68 @@
+// Программа собирается как отдельный модуль (см. TestClientExternalModule) и проверяет,
+// что клиентом можно пользоваться, не импортируя внутренние пакеты сервиса
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"homework10/pkg/client"
+)
+
+func run(baseURL string) error {
+	ctx := context.Background()
+	c := client.NewHTTPClient(baseURL, client.DefaultConfig(), nil)
+	defer c.Close()
+
+	u, err := c.CreateUser(ctx, "Mac Miller", "swimming@circles.com")
+	if err != nil {
+		return fmt.Errorf("create user: %w", err)
+	}
+	var ad *client.Ad
+	if ad, err = c.CreateAd(ctx, u.ID, "Circles", "Good News"); err != nil {
+		return fmt.Errorf("create ad: %w", err)
+	}
+
+	expr, err := client.ParseFilter(`title ~ "circ"`)
+	if err != nil {
+		return fmt.Errorf("parse filter: %w", err)
+	}
+	from := time.Now().Add(-time.Hour)
+	list, err := c.ListAds(ctx, client.ListAdsParams{Uid: &u.ID, CreatedFrom: &from, Filter: expr})
+	if err != nil {
+		return fmt.Errorf("list ads: %w", err)
+	}
+	if len(list) != 1 || list[0].ID != ad.ID {
+		return fmt.Errorf("list ads: unexpected result %v", list)
+	}
+
+	_, err = c.GetAd(ctx, ad.ID+100)
+	var clientErr *client.Error
+	if !errors.Is(err, client.ErrAdNotFound) || !errors.As(err, &clientErr) || clientErr.Reason != client.CodeAdNotFound {
+		return fmt.Errorf("get missing ad: unexpected error %v", err)
+	}
+	_, err = c.CreateAd(ctx, u.ID, "", "text")
+	if !errors.As(err, &clientErr) || clientErr.Reason != client.CodeValidationFailed || len(clientErr.Fields) != 1 {
+		return fmt.Errorf("create invalid ad: unexpected error %v", err)
+	}
+	var violations []client.FieldViolation = clientErr.Fields
+	if violations[0].Field != "title" {
+		return fmt.Errorf("create invalid ad: unexpected fields %v", violations)
+	}
+
+	role := client.RoleModerator
+	if _, err = c.ListUsers(ctx, u.ID, client.ListUsersParams{Role: &role}); !errors.Is(err, client.ErrForbidden) {
+		return fmt.Errorf("list users: unexpected error %v", err)
+	}
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
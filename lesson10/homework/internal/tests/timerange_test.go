@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	grpcPort "homework10/internal/ports/grpc"
+	grpcPortV2 "homework10/internal/ports/grpc/v2"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2023, time.May, 20, 12, 0, 0, 0, time.UTC)
+	for in, want := range map[string]time.Time{
+		"2023-05-20T15:00:00+03:00": now,
+		"2023-05-20T12:00:00Z":      now,
+		"2023-05-19":                time.Date(2023, time.May, 19, 0, 0, 0, 0, time.UTC),
+		"-36h":                      now.Add(-36 * time.Hour),
+		"-7d":                       now.AddDate(0, 0, -7),
+		"-2w":                       now.AddDate(0, 0, -14),
+	} {
+		s := in
+		got, err := app.ParseTime(&s, now)
+		if assert.NoError(t, err, in) {
+			assert.Equal(t, want, *got, in)
+			assert.Equal(t, time.UTC, got.Location(), in)
+		}
+	}
+	for _, in := range []string{"yesterday", "-", "--1h", "-1.5d", "7d", "2023-13-01"} {
+		s := in
+		_, err := app.ParseTime(&s, now)
+		assert.Error(t, err, in)
+	}
+	got, err := app.ParseTime(nil, now)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func (suite *HTTPSuite) TestListAdsByTimeRange() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.Require().NoError(err)
+	ad, err := suite.Client.createAd(u.Data.ID, "Circles", "Good News")
+	suite.Require().NoError(err)
+
+	hourAgo := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	inHour := time.Now().Add(time.Hour).In(time.FixedZone("UTC-5", -5*60*60)).Format(time.RFC3339)
+	for name, tc := range map[string]struct {
+		body map[string]any
+		want int
+	}{
+		"created from":     {map[string]any{"created_from": hourAgo}, 1},
+		"created to":       {map[string]any{"created_to": hourAgo}, 0},
+		"range":            {map[string]any{"created_from": hourAgo, "created_to": inHour}, 1},
+		"relative":         {map[string]any{"created_from": "-7d"}, 1},
+		"changed since":    {map[string]any{"changed_since": "-1m"}, 1},
+		"changed later":    {map[string]any{"changed_since": inHour}, 0},
+		"with other field": {map[string]any{"user_id": u.Data.ID + 1, "created_from": hourAgo}, 0},
+	} {
+		suite.Run(name, func() {
+			var list adsResponse
+			suite.Equal(http.StatusOK, suite.requestV1(tc.body, &list))
+			suite.Len(list.Data, tc.want)
+		})
+	}
+
+	p := suite.problemRequest(http.MethodGet, "/api/v1/ads", map[string]any{"created_from": "last week"})
+	suite.Equal(http.StatusBadRequest, p.Status)
+	suite.Require().Len(p.Errors, 1)
+	suite.Equal("created_from", p.Errors[0].Field)
+
+	p = suite.problemRequest(http.MethodGet, "/api/v1/ads", map[string]any{"created_from": inHour, "created_to": hourAgo})
+	suite.Equal(http.StatusBadRequest, p.Status)
+	suite.Equal(apperr.CodeInvalidTimeRange, p.Code)
+
+	var list struct{ Data []adDataV2 }
+	query := url.Values{"created_from": {hourAgo}, "changed_since": {"-1h"}, "author_id": {fmt.Sprint(u.Data.ID)}}
+	suite.Equal(http.StatusOK, suite.requestV2(http.MethodGet, "/ads?"+query.Encode(), nil, &list))
+	suite.Require().Len(list.Data, 1)
+	suite.Equal(ad.Data.ID, list.Data[0].ID)
+	suite.Equal(http.StatusOK, suite.requestV2(http.MethodGet, "/ads?created_to="+url.QueryEscape(hourAgo), nil, &list))
+	suite.Len(list.Data, 0)
+	suite.Equal(http.StatusBadRequest, suite.requestV2(http.MethodGet, "/ads?changed_since=soon", nil, nil))
+}
+
+// requestV1 выполняет запрос списка объявлений первой версии с фильтрами в теле
+func (suite *HTTPSuite) requestV1(body map[string]any, out any) int {
+	req, err := http.NewRequest(http.MethodGet, suite.Client.baseURL+"/api/v1/ads", jsonBody(body))
+	suite.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	err = suite.Client.getResponse(req, out)
+	if err == ErrBadRequest {
+		return http.StatusBadRequest
+	}
+	suite.Require().NoError(err)
+	return http.StatusOK
+}
+
+func (suite *GRPCSuite) TestGRPCListAdsByTimeRange() {
+	u, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "J.Cole", Email: "foresthill@drive.com"})
+	suite.Require().NoError(err)
+	_, err = suite.Client.CreateAd(suite.Context, &grpcPort.CreateAdRequest{UserId: &u.Id, Title: "GOMD", Text: "Role Modelz"})
+	suite.Require().NoError(err)
+
+	weekAgo, hourAgo := "-1w", time.Now().Add(-time.Hour).Format(time.RFC3339)
+	ads, err := suite.Client.ListAds(suite.Context, &grpcPort.ListAdRequest{CreatedFrom: &weekAgo, ChangedSince: &hourAgo})
+	suite.NoError(err)
+	suite.Len(ads.List, 1)
+	ads, err = suite.Client.ListAds(suite.Context, &grpcPort.ListAdRequest{CreatedTo: &hourAgo})
+	suite.NoError(err)
+	suite.Len(ads.List, 0)
+
+	invalid := "next week"
+	_, err = suite.Client.ListAds(suite.Context, &grpcPort.ListAdRequest{CreatedTo: &invalid})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = suite.Client.ListAds(suite.Context, &grpcPort.ListAdRequest{CreatedFrom: &hourAgo, CreatedTo: &weekAgo})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (suite *GRPCV2Suite) TestListAdsByTimeRange() {
+	u, err := suite.Client.CreateUser(suite.Ctx, &grpcPortV2.CreateUserRequest{Nickname: "Mac Miller", Email: "swimming@circles.com"})
+	suite.Require().NoError(err)
+	_, err = suite.Client.CreateAd(suite.Ctx, &grpcPortV2.CreateAdRequest{AuthorId: u.Id, Title: "Circles", Text: "Good News"})
+	suite.Require().NoError(err)
+
+	hourAgo := timestamppb.New(time.Now().Add(-time.Hour))
+	list, err := suite.Client.ListAds(suite.Ctx, &grpcPortV2.ListAdsRequest{CreatedFrom: hourAgo, ChangedSince: hourAgo})
+	suite.Require().NoError(err)
+	suite.Len(list.Ads, 1)
+	list, err = suite.Client.ListAds(suite.Ctx, &grpcPortV2.ListAdsRequest{CreatedTo: hourAgo})
+	suite.Require().NoError(err)
+	suite.Len(list.Ads, 0)
+
+	_, err = suite.Client.ListAds(suite.Ctx, &grpcPortV2.ListAdsRequest{ChangedSince: &timestamppb.Timestamp{Nanos: -1}})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+}
@@ -55,11 +55,11 @@ func (suite *HTTPSuite) TestCreateUser_ID() {
 	suite.NoError(err)
 	suite.Equal(resp.Data.ID, int64(0))
 
-	resp, err = suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	resp, err = suite.Client.createUser("J.Cole", "foresthill@drive.com")
 	suite.NoError(err)
 	suite.Equal(resp.Data.ID, int64(1))
 
-	resp, err = suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	resp, err = suite.Client.createUser("Kendrick", "good@kid.com")
 	suite.NoError(err)
 	suite.Equal(resp.Data.ID, int64(2))
 }
@@ -71,7 +71,7 @@ func (suite *HTTPSuite) TestDeleteUser() {
 	ad1, err := suite.Client.createAd(user1.Data.ID, "Good News", "Dang!")
 	suite.NoError(err)
 
-	_, err = suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	_, err = suite.Client.createUser("J.Cole", "foresthill@drive.com")
 	suite.NoError(err)
 
 	_, err = suite.Client.deleteUser(user1.Data.ID)
@@ -11,6 +11,9 @@ type userData struct {
 	ID       int64  `json:"id"`
 	Nickname string `json:"nickname"`
 	Email    string `json:"email"`
+	Verified bool   `json:"verified"`
+	Role     string `json:"role"`
+	Banned   bool   `json:"banned"`
 }
 
 type userResponse struct {
@@ -104,3 +107,44 @@ func (tc *testClient) deleteUser(userID any) (userResponse, error) {
 
 	return response, nil
 }
+
+func (tc *testClient) requestEmailVerification(userID any) (userResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/users/%v/verification", userID), nil)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) verifyEmail(token any) (userResponse, error) {
+	body := map[string]any{
+		"token": token,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/verification", bytes.NewReader(data))
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
@@ -16,13 +16,16 @@ import (
 )
 
 type adData struct {
-	ID          int64  `json:"id"`
-	Title       string `json:"title"`
-	Text        string `json:"text"`
-	AuthorID    int64  `json:"author_id"`
-	Published   bool   `json:"published"`
-	DateCreated string `json:"date_created"`
-	DateChanged string `json:"date_changed"`
+	ID          int64    `json:"id"`
+	Title       string   `json:"title"`
+	Text        string   `json:"text"`
+	AuthorID    int64    `json:"author_id"`
+	Published   bool     `json:"published"`
+	DateCreated string   `json:"date_created"`
+	DateChanged string   `json:"date_changed"`
+	PublishAt   *string  `json:"publish_at"`
+	ExpiresAt   *string  `json:"expires_at"`
+	Flags       []string `json:"flags"`
 }
 
 type adResponse struct {
@@ -40,6 +43,7 @@ var (
 	ErrMock             = fmt.Errorf("mock error")
 	ErrInternal         = fmt.Errorf("internal server error")
 	ErrFailedDependency = fmt.Errorf("failed dependency")
+	ErrConflict         = fmt.Errorf("conflict")
 )
 
 type testClient struct {
@@ -48,7 +52,15 @@ type testClient struct {
 }
 
 func getTestClient() *testClient {
-	server := httpgin.NewHTTPServer(":18080", app.NewApp(adrepo.New()))
+	return getTestClientWithRepo(adrepo.New())
+}
+
+func getTestClientWithRepo(repo app.Repository) *testClient {
+	return getTestClientWithApp(app.NewApp(repo))
+}
+
+func getTestClientWithApp(a app.App) *testClient {
+	server := httpgin.NewHTTPServer(":18080", a)
 	testServer := httptest.NewServer(server.Handler)
 
 	return &testClient{
@@ -87,6 +99,8 @@ func (tc *testClient) getResponse(req *http.Request, out any) error {
 			return ErrNotFound
 		case http.StatusFailedDependency:
 			return ErrFailedDependency
+		case http.StatusConflict:
+			return ErrConflict
 		case http.StatusInternalServerError:
 			return ErrInternal
 		}
@@ -206,6 +220,34 @@ func (tc *testClient) updateAd(userID any, adID any, title any, text any) (adRes
 	return response, nil
 }
 
+func (tc *testClient) scheduleAd(userID any, adID any, publishAt any, expiresIn any) (adResponse, error) {
+	body := map[string]any{
+		"user_id":    userID,
+		"publish_at": publishAt,
+		"expires_in": expiresIn,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%v/schedule", adID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
 func (tc *testClient) listAds() (adsResponse, error) {
 	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads", nil)
 	if err != nil {
@@ -0,0 +1,264 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	grpcPort "homework10/internal/ports/grpc"
+	grpcPortV2 "homework10/internal/ports/grpc/v2"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type adDataV2 struct {
+	ID        int64      `json:"id"`
+	Title     string     `json:"title"`
+	Text      string     `json:"text"`
+	AuthorID  int64      `json:"author_id"`
+	Published bool       `json:"published"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// requestV2 выполняет запрос ко второй версии API и возвращает статус ответа
+func (suite *HTTPSuite) requestV2(method string, path string, body any, out any) int {
+	req, err := http.NewRequest(method, suite.Client.baseURL+"/api/v2"+path, nil)
+	suite.Require().NoError(err)
+	if body != nil {
+		req, err = http.NewRequest(method, suite.Client.baseURL+"/api/v2"+path, jsonBody(body))
+		suite.Require().NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := suite.Client.client.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < http.StatusBadRequest {
+		suite.Require().NoError(json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+func (suite *HTTPSuite) TestV2Ads() {
+	var u userResponse
+	suite.Require().Equal(http.StatusCreated, suite.requestV2(http.MethodPost, "/users",
+		map[string]any{"nickname": "Mac Miller", "email": "swimming@circles.com"}, &u))
+
+	var ad struct{ Data adDataV2 }
+	suite.Require().Equal(http.StatusCreated, suite.requestV2(http.MethodPost, "/ads",
+		map[string]any{"author_id": u.Data.ID, "title": "Circles", "text": "Good News"}, &ad))
+	suite.Equal(u.Data.ID, ad.Data.AuthorID)
+	suite.WithinDuration(time.Now(), ad.Data.CreatedAt, time.Minute)
+	suite.Nil(ad.Data.PublishAt)
+	path := fmt.Sprintf("/ads/%d", ad.Data.ID)
+
+	suite.Equal(http.StatusOK, suite.requestV2(http.MethodPatch, path+"?update_mask=title",
+		map[string]any{"user_id": u.Data.ID, "title": "Swimming"}, &ad))
+	suite.Equal("Swimming", ad.Data.Title)
+	suite.Equal("Good News", ad.Data.Text)
+	suite.False(ad.Data.Published)
+
+	suite.Equal(http.StatusOK, suite.requestV2(http.MethodPatch, path+"?update_mask=published",
+		map[string]any{"user_id": u.Data.ID, "published": true}, &ad))
+	suite.True(ad.Data.Published)
+	suite.Equal("Swimming", ad.Data.Title)
+
+	suite.Equal(http.StatusBadRequest, suite.requestV2(http.MethodPatch, path+"?update_mask=author_id",
+		map[string]any{"user_id": u.Data.ID}, nil))
+	suite.Equal(http.StatusBadRequest, suite.requestV2(http.MethodPatch, path+"?update_mask=text",
+		map[string]any{"user_id": u.Data.ID}, nil))
+	suite.Equal(http.StatusForbidden, suite.requestV2(http.MethodPatch, path+"?update_mask=text",
+		map[string]any{"user_id": u.Data.ID + 1, "text": "hacked"}, nil))
+
+	// первая версия видит те же данные в своем формате
+	v1, err := suite.Client.getAd(ad.Data.ID)
+	suite.Require().NoError(err)
+	suite.Equal("Swimming", v1.Data.Title)
+	suite.Equal(app.FormatDate(ad.Data.CreatedAt), v1.Data.DateCreated)
+
+	var list struct{ Data []adDataV2 }
+	suite.Equal(http.StatusOK, suite.requestV2(http.MethodGet,
+		fmt.Sprintf("/ads?published=true&author_id=%d&date=%s", u.Data.ID, ad.Data.CreatedAt.Format(app.DateLayout)), nil, &list))
+	suite.Len(list.Data, 1)
+	suite.Equal(http.StatusOK, suite.requestV2(http.MethodGet, "/ads?published=false", nil, &list))
+	suite.Len(list.Data, 0)
+	suite.Equal(http.StatusBadRequest, suite.requestV2(http.MethodGet, "/ads?date=yesterday", nil, nil))
+
+	suite.Equal(http.StatusBadRequest, suite.requestV2(http.MethodDelete, path, nil, nil))
+	suite.Equal(http.StatusNoContent, suite.requestV2(http.MethodDelete, fmt.Sprintf("%s?user_id=%d", path, u.Data.ID), nil, nil))
+	suite.Equal(http.StatusNotFound, suite.requestV2(http.MethodGet, path, nil, nil))
+}
+
+func (suite *HTTPSuite) TestV2Users() {
+	var u userResponse
+	suite.Require().Equal(http.StatusCreated, suite.requestV2(http.MethodPost, "/users",
+		map[string]any{"nickname": "Mac Miller", "email": "swimming@circles.com"}, &u))
+	path := fmt.Sprintf("/users/%d", u.Data.ID)
+
+	suite.Equal(http.StatusOK, suite.requestV2(http.MethodPatch, path+"?update_mask=nickname",
+		map[string]any{"nickname": "Malcolm"}, &u))
+	suite.Equal("Malcolm", u.Data.Nickname)
+	suite.Equal("swimming@circles.com", u.Data.Email)
+
+	suite.Equal(http.StatusBadRequest, suite.requestV2(http.MethodPatch, path+"?update_mask=email",
+		map[string]any{"email": "not-an-email"}, nil))
+	suite.Equal(http.StatusOK, suite.requestV2(http.MethodGet, path, nil, &u))
+	suite.Equal("swimming@circles.com", u.Data.Email)
+
+	suite.Equal(http.StatusNoContent, suite.requestV2(http.MethodDelete, path, nil, nil))
+	suite.Equal(http.StatusNotFound, suite.requestV2(http.MethodGet, path, nil, nil))
+}
+
+type GRPCV2Suite struct {
+	suite.Suite
+	Ctx    context.Context
+	V1     grpcPort.AdServiceClient
+	Client grpcPortV2.AdServiceClient
+	conn   *grpc.ClientConn
+	server *grpc.Server
+}
+
+func (suite *GRPCV2Suite) SetupTest() {
+	suite.Ctx = context.Background()
+	a := app.NewApp(adrepo.NewRepositoryMap())
+	lis := bufconn.Listen(1024 * 1024)
+	suite.server = grpc.NewServer()
+	grpcPort.RegisterAdServiceServer(suite.server, grpcPort.NewService(a))
+	grpcPortV2.RegisterAdServiceServer(suite.server, grpcPortV2.NewService(a))
+	go func() {
+		_ = suite.server.Serve(lis)
+	}()
+	conn, err := grpc.DialContext(suite.Ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	suite.Require().NoError(err)
+	suite.conn = conn
+	suite.V1 = grpcPort.NewAdServiceClient(conn)
+	suite.Client = grpcPortV2.NewAdServiceClient(conn)
+}
+
+func (suite *GRPCV2Suite) TearDownTest() {
+	_ = suite.conn.Close()
+	suite.server.Stop()
+}
+
+func (suite *GRPCV2Suite) TestAds() {
+	u, err := suite.Client.CreateUser(suite.Ctx, &grpcPortV2.CreateUserRequest{Nickname: "Mac Miller", Email: "swimming@circles.com"})
+	suite.Require().NoError(err)
+	suite.Equal(grpcPortV2.Role_ROLE_USER, u.Role)
+
+	ad, err := suite.Client.CreateAd(suite.Ctx, &grpcPortV2.CreateAdRequest{AuthorId: u.Id, Title: "Circles", Text: "Good News"})
+	suite.Require().NoError(err)
+	suite.Equal(u.Id.Value, ad.AuthorId.Value)
+	suite.WithinDuration(time.Now(), ad.CreateTime.AsTime(), time.Minute)
+	suite.Nil(ad.PublishTime)
+
+	ad, err = suite.Client.UpdateAd(suite.Ctx, &grpcPortV2.UpdateAdRequest{
+		Ad:         &grpcPortV2.Ad{Id: ad.Id, Text: "Blue World", Published: true},
+		UserId:     u.Id,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"text", "published"}},
+	})
+	suite.Require().NoError(err)
+	suite.Equal("Circles", ad.Title)
+	suite.Equal("Blue World", ad.Text)
+	suite.True(ad.Published)
+
+	_, err = suite.Client.UpdateAd(suite.Ctx, &grpcPortV2.UpdateAdRequest{
+		Ad:         &grpcPortV2.Ad{Id: ad.Id},
+		UserId:     u.Id,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"author_id"}},
+	})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+
+	_, err = suite.Client.UpdateAd(suite.Ctx, &grpcPortV2.UpdateAdRequest{
+		Ad:     &grpcPortV2.Ad{Id: ad.Id, Title: "only title"},
+		UserId: u.Id,
+	})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+
+	v1, err := suite.V1.GetAd(suite.Ctx, &grpcPort.GetAdRequest{AdId: &ad.Id.Value})
+	suite.Require().NoError(err)
+	suite.Equal("Blue World", v1.Text)
+
+	list, err := suite.Client.ListAds(suite.Ctx, &grpcPortV2.ListAdsRequest{AuthorId: u.Id, CreatedOn: timestamppb.Now()})
+	suite.Require().NoError(err)
+	suite.Len(list.Ads, 1)
+	list, err = suite.Client.ListAds(suite.Ctx, &grpcPortV2.ListAdsRequest{CreatedOn: timestamppb.New(time.Now().AddDate(0, 0, -2))})
+	suite.Require().NoError(err)
+	suite.Len(list.Ads, 0)
+
+	_, err = suite.Client.DeleteAd(suite.Ctx, &grpcPortV2.DeleteAdRequest{Id: ad.Id})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = suite.Client.DeleteAd(suite.Ctx, &grpcPortV2.DeleteAdRequest{Id: ad.Id, UserId: u.Id})
+	suite.NoError(err)
+	_, err = suite.Client.GetAd(suite.Ctx, &grpcPortV2.GetAdRequest{Id: ad.Id})
+	suite.Equal(codes.NotFound, status.Code(err))
+}
+
+func (suite *GRPCV2Suite) TestUsers() {
+	u, err := suite.Client.CreateUser(suite.Ctx, &grpcPortV2.CreateUserRequest{Nickname: "Mac Miller", Email: "swimming@circles.com"})
+	suite.Require().NoError(err)
+
+	u, err = suite.Client.UpdateUser(suite.Ctx, &grpcPortV2.UpdateUserRequest{
+		User:       &grpcPortV2.User{Id: u.Id, Nickname: "Malcolm"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"nickname"}},
+	})
+	suite.Require().NoError(err)
+	suite.Equal("Malcolm", u.Nickname)
+	suite.Equal("swimming@circles.com", u.Email)
+
+	_, err = suite.Client.UpdateUser(suite.Ctx, &grpcPortV2.UpdateUserRequest{User: &grpcPortV2.User{Nickname: "x"}})
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+
+	// почту проверяет приложение, как и в v1: код VALIDATION_FAILED и нарушение поля email
+	_, err = suite.Client.CreateUser(suite.Ctx, &grpcPortV2.CreateUserRequest{Nickname: "Kendrick", Email: "not-an-email"})
+	suite.emailViolation(err)
+	_, err = suite.Client.UpdateUser(suite.Ctx, &grpcPortV2.UpdateUserRequest{
+		User:       &grpcPortV2.User{Id: u.Id, Email: "not-an-email"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"email"}},
+	})
+	suite.emailViolation(err)
+
+	_, err = suite.Client.DeleteUser(suite.Ctx, &grpcPortV2.DeleteUserRequest{Id: u.Id})
+	suite.NoError(err)
+	_, err = suite.Client.GetUser(suite.Ctx, &grpcPortV2.GetUserRequest{Id: u.Id})
+	suite.Equal(codes.NotFound, status.Code(err))
+}
+
+func (suite *GRPCV2Suite) emailViolation(err error) {
+	st := status.Convert(err)
+	suite.Equal(codes.InvalidArgument, st.Code())
+	var info *errdetails.ErrorInfo
+	var badRequest *errdetails.BadRequest
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.BadRequest:
+			badRequest = d
+		}
+	}
+	suite.Require().NotNil(info)
+	suite.Equal(string(apperr.CodeValidationFailed), info.Reason)
+	suite.Require().NotNil(badRequest)
+	suite.Require().Len(badRequest.FieldViolations, 1)
+	suite.Equal("email", badRequest.FieldViolations[0].Field)
+}
+
+func TestGRPCV2(t *testing.T) {
+	suite.Run(t, new(GRPCV2Suite))
+}
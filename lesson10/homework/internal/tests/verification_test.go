@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"github.com/TobbyMax/validator"
+	"github.com/stretchr/testify/suite"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/app"
+	"homework10/internal/mailer"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/user"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var tokenRegexp = regexp.MustCompile(`verification token: ([0-9a-f]+)`)
+
+type VerificationSuite struct {
+	suite.Suite
+	Repo   *adrepo.RepositoryMap
+	Mailer *mailer.InMemory
+	App    app.App
+	Ctx    context.Context
+}
+
+func (suite *VerificationSuite) SetupTest() {
+	suite.Ctx = context.Background()
+	suite.Repo = adrepo.NewRepositoryMap()
+	suite.Mailer = mailer.NewInMemory()
+	suite.App = app.NewAppWithMailer(suite.Repo, suite.Mailer)
+}
+
+func (suite *VerificationSuite) lastToken(email string) string {
+	msg, ok := suite.Mailer.Last(email)
+	suite.True(ok)
+	m := tokenRegexp.FindStringSubmatch(msg.Body)
+	suite.Len(m, 2)
+	return m[1]
+}
+
+func (suite *VerificationSuite) TestVerifyEmail() {
+	u, err := suite.App.CreateUser(suite.Ctx, "Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	suite.False(u.Verified)
+
+	token := suite.lastToken(u.Email)
+	res, err := suite.App.VerifyEmail(suite.Ctx, token)
+	suite.NoError(err)
+	suite.True(res.Verified)
+
+	// токен одноразовый
+	_, err = suite.App.VerifyEmail(suite.Ctx, token)
+	suite.ErrorIs(err, app.ErrTokenNotFound)
+
+	err = suite.App.RequestEmailVerification(suite.Ctx, u.ID)
+	suite.ErrorIs(err, app.ErrAlreadyExists)
+}
+
+func (suite *VerificationSuite) TestVerifyEmail_Expired() {
+	u, err := suite.App.CreateUser(suite.Ctx, "Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+
+	err = suite.Repo.AddVerificationToken(suite.Ctx, app.VerificationToken{
+		Token: "expired", UserID: u.ID, Email: u.Email, ExpiresAt: time.Now().UTC().Add(-time.Minute),
+	})
+	suite.NoError(err)
+
+	_, err = suite.App.VerifyEmail(suite.Ctx, "expired")
+	suite.ErrorIs(err, app.ErrTokenExpired)
+	_, err = suite.App.VerifyEmail(suite.Ctx, "expired")
+	suite.ErrorIs(err, app.ErrTokenNotFound)
+
+	res, err := suite.App.GetUser(suite.Ctx, u.ID)
+	suite.NoError(err)
+	suite.False(res.Verified)
+}
+
+func (suite *VerificationSuite) TestVerifyEmail_ChangedEmail() {
+	u, err := suite.App.CreateUser(suite.Ctx, "Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	oldToken := suite.lastToken(u.Email)
+
+	_, err = suite.App.UpdateUser(suite.Ctx, u.ID, u.Nickname, "circles@swimming.com")
+	suite.NoError(err)
+
+	_, err = suite.App.VerifyEmail(suite.Ctx, oldToken)
+	suite.ErrorIs(err, app.ErrTokenNotFound)
+
+	res, err := suite.App.VerifyEmail(suite.Ctx, suite.lastToken("circles@swimming.com"))
+	suite.NoError(err)
+	suite.True(res.Verified)
+
+	// смена регистра почты не сбрасывает подтверждение
+	res, err = suite.App.UpdateUser(suite.Ctx, u.ID, u.Nickname, "Circles@Swimming.com")
+	suite.NoError(err)
+	suite.True(res.Verified)
+	suite.Len(suite.Mailer.Sent(), 2)
+
+	res, err = suite.App.UpdateUser(suite.Ctx, u.ID, u.Nickname, "good@news.com")
+	suite.NoError(err)
+	suite.False(res.Verified)
+	res, err = suite.App.GetUser(suite.Ctx, u.ID)
+	suite.NoError(err)
+	suite.False(res.Verified)
+}
+
+func (suite *VerificationSuite) TestUniqueness() {
+	u, err := suite.App.CreateUser(suite.Ctx, "Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+
+	_, err = suite.App.CreateUser(suite.Ctx, "Larry Fisherman", "SWIMMING@circles.com")
+	suite.ErrorIs(err, app.ErrAlreadyExists)
+	suite.ErrorIs(err, adrepo.ErrEmailExists)
+	_, err = suite.App.CreateUser(suite.Ctx, "mac miller", "circles@swimming.com")
+	suite.ErrorIs(err, adrepo.ErrNicknameExists)
+
+	other, err := suite.App.CreateUser(suite.Ctx, "Larry Fisherman", "circles@swimming.com")
+	suite.NoError(err)
+	_, err = suite.App.UpdateUser(suite.Ctx, other.ID, other.Nickname, "Swimming@Circles.com")
+	suite.ErrorIs(err, app.ErrAlreadyExists)
+
+	// освободившиеся почта и никнейм снова доступны
+	suite.NoError(suite.App.DeleteUser(suite.Ctx, u.ID))
+	_, err = suite.App.UpdateUser(suite.Ctx, other.ID, "Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	_, err = suite.App.CreateUser(suite.Ctx, "Larry Fisherman", "circles@swimming.com")
+	suite.NoError(err)
+}
+
+func (suite *VerificationSuite) TestInvalidEmail() {
+	for _, email := range []string{"x", "a@b", "Mac <mac@circles.com>", "mac@circles.", "mac@.com", "mac circles@com.com"} {
+		_, err := suite.App.CreateUser(suite.Ctx, "Mac Miller", email)
+		suite.ErrorAs(err, &validator.ValidationErrors{}, email)
+		suite.False(user.IsValidEmail(email), email)
+	}
+	suite.True(user.IsValidEmail("mac.miller+ads@circles.co.uk"))
+}
+
+func TestVerificationSuite(t *testing.T) {
+	suite.Run(t, new(VerificationSuite))
+}
+
+func (suite *HTTPSuite) TestCreateUser_Conflict() {
+	_, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	_, err = suite.Client.createUser("Larry Fisherman", "Swimming@Circles.com")
+	suite.ErrorIs(err, ErrConflict)
+
+	u, err := suite.Client.createUser("Larry Fisherman", "circles@swimming.com")
+	suite.NoError(err)
+	_, err = suite.Client.updateUser(u.Data.ID, "MAC MILLER", "circles@swimming.com")
+	suite.ErrorIs(err, ErrConflict)
+}
+
+func (suite *HTTPSuite) TestVerifyEmail_Errors() {
+	u, err := suite.Client.createUser("Mac Miller", "swimming@circles.com")
+	suite.NoError(err)
+	suite.False(u.Data.Verified)
+
+	_, err = suite.Client.requestEmailVerification(u.Data.ID)
+	suite.NoError(err)
+	_, err = suite.Client.requestEmailVerification(u.Data.ID + 1)
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.Client.requestEmailVerification("abc")
+	suite.ErrorIs(err, ErrBadRequest)
+
+	_, err = suite.Client.verifyEmail("abc")
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.Client.verifyEmail("")
+	suite.ErrorIs(err, ErrBadRequest)
+}
+
+func (suite *GRPCSuite) TestGRPCUser_AlreadyExists() {
+	_, err := suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "ivanov@yandex.ru"})
+	suite.NoError(err)
+	_, err = suite.Client.CreateUser(suite.Context, &grpcPort.CreateUserRequest{Name: "oleg", Email: "olegov@yandex.ru"})
+	suite.Equal("rpc error: code = AlreadyExists desc = user with such nickname already exists", err.Error())
+
+	_, err = suite.Client.VerifyEmail(suite.Context, &grpcPort.VerifyEmailRequest{Token: "abc"})
+	suite.Equal("rpc error: code = NotFound desc = verification token does not exist", err.Error())
+	_, err = suite.Client.RequestEmailVerification(suite.Context, &grpcPort.GetUserRequest{})
+	suite.Equal(ErrMissingArgument.Error(), err.Error())
+}
@@ -1,7 +1,95 @@
 package user
 
+import (
+	"errors"
+	"github.com/TobbyMax/validator"
+	"net/mail"
+	"strings"
+)
+
+var (
+	ErrInvalidEmail = errors.New("invalid email address")
+	ErrInvalidRole  = errors.New("invalid user role")
+)
+
+// Role определяет права пользователя, нулевое значение - обычный пользователь
+type Role int
+
+const (
+	RoleUser Role = iota
+	RoleModerator
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleUser:      "user",
+	RoleModerator: "moderator",
+	RoleAdmin:     "admin",
+}
+
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func ParseRole(s string) (Role, error) {
+	for r, name := range roleNames {
+		if name == Key(s) {
+			return r, nil
+		}
+	}
+	return RoleUser, ErrInvalidRole
+}
+
+func (r Role) IsValid() bool {
+	_, ok := roleNames[r]
+	return ok
+}
+
+// IsStaff проверяет, может ли пользователь модерировать объявления и пользователей
+func (r Role) IsStaff() bool {
+	return r == RoleModerator || r == RoleAdmin
+}
+
 type User struct {
 	ID       int64
-	Nickname string `validate:"min:1"`
-	Email    string `validate:"min:1"`
+	Nickname string `validate:"min:1; max:100"`
+	Email    string `validate:"min:3; max:254"`
+	Verified bool
+	Role     Role
+	Banned   bool
+}
+
+// Validate проверяет ограничения из тегов и синтаксис почты.
+// Ошибка почты возвращается как validator.ValidationErrors,
+// чтобы транспорт обрабатывал ее так же, как остальные ошибки валидации
+func Validate(u User) error {
+	if err := validator.Validate(u); err != nil {
+		return err
+	}
+	if !IsValidEmail(u.Email) {
+		return validator.ValidationErrors{{Err: ErrInvalidEmail}}
+	}
+	return nil
+}
+
+// IsValidEmail принимает только голый адрес вида local@domain.tld без имени и угловых скобок
+func IsValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return false
+	}
+	at := strings.LastIndex(email, "@")
+	domain := email[at+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return false
+	}
+	return true
+}
+
+// Key приводит почту или никнейм к виду, в котором проверяется уникальность
+func Key(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
 }
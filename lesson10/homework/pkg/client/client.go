@@ -0,0 +1,152 @@
+// Package client - типизированный клиент сервиса объявлений поверх HTTP (JSON) и gRPC.
+//
+// Ошибки сервиса возвращаются как *Error, который разворачивается в ошибки приложения
+// (client.ErrAdNotFound, client.ErrForbidden, ...) или в validator.ValidationErrors,
+// поэтому их можно проверять через errors.Is и errors.As так же, как внутри сервиса.
+// Все типы в сигнатурах доступны через этот пакет (см. types.go), импортировать
+// внутренние пакеты сервиса не нужно.
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/TobbyMax/validator"
+	"homework10/internal/apperr"
+	"time"
+)
+
+// AdsClient - общий интерфейс HTTP и gRPC клиентов
+type AdsClient interface {
+	CreateAd(ctx context.Context, uid int64, title string, text string) (*Ad, error)
+	GetAd(ctx context.Context, id int64) (*Ad, error)
+	UpdateAd(ctx context.Context, id int64, uid int64, title string, text string) (*Ad, error)
+	ChangeAdStatus(ctx context.Context, id int64, uid int64, published bool) (*Ad, error)
+	DeleteAd(ctx context.Context, id int64, uid int64) error
+	ListAds(ctx context.Context, params ListAdsParams) ([]Ad, error)
+
+	CreateUser(ctx context.Context, nickname string, email string) (*User, error)
+	GetUser(ctx context.Context, id int64) (*User, error)
+	UpdateUser(ctx context.Context, id int64, nickname string, email string) (*User, error)
+	DeleteUser(ctx context.Context, id int64) error
+	// ListUsers - административный метод, adminID должен принадлежать модератору или администратору
+	ListUsers(ctx context.Context, adminID int64, params ListUsersParams) ([]User, error)
+
+	Close() error
+}
+
+// Config задает поведение при сбоях. Повторяются только идемпотентные запросы (чтение
+// и изменение). Создание не повторяется, чтобы не получить дубликат, а удаление - чтобы
+// потерянный ответ на успешную попытку не превратился в NotFound на повторе.
+type Config struct {
+	Timeout    time.Duration // ограничение на одну попытку, 0 - без ограничения
+	MaxRetries int           // число повторов после первой попытки
+	Backoff    time.Duration // пауза перед первым повтором, далее удваивается
+	Tenant     string        // арендатор запросов, пустой - арендатор по умолчанию или по имени хоста
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:    5 * time.Second,
+		MaxRetries: 2,
+		Backoff:    100 * time.Millisecond,
+	}
+}
+
+// ErrUnavailable - сервис временно недоступен, запрос можно повторить позже
+var ErrUnavailable = errors.New("ads service is unavailable")
+
+// Error - ошибка, которую вернул сервис
+type Error struct {
+	Code    int              // HTTP статус или код gRPC
+	Reason  Code             // машиночитаемый код ошибки сервиса
+	Message string           // текст ошибки сервера
+	Fields  []FieldViolation // нарушения по полям для ошибок валидации
+	err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// kind - класс ошибки, общий для HTTP статусов и кодов gRPC
+type kind int
+
+const (
+	kindUnknown kind = iota
+	kindInvalid
+	kindForbidden
+	kindNotFound
+	kindConflict
+	kindUnavailable
+)
+
+// newError восстанавливает ошибку приложения по коду ошибки сервиса, а если код незнаком - по классу
+func newError(code int, k kind, reason apperr.Code, msg string, fields []apperr.FieldViolation) *Error {
+	e := &Error{Code: code, Reason: reason, Message: msg, Fields: fields}
+	if target := reason.Err(); target != nil {
+		e.err = target
+		return e
+	}
+	switch k {
+	case kindInvalid:
+		e.err = validationErrors(msg, fields)
+	case kindForbidden:
+		e.err = ErrForbidden
+	case kindConflict:
+		e.err = ErrAlreadyExists
+	case kindUnavailable:
+		e.err = ErrUnavailable
+	}
+	return e
+}
+
+// validationErrors переводит нарушения по полям в validator.ValidationErrors
+func validationErrors(msg string, fields []apperr.FieldViolation) validator.ValidationErrors {
+	if len(fields) == 0 {
+		return validator.ValidationErrors{{Err: errors.New(msg)}}
+	}
+	verrs := make(validator.ValidationErrors, 0, len(fields))
+	for _, f := range fields {
+		verrs = append(verrs, validator.ValidationError{Err: errors.New(f.Description)})
+	}
+	return verrs
+}
+
+// retry выполняет запрос с ограничением времени на попытку и повторяет его при временных сбоях
+func retry(ctx context.Context, cfg Config, idempotent bool, call func(ctx context.Context) error) error {
+	backoff := cfg.Backoff
+	for attempt := 0; ; attempt++ {
+		err := try(ctx, cfg.Timeout, call)
+		if err == nil || !idempotent || attempt >= cfg.MaxRetries || ctx.Err() != nil || !errors.Is(err, ErrUnavailable) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func try(ctx context.Context, timeout time.Duration, call func(ctx context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return call(ctx)
+}
+
+// formatTime передает границы интервалов фильтра в RFC3339 с наносекундами
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339Nano)
+	return &s
+}
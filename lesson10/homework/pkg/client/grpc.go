@@ -0,0 +1,263 @@
+package client
+
+import (
+	"context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/user"
+)
+
+type grpcClient struct {
+	cfg    Config
+	conn   *grpc.ClientConn
+	client grpcPort.AdServiceClient
+	admin  grpcPort.AdminServiceClient
+}
+
+// NewGRPCClient создает клиент поверх готового соединения, Close закрывает соединение
+func NewGRPCClient(conn *grpc.ClientConn, cfg Config) AdsClient {
+	return &grpcClient{
+		cfg:    cfg,
+		conn:   conn,
+		client: grpcPort.NewAdServiceClient(conn),
+		admin:  grpcPort.NewAdminServiceClient(conn),
+	}
+}
+
+// DialGRPC устанавливает соединение и создает клиент
+func DialGRPC(ctx context.Context, target string, cfg Config, opts ...grpc.DialOption) (AdsClient, error) {
+	conn, err := grpc.DialContext(ctx, target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewGRPCClient(conn, cfg), nil
+}
+
+func grpcKind(code codes.Code) kind {
+	switch code {
+	case codes.InvalidArgument:
+		return kindInvalid
+	case codes.PermissionDenied:
+		return kindForbidden
+	case codes.NotFound:
+		return kindNotFound
+	case codes.AlreadyExists:
+		return kindConflict
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded:
+		return kindUnavailable
+	}
+	return kindUnknown
+}
+
+// grpcError переводит статус gRPC в ошибку клиента, отмену вызывающим оставляет как есть
+func grpcError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == context.Canceled {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	var reason apperr.Code
+	var fields []apperr.FieldViolation
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			reason = apperr.Code(d.Reason)
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				fields = append(fields, apperr.FieldViolation{Field: v.Field, Description: v.Description})
+			}
+		}
+	}
+	return newError(int(st.Code()), grpcKind(st.Code()), reason, st.Message(), fields)
+}
+
+// call выполняет запрос с повторами и переводом ошибок
+func call[T any](ctx context.Context, c *grpcClient, idempotent bool, rpc func(ctx context.Context) (T, error)) (T, error) {
+	var out T
+	if c.cfg.Tenant != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, grpcPort.TenantMetadataKey, c.cfg.Tenant)
+	}
+	err := retry(ctx, c.cfg, idempotent, func(ctx context.Context) error {
+		res, err := rpc(ctx)
+		if err != nil {
+			return grpcError(ctx, err)
+		}
+		out = res
+		return nil
+	})
+	return out, err
+}
+
+func fromAdResponse(a *grpcPort.AdResponse) *ads.Ad {
+	return &ads.Ad{
+		ID:          a.Id,
+		Title:       a.Title,
+		Text:        a.Text,
+		AuthorID:    a.AuthorId,
+		Published:   a.Published,
+		DateCreated: parseDate(a.DateCreated),
+		DateChanged: parseDate(a.DateChanged),
+		PublishAt:   parseOptionalDate(a.PublishAt),
+		ExpiresAt:   parseOptionalDate(a.ExpiresAt),
+	}
+}
+
+func fromUserResponse(u *grpcPort.UserResponse) *user.User {
+	role, _ := user.ParseRole(u.Role)
+	return &user.User{
+		ID:       u.Id,
+		Nickname: u.Name,
+		Email:    u.Email,
+		Verified: u.Verified,
+		Role:     role,
+		Banned:   u.Banned,
+	}
+}
+
+func (c *grpcClient) CreateAd(ctx context.Context, uid int64, title string, text string) (*Ad, error) {
+	res, err := call(ctx, c, false, func(ctx context.Context) (*grpcPort.AdResponse, error) {
+		return c.client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: &uid, Title: title, Text: text})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromAdResponse(res), nil
+}
+
+func (c *grpcClient) GetAd(ctx context.Context, id int64) (*Ad, error) {
+	res, err := call(ctx, c, true, func(ctx context.Context) (*grpcPort.AdResponse, error) {
+		return c.client.GetAd(ctx, &grpcPort.GetAdRequest{AdId: &id})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromAdResponse(res), nil
+}
+
+func (c *grpcClient) UpdateAd(ctx context.Context, id int64, uid int64, title string, text string) (*Ad, error) {
+	res, err := call(ctx, c, true, func(ctx context.Context) (*grpcPort.AdResponse, error) {
+		return c.client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: &id, UserId: &uid, Title: title, Text: text})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromAdResponse(res), nil
+}
+
+func (c *grpcClient) ChangeAdStatus(ctx context.Context, id int64, uid int64, published bool) (*Ad, error) {
+	res, err := call(ctx, c, true, func(ctx context.Context) (*grpcPort.AdResponse, error) {
+		return c.client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: &id, UserId: &uid, Published: published})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromAdResponse(res), nil
+}
+
+func (c *grpcClient) DeleteAd(ctx context.Context, id int64, uid int64) error {
+	_, err := call(ctx, c, false, func(ctx context.Context) (any, error) {
+		return c.client.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: &id, AuthorId: &uid})
+	})
+	return err
+}
+
+func (c *grpcClient) ListAds(ctx context.Context, params ListAdsParams) ([]Ad, error) {
+	req := &grpcPort.ListAdRequest{Published: params.Published, UserId: params.Uid, Title: params.Title}
+	if params.Date != nil {
+		date := params.Date.Format(app.DateLayout)
+		req.Date = &date
+	}
+	req.CreatedFrom = formatTime(params.CreatedFrom)
+	req.CreatedTo = formatTime(params.CreatedTo)
+	req.ChangedSince = formatTime(params.ChangedSince)
+	if params.Filter != nil {
+		expr := params.Filter.String()
+		req.Filter = &expr
+	}
+	res, err := call(ctx, c, true, func(ctx context.Context) (*grpcPort.ListAdResponse, error) {
+		return c.client.ListAds(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	al := make([]ads.Ad, 0, len(res.List))
+	for _, ad := range res.List {
+		al = append(al, *fromAdResponse(ad))
+	}
+	return al, nil
+}
+
+func (c *grpcClient) CreateUser(ctx context.Context, nickname string, email string) (*User, error) {
+	res, err := call(ctx, c, false, func(ctx context.Context) (*grpcPort.UserResponse, error) {
+		return c.client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: nickname, Email: email})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromUserResponse(res), nil
+}
+
+func (c *grpcClient) GetUser(ctx context.Context, id int64) (*User, error) {
+	res, err := call(ctx, c, true, func(ctx context.Context) (*grpcPort.UserResponse, error) {
+		return c.client.GetUser(ctx, &grpcPort.GetUserRequest{Id: &id})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromUserResponse(res), nil
+}
+
+func (c *grpcClient) UpdateUser(ctx context.Context, id int64, nickname string, email string) (*User, error) {
+	res, err := call(ctx, c, true, func(ctx context.Context) (*grpcPort.UserResponse, error) {
+		return c.client.UpdateUser(ctx, &grpcPort.UpdateUserRequest{Id: &id, Name: nickname, Email: email})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromUserResponse(res), nil
+}
+
+func (c *grpcClient) DeleteUser(ctx context.Context, id int64) error {
+	_, err := call(ctx, c, false, func(ctx context.Context) (any, error) {
+		return c.client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: &id})
+	})
+	return err
+}
+
+func (c *grpcClient) ListUsers(ctx context.Context, adminID int64, params ListUsersParams) ([]User, error) {
+	req := &grpcPort.ListUsersRequest{
+		AdminId:  &adminID,
+		Banned:   params.Banned,
+		Verified: params.Verified,
+		Nickname: params.Nickname,
+		Email:    params.Email,
+	}
+	if params.Role != nil {
+		role := params.Role.String()
+		req.Role = &role
+	}
+	res, err := call(ctx, c, true, func(ctx context.Context) (*grpcPort.ListUserResponse, error) {
+		return c.admin.ListUsers(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ul := make([]user.User, 0, len(res.List))
+	for _, u := range res.List {
+		ul = append(ul, *fromUserResponse(u))
+	}
+	return ul, nil
+}
+
+func (c *grpcClient) Close() error {
+	return c.conn.Close()
+}
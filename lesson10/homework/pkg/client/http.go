@@ -0,0 +1,346 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"homework10/internal/user"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// adminHeader и tenantHeader совпадают с заголовками пакета httpgin, клиент не зависит от пакета сервера
+const (
+	adminHeader  = "X-Admin-ID"
+	tenantHeader = "X-Tenant-ID"
+)
+
+type httpClient struct {
+	cfg     Config
+	client  *http.Client
+	baseURL string
+}
+
+// NewHTTPClient создает клиент HTTP API, baseURL - адрес сервера без /api/v1
+func NewHTTPClient(baseURL string, cfg Config, hc *http.Client) AdsClient {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &httpClient{cfg: cfg, client: hc, baseURL: strings.TrimSuffix(baseURL, "/") + "/api/v1"}
+}
+
+// httpID принимает ID и числом, и строкой, если сервер отдает идентификаторы строками
+type httpID int64
+
+func (id *httpID) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*id = httpID(v)
+	return nil
+}
+
+type httpAd struct {
+	ID          httpID  `json:"id"`
+	Title       string  `json:"title"`
+	Text        string  `json:"text"`
+	AuthorID    httpID  `json:"author_id"`
+	Published   bool    `json:"published"`
+	DateCreated string  `json:"date_created"`
+	DateChanged string  `json:"date_changed"`
+	PublishAt   *string `json:"publish_at"`
+	ExpiresAt   *string `json:"expires_at"`
+}
+
+type httpUser struct {
+	ID       httpID `json:"id"`
+	Nickname string `json:"nickname"`
+	Email    string `json:"email"`
+	Verified bool   `json:"verified"`
+	Role     string `json:"role"`
+	Banned   bool   `json:"banned"`
+}
+
+type httpResponse[T any] struct {
+	Data T `json:"data"`
+}
+
+// httpProblem - ответ с ошибкой в формате problem+json
+type httpProblem struct {
+	Detail string                  `json:"detail"`
+	Code   apperr.Code             `json:"code"`
+	Errors []apperr.FieldViolation `json:"errors"`
+}
+
+func httpKind(status int) kind {
+	switch status {
+	case http.StatusBadRequest:
+		return kindInvalid
+	case http.StatusForbidden:
+		return kindForbidden
+	case http.StatusNotFound, http.StatusFailedDependency:
+		return kindNotFound
+	case http.StatusConflict:
+		return kindConflict
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return kindUnavailable
+	}
+	return kindUnknown
+}
+
+// do отправляет запрос и декодирует поле data ответа в out
+func do[T any](ctx context.Context, c *httpClient, method string, path string, body any, idempotent bool) (T, error) {
+	return doWithHeader[T](ctx, c, method, path, nil, body, idempotent)
+}
+
+func doWithHeader[T any](ctx context.Context, c *httpClient, method string, path string, header http.Header, body any, idempotent bool) (T, error) {
+	var out T
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return out, fmt.Errorf("unable to marshal: %w", err)
+		}
+	}
+	err := retry(ctx, c.cfg, idempotent, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("unable to create request: %w", err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if c.cfg.Tenant != "" {
+			req.Header.Set(tenantHeader, c.cfg.Tenant)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return transportError(ctx, err)
+		}
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return transportError(ctx, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			var problem httpProblem
+			if err := json.Unmarshal(data, &problem); err != nil || problem.Detail == "" {
+				problem.Detail = resp.Status
+			}
+			return newError(resp.StatusCode, httpKind(resp.StatusCode), problem.Code, problem.Detail, problem.Errors)
+		}
+		var decoded httpResponse[T]
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return fmt.Errorf("unable to unmarshal: %w", err)
+		}
+		out = decoded.Data
+		return nil
+	})
+	return out, err
+}
+
+// transportError отличает отмену вызывающим от сбоя сети или таймаута попытки
+func transportError(ctx context.Context, err error) error {
+	if ctx.Err() == context.Canceled {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrUnavailable, err)
+}
+
+func parseDate(s string) time.Time {
+	date, _ := time.Parse(app.DateTimeLayout, s)
+	return date
+}
+
+func parseOptionalDate(s *string) *time.Time {
+	if s == nil {
+		return nil
+	}
+	date := parseDate(*s)
+	return &date
+}
+
+func (a httpAd) toAd() *ads.Ad {
+	return &ads.Ad{
+		ID:          int64(a.ID),
+		Title:       a.Title,
+		Text:        a.Text,
+		AuthorID:    int64(a.AuthorID),
+		Published:   a.Published,
+		DateCreated: parseDate(a.DateCreated),
+		DateChanged: parseDate(a.DateChanged),
+		PublishAt:   parseOptionalDate(a.PublishAt),
+		ExpiresAt:   parseOptionalDate(a.ExpiresAt),
+	}
+}
+
+func (u httpUser) toUser() *user.User {
+	role, _ := user.ParseRole(u.Role)
+	return &user.User{
+		ID:       int64(u.ID),
+		Nickname: u.Nickname,
+		Email:    u.Email,
+		Verified: u.Verified,
+		Role:     role,
+		Banned:   u.Banned,
+	}
+}
+
+func adPath(id int64) string {
+	return "/ads/" + strconv.FormatInt(id, 10)
+}
+
+func userPath(id int64) string {
+	return "/users/" + strconv.FormatInt(id, 10)
+}
+
+func (c *httpClient) CreateAd(ctx context.Context, uid int64, title string, text string) (*Ad, error) {
+	ad, err := do[httpAd](ctx, c, http.MethodPost, "/ads", map[string]any{"user_id": uid, "title": title, "text": text}, false)
+	if err != nil {
+		return nil, err
+	}
+	return ad.toAd(), nil
+}
+
+func (c *httpClient) GetAd(ctx context.Context, id int64) (*Ad, error) {
+	ad, err := do[httpAd](ctx, c, http.MethodGet, adPath(id), nil, true)
+	if err != nil {
+		return nil, err
+	}
+	return ad.toAd(), nil
+}
+
+func (c *httpClient) UpdateAd(ctx context.Context, id int64, uid int64, title string, text string) (*Ad, error) {
+	ad, err := do[httpAd](ctx, c, http.MethodPut, adPath(id), map[string]any{"user_id": uid, "title": title, "text": text}, true)
+	if err != nil {
+		return nil, err
+	}
+	return ad.toAd(), nil
+}
+
+func (c *httpClient) ChangeAdStatus(ctx context.Context, id int64, uid int64, published bool) (*Ad, error) {
+	ad, err := do[httpAd](ctx, c, http.MethodPut, adPath(id)+"/status", map[string]any{"user_id": uid, "published": published}, true)
+	if err != nil {
+		return nil, err
+	}
+	return ad.toAd(), nil
+}
+
+func (c *httpClient) DeleteAd(ctx context.Context, id int64, uid int64) error {
+	query := url.Values{"user_id": {strconv.FormatInt(uid, 10)}}
+	_, err := do[any](ctx, c, http.MethodDelete, adPath(id)+"?"+query.Encode(), nil, false)
+	return err
+}
+
+func (c *httpClient) ListAds(ctx context.Context, params ListAdsParams) ([]Ad, error) {
+	body := map[string]any{}
+	if params.Published != nil {
+		body["published"] = *params.Published
+	}
+	if params.Uid != nil {
+		body["user_id"] = *params.Uid
+	}
+	if params.Date != nil {
+		body["date"] = params.Date.Format(app.DateLayout)
+	}
+	if params.Title != nil {
+		body["title"] = *params.Title
+	}
+	if params.CreatedFrom != nil {
+		body["created_from"] = *formatTime(params.CreatedFrom)
+	}
+	if params.CreatedTo != nil {
+		body["created_to"] = *formatTime(params.CreatedTo)
+	}
+	if params.ChangedSince != nil {
+		body["changed_since"] = *formatTime(params.ChangedSince)
+	}
+	if params.Filter != nil {
+		body["filter"] = params.Filter.String()
+	}
+	list, err := do[[]httpAd](ctx, c, http.MethodGet, "/ads", body, true)
+	if err != nil {
+		return nil, err
+	}
+	al := make([]ads.Ad, 0, len(list))
+	for _, ad := range list {
+		al = append(al, *ad.toAd())
+	}
+	return al, nil
+}
+
+func (c *httpClient) CreateUser(ctx context.Context, nickname string, email string) (*User, error) {
+	u, err := do[httpUser](ctx, c, http.MethodPost, "/users", map[string]any{"nickname": nickname, "email": email}, false)
+	if err != nil {
+		return nil, err
+	}
+	return u.toUser(), nil
+}
+
+func (c *httpClient) GetUser(ctx context.Context, id int64) (*User, error) {
+	u, err := do[httpUser](ctx, c, http.MethodGet, userPath(id), nil, true)
+	if err != nil {
+		return nil, err
+	}
+	return u.toUser(), nil
+}
+
+func (c *httpClient) UpdateUser(ctx context.Context, id int64, nickname string, email string) (*User, error) {
+	u, err := do[httpUser](ctx, c, http.MethodPut, userPath(id), map[string]any{"nickname": nickname, "email": email}, true)
+	if err != nil {
+		return nil, err
+	}
+	return u.toUser(), nil
+}
+
+func (c *httpClient) DeleteUser(ctx context.Context, id int64) error {
+	_, err := do[any](ctx, c, http.MethodDelete, userPath(id), nil, false)
+	return err
+}
+
+func (c *httpClient) ListUsers(ctx context.Context, adminID int64, params ListUsersParams) ([]User, error) {
+	query := url.Values{}
+	if params.Role != nil {
+		query.Set("role", params.Role.String())
+	}
+	if params.Banned != nil {
+		query.Set("banned", strconv.FormatBool(*params.Banned))
+	}
+	if params.Verified != nil {
+		query.Set("verified", strconv.FormatBool(*params.Verified))
+	}
+	if params.Nickname != nil {
+		query.Set("nickname", *params.Nickname)
+	}
+	if params.Email != nil {
+		query.Set("email", *params.Email)
+	}
+	header := http.Header{adminHeader: {strconv.FormatInt(adminID, 10)}}
+	list, err := doWithHeader[[]httpUser](ctx, c, http.MethodGet, "/admin/users?"+query.Encode(), header, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	ul := make([]user.User, 0, len(list))
+	for _, u := range list {
+		ul = append(ul, *u.toUser())
+	}
+	return ul, nil
+}
+
+func (c *httpClient) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
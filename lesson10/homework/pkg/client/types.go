@@ -0,0 +1,95 @@
+package client
+
+import (
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/apperr"
+	"homework10/internal/filter"
+	"homework10/internal/policy"
+	"homework10/internal/tenant"
+	"homework10/internal/user"
+)
+
+// Типы сервиса, которые принимает и возвращает клиент. Это псевдонимы внутренних типов,
+// поэтому код вне модуля, которому пакеты internal недоступны, называет их через client
+type (
+	Ad              = ads.Ad
+	User            = user.User
+	Role            = user.Role
+	ListAdsParams   = app.ListAdsParams
+	ListUsersParams = app.ListUsersParams
+	// FilterExpr - выражение фильтра для ListAdsParams.Filter, см. ParseFilter
+	FilterExpr     = filter.Expr
+	Code           = apperr.Code
+	FieldViolation = apperr.FieldViolation
+)
+
+const (
+	RoleUser      = user.RoleUser
+	RoleModerator = user.RoleModerator
+	RoleAdmin     = user.RoleAdmin
+)
+
+// ParseRole разбирает название роли ("user", "moderator", "admin")
+func ParseRole(s string) (Role, error) {
+	return user.ParseRole(s)
+}
+
+// ParseFilter разбирает выражение фильтра объявлений, например `published = true AND title ~ "bike"`
+func ParseFilter(s string) (FilterExpr, error) {
+	return filter.Parse(s)
+}
+
+// Коды ошибок сервиса (Error.Reason)
+const (
+	CodeInternal             = apperr.CodeInternal
+	CodeInvalidArgument      = apperr.CodeInvalidArgument
+	CodeValidationFailed     = apperr.CodeValidationFailed
+	CodeInvalidSchedule      = apperr.CodeInvalidSchedule
+	CodeInvalidPage          = apperr.CodeInvalidPage
+	CodeInvalidTimeRange     = apperr.CodeInvalidTimeRange
+	CodeInvalidAuditRange    = apperr.CodeInvalidAuditRange
+	CodeInvalidRole          = apperr.CodeInvalidRole
+	CodeTokenExpired         = apperr.CodeTokenExpired
+	CodeAdNotFound           = apperr.CodeAdNotFound
+	CodeUserNotFound         = apperr.CodeUserNotFound
+	CodeSearchNotFound       = apperr.CodeSearchNotFound
+	CodeConversationNotFound = apperr.CodeConversationNotFound
+	CodeTokenNotFound        = apperr.CodeTokenNotFound
+	CodeAlreadyExists        = apperr.CodeAlreadyExists
+	CodeAlreadyVerified      = apperr.CodeAlreadyVerified
+	CodeForbidden            = apperr.CodeForbidden
+	CodeUserBanned           = apperr.CodeUserBanned
+	CodeUserBlocked          = apperr.CodeUserBlocked
+	CodeInvalidTenant        = apperr.CodeInvalidTenant
+	CodeTenantNotFound       = apperr.CodeTenantNotFound
+	CodeRateLimited          = apperr.CodeRateLimited
+	CodePolicyViolation      = apperr.CodePolicyViolation
+	CodeAdUnderReview        = apperr.CodeAdUnderReview
+)
+
+// Ошибки, в которые разворачивается *Error. Это те же значения, что и внутри сервиса,
+// поэтому errors.Is(err, client.ErrAdNotFound) и errors.Is(err, app.ErrAdNotFound) равносильны
+var (
+	ErrBanned               = app.ErrBanned
+	ErrBlocked              = app.ErrBlocked
+	ErrUnderReview          = app.ErrUnderReview
+	ErrForbidden            = app.ErrForbidden
+	ErrAlreadyVerified      = app.ErrAlreadyVerified
+	ErrAlreadyExists        = app.ErrAlreadyExists
+	ErrAdNotFound           = app.ErrAdNotFound
+	ErrUserNotFound         = app.ErrUserNotFound
+	ErrSearchNotFound       = app.ErrSearchNotFound
+	ErrConversationNotFound = app.ErrConversationNotFound
+	ErrTokenNotFound        = app.ErrTokenNotFound
+	ErrTokenExpired         = app.ErrTokenExpired
+	ErrInvalidSchedule      = app.ErrInvalidSchedule
+	ErrInvalidPage          = app.ErrInvalidPage
+	ErrInvalidTimeRange     = app.ErrInvalidTimeRange
+	ErrInvalidAuditRange    = app.ErrInvalidAuditRange
+	ErrInvalidRole          = user.ErrInvalidRole
+	ErrInvalidTenant        = tenant.ErrInvalidID
+	ErrUnknownTenant        = tenant.ErrUnknownTenant
+	ErrRateLimited          = tenant.ErrRateLimited
+	ErrPolicyViolation      = policy.ErrRejected
+)